@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// actionMenu lists the commands of the actions config to run on an entry,
+// eg to pipe it to jq or upload it to a pastebin, and the checksums of
+// checksum.go, after the conversions of numbers.go for number entries.
+type actionMenu struct {
+	list  list.Model
+	keys  *actionKeyMap
+	help  help.Model
+	theme config.CustomTheme
+	entry item // the commands are run on
+}
+
+// openActionsMsg shows the action menu for the item.
+type openActionsMsg struct {
+	item item
+}
+
+// closeActionsMsg returns from the action menu to the list.
+type closeActionsMsg struct{}
+
+// actionDoneMsg reports the output of an action once it has exited.
+type actionDoneMsg struct {
+	action config.Action
+	output string
+	err    error
+}
+
+func newActionMenu(theme config.CustomTheme) actionMenu {
+	actionList := list.New(actionItems(), newItemDelegate(theme), 0, 0)
+	actionList.Filter = fuzzyFilter
+	configureListKeys(&actionList.KeyMap)
+	actionList.Title = actionsTitle
+	actionList.SetShowHelp(false)
+	actionList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2)
+
+	return actionMenu{
+		list:  styledList(actionList, theme),
+		keys:  newActionKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+func (a *actionMenu) open(i item) {
+	a.entry = i
+	a.list.Title = fmt.Sprintf("%s: %s", actionsTitle, utils.Truncate(i.Title(), actionTitleLen))
+	a.list.ResetFilter()
+	a.list.SetItems(append(conversionItems(i), actionItems()...))
+	a.list.Select(0)
+}
+
+func (a actionMenu) Update(msg tea.Msg) (actionMenu, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		a.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case showStatusMsg:
+		a.list.StatusMessageLifetime = msg.lifetime
+		return a, a.list.NewStatusMessage(msg.text)
+
+	case tea.KeyMsg:
+		if a.list.SettingFilter() {
+			a.list.KeyMap.Quit.SetEnabled(false)
+			break
+		}
+		a.list.KeyMap.Quit.SetEnabled(true)
+
+		if key.Matches(msg, a.keys.back) {
+			return a, func() tea.Msg { return closeActionsMsg{} }
+		}
+		if i, ok := a.list.SelectedItem().(item); ok && key.Matches(msg, a.keys.choose) {
+			run := runChecksum(i.label, a.entry)
+			if action, ok := findAction(i); ok {
+				run = runAction(action, a.entry)
+			}
+			if c, ok := findConversion(i, a.entry); ok {
+				run = copyConversion(c)
+			}
+			return a, tea.Batch(
+				func() tea.Msg { return closeActionsMsg{} },
+				setStatus("Running: "+i.label),
+				run,
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	a.list, cmd = a.list.Update(msg)
+	return a, cmd
+}
+
+func (a actionMenu) View() string {
+	if a.list.SettingFilter() {
+		return style.PaddingLeft(1).Render(a.list.View())
+	}
+	helpView := style.PaddingLeft(2).Render(a.help.ShortHelpView(a.keys.ActionHelp()))
+	return style.PaddingLeft(1).Render(a.list.View() + "\n" + helpView)
+}
+
+// runs the command in the background, giving images to it as their file
+func runAction(action config.Action, entry item) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+
+		value := entry.titleFull
+		var stdin io.Reader = strings.NewReader(value)
+		if entry.filePath != "null" {
+			file, err := os.Open(entry.filePath)
+			if err != nil {
+				return actionDoneMsg{action: action, err: err}
+			}
+			defer file.Close()
+			value, stdin = entry.filePath, file
+		}
+		output, err := shell.RunAction(ctx, action.Command, value, stdin)
+		return actionDoneMsg{action: action, output: output, err: err}
+	}
+}
+
+// shows, copies or drops the output as set for the action
+func actionOutput(msg actionDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		utils.LogERROR(fmt.Sprintf("action %s failed: %s", msg.action.Name, msg.err))
+		return setError(fmt.Sprintf("%s failed: %s", msg.action.Name, msg.err))
+	}
+	switch msg.action.Output {
+	case config.ActionCopy:
+		if err := config.CopyText(strings.TrimSuffix(msg.output, "\n")); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to copy output of action %s: %s", msg.action.Name, err))
+			return setError("Could not copy output.")
+		}
+		return setStatus(fmt.Sprintf("Copied %s: %s", msg.action.Name, utils.Truncate(msg.output, actionTitleLen)))
+	case config.ActionShow:
+		if strings.TrimSpace(msg.output) == "" {
+			return setStatus(msg.action.Name + " printed nothing")
+		}
+		return openPreview(item{title: msg.action.Name, titleFull: msg.output, filePath: "null"})
+	}
+	return setStatus("Done: " + msg.action.Name)
+}
+
+func actionItems() []list.Item {
+	items := []list.Item{}
+	for _, action := range config.ClipseConfig.Actions {
+		items = append(items, item{
+			title:           action.Name,
+			titleBase:       action.Name,
+			titleFull:       action.Command,
+			description:     action.Command,
+			descriptionBase: action.Command,
+			filePath:        "null",
+			label:           action.Name,
+		})
+	}
+	return append(items, checksumItems()...)
+}
+
+// returns the action of the actions config listed as the item, false for
+// the checksums
+func findAction(i item) (config.Action, bool) {
+	for _, action := range config.ClipseConfig.Actions {
+		if action.Name == i.label && action.Command == i.titleFull {
+			return action, true
+		}
+	}
+	return config.Action{}, false
+}
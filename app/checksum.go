@@ -0,0 +1,94 @@
+package app
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// checksums listed in the action menu after the actions config, copying
+// the hash of the entry, or of the file it is the path of
+var checksums = []struct {
+	name string
+	hash func() hash.Hash
+}{
+	{"md5", md5.New},
+	{"sha1", sha1.New},
+	{"sha256", sha256.New},
+}
+
+func checksumItems() []list.Item {
+	items := []list.Item{}
+	for _, sum := range checksums {
+		title := "Copy " + sum.name
+		items = append(items, item{
+			title:           title,
+			titleBase:       title,
+			titleFull:       title,
+			description:     checksumDesc,
+			descriptionBase: checksumDesc,
+			filePath:        "null",
+			label:           sum.name,
+		})
+	}
+	return items
+}
+
+// hashes the entry in the background, its output is copied like that of
+// an action
+func runChecksum(name string, entry item) tea.Cmd {
+	return func() tea.Msg {
+		action := config.Action{Name: name, Output: config.ActionCopy}
+		for _, sum := range checksums {
+			if sum.name != name {
+				continue
+			}
+			var input io.Reader = strings.NewReader(entry.titleFull)
+			path := entry.filePath
+			if path == "null" {
+				path = entryFile(entry.titleFull)
+			}
+			if path != "" {
+				file, err := os.Open(path)
+				if err != nil {
+					return actionDoneMsg{action: action, err: err}
+				}
+				defer file.Close()
+				input = file
+				action.Name += " of " + filepath.Base(path)
+			}
+			h := sum.hash()
+			if _, err := io.Copy(h, input); err != nil {
+				return actionDoneMsg{action: action, err: err}
+			}
+			return actionDoneMsg{action: action, output: hex.EncodeToString(h.Sum(nil))}
+		}
+		return actionDoneMsg{action: action}
+	}
+}
+
+// returns the path of the file the text names, or "" if it is no path to
+// a regular file
+func entryFile(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsRune(text, '\n') || len(text) > maxPathLen {
+		return ""
+	}
+	path := utils.ExpandHome(text)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// confirmDialog asks the user to confirm an action with a yes/no list.
+// The messages to send for either answer are given when it is opened.
+type confirmDialog struct {
+	list      list.Model
+	keys      *confirmationKeyMap
+	help      help.Model
+	onConfirm tea.Msg
+	onCancel  tea.Msg
+	action    string // confirm config key turned off by "don't ask again"
+}
+
+// confirmRequestMsg opens the confirmation dialog.
+type confirmRequestMsg struct {
+	title     string
+	action    string  // confirm config key of the action, offers "don't ask again" if set
+	onConfirm tea.Msg // sent to the list pane if the user picks "Yes"
+	onCancel  tea.Msg // sent to the list pane if the user picks "No"
+}
+
+// confirmDoneMsg closes the dialog, carrying the message for the answer.
+type confirmDoneMsg struct {
+	result tea.Msg
+}
+
+// confirms the action unless it has been turned off in the confirm config,
+// sending onConfirm straight away if so
+func confirmAction(action, title string, onConfirm, onCancel tea.Msg) tea.Cmd {
+	if !config.ConfirmEnabled(action) {
+		return func() tea.Msg { return onConfirm }
+	}
+	return func() tea.Msg {
+		return confirmRequestMsg{title: title, action: action, onConfirm: onConfirm, onCancel: onCancel}
+	}
+}
+
+func newConfirmDialog(theme config.CustomTheme) confirmDialog {
+	return confirmDialog{
+		list: styledList(newConfirmationList(newItemDelegate(theme)), theme),
+		keys: newConfirmationKeymap(),
+		help: styledHelp(help.New(), theme),
+	}
+}
+
+func (c *confirmDialog) open(req confirmRequestMsg) {
+	c.list.Title = req.title
+	c.list.SetItems(confirmationItems(req.action != ""))
+	c.list.Select(0) // default to "No"
+	c.onConfirm = req.onConfirm
+	c.onCancel = req.onCancel
+	c.action = req.action
+}
+
+func (c confirmDialog) Update(msg tea.Msg) (confirmDialog, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		c.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case tea.KeyMsg:
+		if key.Matches(msg, c.keys.cancel) {
+			return c, func() tea.Msg { return confirmDoneMsg{result: c.onCancel} }
+		}
+		if key.Matches(msg, c.keys.choose) {
+			result := c.onCancel
+			var cmd tea.Cmd
+			switch c.list.Index() {
+			case 1: // Yes
+				result = c.onConfirm
+			case 2: // Yes, don't ask again
+				result = c.onConfirm
+				if err := config.DisableConfirm(c.action); err != nil {
+					utils.LogERROR(fmt.Sprintf("failed to save confirm setting: %s", err))
+					cmd = setError("Could not save don't ask again.")
+				}
+			}
+			return c, tea.Batch(func() tea.Msg { return confirmDoneMsg{result: result} }, cmd)
+		}
+	}
+
+	var cmd tea.Cmd
+	c.list, cmd = c.list.Update(msg)
+	return c, cmd
+}
+
+func (c confirmDialog) View() string {
+	helpView := style.PaddingLeft(2).Render(c.help.ShortHelpView(c.keys.ConfirmationHelp()))
+	return style.PaddingLeft(1).Render(c.list.View() + "\n" + helpView)
+}
+
+func newConfirmationList(del itemDelegate) list.Model {
+	items := confirmationItems(false)
+	l := list.New(items, del, 0, 10)
+	l.Title = confirmationTitle
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.SetShowPagination(false)
+	l.KeyMap.Quit.SetEnabled(false)
+	return l
+}
+
+// the answers, with "don't ask again" if the action can be turned off
+func confirmationItems(dontAsk bool) []list.Item {
+	items := []list.Item{
+		item{
+			title:           "No",
+			titleBase:       "No",
+			descriptionBase: "go back",
+		},
+		item{
+			title:           "Yes",
+			titleBase:       "Yes",
+			descriptionBase: "delete the item(s)",
+		},
+	}
+	if dontAsk {
+		items = append(items, item{
+			title:           "Yes, don't ask again",
+			titleBase:       "Yes, don't ask again",
+			descriptionBase: "delete now and from now on without asking",
+		})
+	}
+	return items
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// conflictPane shows the snippets changed differently on this device and
+// another one side by side, one conflict at a time, to pick which to keep.
+type conflictPane struct {
+	conflicts []config.SnippetConflict
+	keys      *conflictKeyMap
+	help      help.Model
+	theme     config.CustomTheme
+	width     int
+}
+
+// closeConflictsMsg switches from the conflicts back to the history once
+// they are resolved or skipped.
+type closeConflictsMsg struct{}
+
+func newConflictPane(theme config.CustomTheme) conflictPane {
+	return conflictPane{
+		keys:  newConflictKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+func (c *conflictPane) open() {
+	c.conflicts = config.SyncConflicts()
+}
+
+func (c conflictPane) Update(msg tea.Msg) (conflictPane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, _ := appStyle.GetFrameSize()
+		c.width = msg.Width - h
+
+	case tea.KeyMsg:
+		if key.Matches(msg, c.keys.skip) || len(c.conflicts) == 0 {
+			return c, func() tea.Msg { return closeConflictsMsg{} }
+		}
+
+		keep := ""
+		switch {
+		case key.Matches(msg, c.keys.keepLeft):
+			keep = config.KeepLocal
+		case key.Matches(msg, c.keys.keepRight):
+			keep = config.KeepRemote
+		case key.Matches(msg, c.keys.keepBoth):
+			keep = config.KeepBoth
+		default:
+			return c, nil
+		}
+
+		if err := config.ResolveConflict(c.conflicts[0], keep); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to resolve sync conflict: %s", err))
+			return c, setError(fmt.Sprintf("Could not resolve conflict: %s", err))
+		}
+		c.conflicts = c.conflicts[1:]
+		if len(c.conflicts) == 0 {
+			return c, func() tea.Msg { return closeConflictsMsg{} }
+		}
+	}
+	return c, nil
+}
+
+func (c conflictPane) View() string {
+	if len(c.conflicts) == 0 {
+		return ""
+	}
+	conflict := c.conflicts[0]
+
+	title := withTitleStyle(style, c.theme).
+		Foreground(lipgloss.Color(c.theme.TitleFore)).
+		Background(lipgloss.Color(c.theme.TitleBack)).
+		Padding(0, 1).
+		MarginTop(1).
+		MarginBottom(1).
+		Render(fmt.Sprintf("Sync conflict with %s (%d left)", conflict.Device, len(c.conflicts)))
+
+	sideWidth := max(10, c.width/2-3) // leaves room for the borders and margin
+	sides := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		c.side("This device", conflict.Local, sideWidth),
+		c.side(conflict.Device, conflict.Remote, sideWidth),
+	)
+
+	helpView := style.PaddingTop(1).Render(c.help.ShortHelpView(c.keys.ConflictHelp()))
+	return style.PaddingLeft(2).Render(lipgloss.JoinVertical(lipgloss.Left, title, sides, helpView))
+}
+
+// renders one version of the snippet, nil if it was deleted on that side
+func (c conflictPane) side(header string, snippet *config.Snippet, width int) string {
+	headerStyle := style.Foreground(lipgloss.Color(c.theme.PreviewBorder)).Bold(true)
+	nameStyle := style.Foreground(lipgloss.Color(c.theme.SelectedTitle))
+	valueStyle := style.Foreground(lipgloss.Color(c.theme.PreviewedText))
+
+	body := style.Foreground(lipgloss.Color(c.theme.DimmedDesc)).Render("deleted")
+	if snippet != nil {
+		body = nameStyle.Render(ansi.Truncate(snippet.Name, width-4, ellipsisChar)) + "\n\n" +
+			valueStyle.Render(ansi.Wrap(snippet.Value, width-4, ""))
+	}
+
+	return style.
+		Border(themeBorder(c.theme)).
+		BorderForeground(lipgloss.Color(c.theme.PreviewBorder)).
+		Padding(0, 1).
+		MarginRight(1).
+		Width(width).
+		Render(headerStyle.Render(header) + "\n\n" + body)
+}
@@ -1,14 +1,94 @@
 package app
 
+import "time"
+
 const (
 	pinChar           = "  "
 	pinColorDefault   = "#FF0000"
 	clipboardTitle    = "Clipboard History"
+	snippetsTitle     = "Snippets"
 	confirmationTitle = "Delete pinned item(s)?"
+	deleteTitle       = "Delete this item?"
+	bulkDeleteTitle   = "Delete the selected items?"
 	previewHeader     = "Preview"
+	inspectHeader     = "Characters"
 	borderRightChar   = "├"
 	borderLeftChar    = "┤"
 	borderMiddleChar  = "─"
 	defaultMsgColor   = "#04B575"
+	darkBackground    = "#000000"
+	lightBackground   = "#ffffff"
 	spaceChar         = "␣"
+	derivedChar       = "↳" // marks entries derived from another by a transform
+	tagChar           = "#"
+	usedChar          = "⎘" // paste count and last use of entries copied out of the history
+	pausedChar        = "⏸ paused"
+	deviceChar        = "@" // badge of entries synced from another device
+	urlChar           = "↗" // badge of entries that are a single URL, opened with openURL
+	formattedChar     = "¶" // badge of entries copied with formatting, see copyFormatted
+	localDevice       = "." // device view of the entries copied on this one, never a sync device name
+	maskedTitle       = "•••••••• (hidden, press reveal to show)"
+	forceQuitKey      = "ctrl+c" // default force quit key of the bubbles list
+	ellipsisChar      = "…"
+	newlineChar       = '↵' // shown in place of line breaks in filter results
+	filterExcerptLen  = 62
+	linkScheme        = "clipse"
+	linkItem          = "item"
+	linkSearch        = "search"
+
+	writeDebounce = 500 * time.Millisecond // wait for more changes before writing
+	undoLimit     = 20                     // deletes that can be undone
+	refreshEvery  = time.Minute            // re-renders the relative copy times
+)
+
+// status messages, see statusbar.go
+const (
+	noticesTitle  = "Notifications"
+	warnChar      = "⚠"
+	errorChar     = "✗"
+	infoLifetime  = 2 * time.Second
+	warnLifetime  = 4 * time.Second
+	errorLifetime = 6 * time.Second
+	statusMinShow = time.Second // before a queued message replaces the one shown
+	noticeHistory = 50
+)
+
+const inspectLimit = 10000 // characters listed by the inspector
+
+// history pages of the sqlite storage, see paging.go
+const (
+	historyPageSize = 200
+	pageLoadAhead   = 20 // entries left below the cursor when the next page is loaded
+)
+
+// password store, emoji picker, QR code, action, transform and generate menus
+const (
+	passwordsTitle     = "Passwords"
+	emojiTitle         = "Emoji and Symbols"
+	qrTitle            = "QR Code"
+	actionsTitle       = "Actions"
+	transformsTitle    = "Transforms"
+	generateTitle      = "Generate"
+	leaderTitle        = "All actions"
+	groupChar          = "▸"              // marks the groups of the leader menu
+	actionTitleLen     = 40               // characters of the entry shown in the action menu title
+	actionTimeout      = 30 * time.Second // before an action command is killed
+	passwordCopyWindow = time.Minute      // for the pinentry prompt before the copy is recorded after all
+	checksumDesc       = "checksum of the entry, or of the file it is the path of"
+	maxPathLen         = 4096 // longer entries are never taken for a file path
+)
+
+// migration assistant, see migrator.go
+const (
+	migratorTitle        = "Migrate to this version"
+	doneChar             = "✓"
+	declineMigrationsKey = "n"
+	progressBarWidth     = 30
+)
+
+// split view layout
+const (
+	splitPaneFrame        = 2 // left border and padding
+	splitPaneHeaderHeight = 4 // top margin, header and bottom spacing
+	splitScrollLines      = 3
 )
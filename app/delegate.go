@@ -3,7 +3,6 @@ package app
 import (
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,9 +10,9 @@ import (
 	"github.com/savedra1/clipse/config"
 )
 
-func (m *Model) newItemDelegate() itemDelegate {
+func newItemDelegate(theme config.CustomTheme) itemDelegate {
 	return itemDelegate{
-		theme: m.theme,
+		theme: theme,
 	}
 }
 
@@ -38,13 +37,14 @@ func (d itemDelegate) Render(
 
 	var renderStr string
 
+	if m.FilterState() != list.Unfiltered {
+		i = i.withMatches(m.MatchesForItem(index))
+	}
+
 	switch {
 
 	case m.SettingFilter():
-		if strings.Contains(
-			strings.ToLower(i.titleFull),
-			strings.ToLower(m.FilterValue()),
-		) && m.FilterValue() != "" {
+		if len(i.matches) > 0 {
 			renderStr = d.itemSelectedStyle(i)
 		} else {
 			renderStr = d.itemFilterStyle(i)
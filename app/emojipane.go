@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// emojiPane lists emoji and other symbols by their Unicode name. A chosen
+// symbol is copied like any other text, so the listener records it.
+type emojiPane struct {
+	list  list.Model
+	keys  *emojiKeyMap
+	help  help.Model
+	theme config.CustomTheme
+}
+
+// openEmojiMsg switches from the history to the emoji picker.
+type openEmojiMsg struct{}
+
+// closeEmojiMsg switches from the emoji picker back to the history.
+type closeEmojiMsg struct{}
+
+// symbolBlock is a range of code points listed by the picker.
+type symbolBlock struct {
+	name     string
+	from, to rune
+}
+
+var symbolBlocks = []symbolBlock{
+	{"smileys", 0x1F600, 0x1F64F},
+	{"people and animals", 0x1F900, 0x1F9FF},
+	{"pictographs", 0x1F300, 0x1F5FF},
+	{"pictographs", 0x1FA70, 0x1FAFF},
+	{"transport and map", 0x1F680, 0x1F6FF},
+	{"symbols", 0x2600, 0x26FF},
+	{"dingbats", 0x2700, 0x27BF},
+	{"arrows", 0x2190, 0x21FF},
+	{"math", 0x2200, 0x22FF},
+	{"currency", 0x20A0, 0x20C0},
+	{"letterlike", 0x2100, 0x214F},
+	{"punctuation", 0x2010, 0x205E},
+	{"latin-1", 0x00A1, 0x00BF},
+	{"greek", 0x0391, 0x03C9},
+	{"shapes", 0x25A0, 0x25FF},
+}
+
+func newEmojiPane(theme config.CustomTheme) emojiPane {
+	emojiList := list.New([]list.Item{}, newItemDelegate(theme), 0, 0)
+	emojiList.Filter = fuzzyFilter
+	configureListKeys(&emojiList.KeyMap)
+	emojiList.Title = emojiTitle
+	emojiList.SetShowHelp(false)
+	emojiList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2)
+
+	return emojiPane{
+		list:  styledList(emojiList, theme),
+		keys:  newEmojiKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+// lists the symbols the first time the picker is opened
+func (e *emojiPane) open() {
+	if len(e.list.Items()) == 0 {
+		e.list.SetItems(emojiItems())
+	}
+	e.list.ResetFilter()
+}
+
+func (e emojiPane) Update(msg tea.Msg) (emojiPane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		e.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case showStatusMsg:
+		e.list.StatusMessageLifetime = msg.lifetime
+		return e, e.list.NewStatusMessage(msg.text)
+
+	case tea.KeyMsg:
+		if e.list.SettingFilter() {
+			e.list.KeyMap.Quit.SetEnabled(false)
+			break
+		}
+		e.list.KeyMap.Quit.SetEnabled(true)
+
+		if key.Matches(msg, e.keys.back) {
+			return e, func() tea.Msg { return closeEmojiMsg{} }
+		}
+		if i, ok := e.list.SelectedItem().(item); ok && key.Matches(msg, e.keys.choose) {
+			return e, e.copy(i)
+		}
+	}
+
+	var cmd tea.Cmd
+	e.list, cmd = e.list.Update(msg)
+	return e, cmd
+}
+
+func (e emojiPane) View() string {
+	if e.list.SettingFilter() {
+		return style.PaddingLeft(1).Render(e.list.View())
+	}
+	helpView := style.PaddingLeft(2).Render(e.help.ShortHelpView(e.keys.EmojiHelp()))
+	return style.PaddingLeft(1).Render(e.list.View() + "\n" + helpView)
+}
+
+// copies the symbol, quitting unless the TUI was opened with keep
+func (e emojiPane) copy(i item) tea.Cmd {
+	if err := config.CopyText(i.titleFull); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to copy symbol: %s", err))
+		return setError("Could not copy symbol.")
+	}
+	switch {
+	case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+		startDirectPaste()
+		shell.KillProcess(os.Args[2])
+		return tea.Quit
+	case len(os.Args) > 1 && os.Args[1] == "keep":
+		return setStatus(fmt.Sprintf("Copied to clipboard: %s %s", i.titleFull, i.label))
+	}
+	return quitAndPaste()
+}
+
+// lists the named, printable code points of the symbol blocks
+func emojiItems() []list.Item {
+	items := []list.Item{}
+	for _, block := range symbolBlocks {
+		for r := block.from; r <= block.to; r++ {
+			name := utils.RuneName(r)
+			if name == "" || !unicode.IsGraphic(r) {
+				continue
+			}
+			title := string(r) + "  " + name
+			items = append(items, item{
+				title:           title,
+				titleBase:       title,
+				titleFull:       string(r),
+				description:     block.name,
+				descriptionBase: block.name,
+				filePath:        "null",
+				label:           name,
+			})
+		}
+	}
+	return items
+}
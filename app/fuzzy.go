@@ -0,0 +1,223 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+/*
+	fzf style fuzzy filtering for the list. Each space separated term of
+	the query must match the full entry value in order, matches are
+	ranked by score so that consecutive characters and matches at word
+	boundaries come first. Matching is case-insensitive unless the term
+	contains an upper case letter.
+*/
+
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	bonusBoundary     = 8 // match at the start of a word
+	bonusCamel        = 7 // lower to upper case transition
+	bonusConsecutive  = 4
+	bonusFirstChar    = 2 // multiplier for the first character of a term
+)
+
+// fuzzyFilter implements list.FilterFunc.
+func fuzzyFilter(term string, targets []string) []list.Rank {
+	terms := strings.Fields(term)
+
+	type scoredRank struct {
+		rank  list.Rank
+		score int
+	}
+	scored := []scoredRank{}
+
+	for index, target := range targets {
+		runes := []rune(target)
+		total := 0
+		positions := []int{}
+		matched := true
+
+		for _, t := range terms {
+			score, pos, ok := fuzzyMatch([]rune(t), runes)
+			if !ok {
+				matched = false
+				break
+			}
+			total += score
+			positions = append(positions, pos...)
+		}
+		if !matched {
+			continue
+		}
+
+		sort.Ints(positions)
+		scored = append(scored, scoredRank{
+			rank:  list.Rank{Index: index, MatchedIndexes: dedupeSorted(positions)},
+			score: total,
+		})
+	}
+
+	// ties keep history order so more recent entries come first
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+
+	ranks := make([]list.Rank, len(scored))
+	for i, s := range scored {
+		ranks[i] = s.rank
+	}
+	return ranks
+}
+
+// fuzzyMatch finds the shortest occurrence of pattern in target, returning
+// its score and the rune positions of the matched characters.
+func fuzzyMatch(pattern, target []rune) (int, []int, bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+	caseSensitive := false
+	for _, r := range pattern {
+		if unicode.IsUpper(r) {
+			caseSensitive = true
+			break
+		}
+	}
+	eq := func(p, t rune) bool {
+		if caseSensitive {
+			return p == t
+		}
+		return unicode.ToLower(p) == unicode.ToLower(t)
+	}
+
+	// forward scan for the first position where the whole pattern matched
+	pi, end := 0, -1
+	for ti, r := range target {
+		if eq(pattern[pi], r) {
+			pi++
+			if pi == len(pattern) {
+				end = ti
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// backward scan from there to find the shortest window
+	pi, start := len(pattern)-1, end
+	for ti := end; ti >= 0; ti-- {
+		if eq(pattern[pi], target[ti]) {
+			pi--
+			if pi < 0 {
+				start = ti
+				break
+			}
+		}
+	}
+
+	// score the window, matching greedily from its start
+	positions := make([]int, 0, len(pattern))
+	score := 0
+	pi = 0
+	inGap, consecutive := false, 0
+	for ti := start; ti <= end && pi < len(pattern); ti++ {
+		if !eq(pattern[pi], target[ti]) {
+			if inGap {
+				score += scoreGapExtension
+			} else {
+				score += scoreGapStart
+			}
+			inGap, consecutive = true, 0
+			continue
+		}
+
+		bonus := charBonus(target, ti)
+		if consecutive > 0 {
+			bonus = max(bonus, bonusConsecutive)
+		}
+		if pi == 0 {
+			bonus *= bonusFirstChar
+		}
+		score += scoreMatch + bonus
+		positions = append(positions, ti)
+		inGap = false
+		consecutive++
+		pi++
+	}
+
+	return score, positions, true
+}
+
+// bonus for matching the rune at i based on the rune before it
+func charBonus(target []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev, curr := target[i-1], target[i]
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && (unicode.IsLetter(curr) || unicode.IsDigit(curr)):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(curr):
+		return bonusCamel
+	}
+	return 0
+}
+
+func dedupeSorted(ints []int) []int {
+	out := ints[:0]
+	for i, v := range ints {
+		if i == 0 || v != ints[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// excerpt returns a single line of at most width runes from value that
+// includes the first match, along with the match positions translated
+// to the excerpt.
+func excerpt(value string, matches []int, width int) (string, []int) {
+	runes := []rune(value)
+	for i, r := range runes {
+		switch r {
+		case '\n':
+			runes[i] = newlineChar
+		case '\t', '\r':
+			runes[i] = ' '
+		}
+	}
+
+	start := 0
+	for start < len(runes) && unicode.IsSpace(runes[start]) {
+		start++
+	}
+	if len(matches) > 0 && matches[0] < start {
+		start = matches[0]
+	}
+
+	var prefix, suffix []rune
+	if len(matches) > 0 && matches[0]-start >= width-1 {
+		// keep some context before the first match
+		start = matches[0] - width/3
+		prefix = []rune(ellipsisChar)
+	}
+	end := min(len(runes), start+width-len(prefix))
+	if end < len(runes) {
+		suffix = []rune(ellipsisChar)
+		end--
+	}
+
+	line := append(append(append([]rune{}, prefix...), runes[start:end]...), suffix...)
+
+	positions := []int{}
+	for _, m := range matches {
+		if m >= start && m < end {
+			positions = append(positions, m-start+len(prefix))
+		}
+	}
+	return string(line), positions
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/generators"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// generateMenu lists the generators creating new entries, eg UUIDs or
+// random bytes. The result is added to the history and copied.
+type generateMenu struct {
+	list  list.Model
+	keys  *generateKeyMap
+	help  help.Model
+	theme config.CustomTheme
+}
+
+// openGenerateMsg shows the generate menu.
+type openGenerateMsg struct{}
+
+// closeGenerateMsg returns from the generate menu to the list.
+type closeGenerateMsg struct{}
+
+// generateMsg creates an entry with the generator.
+type generateMsg struct {
+	name string
+	size string // as typed, "" for the default size
+}
+
+func newGenerateMenu(theme config.CustomTheme) generateMenu {
+	generateList := list.New(generateItems(), newItemDelegate(theme), 0, 0)
+	generateList.Filter = fuzzyFilter
+	configureListKeys(&generateList.KeyMap)
+	generateList.Title = generateTitle
+	generateList.SetShowHelp(false)
+	generateList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2)
+
+	return generateMenu{
+		list:  styledList(generateList, theme),
+		keys:  newGenerateKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+func (g *generateMenu) open() {
+	g.list.ResetFilter()
+}
+
+func (g generateMenu) Update(msg tea.Msg) (generateMenu, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		g.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case showStatusMsg:
+		g.list.StatusMessageLifetime = msg.lifetime
+		return g, g.list.NewStatusMessage(msg.text)
+
+	case tea.KeyMsg:
+		if g.list.SettingFilter() {
+			g.list.KeyMap.Quit.SetEnabled(false)
+			break
+		}
+		g.list.KeyMap.Quit.SetEnabled(true)
+
+		if key.Matches(msg, g.keys.back) {
+			return g, func() tea.Msg { return closeGenerateMsg{} }
+		}
+		if i, ok := g.list.SelectedItem().(item); ok && key.Matches(msg, g.keys.choose) {
+			name := i.label
+			size := generators.DefaultSize(name)
+			if size == 0 {
+				return g, func() tea.Msg { return generateMsg{name: name} }
+			}
+			return g, requestInput(fmt.Sprintf("%s size", name), fmt.Sprintf("%d, enter for the default", size), func(value string) tea.Msg {
+				return generateMsg{name: name, size: value}
+			})
+		}
+	}
+
+	var cmd tea.Cmd
+	g.list, cmd = g.list.Update(msg)
+	return g, cmd
+}
+
+func (g generateMenu) View() string {
+	if g.list.SettingFilter() {
+		return style.PaddingLeft(1).Render(g.list.View())
+	}
+	helpView := style.PaddingLeft(2).Render(g.help.ShortHelpView(g.keys.GenerateHelp()))
+	return style.PaddingLeft(1).Render(g.list.View() + "\n" + helpView)
+}
+
+// stores the generated entry and copies it, quitting unless the TUI was
+// opened with keep
+func generateEntry(msg generateMsg) tea.Cmd {
+	generate, ok := generators.Get(msg.name)
+	if !ok {
+		return nil
+	}
+	size := generators.DefaultSize(msg.name)
+	if value := strings.TrimSpace(msg.size); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return setWarning(fmt.Sprintf("Not a size: %s", value))
+		}
+		size = n
+	}
+	value, err := generate(size)
+	if err != nil {
+		return setError(fmt.Sprintf("Could not generate %s: %s", msg.name, err))
+	}
+	if _, err := config.AddClipboardItem(context.Background(), value, "null", ""); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to store generated %s: %s", msg.name, err))
+		return setError("Could not store the generated entry.")
+	}
+	if err := config.CopyText(value); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to copy generated entry: %s", err))
+		return tea.Batch(setError("Could not copy the generated entry."), func() tea.Msg { return ReRender{} })
+	}
+	switch {
+	case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+		startDirectPaste()
+		shell.KillProcess(os.Args[2])
+		return tea.Quit
+	case len(os.Args) > 1 && os.Args[1] == "keep":
+		return tea.Batch(setStatus(fmt.Sprintf("Copied %s: %s", msg.name, utils.Shorten(value))), func() tea.Msg { return ReRender{} })
+	}
+	return quitAndPaste()
+}
+
+func generateItems() []list.Item {
+	items := []list.Item{}
+	for _, name := range generators.Names() {
+		desc := generators.Description(name)
+		if size := generators.DefaultSize(name); size > 0 {
+			desc += fmt.Sprintf(", %d by default", size)
+		}
+		items = append(items, item{
+			title:           name,
+			titleBase:       name,
+			titleFull:       desc,
+			description:     desc,
+			descriptionBase: desc,
+			filePath:        "null",
+			label:           name,
+		})
+	}
+	return items
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+)
+
+// inputDialog asks the user for a line of text, eg the name of a snippet.
+// The requester builds the message sent with the text once submitted.
+type inputDialog struct {
+	input    textinput.Model
+	keys     *inputKeyMap
+	help     help.Model
+	theme    config.CustomTheme
+	title    string
+	onSubmit func(string) tea.Msg
+}
+
+// inputRequestMsg opens the input dialog.
+type inputRequestMsg struct {
+	title       string
+	placeholder string
+	onSubmit    func(string) tea.Msg // builds the message sent to the components
+}
+
+// inputDoneMsg closes the dialog, carrying the submitted message or nil if
+// the input was cancelled.
+type inputDoneMsg struct {
+	result tea.Msg
+}
+
+func requestInput(title, placeholder string, onSubmit func(string) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return inputRequestMsg{title: title, placeholder: placeholder, onSubmit: onSubmit}
+	}
+}
+
+func newInputDialog(theme config.CustomTheme) inputDialog {
+	input := textinput.New()
+	input.PromptStyle = style.Foreground(lipgloss.Color(theme.FilterPrompt))
+	input.TextStyle = style.Foreground(lipgloss.Color(theme.FilterText))
+	input.Cursor.Style = style.Foreground(lipgloss.Color(theme.FilterCursor))
+
+	return inputDialog{
+		input: input,
+		keys:  newInputKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+func (d *inputDialog) open(req inputRequestMsg) tea.Cmd {
+	d.title = req.title
+	d.onSubmit = req.onSubmit
+	d.input.Placeholder = req.placeholder
+	d.input.SetValue("")
+	return d.input.Focus()
+}
+
+func (d inputDialog) Update(msg tea.Msg) (inputDialog, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, d.keys.submit):
+			d.input.Blur()
+			result := d.onSubmit(d.input.Value())
+			return d, func() tea.Msg { return inputDoneMsg{result: result} }
+		case key.Matches(msg, d.keys.cancel):
+			d.input.Blur()
+			return d, func() tea.Msg { return inputDoneMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	d.input, cmd = d.input.Update(msg)
+	return d, cmd
+}
+
+func (d inputDialog) View() string {
+	title := style.
+		Foreground(lipgloss.Color(d.theme.TitleFore)).
+		Background(lipgloss.Color(d.theme.TitleBack)).
+		Padding(0, 1).
+		MarginTop(1).
+		MarginBottom(1).
+		Render(d.title)
+	helpView := style.PaddingTop(1).Render(d.help.ShortHelpView(d.keys.InputHelp()))
+	return style.PaddingLeft(2).Render(lipgloss.JoinVertical(lipgloss.Left, title, d.input.View(), helpView))
+}
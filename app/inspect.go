@@ -0,0 +1,68 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* The character inspector of the preview lists each character of an entry
+with its code point, name and UTF-8 bytes, to find invisible characters
+such as zero width spaces copied from the web. They are marked with
+warnChar.
+*/
+
+// renders a line per character, up to inspectLimit of them
+func inspectCharacters(value string, theme config.CustomTheme) string {
+	textStyle := style.Foreground(lipgloss.Color(theme.PreviewedText))
+	dimStyle := style.Foreground(lipgloss.Color(theme.DimmedDesc))
+	warnStyle := style.Foreground(lipgloss.Color(theme.PinIndicatorColor))
+
+	lines := []string{}
+	count := 0
+	for _, r := range value {
+		count++
+		if count > inspectLimit {
+			continue
+		}
+		shown, mark := string(r), " "
+		if invisible(r) {
+			shown, mark = " ", warnStyle.Render(warnChar)
+		}
+		name := utils.RuneName(r)
+		if name == "" {
+			name = "unassigned"
+		}
+		lines = append(lines, fmt.Sprintf(
+			"%s %s  %s  %s  %s",
+			mark,
+			dimStyle.Render(fmt.Sprintf("%-7s", fmt.Sprintf("U+%04X", r))),
+			textStyle.Render(shown),
+			textStyle.Render(name),
+			dimStyle.Render(utf8Bytes(r)),
+		))
+	}
+	if count > inspectLimit {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%s more characters", utils.FormatCount(count-inspectLimit))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// whether r can't be seen or would break the layout of the line
+func invisible(r rune) bool {
+	return !unicode.IsGraphic(r) || unicode.Is(unicode.Cf, r) || (unicode.IsSpace(r) && r != ' ')
+}
+
+func utf8Bytes(r rune) string {
+	b := []byte(string(r))
+	parts := make([]string, len(b))
+	for n, c := range b {
+		parts[n] = fmt.Sprintf("%02x", c)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// keyResolver walks through the key bindings bound to more than one
+// action of the same screen, asking which action keeps the key and a new
+// key for the others. It runs before the TUI, which can't be used
+// reliably with the conflicts.
+type keyResolver struct {
+	bindings map[string]string // the key bindings with the changes made so far
+	changes  map[string]string
+	conflict config.KeyConflict
+	rebind   []string // actions of the conflict left to bind to a new key, empty while picking
+	message  string   // about the last key pressed
+	theme    config.CustomTheme
+}
+
+// ResolveKeyConflicts opens the resolver if the key bindings conflict,
+// then saves the new keys to the config. Conflicts skipped with esc are
+// left for the next start.
+func ResolveKeyConflicts() error {
+	conflicts := config.KeyConflicts(config.ClipseConfig.KeyBindings)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	r := keyResolver{
+		bindings: make(map[string]string),
+		changes:  make(map[string]string),
+		conflict: conflicts[0],
+		theme:    loadTheme(),
+	}
+	statusMessageStyle = styledStatusMessage(r.theme)
+	for action, k := range config.ClipseConfig.KeyBindings {
+		r.bindings[action] = k
+	}
+
+	final, err := tea.NewProgram(r).Run()
+	if err != nil {
+		return err
+	}
+	r = final.(keyResolver)
+	for _, c := range config.KeyConflicts(r.bindings) {
+		utils.LogWARN(fmt.Sprintf("key %q is bound to %s, only one of them works", c.Key, strings.Join(c.Actions, " and ")))
+	}
+	if len(r.changes) == 0 {
+		return nil
+	}
+	return config.SaveKeyBindings(r.changes)
+}
+
+func (r keyResolver) Init() tea.Cmd {
+	return tea.EnterAltScreen
+}
+
+func (r keyResolver) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return r, nil
+	}
+	k := keyMsg.String()
+	if k == forceQuitKey {
+		return r, tea.Quit
+	}
+
+	if len(r.rebind) == 0 {
+		// picking the action that keeps the key
+		if k == r.skipKey() {
+			return r, tea.Quit
+		}
+		n, err := strconv.Atoi(k)
+		if err != nil || n < 1 || n > len(r.conflict.Actions) {
+			return r, nil
+		}
+		for i, action := range r.conflict.Actions {
+			if i != n-1 {
+				r.rebind = append(r.rebind, action)
+			}
+		}
+		r.message = ""
+		return r, nil
+	}
+
+	action := r.rebind[0]
+	if other, conflicts := config.KeyBindingConflicts(r.bindings, action, k); conflicts {
+		r.message = fmt.Sprintf("%s is already used by %s", helpChar(k), other)
+		return r, nil
+	}
+	r.bindings[action] = k
+	r.changes[action] = k
+	r.rebind = r.rebind[1:]
+	r.message = fmt.Sprintf("Bound %s to %s", action, helpChar(k))
+	if len(r.rebind) > 0 {
+		return r, nil
+	}
+
+	conflicts := config.KeyConflicts(r.bindings)
+	if len(conflicts) == 0 {
+		return r, tea.Quit
+	}
+	r.conflict = conflicts[0]
+	return r, nil
+}
+
+// leaves the conflicts for the next start: the cancel key, unless it is
+// the key in conflict
+func (r keyResolver) skipKey() string {
+	cancel := config.ClipseConfig.KeyBindings["cancel"]
+	if cancel == r.conflict.Key {
+		return forceQuitKey
+	}
+	return cancel
+}
+
+func (r keyResolver) View() string {
+	title := withTitleStyle(style, r.theme).
+		Foreground(lipgloss.Color(r.theme.TitleFore)).
+		Background(lipgloss.Color(r.theme.TitleBack)).
+		Padding(0, 1).
+		Render("Key binding conflict")
+	keyStyle := style.Foreground(lipgloss.Color(r.theme.SelectedTitle)).Bold(true)
+	textStyle := style.Foreground(lipgloss.Color(r.theme.NormalTitle))
+	hintStyle := style.Foreground(lipgloss.Color(r.theme.HelpDesc))
+
+	var b strings.Builder
+	b.WriteString(title + "\n\n")
+	b.WriteString(textStyle.Render("The key "+keyStyle.Render(helpChar(r.conflict.Key))+" is bound to:") + "\n\n")
+	for i, action := range r.conflict.Actions {
+		b.WriteString(textStyle.Render(fmt.Sprintf("  %d  %s", i+1, action)) + "\n")
+	}
+	b.WriteString("\n")
+
+	if len(r.rebind) == 0 {
+		b.WriteString(hintStyle.Render(fmt.Sprintf(
+			"Press the number of the action to keep %s, %s to resolve later",
+			helpChar(r.conflict.Key), helpChar(r.skipKey()),
+		)))
+	} else {
+		action := r.rebind[0]
+		b.WriteString(textStyle.Render("Press the new key for "+keyStyle.Render(action)) +
+			hintStyle.Render(fmt.Sprintf(" (default %s)", helpChar(config.DefaultKeyBinding(action)))))
+	}
+	if r.message != "" {
+		b.WriteString("\n\n" + statusMessageStyle(r.message))
+	}
+	return appStyle.Render(b.String())
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 
 	"github.com/savedra1/clipse/config"
 )
@@ -20,7 +21,31 @@ type keyMap struct {
 	selectUp      key.Binding
 	selectSingle  key.Binding
 	clearSelected key.Binding
+	pasteStack    key.Binding
+	undo          key.Binding
+	sortUsed      key.Binding
+	pauseCapture  key.Binding
+	switchDevice  key.Binding
+	switchSource  key.Binding
+	notifications key.Binding
+	saveSnippet   key.Binding
+	snippets      key.Binding
+	passwords     key.Binding
+	emoji         key.Binding
 	yankFilter    key.Binding
+	copyLink      key.Binding
+	copyFormatted key.Binding
+	openURL       key.Binding
+	qrCode        key.Binding
+	actions       key.Binding
+	transform     key.Binding
+	generate      key.Binding
+	tmuxBuffer    key.Binding
+	leader        key.Binding
+	reveal        key.Binding
+	splitView     key.Binding
+	splitUp       key.Binding
+	splitDown     key.Binding
 	up            key.Binding
 	down          key.Binding
 	nextPage      key.Binding
@@ -32,11 +57,6 @@ type keyMap struct {
 func newKeyMap() *keyMap {
 	config := config.ClipseConfig.KeyBindings
 
-	previewChar := config["preview"]
-	if previewChar == " " {
-		previewChar = spaceChar
-	}
-
 	return &keyMap{
 		filter: key.NewBinding(
 			key.WithKeys(config["filter"]),
@@ -68,7 +88,7 @@ func newKeyMap() *keyMap {
 		),
 		preview: key.NewBinding(
 			key.WithKeys(config["preview"]),
-			key.WithHelp(previewChar, "preview"),
+			key.WithHelp(helpChar(config["preview"]), "preview"),
 		),
 		selectDown: key.NewBinding(
 			key.WithKeys(config["selectDown"]),
@@ -80,16 +100,112 @@ func newKeyMap() *keyMap {
 		),
 		selectSingle: key.NewBinding(
 			key.WithKeys(config["selectSingle"]),
-			key.WithHelp(config["selectSingle"], "select single"),
+			key.WithHelp(helpChar(config["selectSingle"]), "select single"),
 		),
 		clearSelected: key.NewBinding(
 			key.WithKeys(config["clearSelected"]),
 			key.WithHelp(config["clearSelected"], "clear selected"),
 		),
+		pasteStack: key.NewBinding(
+			key.WithKeys(config["pasteStack"]),
+			key.WithHelp(config["pasteStack"], "stack selected"),
+		),
+		undo: key.NewBinding(
+			key.WithKeys(config["undo"]),
+			key.WithHelp(config["undo"], "undo delete"),
+		),
+		sortUsed: key.NewBinding(
+			key.WithKeys(config["sortUsed"]),
+			key.WithHelp(config["sortUsed"], "sort by last use"),
+		),
+		pauseCapture: key.NewBinding(
+			key.WithKeys(config["pauseCapture"]),
+			key.WithHelp(config["pauseCapture"], "pause/resume recording"),
+		),
+		switchDevice: key.NewBinding(
+			key.WithKeys(config["switchDevice"]),
+			key.WithHelp(config["switchDevice"], "switch device"),
+		),
+		switchSource: key.NewBinding(
+			key.WithKeys(config["switchSource"]),
+			key.WithHelp(config["switchSource"], "switch source app"),
+		),
+		notifications: key.NewBinding(
+			key.WithKeys(config["notifications"]),
+			key.WithHelp(config["notifications"], "notifications"),
+		),
+		saveSnippet: key.NewBinding(
+			key.WithKeys(config["saveSnippet"]),
+			key.WithHelp(config["saveSnippet"], "save snippet"),
+		),
+		snippets: key.NewBinding(
+			key.WithKeys(config["snippets"]),
+			key.WithHelp(config["snippets"], "snippets"),
+		),
+		passwords: key.NewBinding(
+			key.WithKeys(config["passwords"]),
+			key.WithHelp(config["passwords"], "passwords"),
+		),
+		emoji: key.NewBinding(
+			key.WithKeys(config["emoji"]),
+			key.WithHelp(config["emoji"], "emoji"),
+		),
 		yankFilter: key.NewBinding(
 			key.WithKeys(config["yankFilter"]),
 			key.WithHelp(config["yankFilter"], "yank filter results"),
 		),
+		copyLink: key.NewBinding(
+			key.WithKeys(config["copyLink"]),
+			key.WithHelp(config["copyLink"], "copy link"),
+		),
+		copyFormatted: key.NewBinding(
+			key.WithKeys(config["copyFormatted"]),
+			key.WithHelp(config["copyFormatted"], "copy with formatting"),
+		),
+		openURL: key.NewBinding(
+			key.WithKeys(config["openURL"]),
+			key.WithHelp(config["openURL"], "open url"),
+		),
+		qrCode: key.NewBinding(
+			key.WithKeys(config["qrCode"]),
+			key.WithHelp(config["qrCode"], "qr code"),
+		),
+		actions: key.NewBinding(
+			key.WithKeys(config["actions"]),
+			key.WithHelp(config["actions"], "actions"),
+		),
+		transform: key.NewBinding(
+			key.WithKeys(config["transform"]),
+			key.WithHelp(config["transform"], "transform"),
+		),
+		generate: key.NewBinding(
+			key.WithKeys(config["generate"]),
+			key.WithHelp(config["generate"], "generate"),
+		),
+		tmuxBuffer: key.NewBinding(
+			key.WithKeys(config["tmuxBuffer"]),
+			key.WithHelp(config["tmuxBuffer"], "tmux buffer"),
+		),
+		leader: key.NewBinding(
+			key.WithKeys(config["leader"]),
+			key.WithHelp(helpChar(config["leader"]), "all actions"),
+		),
+		reveal: key.NewBinding(
+			key.WithKeys(config["reveal"]),
+			key.WithHelp(config["reveal"], "reveal/hide"),
+		),
+		splitView: key.NewBinding(
+			key.WithKeys(config["splitView"]),
+			key.WithHelp(config["splitView"], "split view"),
+		),
+		splitUp: key.NewBinding(
+			key.WithKeys(config["splitUp"]),
+			key.WithHelp(config["splitUp"], "scroll split up"),
+		),
+		splitDown: key.NewBinding(
+			key.WithKeys(config["splitDown"]),
+			key.WithHelp(config["splitDown"], "scroll split down"),
+		),
 		up: key.NewBinding(
 			key.WithKeys(config["up"]),
 		),
@@ -134,6 +250,9 @@ type filterKeyMap struct {
 	apply       key.Binding
 	cancel      key.Binding
 	yankMatches key.Binding
+	prevQuery   key.Binding
+	nextQuery   key.Binding
+	pinQuery    key.Binding
 }
 
 func newFilterKeymap() *filterKeyMap {
@@ -152,12 +271,275 @@ func newFilterKeymap() *filterKeyMap {
 			key.WithKeys(config["yankFilter"]),
 			key.WithHelp(config["yankFilter"], "yank matched"),
 		),
+		prevQuery: key.NewBinding(
+			key.WithKeys(config["prevQuery"]),
+			key.WithHelp(config["prevQuery"], "prev query"),
+		),
+		nextQuery: key.NewBinding(
+			key.WithKeys(config["nextQuery"]),
+			key.WithHelp(config["nextQuery"], "next query"),
+		),
+		pinQuery: key.NewBinding(
+			key.WithKeys(config["pinQuery"]),
+			key.WithHelp(config["pinQuery"], "pin query"),
+		),
 	}
 }
 
 func (fk filterKeyMap) FilterHelp() []key.Binding {
 	return []key.Binding{
-		fk.apply, fk.cancel, fk.yankMatches,
+		fk.apply, fk.cancel, fk.yankMatches, fk.prevQuery, fk.pinQuery,
+	}
+}
+
+// used by the snippets list
+type snippetKeyMap struct {
+	choose key.Binding
+	remove key.Binding
+	back   key.Binding
+	filter key.Binding
+	quit   key.Binding
+}
+
+func newSnippetKeyMap() *snippetKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &snippetKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "copy"),
+		),
+		remove: key.NewBinding(
+			key.WithKeys(config["remove"]),
+			key.WithHelp(config["remove"], "delete"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["snippets"]),
+			key.WithHelp(config["snippets"], "history"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys(config["filter"]),
+			key.WithHelp(config["filter"], "filter"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys(config["quit"]),
+			key.WithHelp(config["quit"], "quit"),
+		),
+	}
+}
+
+func (sk snippetKeyMap) SnippetHelp() []key.Binding {
+	return []key.Binding{
+		sk.choose, sk.remove, sk.filter, sk.back, sk.quit,
+	}
+}
+
+// used by the password store list
+type passwordKeyMap struct {
+	choose key.Binding
+	back   key.Binding
+	filter key.Binding
+	quit   key.Binding
+}
+
+func newPasswordKeyMap() *passwordKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &passwordKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "copy"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["passwords"]),
+			key.WithHelp(config["passwords"], "history"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys(config["filter"]),
+			key.WithHelp(config["filter"], "filter"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys(config["quit"]),
+			key.WithHelp(config["quit"], "quit"),
+		),
+	}
+}
+
+func (pk passwordKeyMap) PasswordHelp() []key.Binding {
+	return []key.Binding{
+		pk.choose, pk.filter, pk.back, pk.quit,
+	}
+}
+
+// used by the emoji picker
+type emojiKeyMap struct {
+	choose key.Binding
+	back   key.Binding
+	filter key.Binding
+	quit   key.Binding
+}
+
+func newEmojiKeyMap() *emojiKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &emojiKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "copy"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["emoji"]),
+			key.WithHelp(config["emoji"], "history"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys(config["filter"]),
+			key.WithHelp(config["filter"], "search"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys(config["quit"]),
+			key.WithHelp(config["quit"], "quit"),
+		),
+	}
+}
+
+func (ek emojiKeyMap) EmojiHelp() []key.Binding {
+	return []key.Binding{
+		ek.choose, ek.filter, ek.back, ek.quit,
+	}
+}
+
+// used by the action menu
+type actionKeyMap struct {
+	choose key.Binding
+	back   key.Binding
+	filter key.Binding
+	quit   key.Binding
+}
+
+func newActionKeyMap() *actionKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &actionKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "run"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["actions"]),
+			key.WithHelp(config["actions"], "history"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys(config["filter"]),
+			key.WithHelp(config["filter"], "filter"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys(config["quit"]),
+			key.WithHelp(config["quit"], "quit"),
+		),
+	}
+}
+
+func (ak actionKeyMap) ActionHelp() []key.Binding {
+	return []key.Binding{
+		ak.choose, ak.filter, ak.back, ak.quit,
+	}
+}
+
+// used by the transform menu
+type transformKeyMap struct {
+	choose key.Binding
+	back   key.Binding
+	filter key.Binding
+	quit   key.Binding
+}
+
+func newTransformKeyMap() *transformKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &transformKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "apply and copy"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["transform"]),
+			key.WithHelp(config["transform"], "history"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys(config["filter"]),
+			key.WithHelp(config["filter"], "filter"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys(config["quit"]),
+			key.WithHelp(config["quit"], "quit"),
+		),
+	}
+}
+
+func (tk transformKeyMap) TransformHelp() []key.Binding {
+	return []key.Binding{
+		tk.choose, tk.filter, tk.back, tk.quit,
+	}
+}
+
+// used by the generate menu
+type generateKeyMap struct {
+	choose key.Binding
+	back   key.Binding
+	filter key.Binding
+	quit   key.Binding
+}
+
+func newGenerateKeyMap() *generateKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &generateKeyMap{
+		choose: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "generate and copy"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["generate"]),
+			key.WithHelp(config["generate"], "history"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys(config["filter"]),
+			key.WithHelp(config["filter"], "filter"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys(config["quit"]),
+			key.WithHelp(config["quit"], "quit"),
+		),
+	}
+}
+
+func (gk generateKeyMap) GenerateHelp() []key.Binding {
+	return []key.Binding{
+		gk.choose, gk.filter, gk.back, gk.quit,
+	}
+}
+
+// used by the text input dialog
+type inputKeyMap struct {
+	submit key.Binding
+	cancel key.Binding
+}
+
+func newInputKeyMap() *inputKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &inputKeyMap{
+		submit: key.NewBinding(
+			key.WithKeys(config["choose"]),
+			key.WithHelp("↵", "save"),
+		),
+		cancel: newCancelKey(),
+	}
+}
+
+func (ik inputKeyMap) InputHelp() []key.Binding {
+	return []key.Binding{
+		ik.submit, ik.cancel,
 	}
 }
 
@@ -165,6 +547,7 @@ type confirmationKeyMap struct {
 	up     key.Binding
 	down   key.Binding
 	choose key.Binding
+	cancel key.Binding
 }
 
 func newConfirmationKeymap() *confirmationKeyMap {
@@ -183,30 +566,66 @@ func newConfirmationKeymap() *confirmationKeyMap {
 			key.WithKeys(config["choose"]),
 			key.WithHelp(config["choose"], "choose"),
 		),
+		cancel: newCancelKey(),
 	}
 }
 
 func (ck confirmationKeyMap) ConfirmationHelp() []key.Binding {
 	return []key.Binding{
-		ck.up, ck.down, ck.choose,
+		ck.up, ck.down, ck.choose, ck.cancel,
 	}
 }
 
-type previewKeymap struct {
-	up       key.Binding
-	down     key.Binding
-	back     key.Binding
-	pageDown key.Binding
-	pageUp   key.Binding
+// used by the sync conflicts screen
+type conflictKeyMap struct {
+	keepLeft  key.Binding
+	keepRight key.Binding
+	keepBoth  key.Binding
+	skip      key.Binding
 }
 
-func newPreviewKeyMap() *previewKeymap {
+func newConflictKeyMap() *conflictKeyMap {
 	config := config.ClipseConfig.KeyBindings
 
-	previewChar := config["preview"]
-	if previewChar == " " {
-		previewChar = spaceChar
+	return &conflictKeyMap{
+		keepLeft: key.NewBinding(
+			key.WithKeys(config["keepLeft"]),
+			key.WithHelp(config["keepLeft"], "keep this device"),
+		),
+		keepRight: key.NewBinding(
+			key.WithKeys(config["keepRight"]),
+			key.WithHelp(config["keepRight"], "keep other device"),
+		),
+		keepBoth: key.NewBinding(
+			key.WithKeys(config["keepBoth"]),
+			key.WithHelp(config["keepBoth"], "keep both"),
+		),
+		skip: key.NewBinding(
+			key.WithKeys(config["cancel"], config["quit"]),
+			key.WithHelp(config["cancel"], "resolve later"),
+		),
 	}
+}
+
+func (ck conflictKeyMap) ConflictHelp() []key.Binding {
+	return []key.Binding{
+		ck.keepLeft, ck.keepRight, ck.keepBoth, ck.skip,
+	}
+}
+
+type previewKeymap struct {
+	up          key.Binding
+	down        key.Binding
+	back        key.Binding
+	pageDown    key.Binding
+	pageUp      key.Binding
+	inspect     key.Binding
+	copyEscaped key.Binding
+	actions     key.Binding
+}
+
+func newPreviewKeyMap() *previewKeymap {
+	config := config.ClipseConfig.KeyBindings
 
 	return &previewKeymap{
 		up: key.NewBinding(
@@ -218,22 +637,153 @@ func newPreviewKeyMap() *previewKeymap {
 			key.WithHelp(config["down"], "↓"),
 		),
 		pageDown: key.NewBinding(
-			key.WithKeys("PgDn"),
-			key.WithHelp("PgDn", "page down"),
+			key.WithKeys(config["pageDown"]),
+			key.WithHelp(config["pageDown"], "page down"),
 		),
 		pageUp: key.NewBinding(
-			key.WithKeys("PgUp"),
-			key.WithHelp("PgUp", "page up"),
+			key.WithKeys(config["pageUp"]),
+			key.WithHelp(config["pageUp"], "page up"),
 		),
 		back: key.NewBinding(
 			key.WithKeys(config["preview"], config["quit"]),
-			key.WithHelp(previewChar, "back"),
+			key.WithHelp(helpChar(config["preview"]), "back"),
+		),
+		inspect: key.NewBinding(
+			key.WithKeys(config["inspect"]),
+			key.WithHelp(config["inspect"], "characters"),
+		),
+		copyEscaped: key.NewBinding(
+			key.WithKeys(config["copyEscaped"]),
+			key.WithHelp(config["copyEscaped"], "copy \\u escaped"),
+		),
+		actions: key.NewBinding(
+			key.WithKeys(config["actions"]),
+			key.WithHelp(config["actions"], "actions"),
 		),
 	}
 }
 
 func (pk previewKeymap) PreviewHelp() []key.Binding {
 	return []key.Binding{
-		pk.up, pk.down, pk.pageDown, pk.pageUp, pk.back,
+		pk.up, pk.down, pk.pageDown, pk.pageUp, pk.inspect, pk.copyEscaped, pk.actions, pk.back,
+	}
+}
+
+type noticeKeyMap struct {
+	up   key.Binding
+	down key.Binding
+	back key.Binding
+}
+
+func newNoticeKeyMap() *noticeKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &noticeKeyMap{
+		up: key.NewBinding(
+			key.WithKeys(config["up"]),
+			key.WithHelp(config["up"], "↑"),
+		),
+		down: key.NewBinding(
+			key.WithKeys(config["down"]),
+			key.WithHelp(config["down"], "↓"),
+		),
+		back: key.NewBinding(
+			key.WithKeys(config["notifications"], config["cancel"], config["quit"]),
+			key.WithHelp(config["cancel"], "back"),
+		),
+	}
+}
+
+func (nk noticeKeyMap) NoticeHelp() []key.Binding {
+	return []key.Binding{
+		nk.up, nk.down, nk.back,
+	}
+}
+
+// used by the QR code screen
+// used by the leader menu
+type leaderKeyMap struct {
+	back  key.Binding
+	close key.Binding
+}
+
+func newLeaderKeyMap() *leaderKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &leaderKeyMap{
+		back: key.NewBinding(
+			key.WithKeys(config["cancel"], "backspace"),
+			key.WithHelp(config["cancel"], "back"),
+		),
+		close: key.NewBinding(
+			key.WithKeys(config["leader"]),
+			key.WithHelp(helpChar(config["leader"]), "close"),
+		),
+	}
+}
+
+func (lk leaderKeyMap) LeaderHelp() []key.Binding {
+	return []key.Binding{lk.back, lk.close}
+}
+
+type qrKeyMap struct {
+	back key.Binding
+}
+
+func newQRKeyMap() *qrKeyMap {
+	config := config.ClipseConfig.KeyBindings
+
+	return &qrKeyMap{
+		back: key.NewBinding(
+			key.WithKeys(config["qrCode"], config["cancel"], config["quit"]),
+			key.WithHelp(config["cancel"], "back"),
+		),
+	}
+}
+
+func (qk qrKeyMap) QRHelp() []key.Binding {
+	return []key.Binding{qk.back}
+}
+
+// cancels the input dialog or the running task
+func newCancelKey() key.Binding {
+	k := config.ClipseConfig.KeyBindings["cancel"]
+	return key.NewBinding(
+		key.WithKeys(k),
+		key.WithHelp(k, "cancel"),
+	)
+}
+
+// binds the bubbles list's own navigation keys to the config. Actions left
+// at their default key keep the list's extra vim style keys, eg j and k.
+func configureListKeys(km *list.KeyMap) {
+	bindings := []struct {
+		action  string
+		binding *key.Binding
+	}{
+		{"up", &km.CursorUp},
+		{"down", &km.CursorDown},
+		{"nextPage", &km.NextPage},
+		{"prevPage", &km.PrevPage},
+		{"home", &km.GoToStart},
+		{"end", &km.GoToEnd},
+		{"filter", &km.Filter},
+		{"quit", &km.Quit},
+		{"more", &km.ShowFullHelp},
+		{"more", &km.CloseFullHelp},
+	}
+	for _, b := range bindings {
+		if k, custom := config.CustomKeyBinding(b.action); custom {
+			b.binding.SetKeys(k)
+			b.binding.SetHelp(helpChar(k), b.binding.Help().Desc)
+		}
+	}
+}
+
+// makes a space key binding visible in the help menu
+func helpChar(k string) string {
+	if k == " " {
+		return spaceChar
 	}
+	return k
 }
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+)
+
+// leaderMenu lists the actions of the history list in nested groups,
+// opened with the leader key, so each one can be reached by the letters
+// shown without knowing its key binding. An action picked runs as if its
+// own key was pressed in the list.
+type leaderMenu struct {
+	root  leaderEntry
+	path  []int // groups opened, by index in their parent
+	keys  *leaderKeyMap
+	help  help.Model
+	theme config.CustomTheme
+}
+
+// leaderEntry is a group of entries, or an action of the list.
+type leaderEntry struct {
+	key     string // picks the entry in its group
+	label   string
+	entries []leaderEntry // of a group
+	binding key.Binding   // of an action
+}
+
+// openLeaderMsg opens the leader menu.
+type openLeaderMsg struct{}
+
+// closeLeaderMsg returns from the leader menu to the list.
+type closeLeaderMsg struct{}
+
+// leaderActionMsg runs the action of the list bound to binding.
+type leaderActionMsg struct {
+	binding key.Binding
+}
+
+func newLeaderMenu(theme config.CustomTheme) leaderMenu {
+	return leaderMenu{
+		keys:  newLeaderKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+// the menu of the list's actions, leaving out those disabled, eg passwords
+// without a password store
+func leaderTree(k *keyMap) leaderEntry {
+	action := func(key, label string, b key.Binding) leaderEntry {
+		return leaderEntry{key: key, label: label, binding: b}
+	}
+	group := func(key, label string, entries ...leaderEntry) leaderEntry {
+		return leaderEntry{key: key, label: label, entries: entries}
+	}
+	return group("", "", // the menu itself
+		group("c", "copy",
+			action("c", "copy", k.choose),
+			action("s", "stack selected", k.pasteStack),
+			action("f", "copy with formatting", k.copyFormatted),
+			action("l", "copy link", k.copyLink),
+			action("t", "tmux buffer", k.tmuxBuffer),
+			action("o", "open url", k.openURL),
+			action("q", "qr code", k.qrCode),
+		),
+		group("t", "transform",
+			action("t", "transform", k.transform),
+			action("a", "actions", k.actions),
+			action("g", "generate", k.generate),
+			action("n", "save snippet", k.saveSnippet),
+		),
+		group("m", "manage",
+			action("p", "pin/unpin", k.togglePin),
+			action("d", "delete", k.remove),
+			action("u", "undo delete", k.undo),
+			action("r", "reveal/hide", k.reveal),
+			action("m", "select", k.selectSingle),
+			action("j", "select down", k.selectDown),
+			action("k", "select up", k.selectUp),
+			action("c", "clear selected", k.clearSelected),
+			action("P", "pause/resume recording", k.pauseCapture),
+		),
+		group("v", "view",
+			action("f", "filter", k.filter),
+			action("p", "preview", k.preview),
+			action("s", "split view", k.splitView),
+			action("P", "show pinned", k.togglePinned),
+			action("o", "sort by last use", k.sortUsed),
+			action("d", "switch device", k.switchDevice),
+			action("a", "switch source app", k.switchSource),
+			action("?", "more help", k.more),
+		),
+		group("o", "open",
+			action("n", "snippets", k.snippets),
+			action("w", "passwords", k.passwords),
+			action("e", "emoji", k.emoji),
+			action("m", "notifications", k.notifications),
+		),
+		action("q", "quit", k.quit),
+	).enabled()
+}
+
+// the entry without its disabled actions and the groups left empty
+func (e leaderEntry) enabled() leaderEntry {
+	entries := []leaderEntry{}
+	for _, entry := range e.entries {
+		if entry.isGroup() {
+			if entry = entry.enabled(); len(entry.entries) == 0 {
+				continue
+			}
+		} else if !entry.binding.Enabled() || len(entry.binding.Keys()) == 0 {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	e.entries = entries
+	return e
+}
+
+func (e leaderEntry) isGroup() bool {
+	return e.entries != nil
+}
+
+// builds the menu from the list's key bindings as they are now
+func (m *leaderMenu) open(keys *keyMap) {
+	m.root = leaderTree(keys)
+	m.path = nil
+}
+
+// the group opened
+func (m leaderMenu) current() leaderEntry {
+	group := m.root
+	for _, i := range m.path {
+		group = group.entries[i]
+	}
+	return group
+}
+
+func (m leaderMenu) Update(msg tea.Msg) (leaderMenu, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch {
+	case key.Matches(keyMsg, m.keys.close):
+		return m, func() tea.Msg { return closeLeaderMsg{} }
+	case key.Matches(keyMsg, m.keys.back):
+		if len(m.path) == 0 {
+			return m, func() tea.Msg { return closeLeaderMsg{} }
+		}
+		m.path = m.path[:len(m.path)-1]
+		return m, nil
+	}
+
+	for i, entry := range m.current().entries {
+		if entry.key != keyMsg.String() {
+			continue
+		}
+		if entry.isGroup() {
+			m.path = append(m.path, i)
+			return m, nil
+		}
+		return m, func() tea.Msg { return leaderActionMsg{binding: entry.binding} }
+	}
+	return m, nil
+}
+
+// a key press the binding matches, key.Matches compares the keys bound
+// with the string of the key pressed
+func pressOf(b key.Binding) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(b.Keys()[0])}
+}
+
+func (m leaderMenu) View() string {
+	crumbs := []string{leaderTitle}
+	group := m.root
+	for _, i := range m.path {
+		group = group.entries[i]
+		crumbs = append(crumbs, group.label)
+	}
+	title := withTitleStyle(style, m.theme).
+		Foreground(lipgloss.Color(m.theme.TitleFore)).
+		Background(lipgloss.Color(m.theme.TitleBack)).
+		Padding(0, 1).
+		MarginTop(1).
+		MarginBottom(1).
+		Render(strings.Join(crumbs, " "+groupChar+" "))
+
+	keyStyle := style.Foreground(lipgloss.Color(m.theme.SelectedTitle)).Bold(true)
+	labelStyle := style.Foreground(lipgloss.Color(m.theme.NormalTitle))
+	hintStyle := style.Foreground(lipgloss.Color(m.theme.DimmedDesc))
+
+	labels := make([]string, len(group.entries))
+	width := 0
+	for i, entry := range group.entries {
+		labels[i] = entry.label
+		if entry.isGroup() {
+			labels[i] += " " + groupChar
+		}
+		width = max(width, lipgloss.Width(labels[i]))
+	}
+	lines := make([]string, len(group.entries))
+	for i, entry := range group.entries {
+		// actions show their own key, to learn the shortcut
+		hint := ""
+		if !entry.isGroup() {
+			hint = hintStyle.Render(helpChar(entry.binding.Keys()[0]))
+		}
+		lines[i] = keyStyle.Render(entry.key) + "  " + labelStyle.Render(fmt.Sprintf("%-*s", width, labels[i])) + "  " + hint
+	}
+
+	helpView := style.PaddingTop(1).Render(m.help.ShortHelpView(m.keys.LeaderHelp()))
+	return style.PaddingLeft(2).Render(lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(lines, "\n"), helpView))
+}
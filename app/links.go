@@ -0,0 +1,98 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/*
+	Deep links allow external tools to open the TUI focused on a
+	specific history item or search, eg:
+		clipse://item/2024-05-01T12:00:00.000000000Z
+		clipse://search/some%20query
+*/
+
+type deepLink struct {
+	kind   string // linkItem | linkSearch
+	target string // item timestamp or search query
+}
+
+// ItemLink returns the deep link pointing to the history item recorded at ts.
+func ItemLink(ts string) string {
+	return fmt.Sprintf("%s://%s/%s", linkScheme, linkItem, url.PathEscape(ts))
+}
+
+func parseDeepLink(uri string) (deepLink, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return deepLink{}, fmt.Errorf("invalid link %q: %w", uri, err)
+	}
+	if u.Scheme != linkScheme {
+		return deepLink{}, fmt.Errorf("invalid link %q: scheme must be %s://", uri, linkScheme)
+	}
+
+	target, err := url.PathUnescape(strings.TrimPrefix(u.EscapedPath(), "/"))
+	if err != nil {
+		return deepLink{}, fmt.Errorf("invalid link %q: %w", uri, err)
+	}
+	if target == "" {
+		return deepLink{}, fmt.Errorf("invalid link %q: missing target", uri)
+	}
+
+	switch u.Host {
+	case linkItem:
+		// links made before timestamps were stored as RFC3339 still resolve
+		return deepLink{kind: u.Host, target: utils.NormalizeTimeStamp(target)}, nil
+	case linkSearch:
+		return deepLink{kind: u.Host, target: target}, nil
+	default:
+		return deepLink{}, fmt.Errorf("invalid link %q: unknown link type %q", uri, u.Host)
+	}
+}
+
+// OpenLink parses a clipse:// link to be focused once the TUI has rendered.
+func (m *Model) OpenLink(uri string) error {
+	link, err := parseDeepLink(uri)
+	if err != nil {
+		return err
+	}
+	m.list.pendingLink = &link
+	return nil
+}
+
+// focuses the list on the pending deep link. Needs to wait for the initial
+// window size message so the list pagination is known.
+func (l *listPane) focusLink() tea.Cmd {
+	link := l.pendingLink
+	l.pendingLink = nil
+
+	switch link.kind {
+	case linkItem:
+		for index, listItem := range l.list.Items() {
+			if i, ok := listItem.(item); ok && i.timeStamp == link.target {
+				l.list.Select(index)
+				return nil
+			}
+		}
+		return setWarning("Linked item no longer in history")
+
+	case linkSearch:
+		// open the filter prompt pre-filled with the linked query, with the
+		// filter key as configured, the list has no other way to open it
+		if len(l.list.KeyMap.Filter.Keys()) == 0 {
+			return setWarning("No key bound to filter, can't open the linked search")
+		}
+		var cmd tea.Cmd
+		l.list, cmd = l.list.Update(pressOf(l.list.KeyMap.Filter))
+		l.list.FilterInput.SetValue(link.target)
+		l.list.FilterInput.CursorEnd()
+		return tea.Batch(cmd, l.list.SetItems(l.list.Items()))
+	}
+
+	return nil
+}
@@ -0,0 +1,1053 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/ipc"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// listPane is the main clipboard history list along with its help menu,
+// filter prompt and multi-selection state.
+type listPane struct {
+	list          list.Model         // list items
+	keys          *keyMap            // keybindings
+	help          help.Model         // custom help menu
+	prompt        queryPrompt        // filter prompt history
+	theme         config.CustomTheme // colors scheme to uses
+	togglePinned  bool               // pinned view indicator
+	sortUsed      bool               // most recently copied out first instead of most recently captured
+	paused        bool               // the listener isn't recording, shown in the title
+	device        string             // sync device whose entries are shown, localDevice for this one, empty for all
+	source        string             // application whose entries are shown, empty for all
+	prevDirection string             // prev direction used to track selections
+	selectCount   int                // number of selections made, orders the selected items
+	itemCache     []SelectedItem     // items awaiting a delete confirmation
+	writes        writeQueue         // changes yet to be written to the history file
+	undo          []undoEntry        // deleted items, most recent delete last
+	pendingLink   *deepLink          // deep link to focus once the window size is known
+	pages         historyPages       // the loaded part of the history with the sqlite storage, see paging.go
+	tmuxMode      bool               // chosen entries are loaded into a tmux paste buffer, see clipse --tmux
+}
+
+// undoEntry holds the items removed by a single delete.
+type undoEntry []config.ClipboardItem
+
+// deleteCachedMsg deletes the items awaiting confirmation.
+type deleteCachedMsg struct{}
+
+// clearCacheMsg drops the items awaiting confirmation.
+type clearCacheMsg struct{}
+
+func newListPane(clipboardItems []config.ClipboardItem, theme config.CustomTheme) listPane {
+	listKeys := newKeyMap()
+
+	entryItems := filterItems(clipboardItems, false, theme)
+
+	clipboardList := list.New(entryItems, newItemDelegate(theme), 0, 0)
+
+	clipboardList.Filter = fuzzyFilter
+	configureListKeys(&clipboardList.KeyMap)
+	clipboardList.Title = clipboardTitle                                       // set hardcoded title
+	clipboardList.SetShowHelp(false)                                           // override with custom
+	clipboardList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2) // set custom pagination spacing
+	//clipboardList.StatusMessageLifetime = time.Second // can override this if necessary
+	clipboardList.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			listKeys.preview,
+			listKeys.selectDown,
+			listKeys.selectSingle,
+			listKeys.clearSelected,
+			listKeys.pasteStack,
+			listKeys.undo,
+			listKeys.sortUsed,
+			listKeys.pauseCapture,
+			listKeys.switchDevice,
+			listKeys.switchSource,
+			listKeys.notifications,
+			listKeys.saveSnippet,
+			listKeys.snippets,
+			listKeys.passwords,
+			listKeys.emoji,
+			listKeys.copyLink,
+			listKeys.copyFormatted,
+			listKeys.openURL,
+			listKeys.qrCode,
+			listKeys.actions,
+			listKeys.transform,
+			listKeys.generate,
+			listKeys.tmuxBuffer,
+			listKeys.reveal,
+			listKeys.splitView,
+			listKeys.splitDown,
+			listKeys.splitUp,
+			listKeys.leader,
+		}
+	}
+
+	if len(clipboardItems) < 1 {
+		clipboardList.SetShowStatusBar(false) // remove duplicate "No items"
+	}
+	listKeys.switchDevice.SetEnabled(config.SyncEnabled())
+	listKeys.passwords.SetEnabled(config.ClipseConfig.PasswordStore != "")
+	listKeys.tmuxBuffer.SetEnabled(shell.InTmux())
+
+	l := listPane{
+		list:   styledList(clipboardList, theme),
+		keys:   listKeys,
+		help:   styledHelp(help.New(), theme),
+		prompt: newQueryPrompt(theme),
+		theme:  theme,
+		writes: newWriteQueue(),
+		pages:  newHistoryPages(),
+	}
+	l.paused, _ = config.CapturePaused()
+	l.list.Title = l.title()
+	return l
+}
+
+func (l listPane) Update(msg tea.Msg) (listPane, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case ReRender:
+		l.paused, _ = config.CapturePaused()
+		l.list.Title = l.title()
+		return l, l.reloadItems()
+
+	case refreshTimesMsg:
+		// the pause may have ended or been changed with clipse pause
+		l.paused, _ = config.CapturePaused()
+		l.list.Title = l.title()
+		return l, tea.Batch(l.reloadItems(), refreshTimes())
+
+	case showStatusMsg:
+		l.list.StatusMessageLifetime = msg.lifetime
+		return l, l.list.NewStatusMessage(msg.text)
+
+	case setFilterMsg:
+		l.list.FilterInput.SetValue(msg.query)
+		l.list.FilterInput.CursorEnd()
+		// re-run the filter against the new query
+		return l, l.list.SetItems(l.list.Items())
+
+	case deleteCachedMsg:
+		return l, l.deleteCached()
+
+	case clearCacheMsg:
+		l.itemCache = []SelectedItem{}
+		return l, nil
+
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		l.list.SetSize(msg.Width-h, msg.Height-v)
+
+		if l.pendingLink != nil {
+			cmds = append(cmds, l.focusLink())
+		}
+
+	case tea.KeyMsg:
+		if key.Matches(msg, l.keys.filter) && l.list.ShowHelp() {
+			l.list.Help.ShowAll = false // change default back to short help to keep in sync
+			l.list.SetShowHelp(false)
+			l.updatePaginator()
+		}
+
+		if key.Matches(msg, l.keys.filter) && !l.list.SettingFilter() {
+			l.prompt.reset()
+		}
+
+		if l.list.SettingFilter() && key.Matches(msg, l.keys.yankFilter) {
+			filterMatches := l.filterMatches()
+			if len(filterMatches) >= 1 {
+				if err := config.CopyText(strings.Join(filterMatches, "\n")); err == nil {
+					return l, tea.Quit
+				}
+				cmds = append(cmds, setError("Failed to copy all selected items."))
+			}
+			return l, tea.Batch(cmds...)
+		}
+
+		if l.list.SettingFilter() && l.prompt.Matches(msg) {
+			var cmd tea.Cmd
+			l.prompt, cmd = l.prompt.Update(msg, l.list.FilterValue())
+			return l, cmd
+		}
+
+		// Don't match any of the keys below if we're actively filtering.
+		if l.list.SettingFilter() {
+			l.setQuitEnabled(false) // disable main list quit to allow filter cancel
+			break
+		}
+
+		switch {
+		case key.Matches(msg, l.keys.splitView):
+			return l, func() tea.Msg { return toggleSplitMsg{} }
+		case key.Matches(msg, l.keys.splitDown):
+			return l, func() tea.Msg { return scrollSplitMsg{lines: splitScrollLines} }
+		case key.Matches(msg, l.keys.splitUp):
+			return l, func() tea.Msg { return scrollSplitMsg{lines: -splitScrollLines} }
+		case key.Matches(msg, l.keys.undo):
+			return l, l.undoDelete()
+		case key.Matches(msg, l.keys.sortUsed):
+			return l, l.toggleSortUsed()
+		case key.Matches(msg, l.keys.pauseCapture):
+			return l, l.togglePause()
+		case key.Matches(msg, l.keys.switchDevice):
+			return l, l.switchDevice()
+		case key.Matches(msg, l.keys.switchSource):
+			return l, l.switchSource()
+		case key.Matches(msg, l.keys.notifications):
+			return l, func() tea.Msg { return openNoticesMsg{} }
+		case key.Matches(msg, l.keys.snippets):
+			return l, func() tea.Msg { return openSnippetsMsg{} }
+		case key.Matches(msg, l.keys.passwords):
+			return l, func() tea.Msg { return openPasswordsMsg{} }
+		case key.Matches(msg, l.keys.emoji):
+			return l, func() tea.Msg { return openEmojiMsg{} }
+		case key.Matches(msg, l.keys.generate):
+			return l, func() tea.Msg { return openGenerateMsg{} }
+		case key.Matches(msg, l.keys.leader):
+			return l, func() tea.Msg { return openLeaderMsg{} }
+		}
+
+		i, ok := l.list.SelectedItem().(item)
+		if !ok {
+
+			switch {
+			case key.Matches(msg, l.keys.more):
+				l.list.SetShowHelp(!l.list.ShowHelp())
+				l.updatePaginator()
+			}
+			break
+		}
+		title := i.Title()
+		fullValue := i.TitleFull()
+		fp := i.FilePath()
+		timestamp := i.TimeStamp()
+
+		switch {
+
+		case key.Matches(msg, l.keys.choose):
+			selectedItems := l.selectedItems()
+			markUsed(timestamp, selectedItems)
+			if l.tmuxMode {
+				return l, l.loadTmuxBuffer(i, selectedItems)
+			}
+
+			if len(selectedItems) < 1 {
+				switch {
+				case fp != "null":
+					err := config.CopyImage(fp)
+					if errors.Is(err, config.ErrNoDisplay) {
+						return l, setError("Images can't be copied without a display server")
+					}
+					utils.HandleError(err)
+					return l, quitAndPaste()
+
+				case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+					startDirectPaste()
+					shell.KillProcess(os.Args[2])
+					return l, tea.Quit
+
+				case len(os.Args) > 1 && os.Args[1] == "keep":
+					utils.HandleError(config.CopyEntry(fullValue, i.html))
+					return l, setStatus("Copied to clipboard: " + title)
+
+				default:
+					utils.HandleError(config.CopyEntry(fullValue, i.html))
+					return l, quitAndPaste()
+				}
+			}
+
+			yank := ""
+			for _, item := range selectedItems {
+				if fullValue != item.Value {
+					yank += item.Value + "\n"
+				}
+			}
+			yank += fullValue
+			switch {
+
+			case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+				utils.HandleError(config.CopyText(yank))
+				startDirectPaste()
+				shell.KillProcess(os.Args[2])
+				return l, tea.Quit
+
+			case len(os.Args) > 1 && os.Args[1] == "keep":
+				if err := config.CopyText(yank); err != nil {
+					return l, setError("Could not copy all selected items.")
+				}
+				return l, setStatus("Copied to clipboard: *selected items*")
+
+			default:
+				if err := config.CopyText(yank); err == nil {
+					return l, quitAndPaste()
+				}
+				cmds = append(cmds, setError("Could not copy all selected items."))
+			}
+
+		case key.Matches(msg, l.keys.remove):
+			selectedItems := l.selectedItems()
+			var pinnedItemSelected bool
+
+			l.itemCache = append(
+				l.itemCache,
+				SelectedItem{
+					Index:     l.list.Index(),
+					TimeStamp: timestamp,
+					Value:     i.titleFull,
+					Title:     title,
+					Pinned:    i.pinned,
+				},
+			)
+
+			if i.pinned {
+				pinnedItemSelected = true
+			}
+
+			for _, selectedItem := range selectedItems {
+				if selectedItem.Pinned {
+					pinnedItemSelected = true
+				}
+				l.itemCache = append(
+					l.itemCache,
+					selectedItem,
+				)
+			}
+
+			switch {
+			case pinnedItemSelected:
+				cmds = append(cmds, confirmAction(config.ConfirmPinned, confirmationTitle, deleteCachedMsg{}, clearCacheMsg{}))
+			case len(selectedItems) >= 1:
+				cmds = append(cmds, confirmAction(config.ConfirmBulkDelete, bulkDeleteTitle, deleteCachedMsg{}, clearCacheMsg{}))
+			default:
+				cmds = append(cmds, confirmAction(config.ConfirmDelete, deleteTitle, deleteCachedMsg{}, clearCacheMsg{}))
+			}
+
+		case key.Matches(msg, l.keys.togglePin):
+			if len(l.list.Items()) == 0 {
+				l.keys.togglePin.SetEnabled(false)
+			}
+			pinned := !i.pinned
+			cmds = append(cmds, l.writes.pin(timestamp, pinned))
+			l.togglePinUpdate()
+
+			pinEvent := "Unpinned"
+			if pinned {
+				pinEvent = "Pinned"
+			}
+			cmds = append(cmds, setStatus(fmt.Sprintf("%s: %s", pinEvent, title)))
+
+		case key.Matches(msg, l.keys.togglePinned):
+			if len(l.list.Items()) == 0 {
+				l.keys.togglePinned.SetEnabled(false)
+			}
+			l.togglePinned = !l.togglePinned
+			l.list.Title = l.title()
+
+			filteredItems := filterItems(l.history(), l.togglePinned, l.theme)
+
+			if len(filteredItems) == 0 {
+				l.list.Title = clipboardTitle
+				cmds = append(cmds, setWarning("No pinned items"))
+				break
+			}
+
+			for i := len(l.list.Items()) - 1; i >= 0; i-- { // clear all items
+				l.list.RemoveItem(i)
+			}
+			for _, i := range filteredItems { // redraw all required items
+				l.list.InsertItem(len(l.list.Items()), i)
+			}
+
+		case key.Matches(msg, l.keys.selectDown):
+			if l.list.IsFiltered() {
+				cmds = append(cmds, setWarning("cannot select items with filter applied"))
+				break
+			}
+			l.toggleSelected("down")
+
+		case key.Matches(msg, l.keys.selectUp):
+			if l.list.IsFiltered() {
+				cmds = append(cmds, setWarning("cannot select items with filter applied"))
+				break
+			}
+			l.toggleSelected("up")
+
+		case key.Matches(msg, l.keys.selectSingle):
+			if l.list.IsFiltered() {
+				cmds = append(cmds, setWarning("cannot select items with filter applied"))
+				break
+			}
+			l.toggleSelectedSingle()
+
+		case key.Matches(msg, l.keys.clearSelected), key.Matches(msg, l.keys.filter):
+			l.resetSelected()
+
+		case key.Matches(msg, l.keys.pasteStack):
+			return l, l.stackSelected()
+
+		case key.Matches(msg, l.keys.reveal):
+			if !i.sensitive {
+				cmds = append(cmds, setWarning("Item is not hidden"))
+				break
+			}
+			l.toggleReveal()
+
+		case key.Matches(msg, l.keys.saveSnippet):
+			if fp != "null" {
+				cmds = append(cmds, setWarning("Images can't be saved as snippets"))
+				break
+			}
+			return l, requestInput("Save as snippet", "snippet name", func(name string) tea.Msg {
+				return saveSnippetMsg{name: name, value: fullValue}
+			})
+
+		case key.Matches(msg, l.keys.copyLink):
+			if err := config.CopyText(ItemLink(timestamp)); err != nil {
+				utils.LogERROR(fmt.Sprintf("failed to copy item link: %s", err))
+				cmds = append(cmds, setError("Could not copy link."))
+				break
+			}
+			cmds = append(cmds, setStatus("Copied link: "+title))
+
+		case key.Matches(msg, l.keys.copyFormatted):
+			if i.html == "" {
+				cmds = append(cmds, setWarning("Not copied with formatting"))
+				break
+			}
+			markUsed(timestamp, nil)
+			if err := config.CopyFormatted(i.html); err != nil {
+				utils.LogERROR(fmt.Sprintf("failed to copy with formatting: %s", err))
+				cmds = append(cmds, setError("Could not copy with formatting."))
+				break
+			}
+			if len(os.Args) > 1 && os.Args[1] == "keep" {
+				cmds = append(cmds, setStatus("Copied with formatting: "+title))
+				break
+			}
+			return l, quitAndPaste()
+
+		case key.Matches(msg, l.keys.tmuxBuffer):
+			cmds = append(cmds, l.loadTmuxBuffer(i, l.selectedItems()))
+
+		case key.Matches(msg, l.keys.openURL):
+			if fp != "null" || !utils.IsURL(fullValue) {
+				cmds = append(cmds, setWarning("Not a URL"))
+				break
+			}
+			if err := shell.OpenURL(strings.TrimSpace(fullValue)); err != nil {
+				utils.LogERROR(fmt.Sprintf("failed to open url: %s", err))
+				cmds = append(cmds, setError("Could not open URL."))
+				break
+			}
+			cmds = append(cmds, setStatus("Opened: "+title))
+
+		case key.Matches(msg, l.keys.yankFilter):
+			cmds = append(cmds, setWarning("no filtered items"))
+
+		case key.Matches(msg, l.keys.more):
+			// switch to default help for full view (better rendering)
+			l.list.SetShowHelp(!l.list.ShowHelp())
+			l.updatePaginator()
+
+		case key.Matches(msg, l.keys.up),
+			key.Matches(msg, l.keys.down),
+			key.Matches(msg, l.keys.nextPage),
+			key.Matches(msg, l.keys.prevPage),
+			key.Matches(msg, l.keys.home),
+			key.Matches(msg, l.keys.end):
+			l.prevDirection = ""
+
+		case key.Matches(msg, l.keys.preview):
+			return l, openPreview(i)
+
+		case key.Matches(msg, l.keys.actions):
+			return l, func() tea.Msg { return openActionsMsg{item: i} }
+
+		case key.Matches(msg, l.keys.transform):
+			switch {
+			case fp != "null":
+				cmds = append(cmds, setWarning("Images can't be transformed"))
+			case i.sensitive && !i.revealed:
+				cmds = append(cmds, setWarning("Reveal the entry to transform it"))
+			default:
+				return l, func() tea.Msg { return openTransformsMsg{item: i} }
+			}
+
+		case key.Matches(msg, l.keys.qrCode):
+			switch {
+			case fp != "null":
+				cmds = append(cmds, setWarning("Images can't be shown as QR codes"))
+			case i.sensitive && !i.revealed:
+				cmds = append(cmds, setWarning("Reveal the entry to show its QR code"))
+			default:
+				return l, func() tea.Msg { return openQRMsg{item: i} }
+			}
+		}
+	}
+
+	wasFiltering := l.list.SettingFilter()
+	newListModel, cmd := l.list.Update(msg)
+	l.list = newListModel
+	cmds = append(cmds, cmd, l.loadMore(), l.findFiltered())
+
+	if wasFiltering && !l.list.SettingFilter() {
+		l.setQuitEnabled(true)
+		if l.list.FilterState() == list.FilterApplied {
+			l.prompt.save(l.list.FilterValue())
+		}
+	}
+
+	return l, tea.Batch(cmds...)
+}
+
+func (l listPane) View() string {
+	switch {
+	case l.list.SettingFilter():
+		return l.viewWithFooter(l.prompt.View())
+
+	case l.list.ShowHelp():
+		return style.PaddingLeft(1).Render(l.list.View())
+
+	default:
+		return l.viewWithFooter(style.PaddingLeft(2).Render(l.help.View(l.keys)))
+	}
+}
+
+// renders the list with the footer in place of the help line
+func (l listPane) viewWithFooter(footer string) string {
+	return style.PaddingLeft(1).Render(l.list.View() + "\n" + footer)
+}
+
+/*
+	HELPER FUNCS
+*/
+
+// returns the item under the cursor
+func (l *listPane) currentItem() (item, bool) {
+	i, ok := l.list.SelectedItem().(item)
+	return i, ok
+}
+
+// deletes the items cached by a remove key press once confirmed
+func (l *listPane) deleteCached() tea.Cmd {
+	currentContent, _ := config.ReadClipboard()
+	timeStamps := []string{}
+	for _, item := range l.itemCache {
+		if item.Value == currentContent {
+			if err := config.CopyText(""); err != nil {
+				utils.LogERROR(fmt.Sprintf("could not delete all items from history: %s", err))
+			}
+		}
+		timeStamps = append(timeStamps, item.TimeStamp)
+		l.removeCachedItem(item.TimeStamp)
+	}
+
+	statusMsg := "Deleted: *selected items*"
+	if len(l.itemCache) == 1 {
+		statusMsg = "Deleted: " + l.itemCache[0].Title
+	}
+	l.itemCache = []SelectedItem{}
+
+	if len(l.list.Items()) == 0 {
+		l.keys.remove.SetEnabled(false)
+		l.list.SetShowStatusBar(false)
+	}
+
+	return tea.Batch(l.deleteItems(timeStamps), setStatus(statusMsg))
+}
+
+// queues the items for deletion, keeping them on the undo stack. The list
+// is expected to have removed them already.
+func (l *listPane) deleteItems(timeStamps []string) tea.Cmd {
+	toDelete := make(map[string]bool)
+	for _, ts := range timeStamps {
+		toDelete[ts] = true
+	}
+	deleted := []config.ClipboardItem{}
+	for _, i := range l.writes.applyPending(config.GetHistory()) {
+		if toDelete[i.Recorded] {
+			deleted = append(deleted, i)
+		}
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	l.undo = append(l.undo, undoEntry(deleted))
+	if len(l.undo) > undoLimit {
+		l.undo = l.undo[1:]
+	}
+	return l.writes.delete(deleted...)
+}
+
+// puts the most recently deleted items back in the list and history
+func (l *listPane) undoDelete() tea.Cmd {
+	if len(l.undo) == 0 {
+		return setWarning("Nothing to undo")
+	}
+	restored := l.undo[len(l.undo)-1]
+	l.undo = l.undo[:len(l.undo)-1]
+
+	statusMsg := fmt.Sprintf("Restored: %d items", len(restored))
+	if len(restored) == 1 {
+		statusMsg = "Restored: " + utils.Shorten(restored[0].Value)
+		if restored[0].Sensitive {
+			statusMsg = "Restored: " + maskedTitle
+		}
+	}
+
+	cmd := l.writes.restore(restored...)
+	return tea.Batch(cmd, l.reloadItems(), setStatus(statusMsg))
+}
+
+func (l *listPane) togglePinUpdate() {
+	index := l.list.Index()
+	item, ok := l.list.SelectedItem().(item)
+	if !ok {
+		return
+	}
+	item.description = item.descriptionBase
+	if !item.pinned {
+		item.description = fmt.Sprintf("%s %s", item.descriptionBase, styledPin(l.theme))
+	}
+
+	item.pinned = !item.pinned
+	l.list.SetItem(index, item)
+	if l.list.IsFiltered() {
+		l.list.ResetFilter() // move selected pinned item to front
+	}
+}
+
+// shows or hides the value of the sensitive item under the cursor
+func (l *listPane) toggleReveal() {
+	index := l.list.Index()
+	item, ok := l.list.SelectedItem().(item)
+	if !ok {
+		return
+	}
+	item.revealed = !item.revealed
+	item.title = maskedTitle
+	if item.revealed {
+		item.title = utils.Shorten(item.titleFull)
+	}
+	item.titleBase = item.title
+	l.list.SetItem(index, item)
+}
+
+func (l *listPane) updatePaginator() {
+	pagStyle := lipgloss.NewStyle().MarginBottom(1).MarginLeft(2)
+	if l.list.ShowHelp() {
+		pagStyle = lipgloss.NewStyle().MarginBottom(0).MarginLeft(2)
+	}
+	l.list.Styles.PaginationStyle = pagStyle
+}
+
+func (l *listPane) toggleSelectedSingle() {
+	l.prevDirection = ""
+	index := l.list.Index()
+	item, ok := l.list.SelectedItem().(item)
+	if !ok {
+		return
+	}
+	item.selected = !item.selected
+	if item.selected {
+		l.selectCount++
+		item.selectOrder = l.selectCount
+	}
+	l.list.SetItem(index, item)
+}
+
+func (l *listPane) toggleSelected(direction string) {
+	if l.prevDirection == "" {
+		l.prevDirection = direction
+	}
+
+	item, ok := l.list.SelectedItem().(item)
+	if !ok {
+		return
+	}
+
+	index := l.list.Index()
+
+	switch {
+	case item.selected:
+		item.selected = false
+	case l.prevDirection == direction && !item.selected:
+		item.selected = true
+		l.selectCount++
+		item.selectOrder = l.selectCount
+	default:
+		l.prevDirection = ""
+	}
+
+	l.list.SetItem(index, item)
+
+	switch direction {
+	case "down":
+		l.list.CursorDown()
+	case "up":
+		l.list.CursorUp()
+	}
+}
+
+func (l *listPane) selectedItems() []SelectedItem {
+	selectedItems := []SelectedItem{}
+	for index, i := range l.list.Items() {
+		item, ok := i.(item)
+		if !ok {
+			continue
+		}
+		if item.selected {
+			selectedItems = append(
+				selectedItems,
+				SelectedItem{
+					Index:     index,
+					TimeStamp: item.TimeStamp(),
+					Value:     item.titleFull,
+					Title:     item.Title(),
+					Pinned:    item.pinned,
+					Order:     item.selectOrder,
+				},
+			)
+		}
+
+	}
+	return selectedItems
+}
+
+// queues the selected items on the paste stack in the order they were
+// selected and copies the first, `clipse -pop` copies each of the others
+func (l *listPane) stackSelected() tea.Cmd {
+	selectedItems := l.selectedItems()
+	if len(selectedItems) == 0 {
+		return setWarning("No items selected")
+	}
+	sort.SliceStable(selectedItems, func(i, j int) bool {
+		return selectedItems[i].Order < selectedItems[j].Order
+	})
+
+	timeStamps := []string{}
+	for _, selectedItem := range selectedItems {
+		timeStamps = append(timeStamps, selectedItem.TimeStamp)
+	}
+	if err := config.SetPasteStack(timeStamps); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to create paste stack: %s", err))
+		return setError("Could not create paste stack.")
+	}
+
+	// the queue runs in a process of its own, which a passphrase can't unlock
+	if config.ClipseConfig.PasteQueue && shell.PasteQueueSupported(config.DisplayServer()) && !config.PassphraseProtected() {
+		if err := shell.StartPasteQueue(); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to start paste queue: %s", err))
+			return setError("Could not start paste queue.")
+		}
+		l.resetSelected()
+		return setStatus(fmt.Sprintf("Queued %d items, each paste copies the next", len(timeStamps)))
+	}
+
+	first, remaining, err := config.PopPasteStack()
+	if err == nil {
+		if first.FilePath != "null" {
+			err = config.CopyImage(first.FilePath)
+		} else {
+			err = config.CopyText(first.Value)
+		}
+	}
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to copy first paste stack item: %s", err))
+		return setError("Could not copy first stacked item.")
+	}
+
+	l.resetSelected()
+	return setStatus(fmt.Sprintf("Copied first of %d stacked items, run clipse -pop for the next", remaining+1))
+}
+
+func (l *listPane) removeMultiSelected() {
+	items := l.list.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		if item, ok := items[i].(item); ok && item.selected {
+			l.list.RemoveItem(i)
+		}
+	}
+}
+
+func (l *listPane) resetSelected() {
+	items := l.list.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		if item, ok := items[i].(item); ok && item.selected {
+			item.selected = false
+			l.list.SetItem(i, item)
+		}
+	}
+}
+
+// returns the full values of the items matching the current filter
+func (l *listPane) filterMatches() []string {
+	filteredItems := []string{}
+	if l.list.FilterValue() == "" {
+		return filteredItems
+	}
+	for _, i := range l.list.VisibleItems() {
+		if item, ok := i.(item); ok {
+			filteredItems = append(filteredItems, item.titleFull)
+		}
+	}
+
+	return filteredItems
+}
+
+func (l *listPane) removeCachedItem(ts string) {
+	items := l.list.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		if item, ok := items[i].(item); ok && item.timeStamp == ts {
+			l.list.RemoveItem(i)
+		}
+	}
+}
+
+func (l *listPane) setQuitEnabled(v bool) {
+	l.list.KeyMap.Quit.SetEnabled(v)
+}
+
+// the history with the changes yet to be written, in the current sort order,
+// of the device and application being viewed
+func (l listPane) history() []config.ClipboardItem {
+	var history []config.ClipboardItem
+	if l.pages.enabled {
+		history = l.pages.history()
+	} else {
+		history = config.GetHistory()
+	}
+	history = l.writes.applyPending(history)
+	if l.device != "" {
+		history = onDevice(history, l.device)
+	}
+	if l.source != "" {
+		history = fromSource(history, l.source)
+	}
+	if l.sortUsed {
+		history = sortByUsed(history)
+	}
+	return history
+}
+
+// list title reflecting the pinned view and sort order
+func (l listPane) title() string {
+	title := clipboardTitle
+	if l.togglePinned {
+		title = "Pinned " + title
+	}
+	if l.sortUsed {
+		title += " by last use"
+	}
+	switch l.device {
+	case "":
+	case localDevice:
+		title += " on this device"
+	default:
+		title += " from " + l.device
+	}
+	if l.source != "" {
+		title += " in " + l.source
+	}
+	if l.paused {
+		title += " " + pausedChar
+	}
+	return title
+}
+
+// pauses or resumes recording by the listener until toggled again
+func (l *listPane) togglePause() tea.Cmd {
+	if l.paused {
+		_, err := ipc.Resume()
+		if errors.Is(err, ipc.ErrNotRunning) {
+			_, err = config.ResumeCapture()
+		}
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to resume capture: %s", err))
+			return setError("Could not resume recording.")
+		}
+	} else {
+		err := ipc.Pause(0)
+		if errors.Is(err, ipc.ErrNotRunning) {
+			err = config.PauseCapture(0)
+		}
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to pause capture: %s", err))
+			return setError("Could not pause recording.")
+		}
+	}
+
+	l.paused = !l.paused
+	l.list.Title = l.title()
+	if l.paused {
+		return setStatus("Paused recording")
+	}
+	return setStatus("Resumed recording")
+}
+
+// cycles the entries shown through all devices, this one and each device
+// entries were synced from
+func (l *listPane) switchDevice() tea.Cmd {
+	views := append([]string{"", localDevice}, syncedDevices(l.writes.applyPending(config.GetHistory()))...)
+	next := 0
+	for i, device := range views {
+		if device == l.device {
+			next = (i + 1) % len(views)
+		}
+	}
+	l.device = views[next]
+	l.list.Title = l.title()
+
+	status := "Showing all devices"
+	switch l.device {
+	case "":
+	case localDevice:
+		status = "Showing this device"
+	default:
+		status = "Showing " + l.device
+	}
+	return tea.Batch(l.reloadItems(), setStatus(status))
+}
+
+// the devices entries in the history were synced from, by name
+func syncedDevices(history []config.ClipboardItem) []string {
+	seen := make(map[string]bool)
+	devices := []string{}
+	for _, entry := range history {
+		if entry.Device != "" && !seen[entry.Device] {
+			seen[entry.Device] = true
+			devices = append(devices, entry.Device)
+		}
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// cycles the entries shown through all applications and each application
+// entries were copied from
+func (l *listPane) switchSource() tea.Cmd {
+	views := append([]string{""}, copiedSources(l.writes.applyPending(config.GetHistory()))...)
+	if len(views) == 1 {
+		return setWarning("No entries with a source app")
+	}
+	next := 0
+	for i, source := range views {
+		if source == l.source {
+			next = (i + 1) % len(views)
+		}
+	}
+	l.source = views[next]
+	l.list.Title = l.title()
+
+	status := "Showing all apps"
+	if l.source != "" {
+		status = "Showing " + l.source
+	}
+	return tea.Batch(l.reloadItems(), setStatus(status))
+}
+
+// the applications entries in the history were copied from, by name
+func copiedSources(history []config.ClipboardItem) []string {
+	seen := make(map[string]bool)
+	sources := []string{}
+	for _, entry := range history {
+		if entry.Source != "" && !seen[entry.Source] {
+			seen[entry.Source] = true
+			sources = append(sources, entry.Source)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// the entries copied from source
+func fromSource(history []config.ClipboardItem, source string) []config.ClipboardItem {
+	filtered := []config.ClipboardItem{}
+	for _, entry := range history {
+		if entry.Source == source {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// the entries copied on device, localDevice for this one
+func onDevice(history []config.ClipboardItem, device string) []config.ClipboardItem {
+	if device == localDevice {
+		device = ""
+	}
+	filtered := []config.ClipboardItem{}
+	for _, entry := range history {
+		if entry.Device == device {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func (l *listPane) toggleSortUsed() tea.Cmd {
+	l.sortUsed = !l.sortUsed
+	l.list.Title = l.title()
+
+	status := "Sorted by capture time"
+	if l.sortUsed {
+		status = "Sorted by last use"
+	}
+	return tea.Batch(l.reloadItems(), setStatus(status))
+}
+
+// entries copied out most recently first, followed by the entries never
+// copied out in capture order
+func sortByUsed(history []config.ClipboardItem) []config.ClipboardItem {
+	sorted := append([]config.ClipboardItem{}, history...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastUsed > sorted[j].LastUsed
+	})
+	return sorted
+}
+
+// records the copied entries' use in the history file
+func markUsed(timeStamp string, selected []SelectedItem) {
+	timeStamps := []string{timeStamp}
+	for _, s := range selected {
+		if s.TimeStamp != timeStamp {
+			timeStamps = append(timeStamps, s.TimeStamp)
+		}
+	}
+	if err := config.MarkUsed(timeStamps...); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to record use of entries: %s", err))
+	}
+}
+
+// quits the TUI once an entry has been copied, pasting it into the window
+// it was opened from if directPaste is enabled
+func quitAndPaste() tea.Cmd {
+	startDirectPaste()
+	return tea.Quit
+}
+
+func startDirectPaste() {
+	if !config.ClipseConfig.DirectPaste.Enabled {
+		return
+	}
+	if err := shell.StartPaste(); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to start direct paste: %s", err))
+	}
+}
@@ -0,0 +1,299 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// migrator lists the data found that can be carried over after an
+// upgrade, see config/migrations.go, and migrates the sources picked one
+// after the other, showing the progress of each. It runs before the TUI,
+// so the history it opens has the migrated entries.
+type migrator struct {
+	sources  []config.MigrationSource
+	picked   []bool
+	cursor   int
+	running  bool
+	current  int     // index of the source being migrated
+	progress float64 // of the current source, 0 to 1
+	results  []string
+	finished bool
+	events   chan tea.Msg
+	cancel   context.CancelFunc
+	spinner  spinner.Model
+	theme    config.CustomTheme
+}
+
+// migrationProgressMsg reports the steps done of the source migrated.
+type migrationProgressMsg struct {
+	index, done, total int
+}
+
+// migrationDoneMsg reports a source migrated, or that failed to.
+type migrationDoneMsg struct {
+	index  int
+	result config.MigrationResult
+	err    error
+}
+
+// migrationsFinishedMsg is sent once every source picked was migrated.
+type migrationsFinishedMsg struct{}
+
+// RunMigrator opens the migration assistant if there is data that wasn't
+// migrated or declined yet, or declined too with withDeclined. Sources left
+// unpicked are declined, leaving with the cancel key asks again on the next
+// start. Returns whether there was any data to migrate.
+func RunMigrator(withDeclined bool) (bool, error) {
+	sources := config.PendingMigrations(withDeclined)
+	if len(sources) == 0 {
+		return false, nil
+	}
+
+	m := migrator{
+		sources: sources,
+		picked:  make([]bool, len(sources)),
+		theme:   loadTheme(),
+	}
+	for i := range m.picked {
+		m.picked[i] = true
+	}
+	m.spinner = spinner.New(spinner.WithSpinner(spinner.Dot))
+	m.spinner.Style = style.Foreground(lipgloss.Color(m.theme.StatusMsg))
+	statusMessageStyle = styledStatusMessage(m.theme)
+
+	_, err := tea.NewProgram(m).Run()
+	return true, err
+}
+
+func (m migrator) Init() tea.Cmd {
+	return tea.EnterAltScreen
+}
+
+func (m migrator) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !m.running {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case migrationProgressMsg:
+		m.current = msg.index
+		m.progress = float64(msg.done) / float64(max(msg.total, 1))
+		return m, m.next()
+
+	case migrationDoneMsg:
+		m.results = append(m.results, m.describeResult(msg))
+		m.progress = 0
+		return m, m.next()
+
+	case migrationsFinishedMsg:
+		m.running = false
+		m.finished = true
+		var declined []config.MigrationSource
+		for i, source := range m.sources {
+			if !m.picked[i] {
+				declined = append(declined, source)
+			}
+		}
+		if err := config.DeclineMigrations(declined); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to save the declined migrations: %s", err))
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m migrator) handleKey(k string) (tea.Model, tea.Cmd) {
+	bindings := config.ClipseConfig.KeyBindings
+	if k == forceQuitKey {
+		if m.running {
+			m.cancel()
+			return m, nil
+		}
+		return m, tea.Quit
+	}
+	if m.finished {
+		if k == bindings["choose"] || k == bindings["cancel"] || k == bindings["quit"] {
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+	if m.running {
+		if k == bindings["cancel"] {
+			m.cancel()
+		}
+		return m, nil
+	}
+
+	switch k {
+	case bindings["up"], "k":
+		m.cursor = max(m.cursor-1, 0)
+	case bindings["down"], "j":
+		m.cursor = min(m.cursor+1, len(m.sources)-1)
+	case bindings["selectSingle"]:
+		m.picked[m.cursor] = !m.picked[m.cursor]
+	case bindings["choose"]:
+		return m.start()
+	case declineMigrationsKey:
+		if err := config.DeclineMigrations(m.sources); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to save the declined migrations: %s", err))
+		}
+		return m, tea.Quit
+	case bindings["cancel"]:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// migrates the picked sources in the background, sending their progress
+// to events
+func (m migrator) start() (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.running = true
+	m.events = make(chan tea.Msg)
+
+	go func() {
+		defer cancel()
+		for i, source := range m.sources {
+			if !m.picked[i] {
+				continue
+			}
+			m.events <- migrationProgressMsg{index: i}
+			progress := func(done, total int) {
+				m.events <- migrationProgressMsg{index: i, done: done, total: total}
+			}
+			result, err := config.Migrate(ctx, source, progress)
+			m.events <- migrationDoneMsg{index: i, result: result, err: err}
+		}
+		m.events <- migrationsFinishedMsg{}
+	}()
+	return m, tea.Batch(m.spinner.Tick, m.next())
+}
+
+// waits for the next event of the running migration
+func (m migrator) next() tea.Cmd {
+	return func() tea.Msg { return <-m.events }
+}
+
+func (m migrator) describeResult(msg migrationDoneMsg) string {
+	name := describeSource(m.sources[msg.index])
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		return fmt.Sprintf("%s %s: cancelled", warnChar, name)
+	case msg.err != nil:
+		utils.LogERROR(fmt.Sprintf("failed to migrate %s: %s", name, msg.err))
+		return fmt.Sprintf("%s %s: %s", errorChar, name, msg.err)
+	}
+
+	r := msg.result
+	if m.sources[msg.index].Kind == config.MigrateSchema {
+		return fmt.Sprintf("%s %s: upgraded to schema %d", doneChar, name, config.HistorySchema)
+	}
+	parts := []string{"imported " + countOf(r.Added, "entry", "entries")}
+	if r.Merged > 0 {
+		parts = append(parts, "merged "+countOf(r.Merged, "duplicate", "duplicates"))
+	}
+	if r.Images > 0 {
+		parts = append(parts, "copied "+countOf(r.Images, "image", "images"))
+	}
+	if r.Theme {
+		parts = append(parts, "copied the theme")
+	}
+	if r.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("skipped %s", utils.FormatCount(r.Skipped)))
+	}
+	if r.Dropped > 0 {
+		parts = append(parts, "removed "+countOf(r.Dropped, "entry", "entries")+" over maxHistory")
+	}
+	return fmt.Sprintf("%s %s: %s", doneChar, name, strings.Join(parts, ", "))
+}
+
+func countOf(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return utils.FormatCount(n) + " " + plural
+}
+
+func describeSource(source config.MigrationSource) string {
+	switch source.Kind {
+	case config.MigrateLegacy:
+		return "clipboard_manager data"
+	case config.MigrateSchema:
+		return fmt.Sprintf("history from schema %d", source.Schema)
+	}
+	return source.From + " history"
+}
+
+func (m migrator) View() string {
+	title := withTitleStyle(style, m.theme).
+		Foreground(lipgloss.Color(m.theme.TitleFore)).
+		Background(lipgloss.Color(m.theme.TitleBack)).
+		Padding(0, 1).
+		Render(migratorTitle)
+	selectedStyle := style.Foreground(lipgloss.Color(m.theme.SelectedTitle)).Bold(true)
+	textStyle := style.Foreground(lipgloss.Color(m.theme.NormalTitle))
+	descStyle := style.Foreground(lipgloss.Color(m.theme.DimmedDesc))
+	hintStyle := style.Foreground(lipgloss.Color(m.theme.HelpDesc))
+
+	var b strings.Builder
+	b.WriteString(title + "\n\n")
+	b.WriteString(textStyle.Render("Found data to carry over into this version of clipse:") + "\n\n")
+	for i, source := range m.sources {
+		box := "[ ]"
+		if m.picked[i] {
+			box = "[x]"
+		}
+		line := fmt.Sprintf("%s %s", box, describeSource(source))
+		if i == m.cursor && !m.running && !m.finished {
+			line = selectedStyle.Render(line)
+		} else {
+			line = textStyle.Render(line)
+		}
+		b.WriteString("  " + line + "  " + descStyle.Render(source.Path) + "\n")
+	}
+	b.WriteString("\n")
+
+	for _, result := range m.results {
+		b.WriteString(statusMessageStyle(result) + "\n")
+	}
+	switch {
+	case m.running:
+		b.WriteString(fmt.Sprintf("%s %s %s\n\n", m.spinner.View(),
+			textStyle.Render("Migrating "+describeSource(m.sources[m.current])),
+			progressBar(m.progress, selectedStyle, descStyle)))
+		b.WriteString(hintStyle.Render(fmt.Sprintf("%s to cancel", helpChar(config.ClipseConfig.KeyBindings["cancel"]))))
+	case m.finished:
+		b.WriteString("\n" + hintStyle.Render(fmt.Sprintf("Press %s to open clipse", helpChar(config.ClipseConfig.KeyBindings["choose"]))))
+	default:
+		keys := config.ClipseConfig.KeyBindings
+		b.WriteString(hintStyle.Render(fmt.Sprintf(
+			"%s toggle • %s migrate the picked ones • %s ask again next time • %s don't ask again",
+			helpChar(keys["selectSingle"]), helpChar(keys["choose"]), helpChar(keys["cancel"]), declineMigrationsKey,
+		)))
+	}
+	return appStyle.Render(b.String())
+}
+
+func progressBar(progress float64, filled, empty lipgloss.Style) string {
+	n := int(progress * progressBarWidth)
+	return filled.Render(strings.Repeat("█", n)) +
+		empty.Render(strings.Repeat("░", progressBarWidth-n)) +
+		fmt.Sprintf(" %3.0f%%", progress*100)
+}
@@ -4,53 +4,90 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/charmbracelet/bubbles/help"
-	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/savedra1/clipse/config"
 	"github.com/savedra1/clipse/utils"
 )
 
+/*
+	The TUI is made of components that each own their state, keys and
+	view and talk to each other through messages. Model only tracks
+	which screen is focused and routes messages, so a new screen is a
+	new component plus a screen constant.
+*/
+
+type screen int
+
+const (
+	screenList     screen = iota // the clipboard history list
+	screenPreview                // full view of the selected entry
+	screenConfirm                // yes/no dialog for destructive actions
+	screenSnippet                // saved snippets list
+	screenInput                  // text input dialog, eg naming a snippet
+	screenConflict               // sync conflicts to resolve
+	screenNotices                // status message history
+	screenSecrets                // password store entries
+	screenEmoji                  // emoji and symbol picker
+	screenQR                     // QR code of an entry
+	screenActions                // commands to run on an entry
+	screenReshape                // built-in transforms of an entry
+	screenCreate                 // generators of new entries
+	screenLeader                 // every action of the list in groups
+)
+
 type Model struct {
-	list             list.Model          // list items
-	keys             *keyMap             // keybindings
-	filterKeys       *filterKeyMap       // keybindings for filter view
-	confirmationKeys *confirmationKeyMap // keybindings for the confirmation view
-	help             help.Model          // custom help menu
-	togglePinned     bool                // pinned view indicator
-	theme            config.CustomTheme  // colors scheme to uses
-	prevDirection    string              // prev direction used to track selections
-	confirmationList list.Model          // secondary list Model used for confirmation screen
-	showConfirmation bool                // whether to show confirmation screen
-	itemCache        []SelectedItem      // easy access for related items following confirmation screen
-	preview          viewport.Model      // viewport model used for displaying previews
-	originalHeight   int                 // for restore height of preview viewport in sixel mode
-	previewReady     bool                // viewport needs to wait for the initial window size message
-	showPreview      bool                // whether the viewport preview should be displayed
-	previewKeys      *previewKeymap      // keybindings for the viewport model
-	lastUpdated      time.Time
+	list      listPane      // clipboard history list, filter prompt and status bar
+	preview   previewPane   // viewport used for displaying previews
+	confirm   confirmDialog // confirmation screen
+	snippet   snippetPane   // saved snippets list
+	secrets   passwordPane  // password store entries
+	emoji     emojiPane     // emoji and symbol picker
+	qr        qrPane        // QR code screen
+	actions   actionMenu    // commands of the actions config
+	reshape   transformMenu // built-in transforms
+	create    generateMenu  // generators of new entries
+	leader    leaderMenu    // every action of the list in groups
+	input     inputDialog   // text input screen
+	conflict  conflictPane  // sync conflicts screen
+	notices   noticePane    // status message history screen
+	notifier  notifier      // queue of the status messages shown by the list panes
+	split     splitPane     // full entry shown next to the list
+	task      taskRunner    // slow operation running in the background
+	showSplit bool          // whether the split view is displayed
+	screen    screen        // component currently receiving key presses
+	inputFrom screen        // screen to return to once the input dialog closes
+	askedFrom screen        // screen to return to once the confirmation dialog closes
+	width     int           // last known window size, used to lay out the split view
+	height    int
 }
 
 type item struct {
 	title           string // display title in list
 	titleBase       string // unstyled string used for rendering
 	titleFull       string // full value stored in history file
-	timeStamp       string // local date and time of copy event
+	timeStamp       string // recorded time of copy event, utils.TimeLayout
 	description     string // displayed description in list
 	descriptionBase string // unstyled string used for rendering
 	filePath        string // "path/to/file" | "null"
 	pinned          bool   // pinned status
 	selected        bool   // selected status
+	selectOrder     int    // when the item was selected, orders the paste stack
+	sensitive       bool   // likely secret, hidden until revealed
+	revealed        bool   // sensitive value currently shown
+	matches         []int  // runes of titleBase matched by the filter
+	label           string // snippet name, searched along with the value
+	html            string // text/html the entry was copied with, if any
 }
 
 type SelectedItem struct {
 	Index     int    // list index needed for deletion
 	TimeStamp string // timestamp needed for deletion
 	Value     string // full val needed for copy
+	Title     string // display title shown once deleted
 	Pinned    bool   // pinned val needed to determine whether confirmation screen is needed
+	Order     int    // selection order needed for the paste stack
 }
 
 func (i item) Title() string       { return i.title }
@@ -58,91 +95,141 @@ func (i item) TitleFull() string   { return i.titleFull }
 func (i item) TimeStamp() string   { return i.timeStamp }
 func (i item) Description() string { return i.description }
 func (i item) FilePath() string    { return i.filePath }
-func (i item) FilterValue() string { return i.title }
-
-func (m Model) Init() tea.Cmd {
-	return tea.EnterAltScreen
+func (i item) FilterValue() string {
+	// hidden values can't be searched, their matches would give them away
+	if i.sensitive && !i.revealed {
+		return i.title
+	}
+	if i.label != "" {
+		return i.label + ": " + i.titleFull
+	}
+	return i.titleFull
 }
 
-func NewModel() Model {
-	var (
-		listKeys         = newKeyMap()
-		filterKeys       = newFilterKeymap()
-		confirmationKeys = newConfirmationKeymap()
-	)
-
-	clipboardItems := config.GetHistory()
-
-	theme := config.GetTheme()
-
-	m := Model{
-		keys:             listKeys,
-		filterKeys:       filterKeys,
-		confirmationKeys: confirmationKeys,
-		help:             help.New(),
-		togglePinned:     false,
-		theme:            theme,
-		prevDirection:    "",
-		showConfirmation: false,
-		preview:          NewPreview(),
-		showPreview:      false,
-		previewKeys:      newPreviewKeyMap(),
+// shows the part of the value matched by the filter in place of the title
+func (i item) withMatches(matches []int) item {
+	if len(matches) == 0 {
+		return i
 	}
+	i.titleBase, i.matches = excerpt(i.FilterValue(), matches, filterExcerptLen)
+	return i
+}
 
-	entryItems := filterItems(clipboardItems, false, m.theme)
-
-	del := m.newItemDelegate()
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(tea.EnterAltScreen, refreshTimes())
+}
 
-	clipboardList := list.New(entryItems, del, 0, 0)
+func NewModel() Model {
+	theme := loadTheme()
+	statusMessageStyle = styledStatusMessage(theme)
 
-	clipboardList.Title = clipboardTitle                                       // set hardcoded title
-	clipboardList.SetShowHelp(false)                                           // override with custom
-	clipboardList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2) // set custom pagination spacing
-	//clipboardList.StatusMessageLifetime = time.Second // can override this if necessary
-	clipboardList.AdditionalFullHelpKeys = func() []key.Binding {
-		return []key.Binding{
-			listKeys.preview,
-			listKeys.selectDown,
-			listKeys.selectSingle,
-			listKeys.clearSelected,
-		}
+	return Model{
+		list:     newListPane(initialHistory(), theme),
+		preview:  newPreviewPane(theme),
+		confirm:  newConfirmDialog(theme),
+		snippet:  newSnippetPane(theme),
+		secrets:  newPasswordPane(theme),
+		emoji:    newEmojiPane(theme),
+		qr:       newQRPane(theme),
+		actions:  newActionMenu(theme),
+		reshape:  newTransformMenu(theme),
+		create:   newGenerateMenu(theme),
+		leader:   newLeaderMenu(theme),
+		input:    newInputDialog(theme),
+		conflict: newConflictPane(theme),
+		notices:  newNoticePane(theme),
+		split:    newSplitPane(theme),
+		task:     newTaskRunner(theme),
+		screen:   screenList,
 	}
+}
 
-	confirmationList := newConfirmationList(del)
+// OpenConflicts opens the TUI on the sync conflicts waiting to be resolved.
+func (m *Model) OpenConflicts() {
+	m.conflict.open()
+	m.screen = screenConflict
+}
 
-	if len(clipboardItems) < 1 {
-		clipboardList.SetShowStatusBar(false) // remove duplicate "No items"
-	}
+// OpenEmoji opens the TUI on the emoji picker.
+func (m *Model) OpenEmoji() {
+	m.emoji.open()
+	m.screen = screenEmoji
+}
 
-	statusMessageStyle = styledStatusMessage(theme)
-	m.help = styledHelp(m.help, theme)
-	m.list = styledList(clipboardList, theme)
-	m.confirmationList = styledList(confirmationList, theme)
-	m.enableConfirmationKeys(false)
+// LoadIntoTmux makes choosing an entry load it into a tmux paste buffer
+// instead of copying it.
+func (m *Model) LoadIntoTmux() {
+	m.list.tmuxMode = true
+}
 
-	return m
+// FlushWrites writes any changes made in the TUI that are still waiting on
+// the debounce timer, it should be called once the program has exited.
+func (m Model) FlushWrites() error {
+	return m.list.writes.flush()
 }
 
 // if isPinned is true, returns only an array of pinned items, otherwise all
+// a stored timestamp relative to now, eg "2 minutes ago"
+func relativeTime(ts string, now time.Time) string {
+	t, err := utils.ParseTimeStamp(ts)
+	if err != nil {
+		return ts
+	}
+	return utils.RelativeTime(t, now)
+}
+
 func filterItems(clipboardItems []config.ClipboardItem, isPinned bool, theme config.CustomTheme) []list.Item {
 	var filteredItems []list.Item
+	now := time.Now()
 
 	for _, entry := range clipboardItems {
 		shortenedVal := utils.Shorten(entry.Value)
+		desc := "copied " + relativeTime(entry.Recorded, now)
+		if entry.Selection == config.SelectionPrimary {
+			desc = "selected " + relativeTime(entry.Recorded, now)
+		}
+		if entry.FilePath == "null" && !entry.Sensitive && utils.IsURL(entry.Value) {
+			desc = urlChar + " " + desc
+		}
+		if entry.Source != "" {
+			desc += " from " + entry.Source
+		}
+		if entry.HTML != "" {
+			desc += " " + formattedChar
+		}
+		if entry.Transform != "" {
+			desc += fmt.Sprintf(" %s %s", derivedChar, entry.Transform)
+		}
+		if entry.Tag != "" {
+			desc += fmt.Sprintf(" %s%s", tagChar, entry.Tag)
+		}
+		if entry.Device != "" {
+			desc += fmt.Sprintf(" %s%s", deviceChar, entry.Device)
+		}
+		if entry.Pastes > 0 {
+			desc += fmt.Sprintf(" %s %s× %s", usedChar, utils.FormatCount(entry.Pastes), relativeTime(entry.LastUsed, now))
+		}
 		item := item{
 			title:           shortenedVal,
 			titleBase:       shortenedVal,
 			titleFull:       entry.Value,
-			description:     "Date copied: " + entry.Recorded,
-			descriptionBase: "Date copied: " + entry.Recorded,
+			description:     desc,
+			descriptionBase: desc,
 			filePath:        entry.FilePath,
 			pinned:          entry.Pinned,
 			timeStamp:       entry.Recorded,
 			selected:        false,
+			html:            entry.HTML,
+		}
+
+		if entry.Sensitive {
+			item.sensitive = true
+			item.title = maskedTitle
+			item.titleBase = maskedTitle
 		}
 
 		if entry.Pinned {
-			item.description = fmt.Sprintf("Date copied: %s %s", entry.Recorded, styledPin(theme))
+			item.description = fmt.Sprintf("%s %s", desc, styledPin(theme))
 		}
 
 		if !isPinned || entry.Pinned {
@@ -152,30 +239,3 @@ func filterItems(clipboardItems []config.ClipboardItem, isPinned bool, theme con
 
 	return filteredItems
 }
-
-func newConfirmationList(del itemDelegate) list.Model {
-	items := confirmationItems()
-	l := list.New(items, del, 0, 10)
-	l.Title = confirmationTitle
-	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
-	l.SetShowHelp(false)
-	l.SetShowPagination(false)
-	l.KeyMap.Quit.SetEnabled(false)
-	return l
-}
-
-func confirmationItems() []list.Item {
-	return []list.Item{
-		item{
-			title:           "No",
-			titleBase:       "No",
-			descriptionBase: "go back",
-		},
-		item{
-			title:           "Yes",
-			titleBase:       "Yes",
-			descriptionBase: "delete the item(s)",
-		},
-	}
-}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// noticePane lists the last status messages, newest first, for feedback
+// that was replaced or timed out before it could be read.
+type noticePane struct {
+	viewport viewport.Model
+	keys     *noticeKeyMap
+	help     help.Model
+	theme    config.CustomTheme
+}
+
+// openNoticesMsg shows the status message history.
+type openNoticesMsg struct{}
+
+// closeNoticesMsg returns from the status message history to the list.
+type closeNoticesMsg struct{}
+
+func newNoticePane(theme config.CustomTheme) noticePane {
+	return noticePane{
+		viewport: viewport.New(0, 0),
+		keys:     newNoticeKeyMap(),
+		help:     styledHelp(help.New(), theme),
+		theme:    theme,
+	}
+}
+
+func (p *noticePane) open(history []notice) {
+	timeStyle := style.Foreground(lipgloss.Color(p.theme.DimmedDesc))
+	textStyle := style.Foreground(lipgloss.Color(p.theme.NormalTitle))
+
+	lines := []string{}
+	for i := len(history) - 1; i >= 0; i-- {
+		n := history[i]
+		lines = append(lines, timeStyle.Render(utils.FormatClock(n.at))+"  "+textStyle.Render(n.String()))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, timeStyle.Render("No notifications yet"))
+	}
+	p.viewport.SetContent(strings.Join(lines, "\n"))
+	p.viewport.GotoTop()
+}
+
+func (p noticePane) Update(msg tea.Msg) (noticePane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		p.viewport.Width = msg.Width - h
+		// title and help lines
+		p.viewport.Height = max(1, msg.Height-v-6)
+
+	case tea.KeyMsg:
+		if key.Matches(msg, p.keys.back) {
+			return p, func() tea.Msg { return closeNoticesMsg{} }
+		}
+	}
+
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return p, cmd
+}
+
+func (p noticePane) View() string {
+	title := withTitleStyle(style, p.theme).
+		Foreground(lipgloss.Color(p.theme.TitleFore)).
+		Background(lipgloss.Color(p.theme.TitleBack)).
+		Padding(0, 1).
+		MarginTop(1).
+		MarginBottom(1).
+		Render(noticesTitle)
+
+	helpView := style.PaddingTop(1).Render(p.help.ShortHelpView(p.keys.NoticeHelp()))
+	return style.PaddingLeft(2).Render(lipgloss.JoinVertical(lipgloss.Left, title, p.viewport.View(), helpView))
+}
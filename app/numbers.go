@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+)
+
+/* Entries that are a single integer, in decimal or with a 0x, 0o or 0b
+prefix, are shown in the other bases in the preview, and as a date if they
+are plausibly a unix timestamp in seconds or milliseconds. Each of them can
+be copied from the action menu.
+*/
+
+// conversion is one representation of a number entry.
+type conversion struct {
+	name  string
+	value string
+}
+
+// unix timestamps between these are shown as dates
+var (
+	minTimestamp = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	maxTimestamp = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+// returns the conversions of text, nil unless it is an integer
+func numberConversions(text string) []conversion {
+	n, ok := parseInteger(strings.TrimSpace(text))
+	if !ok {
+		return nil
+	}
+	convs := []conversion{
+		{"dec", strconv.FormatInt(n, 10)},
+		{"hex", signed(n, "0x", 16)},
+		{"oct", signed(n, "0o", 8)},
+		{"bin", signed(n, "0b", 2)},
+	}
+
+	var t time.Time
+	switch {
+	case n >= minTimestamp && n < maxTimestamp:
+		t = time.Unix(n, 0)
+	case n/1000 >= minTimestamp && n/1000 < maxTimestamp:
+		t = time.UnixMilli(n)
+	default:
+		return convs
+	}
+	return append(convs,
+		conversion{"date", t.Local().Format(time.RFC3339Nano)},
+		conversion{"utc", t.UTC().Format(time.RFC3339Nano)},
+	)
+}
+
+// reads a decimal integer, leading zeros included, or one prefixed with
+// 0x, 0o or 0b
+func parseInteger(s string) (int64, bool) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if digits == "" || strings.ContainsRune(digits, '_') {
+		return 0, false
+	}
+	base := 10
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X', 'o', 'O', 'b', 'B':
+			base = 0
+		}
+	}
+	n, err := strconv.ParseInt(s, base, 64)
+	return n, err == nil && n != math.MinInt64
+}
+
+func signed(n int64, prefix string, base int) string {
+	if n < 0 {
+		return "-" + prefix + strconv.FormatInt(-n, base)
+	}
+	return prefix + strconv.FormatInt(n, base)
+}
+
+// renders the conversions below the entry in the preview
+func conversionsView(convs []conversion, theme config.CustomTheme) string {
+	nameStyle := style.Foreground(lipgloss.Color(theme.DimmedDesc))
+	valueStyle := style.Foreground(lipgloss.Color(theme.PreviewedText))
+
+	lines := []string{}
+	for _, c := range convs {
+		lines = append(lines, nameStyle.Render(fmt.Sprintf("%-5s", c.name))+" "+valueStyle.Render(c.value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lists the conversions of a number entry in the action menu, hidden
+// entries must be revealed first
+func conversionItems(entry item) []list.Item {
+	items := []list.Item{}
+	if entry.filePath != "null" || (entry.sensitive && !entry.revealed) {
+		return items
+	}
+	for _, c := range numberConversions(entry.titleFull) {
+		title := "Copy " + c.name
+		items = append(items, item{
+			title:           title,
+			titleBase:       title,
+			titleFull:       c.value,
+			description:     c.value,
+			descriptionBase: c.value,
+			filePath:        "null",
+			label:           c.name,
+		})
+	}
+	return items
+}
+
+// returns the conversion listed as the item, false for the other items of
+// the action menu
+func findConversion(i item, entry item) (conversion, bool) {
+	for _, c := range numberConversions(entry.titleFull) {
+		if c.name == i.label && c.value == i.titleFull {
+			return c, true
+		}
+	}
+	return conversion{}, false
+}
+
+// copies the conversion like the output of an action
+func copyConversion(c conversion) tea.Cmd {
+	return func() tea.Msg {
+		return actionDoneMsg{action: config.Action{Name: c.name, Output: config.ActionCopy}, output: c.value}
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* With the sqlite storage the list holds the history a page at a time,
+along with the pinned entries, and loads the next page as the cursor nears
+the last loaded entry. The fuzzy filter only sees the loaded entries, so
+while filtering the entries containing the filter terms are looked up in
+the whole history and listed too.
+*/
+
+// historyPages is the part of the history loaded by the list.
+type historyPages struct {
+	enabled  bool
+	loaded   int                    // entries loaded by pages
+	found    []config.ClipboardItem // entries containing the filter terms, beyond the loaded pages
+	searched string                 // filter the found entries were looked up for
+}
+
+func newHistoryPages() historyPages {
+	return historyPages{enabled: config.PagedHistory(), loaded: historyPageSize}
+}
+
+// the entries the list starts with
+func initialHistory() []config.ClipboardItem {
+	if !config.PagedHistory() {
+		return config.GetHistory()
+	}
+	return newHistoryPages().history()
+}
+
+// the first loaded entries, the pinned ones and those found for the filter,
+// newest first
+func (p historyPages) history() []config.ClipboardItem {
+	history, _, err := config.HistoryPage(0, p.loaded)
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to load history page: %s", err))
+	}
+	pinned, err := config.PinnedItems()
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to load pinned entries: %s", err))
+	}
+
+	listed := make(map[string]bool, len(history))
+	for _, item := range history {
+		listed[item.Recorded] = true
+	}
+	for _, item := range append(pinned, p.found...) {
+		if !listed[item.Recorded] {
+			listed[item.Recorded] = true
+			history = append(history, item)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Recorded > history[j].Recorded
+	})
+	return history
+}
+
+// loads the next page once the cursor is within pageLoadAhead entries of
+// the end of the unfiltered list
+func (l *listPane) loadMore() tea.Cmd {
+	if !l.pages.enabled || l.list.FilterState() != list.Unfiltered {
+		return nil
+	}
+	if l.list.Index() < len(l.list.Items())-pageLoadAhead {
+		return nil
+	}
+	_, total, err := config.HistoryPage(0, 0)
+	if err != nil || l.pages.loaded >= total {
+		return nil
+	}
+	l.pages.loaded += historyPageSize
+	return l.reloadItems()
+}
+
+// looks up the entries containing the filter terms in the whole history
+// when the filter changes
+func (l *listPane) findFiltered() tea.Cmd {
+	if !l.pages.enabled || l.list.FilterValue() == l.pages.searched {
+		return nil
+	}
+	l.pages.searched = l.list.FilterValue()
+	l.pages.found = nil
+	if terms := strings.Fields(l.pages.searched); len(terms) > 0 {
+		found, err := config.FindItems(terms, historyPageSize)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to search the history: %s", err))
+		}
+		l.pages.found = found
+	}
+	return l.reloadItems()
+}
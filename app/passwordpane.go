@@ -0,0 +1,160 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// passwordPane lists the entries of the pass or gopass password store by
+// name. Choosing one has the password store copy it, and the listener is
+// told to skip the copy, so passwords never reach the history.
+type passwordPane struct {
+	list  list.Model
+	keys  *passwordKeyMap
+	help  help.Model
+	theme config.CustomTheme
+}
+
+// openPasswordsMsg switches from the history to the password store.
+type openPasswordsMsg struct{}
+
+// closePasswordsMsg switches from the password store back to the history.
+type closePasswordsMsg struct{}
+
+// passwordCopiedMsg reports the end of copying a password.
+type passwordCopiedMsg struct {
+	name string
+	err  error
+}
+
+func newPasswordPane(theme config.CustomTheme) passwordPane {
+	passwordList := list.New([]list.Item{}, newItemDelegate(theme), 0, 0)
+	passwordList.Filter = fuzzyFilter
+	configureListKeys(&passwordList.KeyMap)
+	passwordList.Title = passwordsTitle
+	passwordList.SetShowHelp(false)
+	passwordList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2)
+
+	return passwordPane{
+		list:  styledList(passwordList, theme),
+		keys:  newPasswordKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+// lists the entries again, they may have changed since the last time
+func (p *passwordPane) open() error {
+	names, err := shell.ListPasswords(config.ClipseConfig.PasswordStore)
+	if err != nil {
+		return err
+	}
+	p.list.ResetFilter()
+	p.list.SetItems(passwordItems(names))
+	return nil
+}
+
+func (p passwordPane) Update(msg tea.Msg) (passwordPane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		p.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case showStatusMsg:
+		p.list.StatusMessageLifetime = msg.lifetime
+		return p, p.list.NewStatusMessage(msg.text)
+
+	case passwordCopiedMsg:
+		return p, p.copied(msg)
+
+	case tea.KeyMsg:
+		if p.list.SettingFilter() {
+			p.list.KeyMap.Quit.SetEnabled(false)
+			break
+		}
+		p.list.KeyMap.Quit.SetEnabled(true)
+
+		if key.Matches(msg, p.keys.back) {
+			return p, func() tea.Msg { return closePasswordsMsg{} }
+		}
+		if i, ok := p.list.SelectedItem().(item); ok && key.Matches(msg, p.keys.choose) {
+			return p, p.copy(i.label)
+		}
+	}
+
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+	return p, cmd
+}
+
+func (p passwordPane) View() string {
+	if p.list.SettingFilter() {
+		return style.PaddingLeft(1).Render(p.list.View())
+	}
+	helpView := style.PaddingLeft(2).Render(p.help.ShortHelpView(p.keys.PasswordHelp()))
+	return style.PaddingLeft(1).Render(p.list.View() + "\n" + helpView)
+}
+
+// has the password store copy the entry, handing it the terminal in case
+// it asks for the passphrase of its key
+func (p passwordPane) copy(name string) tea.Cmd {
+	if err := config.SkipNextCopy(passwordCopyWindow); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to skip password copy: %s", err))
+		return setError("Could not copy password, the listener would record it.")
+	}
+	cmd := shell.CopyPasswordCmd(config.ClipseConfig.PasswordStore, name)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return passwordCopiedMsg{name: name, err: err}
+	})
+}
+
+// quits the TUI once a password has been copied, unless it was opened with
+// keep
+func (p passwordPane) copied(msg passwordCopiedMsg) tea.Cmd {
+	if msg.err != nil {
+		if err := config.CancelSkipCopy(); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to stop skipping the next copy: %s", err))
+		}
+		utils.LogERROR(fmt.Sprintf("failed to copy password %s: %s", msg.name, msg.err))
+		return setError(fmt.Sprintf("Could not copy password: %s", msg.err))
+	}
+	switch {
+	case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+		startDirectPaste()
+		shell.KillProcess(os.Args[2])
+		return tea.Quit
+	case len(os.Args) > 1 && os.Args[1] == "keep":
+		return setStatus("Copied password: " + msg.name)
+	}
+	return quitAndPaste()
+}
+
+func passwordItems(names []string) []list.Item {
+	items := []list.Item{}
+	for _, name := range names {
+		desc := path.Dir(name)
+		if desc == "." {
+			desc = config.ClipseConfig.PasswordStore
+		}
+		items = append(items, item{
+			title:           path.Base(name),
+			titleBase:       path.Base(name),
+			titleFull:       name,
+			description:     desc,
+			descriptionBase: desc,
+			filePath:        "null",
+			label:           name,
+		})
+	}
+	return items
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// previewPane shows the full value of a single entry, or the image it
+// points to, in a scrollable viewport.
+type previewPane struct {
+	viewport       viewport.Model
+	keys           *previewKeymap
+	help           help.Model
+	theme          config.CustomTheme
+	originalHeight int  // for restore height of preview viewport in sixel mode
+	ready          bool // viewport needs to wait for the initial window size message
+	isImage        bool // the previewed entry is an image
+	inspecting     bool // listing the characters of value, see inspect.go
+
+	// text shown, "" for images and hidden entries
+	value string
+	entry item // previewed, for the action menu
+}
+
+// openPreviewMsg shows the preview for the item.
+type openPreviewMsg struct {
+	item item
+}
+
+// closePreviewMsg returns from the preview to the list.
+type closePreviewMsg struct{}
+
+func openPreview(i item) tea.Cmd {
+	return func() tea.Msg { return openPreviewMsg{item: i} }
+}
+
+func newPreviewPane(theme config.CustomTheme) previewPane {
+	return previewPane{
+		viewport: NewPreview(),
+		keys:     newPreviewKeyMap(),
+		help:     styledHelp(help.New(), theme),
+		theme:    theme,
+	}
+}
+
+func (p *previewPane) open(i item) {
+	clearKittyImages()
+
+	p.value = i.titleFull
+	p.entry = i
+	p.inspecting = false
+	content := p.textContent()
+	if i.sensitive && !i.revealed {
+		content = p.styledPreviewContent(maskedTitle)
+		p.value = ""
+	}
+
+	p.isImage = i.filePath != "null"
+	if p.isImage {
+		p.value = ""
+		content = getImgPreview(i.filePath, p.viewport.Width, p.viewport.Height)
+		if config.ClipseConfig.ImageDisplay.Type != "basic" {
+			p.originalHeight = p.viewport.Height
+			p.viewport.Height /= config.ClipseConfig.ImageDisplay.HeightCut
+		}
+	}
+
+	p.viewport.SetContent(content)
+	p.viewport.GotoTop()
+}
+
+func (p *previewPane) close() {
+	clearKittyImages()
+	if p.isImage && config.ClipseConfig.ImageDisplay.Type != "basic" {
+		p.viewport.Height = p.originalHeight
+	}
+}
+
+func (p previewPane) Update(msg tea.Msg) (previewPane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		headerHeight := lipgloss.Height(p.headerView())
+		footerHeight := lipgloss.Height(p.footerView())
+		verticalMarginHeight := headerHeight + footerHeight
+
+		if !p.ready {
+			p.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
+			p.ready = true
+			p.viewport.YPosition = headerHeight // '+ 1' needed for high performance rendering only
+			return p, nil
+		}
+		p.viewport.Width = msg.Width
+		p.viewport.Height = msg.Height - verticalMarginHeight
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, p.keys.back):
+			return p, func() tea.Msg { return closePreviewMsg{} }
+
+		case key.Matches(msg, p.keys.inspect):
+			if p.value == "" {
+				return p, setWarning("Only text entries can be inspected")
+			}
+			p.inspecting = !p.inspecting
+			if p.inspecting {
+				p.viewport.SetContent(inspectCharacters(p.value, p.theme))
+			} else {
+				p.viewport.SetContent(p.textContent())
+			}
+			p.viewport.GotoTop()
+			return p, nil
+
+		case key.Matches(msg, p.keys.actions):
+			if p.entry.timeStamp == "" {
+				break // action output shown in the preview
+			}
+			return p, func() tea.Msg { return openActionsMsg{item: p.entry} }
+
+		case key.Matches(msg, p.keys.copyEscaped):
+			if p.value == "" {
+				return p, setWarning("Only text entries can be escaped")
+			}
+			if err := config.CopyText(utils.EscapeUnicode(p.value)); err != nil {
+				utils.LogERROR(fmt.Sprintf("failed to copy escaped text: %s", err))
+				return p, setError("Could not copy escaped text.")
+			}
+			return p, setStatus("Copied with \\u escapes")
+		}
+	}
+
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return p, cmd
+}
+
+// the text of the entry, followed by its conversions if it is a number
+func (p previewPane) textContent() string {
+	content := p.styledPreviewContent(p.value)
+	if convs := numberConversions(p.value); convs != nil {
+		content += "\n\n" + conversionsView(convs, p.theme)
+	}
+	return content
+}
+
+func (p previewPane) View() string {
+	helpView := style.PaddingLeft(2).Render(p.help.ShortHelpView(p.keys.PreviewHelp()))
+	return fmt.Sprintf(
+		"\n%s\n%s\n%s\n%s\n",
+		p.headerView(), p.viewport.View(), p.footerView(), helpView,
+	)
+}
+
+func (p previewPane) headerView() string {
+	header := previewHeader
+	if p.inspecting {
+		header = inspectHeader
+	}
+	title := previewTitleStyle.Render(header)
+	line := strings.Repeat(borderMiddleChar, max(0, p.viewport.Width-lipgloss.Width(title)))
+	return p.styledPreviewHeader(lipgloss.JoinHorizontal(lipgloss.Center, title, line))
+}
+
+func (p previewPane) footerView() string {
+	info := previewInfoStyle.Render(fmt.Sprintf("%3.f%%", p.viewport.ScrollPercent()*100))
+	line := strings.Repeat(borderMiddleChar, max(0, p.viewport.Width-lipgloss.Width(info)))
+	return p.styledPreviewFooter(lipgloss.JoinHorizontal(lipgloss.Center, line, info))
+}
+
+// removes any images drawn with the kitty graphics protocol
+func clearKittyImages() {
+	if config.ClipseConfig.ImageDisplay.Type == "kitty" {
+		fmt.Print("\x1B_Ga=d\x1B\\")
+	}
+}
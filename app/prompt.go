@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// queryPrompt adds shell-like history to the list filter prompt, recalling
+// previously used queries and pinning favourites.
+type queryPrompt struct {
+	keys    *filterKeyMap
+	help    help.Model
+	history config.SearchHistory // previously used filter queries
+	index   int                  // position when cycling through history, -1 when not recalling
+	draft   string               // the typed filter value to restore after cycling
+}
+
+// setFilterMsg replaces the value of the list filter prompt.
+type setFilterMsg struct {
+	query string
+}
+
+func newQueryPrompt(theme config.CustomTheme) queryPrompt {
+	return queryPrompt{
+		keys:    newFilterKeymap(),
+		help:    styledHelp(help.New(), theme),
+		history: config.GetSearchHistory(),
+		index:   -1,
+	}
+}
+
+// Matches reports whether the key is handled by the prompt rather than
+// typed into the filter.
+func (p queryPrompt) Matches(msg tea.KeyMsg) bool {
+	return key.Matches(msg, p.keys.prevQuery, p.keys.nextQuery, p.keys.pinQuery)
+}
+
+// Update handles the prompt keys, current is the value of the filter input.
+func (p queryPrompt) Update(msg tea.KeyMsg, current string) (queryPrompt, tea.Cmd) {
+	switch {
+	case key.Matches(msg, p.keys.prevQuery):
+		return p, p.recall(1, current)
+	case key.Matches(msg, p.keys.nextQuery):
+		return p, p.recall(-1, current)
+	case key.Matches(msg, p.keys.pinQuery):
+		return p, p.togglePin(current)
+	}
+	return p, nil
+}
+
+func (p queryPrompt) View() string {
+	return style.PaddingLeft(2).Render(p.help.ShortHelpView(p.keys.FilterHelp()))
+}
+
+// cycles through previously used queries, a step of 1 moves to an older
+// query and -1 back towards the typed draft
+func (p *queryPrompt) recall(step int, current string) tea.Cmd {
+	queries := p.history.Queries()
+	if len(queries) == 0 {
+		return nil
+	}
+	if p.index == -1 {
+		p.draft = current
+	}
+	p.index = max(-1, min(len(queries)-1, p.index+step))
+
+	query := p.draft
+	if p.index >= 0 {
+		query = queries[p.index]
+	}
+	return func() tea.Msg { return setFilterMsg{query: query} }
+}
+
+func (p *queryPrompt) togglePin(query string) tea.Cmd {
+	if query == "" {
+		return setWarning("No query to pin")
+	}
+	isPinned, err := config.TogglePinSearchQuery(query)
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to pin search query: %s", err))
+		return nil
+	}
+	p.history = config.GetSearchHistory()
+
+	pinEvent := "Unpinned query"
+	if isPinned {
+		pinEvent = "Pinned query"
+	}
+	return setStatus(fmt.Sprintf("%s: %s", pinEvent, query))
+}
+
+// start recalling from the most recent query
+func (p *queryPrompt) reset() {
+	p.index = -1
+}
+
+func (p *queryPrompt) save(query string) {
+	if err := config.AddSearchQuery(query); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to save search query: %s", err))
+	}
+	p.history = config.GetSearchHistory()
+	p.index = -1
+}
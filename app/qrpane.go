@@ -0,0 +1,86 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/savedra1/clipse/config"
+)
+
+// qrPane shows an entry as a QR code drawn with half block characters, to
+// scan it with a phone instead of typing it.
+type qrPane struct {
+	code   string // rendered QR code
+	keys   *qrKeyMap
+	help   help.Model
+	theme  config.CustomTheme
+	width  int
+	height int
+}
+
+// openQRMsg shows the QR code of the item.
+type openQRMsg struct {
+	item item
+}
+
+// closeQRMsg returns from the QR code to the list.
+type closeQRMsg struct{}
+
+func newQRPane(theme config.CustomTheme) qrPane {
+	return qrPane{
+		keys:  newQRKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+// encodes the value, failing if it is too long for a QR code
+func (p *qrPane) open(value string) error {
+	q, err := qrcode.New(value, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	// drawn light on dark whatever the theme, scanners need the contrast
+	p.code = style.
+		Foreground(lipgloss.Color(lightBackground)).
+		Background(lipgloss.Color(darkBackground)).
+		Render(strings.TrimSuffix(q.ToSmallString(false), "\n"))
+	return nil
+}
+
+func (p qrPane) Update(msg tea.Msg) (qrPane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width, p.height = msg.Width, msg.Height
+
+	case tea.KeyMsg:
+		if key.Matches(msg, p.keys.back) {
+			return p, func() tea.Msg { return closeQRMsg{} }
+		}
+	}
+	return p, nil
+}
+
+func (p qrPane) View() string {
+	title := withTitleStyle(style, p.theme).
+		Foreground(lipgloss.Color(p.theme.TitleFore)).
+		Background(lipgloss.Color(p.theme.TitleBack)).
+		Padding(0, 1).
+		MarginTop(1).
+		MarginBottom(1).
+		Render(qrTitle)
+
+	code := p.code
+	// title and help lines
+	if lipgloss.Width(code) > p.width-4 || lipgloss.Height(code) > p.height-6 {
+		code = style.Foreground(lipgloss.Color(p.theme.DimmedDesc)).
+			Render("The QR code doesn't fit, enlarge the window or reduce the font size.")
+	}
+	helpView := style.PaddingTop(1).Render(p.help.ShortHelpView(p.keys.QRHelp()))
+	return style.PaddingLeft(2).Render(lipgloss.JoinVertical(lipgloss.Left, title, code, helpView))
+}
@@ -1,38 +1,85 @@
 package app
 
 import (
-	"os"
 	"time"
 
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/savedra1/clipse/config"
-	"github.com/savedra1/clipse/utils"
+	"github.com/savedra1/clipse/ipc"
 )
 
 type ReRender struct{}
 
+// refreshTimesMsg re-renders the list so relative copy times stay current.
+type refreshTimesMsg struct{}
+
+func refreshTimes() tea.Cmd {
+	return tea.Tick(refreshEvery, func(time.Time) tea.Msg {
+		return refreshTimesMsg{}
+	})
+}
+
+// ListenRealTime sends a ReRender msg to the program whenever the history
+// changes or recording is paused, so entries copied while the TUI is open
+// show up without a restart. The events come from the listener as they
+// happen, or from watching the history file when it isn't running.
 func (m Model) ListenRealTime(p *tea.Program) {
-	historyPath := config.ClipseConfig.HistoryFilePath
-	info, err := os.Stat(historyPath)
-	if err != nil {
-		utils.LogERROR("Could not get Modification time of history file, starting real time mode failed")
-		return
+	if events, cancel, err := ipc.Subscribe(); err == nil {
+		for range events {
+			p.Send(ReRender{})
+		}
+		cancel()
+		// the listener stopped
+	}
+
+	changes, cancel := config.Subscribe()
+	defer cancel()
+
+	for range changes {
+		p.Send(ReRender{})
+	}
+}
+
+// reloads the list items from the history file in place, keeping the
+// current view, filter, cursor and multi-selection intact.
+func (l *listPane) reloadItems() tea.Cmd {
+	selected := make(map[string]int)
+	for _, s := range l.selectedItems() {
+		selected[s.TimeStamp] = s.Order
+	}
+
+	var cursorTS string
+	if i, ok := l.list.SelectedItem().(item); ok {
+		cursorTS = i.timeStamp
 	}
-	m.lastUpdated = info.ModTime()
-
-	rr := ReRender{}
-	var currModTime time.Time
-	for {
-		historyFileInfo, err := os.Stat(historyPath)
-		if err != nil {
-			continue
+
+	entryItems := filterItems(l.history(), l.togglePinned, l.theme)
+	for index, listItem := range entryItems {
+		if i, ok := listItem.(item); ok {
+			if order, ok := selected[i.timeStamp]; ok {
+				i.selected, i.selectOrder = true, order
+				entryItems[index] = i
+			}
 		}
-		currModTime = historyFileInfo.ModTime()
+	}
 
-		if currModTime.After(m.lastUpdated) {
-			m.lastUpdated = currModTime
-			p.Send(rr)
+	cmd := l.list.SetItems(entryItems)
+
+	l.list.SetShowStatusBar(len(entryItems) > 0)
+	l.keys.remove.SetEnabled(len(entryItems) > 0)
+
+	// the filtered view keeps its own cursor, only re-anchor when unfiltered
+	if cursorTS == "" || l.list.FilterState() != list.Unfiltered {
+		return cmd
+	}
+	for index, listItem := range entryItems {
+		if i, ok := listItem.(item); ok && i.timeStamp == cursorTS {
+			l.list.Select(index)
+			break
 		}
 	}
+
+	return cmd
 }
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// snippetPane lists the saved snippets, which are searched and copied like
+// history items but kept in their own file.
+type snippetPane struct {
+	list  list.Model
+	keys  *snippetKeyMap
+	help  help.Model
+	theme config.CustomTheme
+}
+
+// openSnippetsMsg switches from the history to the snippets.
+type openSnippetsMsg struct{}
+
+// closeSnippetsMsg switches from the snippets back to the history.
+type closeSnippetsMsg struct{}
+
+// deleteSnippetMsg deletes a snippet once confirmed.
+type deleteSnippetMsg struct {
+	name string
+}
+
+// saveSnippetMsg saves a value as a named snippet.
+type saveSnippetMsg struct {
+	name  string
+	value string
+}
+
+func newSnippetPane(theme config.CustomTheme) snippetPane {
+	snippetList := list.New(snippetItems(config.GetSnippets()), newItemDelegate(theme), 0, 0)
+	snippetList.Filter = fuzzyFilter
+	configureListKeys(&snippetList.KeyMap)
+	snippetList.Title = snippetsTitle
+	snippetList.SetShowHelp(false)
+	snippetList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2)
+
+	return snippetPane{
+		list:  styledList(snippetList, theme),
+		keys:  newSnippetKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+func (s snippetPane) Update(msg tea.Msg) (snippetPane, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		s.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case showStatusMsg:
+		s.list.StatusMessageLifetime = msg.lifetime
+		return s, s.list.NewStatusMessage(msg.text)
+
+	case deleteSnippetMsg:
+		if err := config.DeleteSnippet(msg.name); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to delete snippet: %s", err))
+			return s, setError("Could not delete snippet.")
+		}
+		return s, tea.Batch(s.reload(), setStatus("Deleted snippet: "+msg.name))
+
+	case saveSnippetMsg:
+		replaced, err := config.SaveSnippet(msg.name, msg.value)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to save snippet: %s", err))
+			return s, setError(fmt.Sprintf("Could not save snippet: %s", err))
+		}
+		statusMsg := "Saved snippet: " + msg.name
+		if replaced {
+			statusMsg = "Updated snippet: " + msg.name
+		}
+		return s, tea.Batch(s.reload(), setStatus(statusMsg))
+
+	case tea.KeyMsg:
+		if s.list.SettingFilter() {
+			s.list.KeyMap.Quit.SetEnabled(false)
+			break
+		}
+		s.list.KeyMap.Quit.SetEnabled(true)
+
+		if key.Matches(msg, s.keys.back) {
+			return s, func() tea.Msg { return closeSnippetsMsg{} }
+		}
+
+		i, ok := s.list.SelectedItem().(item)
+		if !ok {
+			break
+		}
+		switch {
+		case key.Matches(msg, s.keys.choose):
+			return s, s.copy(i)
+
+		case key.Matches(msg, s.keys.remove):
+			title := fmt.Sprintf("Delete snippet %s?", i.label)
+			return s, confirmAction(config.ConfirmSnippet, title, deleteSnippetMsg{name: i.label}, nil)
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return s, cmd
+}
+
+func (s snippetPane) View() string {
+	if s.list.SettingFilter() {
+		return style.PaddingLeft(1).Render(s.list.View())
+	}
+	helpView := style.PaddingLeft(2).Render(s.help.ShortHelpView(s.keys.SnippetHelp()))
+	return style.PaddingLeft(1).Render(s.list.View() + "\n" + helpView)
+}
+
+// copies the snippet, quitting unless the TUI was opened with keep
+func (s snippetPane) copy(i item) tea.Cmd {
+	if err := config.CopyText(i.titleFull); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to copy snippet: %s", err))
+		return setError("Could not copy snippet.")
+	}
+	switch {
+	case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+		startDirectPaste()
+		shell.KillProcess(os.Args[2])
+		return tea.Quit
+	case len(os.Args) > 1 && os.Args[1] == "keep":
+		return setStatus("Copied to clipboard: " + i.label)
+	}
+	return quitAndPaste()
+}
+
+func (s *snippetPane) reload() tea.Cmd {
+	return s.list.SetItems(snippetItems(config.GetSnippets()))
+}
+
+func snippetItems(snippets []config.Snippet) []list.Item {
+	items := []list.Item{}
+	for _, snippet := range snippets {
+		desc := utils.Shorten(snippet.Value)
+		items = append(items, item{
+			title:           snippet.Name,
+			titleBase:       snippet.Name,
+			titleFull:       snippet.Value,
+			description:     desc,
+			descriptionBase: desc,
+			filePath:        "null",
+			label:           snippet.Name,
+		})
+	}
+	return items
+}
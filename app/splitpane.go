@@ -0,0 +1,130 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/savedra1/clipse/config"
+)
+
+// splitPane shows the full selected entry next to the list, word wrapped
+// and with line numbers, so it can be checked before pasting.
+type splitPane struct {
+	viewport viewport.Model
+	theme    config.CustomTheme
+	current  item
+	rendered bool
+}
+
+// toggleSplitMsg shows or hides the split view.
+type toggleSplitMsg struct{}
+
+// scrollSplitMsg scrolls the split view by the given number of lines.
+type scrollSplitMsg struct {
+	lines int
+}
+
+func newSplitPane(theme config.CustomTheme) splitPane {
+	return splitPane{
+		viewport: viewport.New(0, 0),
+		theme:    theme,
+	}
+}
+
+func (s *splitPane) setSize(width, height int) {
+	s.viewport.Width = max(0, width-splitPaneFrame)
+	s.viewport.Height = max(0, height-splitPaneHeaderHeight)
+	s.render()
+}
+
+// shows the item, keeping the scroll position if it is already shown
+func (s *splitPane) show(i item) {
+	if s.rendered && s.current.timeStamp == i.timeStamp && s.current.revealed == i.revealed {
+		return
+	}
+	s.current = i
+	s.render()
+	s.viewport.GotoTop()
+}
+
+func (s *splitPane) clear() {
+	s.current = item{}
+	s.rendered = false
+	s.viewport.SetContent("")
+}
+
+func (s splitPane) Update(msg tea.Msg) (splitPane, tea.Cmd) {
+	if msg, ok := msg.(scrollSplitMsg); ok {
+		if msg.lines > 0 {
+			s.viewport.LineDown(msg.lines)
+		} else {
+			s.viewport.LineUp(-msg.lines)
+		}
+	}
+	return s, nil
+}
+
+func (s splitPane) View() string {
+	header := s.styledSplitHeader(
+		fmt.Sprintf("%s %3.f%%", previewHeader, s.viewport.ScrollPercent()*100),
+	)
+	return style.
+		Border(themeBorder(s.theme), false, false, false, true).
+		BorderForeground(lipgloss.Color(s.theme.PreviewBorder)).
+		PaddingLeft(1).
+		MarginTop(1).
+		Render(header + "\n" + s.viewport.View())
+}
+
+func (s *splitPane) render() {
+	if s.current.timeStamp == "" {
+		return
+	}
+	s.rendered = true
+
+	content := s.current.titleFull
+	switch {
+	case s.current.filePath != "null":
+		content = "Image: " + s.current.filePath
+	case s.current.sensitive && !s.current.revealed:
+		content = maskedTitle
+	}
+	s.viewport.SetContent(s.numberedLines(content))
+}
+
+// wraps each line to the viewport width, numbering only the first
+// row of every wrapped line
+func (s *splitPane) numberedLines(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\t", "    "), "\n")
+	gutter := len(strconv.Itoa(len(lines)))
+	width := max(1, s.viewport.Width-gutter-1)
+
+	numStyle := style.Foreground(lipgloss.Color(s.theme.PreviewBorder))
+	textStyle := style.Foreground(lipgloss.Color(s.theme.PreviewedText))
+
+	var sb strings.Builder
+	for n, line := range lines {
+		for row, part := range strings.Split(ansi.Wrap(line, width, ""), "\n") {
+			num := ""
+			if row == 0 {
+				num = strconv.Itoa(n + 1)
+			}
+			sb.WriteString(numStyle.Render(fmt.Sprintf("%*s", gutter, num)))
+			sb.WriteString(" " + textStyle.Render(part) + "\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (s splitPane) styledSplitHeader(str string) string {
+	return style.
+		Foreground(lipgloss.Color(s.theme.PreviewBorder)).
+		Bold(true).
+		Render(str)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+/*
+	The status bar is rendered by the list pane. Other components report
+	feedback to it with a statusMsg instead of reaching into the list.
+	The model queues the messages so ones sent in quick succession are
+	each shown for a moment rather than replacing each other, and counts
+	repeats instead of queueing them. The last messages can be listed
+	with the notifications key, see noticepane.go.
+*/
+
+type statusLevel int
+
+const (
+	levelInfo statusLevel = iota
+	levelWarn
+	levelError
+)
+
+type statusMsg struct {
+	text  string
+	level statusLevel
+}
+
+func setStatus(text string) tea.Cmd {
+	return func() tea.Msg { return statusMsg{text: text, level: levelInfo} }
+}
+
+func setWarning(text string) tea.Cmd {
+	return func() tea.Msg { return statusMsg{text: text, level: levelWarn} }
+}
+
+func setError(text string) tea.Cmd {
+	return func() tea.Msg { return statusMsg{text: text, level: levelError} }
+}
+
+// showStatusMsg shows a message in the status bar of the list panes until
+// another replaces it or its lifetime has passed.
+type showStatusMsg struct {
+	text     string
+	lifetime time.Duration
+}
+
+// statusNextMsg shows the next queued message once the one shown with the
+// same id has been up for statusMinShow.
+type statusNextMsg struct {
+	id int
+}
+
+// notice is a status message with the number of times it was sent in a row.
+type notice struct {
+	statusMsg
+	count int
+	at    time.Time // last sent
+}
+
+func (n notice) String() string {
+	text := n.text
+	switch n.level {
+	case levelWarn:
+		text = warnChar + " " + text
+	case levelError:
+		text = errorChar + " " + text
+	}
+	if n.count > 1 {
+		text += fmt.Sprintf(" (×%d)", n.count)
+	}
+	return text
+}
+
+func (n notice) lifetime() time.Duration {
+	switch n.level {
+	case levelWarn:
+		return warnLifetime
+	case levelError:
+		return errorLifetime
+	default:
+		return infoLifetime
+	}
+}
+
+type notifier struct {
+	current notice
+	shownAt time.Time
+	id      int      // of the current message, ticks of older ones are ignored
+	queue   []notice // waiting to be shown
+	history []notice // last noticeHistory messages, oldest first
+}
+
+// push shows msg, or queues it while the current message is fresh.
+func (n *notifier) push(msg statusMsg) tea.Cmd {
+	now := time.Now()
+	n.record(msg, now)
+
+	showing := !n.shownAt.IsZero() && now.Before(n.shownAt.Add(n.current.lifetime()))
+	switch {
+	case showing && len(n.queue) == 0 && n.current.statusMsg == msg:
+		n.current.count++
+		return n.show(n.current, now)
+	case len(n.queue) > 0 && n.queue[len(n.queue)-1].statusMsg == msg:
+		n.queue[len(n.queue)-1].count++
+		return nil
+	case showing && now.Sub(n.shownAt) < statusMinShow:
+		n.queue = append(n.queue, notice{statusMsg: msg, count: 1, at: now})
+		return nil
+	}
+	return n.show(notice{statusMsg: msg, count: 1, at: now}, now)
+}
+
+// next shows the first queued message, if the current one was shown long
+// enough.
+func (n *notifier) next(msg statusNextMsg) tea.Cmd {
+	if msg.id != n.id || len(n.queue) == 0 {
+		return nil
+	}
+	next := n.queue[0]
+	n.queue = n.queue[1:]
+	return n.show(next, time.Now())
+}
+
+func (n *notifier) show(msg notice, now time.Time) tea.Cmd {
+	n.current, n.shownAt = msg, now
+	n.id++
+	id := n.id
+	show := showStatusMsg{text: statusMessageStyle(msg.String()), lifetime: msg.lifetime()}
+	return tea.Batch(
+		func() tea.Msg { return show },
+		tea.Tick(statusMinShow, func(time.Time) tea.Msg { return statusNextMsg{id: id} }),
+	)
+}
+
+// adds the message to the history, counting it with the last one if equal
+func (n *notifier) record(msg statusMsg, now time.Time) {
+	if last := len(n.history) - 1; last >= 0 && n.history[last].statusMsg == msg {
+		n.history[last].count++
+		n.history[last].at = now
+		return
+	}
+	n.history = append(n.history, notice{statusMsg: msg, count: 1, at: now})
+	if len(n.history) > noticeHistory {
+		n.history = n.history[1:]
+	}
+}
@@ -2,12 +2,14 @@ package app
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
 )
 
 var (
@@ -34,11 +36,27 @@ var (
 	}()
 )
 
+// the theme with its colors checked against the terminal background
+func loadTheme() config.CustomTheme {
+	theme := config.GetTheme()
+	theme.EnforceContrast(terminalBackground())
+	return theme
+}
+
+// the background theme colors are read against, black or white as the
+// terminal's exact color isn't always known
+func terminalBackground() string {
+	if lipgloss.HasDarkBackground() {
+		return darkBackground
+	}
+	return lightBackground
+}
+
 func (d itemDelegate) itemFilterStyle(i item) string {
 	titleStyle := style.
 		Foreground(lipgloss.Color(d.theme.DimmedTitle)).
 		PaddingLeft(2).
-		Render(i.titleBase)
+		Render(d.styledTitle(i, d.theme.DimmedTitle))
 
 	descStyle := style.
 		Foreground(lipgloss.Color(d.theme.DimmedDesc)).
@@ -52,14 +70,14 @@ func (d itemDelegate) itemChosenStyle(i item) string {
 	titleStyle = style.
 		Foreground(lipgloss.Color(d.theme.SelectedTitle)).
 		PaddingLeft(1).
-		BorderLeft(true).BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).BorderStyle(themeBorder(d.theme)).
 		BorderForeground(lipgloss.Color(d.theme.SelectedDescBorder)).
-		Render(i.titleBase)
+		Render(d.styledTitle(i, d.theme.SelectedTitle))
 
 	descStyle = style.
 		Foreground(lipgloss.Color(d.theme.SelectedDesc)).
 		PaddingLeft(1).
-		BorderLeft(true).BorderStyle(lipgloss.NormalBorder()).
+		BorderLeft(true).BorderStyle(themeBorder(d.theme)).
 		BorderForeground(lipgloss.Color(d.theme.SelectedDescBorder)).
 		Render(i.descriptionBase)
 
@@ -75,7 +93,7 @@ func (d itemDelegate) itemSelectedStyle(i item) string {
 	titleStyle = style.
 		Foreground(lipgloss.Color(d.theme.SelectedTitle)).
 		PaddingLeft(2).
-		Render(i.titleBase)
+		Render(d.styledTitle(i, d.theme.SelectedTitle))
 
 	descStyle = style.
 		Foreground(lipgloss.Color(d.theme.SelectedDesc)).
@@ -93,7 +111,7 @@ func (d itemDelegate) itemNormalStyle(i item) string {
 	titleStyle = style.
 		Foreground(lipgloss.Color(d.theme.NormalTitle)).
 		PaddingLeft(2).
-		Render(i.titleBase)
+		Render(d.styledTitle(i, d.theme.NormalTitle))
 
 	descStyle = style.
 		Foreground(lipgloss.Color(d.theme.NormalDesc)).
@@ -107,6 +125,17 @@ func (d itemDelegate) itemNormalStyle(i item) string {
 	return fmt.Sprintf("%s\n%s", titleStyle, descStyle)
 }
 
+// highlights the runes of the title matched by the filter, the title color
+// is set on both parts as the outer style's color doesn't survive the resets
+func (d itemDelegate) styledTitle(i item, color string) string {
+	if len(i.matches) == 0 {
+		return i.titleBase
+	}
+	unmatched := style.Foreground(lipgloss.Color(color))
+	matched := style.Foreground(lipgloss.Color(d.theme.FilteredMatch)).Bold(true).Underline(true)
+	return lipgloss.StyleRunes(i.titleBase, i.matches, matched, unmatched)
+}
+
 func styledList(clipboardList list.Model, ct config.CustomTheme) list.Model {
 	clipboardList.FilterInput.PromptStyle = style.
 		Foreground(lipgloss.Color(ct.FilterPrompt)).
@@ -129,7 +158,7 @@ func styledList(clipboardList list.Model, ct config.CustomTheme) list.Model {
 		Foreground(lipgloss.Color(ct.TitleInfo)).
 		MarginBottom(1).
 		MarginLeft(2)
-	clipboardList.Styles.Title = style.
+	clipboardList.Styles.Title = withTitleStyle(style, ct).
 		Foreground(lipgloss.Color(ct.TitleFore)).
 		Background(lipgloss.Color(ct.TitleBack)).
 		MarginTop(1).
@@ -157,6 +186,40 @@ func styledList(clipboardList list.Model, ct config.CustomTheme) list.Model {
 	return clipboardList
 }
 
+// applies the theme's TitleStyle, eg "bold,italic"
+func withTitleStyle(s lipgloss.Style, ct config.CustomTheme) lipgloss.Style {
+	for _, attr := range strings.Split(ct.TitleStyle, ",") {
+		switch strings.TrimSpace(attr) {
+		case "bold":
+			s = s.Bold(true)
+		case "italic":
+			s = s.Italic(true)
+		case "underline":
+			s = s.Underline(true)
+		case "", "plain":
+		default:
+			utils.LogWARN(fmt.Sprintf("unknown TitleStyle %q, must be bold, italic or underline", attr))
+		}
+	}
+	return s
+}
+
+// the border drawn next to the chosen item and the split view
+func themeBorder(ct config.CustomTheme) lipgloss.Border {
+	switch ct.BorderStyle {
+	case "rounded":
+		return lipgloss.RoundedBorder()
+	case "thick":
+		return lipgloss.ThickBorder()
+	case "double":
+		return lipgloss.DoubleBorder()
+	case "hidden":
+		return lipgloss.HiddenBorder()
+	default:
+		return lipgloss.NormalBorder()
+	}
+}
+
 func styledHelp(help help.Model, ct config.CustomTheme) help.Model {
 	help.Styles.ShortKey = style.Foreground(lipgloss.Color(ct.HelpKey))
 	help.Styles.ShortDesc = style.Foreground(lipgloss.Color(ct.HelpDesc))
@@ -186,21 +249,21 @@ func styledPin(theme config.CustomTheme) string {
 		Render(pinChar)
 }
 
-func (m *Model) styledPreviewHeader(str string) string {
+func (p previewPane) styledPreviewHeader(str string) string {
 	return style.
-		Foreground(lipgloss.Color(m.theme.PreviewBorder)).
+		Foreground(lipgloss.Color(p.theme.PreviewBorder)).
 		MarginTop(2).
 		Render(str)
 }
 
-func (m *Model) styledPreviewFooter(str string) string {
+func (p previewPane) styledPreviewFooter(str string) string {
 	return style.
-		Foreground(lipgloss.Color(m.theme.PreviewBorder)).
+		Foreground(lipgloss.Color(p.theme.PreviewBorder)).
 		Render(str)
 }
 
-func (m *Model) styledPreviewContent(content string) string {
+func (p previewPane) styledPreviewContent(content string) string {
 	return style.
-		Foreground(lipgloss.Color(m.theme.PreviewedText)).
+		Foreground(lipgloss.Color(p.theme.PreviewedText)).
 		Render(content)
 }
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// taskRunner runs slow operations, eg large deletes, off the UI thread and
+// shows a spinner in place of the help line until they finish. Only one
+// task runs at a time and the running task can be cancelled.
+type taskRunner struct {
+	spinner   spinner.Model
+	cancelKey key.Binding
+	label     string
+	cancel    context.CancelFunc
+	id        int // ignores results of tasks that were cancelled
+	running   bool
+}
+
+// taskFunc does the work of a task, returning the status message to show
+// once done, or "" to leave the current one. It should stop early when ctx
+// is cancelled.
+type taskFunc func(ctx context.Context) (string, error)
+
+// taskStartMsg runs a task.
+type taskStartMsg struct {
+	label string
+	run   taskFunc
+}
+
+// taskDoneMsg reports the result of a finished or cancelled task.
+type taskDoneMsg struct {
+	id     int
+	status string
+	err    error
+}
+
+func startTask(label string, run taskFunc) tea.Cmd {
+	return func() tea.Msg { return taskStartMsg{label: label, run: run} }
+}
+
+func newTaskRunner(theme config.CustomTheme) taskRunner {
+	s := spinner.New(spinner.WithSpinner(spinner.Dot))
+	s.Style = style.Foreground(lipgloss.Color(theme.StatusMsg))
+	return taskRunner{spinner: s, cancelKey: newCancelKey()}
+}
+
+func (t *taskRunner) start(msg taskStartMsg) tea.Cmd {
+	if t.running {
+		return setWarning(fmt.Sprintf("Busy: %s", t.label))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.id++
+	t.label = msg.label
+	t.cancel = cancel
+	t.running = true
+
+	id := t.id
+	run := func() tea.Msg {
+		status, err := msg.run(ctx)
+		return taskDoneMsg{id: id, status: status, err: err}
+	}
+	return tea.Batch(t.spinner.Tick, run)
+}
+
+// stops the running task, its result is reported as cancelled
+func (t *taskRunner) stop() {
+	if t.running {
+		t.cancel()
+	}
+}
+
+func (t taskRunner) Update(msg tea.Msg) (taskRunner, tea.Cmd) {
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		if !t.running {
+			return t, nil
+		}
+		var cmd tea.Cmd
+		t.spinner, cmd = t.spinner.Update(msg)
+		return t, cmd
+
+	case taskDoneMsg:
+		if msg.id != t.id {
+			return t, nil
+		}
+		t.running = false
+		t.cancel()
+
+		switch {
+		case errors.Is(msg.err, context.Canceled):
+			return t, setStatus("Cancelled: " + t.label)
+		case msg.err != nil:
+			utils.LogERROR(fmt.Sprintf("%s failed: %s", t.label, msg.err))
+			return t, setError(fmt.Sprintf("Failed: %s", t.label))
+		case msg.status == "":
+			return t, nil
+		}
+		return t, setStatus(msg.status)
+	}
+	return t, nil
+}
+
+func (t taskRunner) View() string {
+	return style.PaddingLeft(2).Render(
+		fmt.Sprintf("%s %s... (%s to cancel)", t.spinner.View(), t.label, t.cancelKey.Help().Key),
+	)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// loads the entry, after any other selected entries one per line as they
+// are copied, into a tmux paste buffer. The TUI quits afterwards when it
+// was started with clipse --tmux.
+func (l listPane) loadTmuxBuffer(i item, selected []SelectedItem) tea.Cmd {
+	if i.FilePath() != "null" {
+		return setWarning("Images can't be loaded into tmux")
+	}
+	value := ""
+	for _, s := range selected {
+		if s.Value != i.TitleFull() {
+			value += s.Value + "\n"
+		}
+	}
+	value += i.TitleFull()
+
+	if err := shell.LoadTmuxBuffer(value); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to load tmux buffer: %s", err))
+		return setError("Could not load tmux buffer.")
+	}
+	if l.tmuxMode {
+		return tea.Quit
+	}
+	return setStatus("Loaded into tmux buffer: " + i.Title())
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/transforms"
+	"github.com/savedra1/clipse/utils"
+)
+
+// transformMenu lists the built-in transforms to apply to an entry. The
+// result is added to the history as derived from the entry, as with
+// clipse transform, and copied.
+type transformMenu struct {
+	list  list.Model
+	keys  *transformKeyMap
+	help  help.Model
+	theme config.CustomTheme
+	entry item // the transform is applied to
+}
+
+// openTransformsMsg shows the transform menu for the item.
+type openTransformsMsg struct {
+	item item
+}
+
+// closeTransformsMsg returns from the transform menu to the list.
+type closeTransformsMsg struct{}
+
+func newTransformMenu(theme config.CustomTheme) transformMenu {
+	transformList := list.New(transformItems(), newItemDelegate(theme), 0, 0)
+	transformList.Filter = fuzzyFilter
+	configureListKeys(&transformList.KeyMap)
+	transformList.Title = transformsTitle
+	transformList.SetShowHelp(false)
+	transformList.Styles.PaginationStyle = style.MarginBottom(1).MarginLeft(2)
+
+	return transformMenu{
+		list:  styledList(transformList, theme),
+		keys:  newTransformKeyMap(),
+		help:  styledHelp(help.New(), theme),
+		theme: theme,
+	}
+}
+
+func (t *transformMenu) open(i item) {
+	t.entry = i
+	t.list.Title = fmt.Sprintf("%s: %s", transformsTitle, utils.Truncate(i.Title(), actionTitleLen))
+	t.list.ResetFilter()
+	t.list.Select(0)
+}
+
+func (t transformMenu) Update(msg tea.Msg) (transformMenu, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := appStyle.GetFrameSize()
+		t.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case showStatusMsg:
+		t.list.StatusMessageLifetime = msg.lifetime
+		return t, t.list.NewStatusMessage(msg.text)
+
+	case tea.KeyMsg:
+		if t.list.SettingFilter() {
+			t.list.KeyMap.Quit.SetEnabled(false)
+			break
+		}
+		t.list.KeyMap.Quit.SetEnabled(true)
+
+		if key.Matches(msg, t.keys.back) {
+			return t, func() tea.Msg { return closeTransformsMsg{} }
+		}
+		if i, ok := t.list.SelectedItem().(item); ok && key.Matches(msg, t.keys.choose) {
+			return t, tea.Batch(
+				func() tea.Msg { return closeTransformsMsg{} },
+				applyTransform(i.label, t.entry),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	t.list, cmd = t.list.Update(msg)
+	return t, cmd
+}
+
+func (t transformMenu) View() string {
+	if t.list.SettingFilter() {
+		return style.PaddingLeft(1).Render(t.list.View())
+	}
+	helpView := style.PaddingLeft(2).Render(t.help.ShortHelpView(t.keys.TransformHelp()))
+	return style.PaddingLeft(1).Render(t.list.View() + "\n" + helpView)
+}
+
+// stores the transformed entry and copies it, quitting unless the TUI was
+// opened with keep
+func applyTransform(name string, entry item) tea.Cmd {
+	transform, ok := transforms.Get(name)
+	if !ok {
+		return nil
+	}
+	derived, err := transform(entry.titleFull)
+	if err != nil {
+		return setError(fmt.Sprintf("Could not apply %s: %s", name, err))
+	}
+	if err := config.AddDerivedItem(derived, config.ClipboardItem{Recorded: entry.timeStamp}, name); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to store %s of entry: %s", name, err))
+		return setError("Could not store the transformed entry.")
+	}
+	if err := config.CopyText(derived); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to copy transformed entry: %s", err))
+		return tea.Batch(setError("Could not copy the transformed entry."), func() tea.Msg { return ReRender{} })
+	}
+	switch {
+	case len(os.Args) > 2 && utils.IsInt(os.Args[2]):
+		startDirectPaste()
+		shell.KillProcess(os.Args[2])
+		return tea.Quit
+	case len(os.Args) > 1 && os.Args[1] == "keep":
+		return tea.Batch(setStatus(fmt.Sprintf("Copied %s: %s", name, utils.Shorten(derived))), func() tea.Msg { return ReRender{} })
+	}
+	return quitAndPaste()
+}
+
+func transformItems() []list.Item {
+	items := []list.Item{}
+	for _, name := range transforms.Names() {
+		desc := transforms.Description(name)
+		items = append(items, item{
+			title:           name,
+			titleBase:       name,
+			titleFull:       desc,
+			description:     desc,
+			descriptionBase: desc,
+			filePath:        "null",
+			label:           name,
+		})
+	}
+	return items
+}
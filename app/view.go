@@ -1,55 +1,44 @@
 package app
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/charmbracelet/lipgloss"
-)
+import "github.com/charmbracelet/lipgloss"
 
 func (m Model) View() string {
-	render := style.PaddingLeft(1).Render
+	switch m.screen {
+	case screenPreview:
+		return m.preview.View()
+	case screenConfirm:
+		return m.confirm.View()
+	case screenSnippet:
+		return m.snippet.View()
+	case screenInput:
+		return m.input.View()
+	case screenConflict:
+		return m.conflict.View()
+	case screenNotices:
+		return m.notices.View()
+	case screenSecrets:
+		return m.secrets.View()
+	case screenEmoji:
+		return m.emoji.View()
+	case screenQR:
+		return m.qr.View()
+	case screenActions:
+		return m.actions.View()
+	case screenReshape:
+		return m.reshape.View()
+	case screenCreate:
+		return m.create.View()
+	case screenLeader:
+		return m.leader.View()
+	}
 
 	listView := m.list.View()
-	helpView := style.PaddingLeft(2).Render(m.help.View(m.keys))
-
-	switch {
-
-	case m.showPreview:
-		helpView = style.PaddingLeft(2).Render(
-			m.list.Help.ShortHelpView(m.previewKeys.PreviewHelp()))
-		return fmt.Sprintf(
-			"\n%s\n%s\n%s\n%s\n",
-			m.previewHeaderView(), m.preview.View(), m.previewFooterView(), helpView,
-		)
-
-	case m.showConfirmation:
-		listView = m.confirmationList.View()
-		helpView = style.PaddingLeft(2).Render(
-			m.list.Help.ShortHelpView(m.confirmationKeys.ConfirmationHelp()))
-		return render(listView + "\n" + helpView)
-
-	case m.list.SettingFilter():
-		return render(listView + "\n" + style.PaddingLeft(2).Render(
-			m.list.Help.ShortHelpView(m.filterKeys.FilterHelp())),
-		)
-
-	case m.list.ShowHelp():
-		return render(listView)
-
-	default:
-		return render(listView + "\n" + helpView)
+	if m.task.running {
+		listView = m.list.viewWithFooter(m.task.View())
 	}
-}
-
-func (m *Model) previewHeaderView() string {
-	title := previewTitleStyle.Render(previewHeader)
-	line := strings.Repeat(borderMiddleChar, max(0, m.preview.Width-lipgloss.Width(title)))
-	return m.styledPreviewHeader(lipgloss.JoinHorizontal(lipgloss.Center, title, line))
-}
-
-func (m *Model) previewFooterView() string {
-	info := previewInfoStyle.Render(fmt.Sprintf("%3.f%%", m.preview.ScrollPercent()*100))
-	line := strings.Repeat(borderMiddleChar, max(0, m.preview.Width-lipgloss.Width(info)))
-	return m.styledPreviewFooter(lipgloss.JoinHorizontal(lipgloss.Center, line, info))
+	if m.showSplit {
+		listView = style.MaxWidth(m.width / 2).Render(listView)
+		return lipgloss.JoinHorizontal(lipgloss.Top, listView, m.split.View())
+	}
+	return listView
 }
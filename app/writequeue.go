@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/config"
+)
+
+// writeQueue batches the deletes, restores and pin toggles made in the TUI
+// so quick successive changes cost a single rewrite of the history file.
+// The list is updated straight away, the changes are written once none
+// have been made for writeDebounce. If the write fails or is cancelled the
+// list is reloaded from the file, rolling back the changes shown.
+type writeQueue struct {
+	deletes  []string
+	patches  map[string]config.ItemPatch // keyed by recorded timestamp
+	restores []config.ClipboardItem
+	images   []string        // image files of deleted items, kept for undo until exit
+	id       int             // ignores flushes superseded by newer changes
+	inflight *sync.WaitGroup // writes running in the background
+}
+
+// flushWritesMsg writes the queued changes if no newer ones were made.
+type flushWritesMsg struct {
+	id int
+}
+
+func newWriteQueue() writeQueue {
+	return writeQueue{
+		patches:  make(map[string]config.ItemPatch),
+		inflight: &sync.WaitGroup{},
+	}
+}
+
+func (q *writeQueue) delete(items ...config.ClipboardItem) tea.Cmd {
+	for _, i := range items {
+		delete(q.patches, i.Recorded)
+		if i.FilePath != "null" {
+			q.images = append(q.images, i.FilePath)
+		}
+		if index := restoreIndex(q.restores, i.Recorded); index >= 0 {
+			// restored and deleted again before being written
+			q.restores = append(q.restores[:index], q.restores[index+1:]...)
+			continue
+		}
+		q.deletes = append(q.deletes, i.Recorded)
+	}
+	return q.schedule()
+}
+
+func (q *writeQueue) restore(items ...config.ClipboardItem) tea.Cmd {
+	for _, i := range items {
+		if index := deleteIndex(q.deletes, i.Recorded); index >= 0 {
+			// deleted and restored before being written
+			q.deletes = append(q.deletes[:index], q.deletes[index+1:]...)
+			continue
+		}
+		q.restores = append(q.restores, i)
+	}
+	return q.schedule()
+}
+
+func (q *writeQueue) pin(timeStamp string, pinned bool) tea.Cmd {
+	patch := q.patches[timeStamp]
+	patch.Pinned = &pinned
+	q.patches[timeStamp] = patch
+	return q.schedule()
+}
+
+// restarts the debounce timer
+func (q *writeQueue) schedule() tea.Cmd {
+	q.id++
+	id := q.id
+	return tea.Tick(writeDebounce, func(time.Time) tea.Msg {
+		return flushWritesMsg{id: id}
+	})
+}
+
+func (q writeQueue) pending() int {
+	return len(q.deletes) + len(q.patches) + len(q.restores)
+}
+
+// empties the queue, returning its changes
+func (q *writeQueue) changes() config.Changes {
+	changes := config.Changes{
+		Delete:     q.deletes,
+		Patch:      q.patches,
+		Restore:    q.restores,
+		KeepImages: true,
+	}
+	q.deletes, q.patches, q.restores = nil, make(map[string]config.ItemPatch), nil
+	return changes
+}
+
+// hands over the queued changes as a task writing them in one go
+func (q *writeQueue) take() taskStartMsg {
+	changes := q.changes()
+	count := len(changes.Delete) + len(changes.Patch) + len(changes.Restore)
+
+	inflight := q.inflight
+	inflight.Add(1)
+	return taskStartMsg{
+		label: fmt.Sprintf("Saving %d change(s)", count),
+		run: func(ctx context.Context) (string, error) {
+			defer inflight.Done()
+			// the changes were already shown, keep their status message
+			return "", config.ApplyChanges(ctx, changes)
+		},
+	}
+}
+
+// writes anything still queued and waits for running writes, then removes
+// the image files of items that stayed deleted. Used on exit.
+func (q *writeQueue) flush() error {
+	q.inflight.Wait()
+	if q.pending() > 0 {
+		if err := config.ApplyChanges(context.Background(), q.changes()); err != nil {
+			return err
+		}
+	}
+	config.DeleteUnusedImages(q.images)
+	return nil
+}
+
+// applies the queued changes to items read from the history file so a
+// reload doesn't bring back changes that are yet to be written
+func (q writeQueue) applyPending(items []config.ClipboardItem) []config.ClipboardItem {
+	if q.pending() == 0 {
+		return items
+	}
+	deleted := make(map[string]bool)
+	for _, ts := range q.deletes {
+		deleted[ts] = true
+	}
+
+	updated := []config.ClipboardItem{}
+	for _, i := range items {
+		if deleted[i.Recorded] {
+			continue
+		}
+		if patch, ok := q.patches[i.Recorded]; ok {
+			i = patch.Apply(i)
+		}
+		updated = append(updated, i)
+	}
+	for _, i := range q.restores {
+		updated = config.RestoreItem(updated, i)
+	}
+	return updated
+}
+
+func deleteIndex(timeStamps []string, ts string) int {
+	for index, t := range timeStamps {
+		if t == ts {
+			return index
+		}
+	}
+	return -1
+}
+
+func restoreIndex(items []config.ClipboardItem, ts string) int {
+	for index, i := range items {
+		if i.Recorded == ts {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* The subcommands of the CLI, eg `clipse search`. Each takes its own flags
+and arguments, prints its usage with --help and exits with utils.ExitUsage
+when they are invalid. The single dash flags of earlier versions, eg
+-listen or -clear-all, still work so existing key bindings and scripts
+keep working, and are listed by `clipse help` after the commands.
+*/
+
+type command struct {
+	name     string
+	args     string // usage after the name
+	summary  string
+	run      func(args []string)
+	hasFlags bool // parses its args with newFlagSet, which handles --help
+	noUnlock bool // never prompts for the history passphrase
+}
+
+// in the order clipse help lists them, set in init as handleHelp refers
+// to the list itself
+var commands []command
+
+var usageOutput io.Writer = os.Stderr // os.Stdout for clipse help <command>
+
+func init() {
+	commands = []command{
+		{name: "listen", args: "[--shell] [--debug-pprof]", summary: "Start the listener recording clipboard changes in the background.", run: handleListenCmd, hasFlags: true, noUnlock: true},
+		{name: "kill", summary: "Stop the listener and any other clipse processes.", run: noArgs("kill", func() { handleKill(config.DisplayServer()) }), noUnlock: true},
+		{name: "status", summary: "Show whether the listener is running and when it last recorded a copy.", run: noArgs("status", handleStatus)},
+		{name: "digest", args: "[--days <n>] [--json] [--notify]", summary: "Sum up the copies, top sources, biggest entries and storage growth of the past week.", run: handleDigest, hasFlags: true},
+		{name: "doctor", summary: "Check what clipse can do where it runs, eg without a display server.", run: noArgs("doctor", handleDoctor)},
+		{name: "add", args: "[text]", summary: "Add the text, or the stdin, to the history without copying it.", run: handleAddCmd},
+		{name: "copy", args: "[--formatted] [N | -]", summary: "Copy the Nth most recent entry, or the stdin, to the clipboard.", run: handleCopyCmd, hasFlags: true},
+		{name: "clear", args: "[--all | --images | --text]", summary: "Remove the history, keeping pinned entries unless a flag is given.", run: handleClearCmd, hasFlags: true},
+		{name: "search", args: "[--json] [--regex] [--archive] [--since <time>] [--limit <n>] [query]", summary: "Print the entries matching a filter query, numbered for copy.", run: handleSearch, hasFlags: true},
+		{name: "export", args: "[--format json|csv|markdown] [--snippets] [file]", summary: "Write the history or the snippets to a file or the stdout.", run: handleExport, hasFlags: true},
+		{name: "import", args: "[--snippets | --from copyq|gpaste|clipman|greenclip] <file>", summary: "Merge an export, snippets or another clipboard manager's history.", run: handleImport, hasFlags: true},
+		{name: "config", args: "[path | show]", summary: "Print the config files in use, or the config they add up to.", run: handleConfig, noUnlock: true},
+		{name: "pipe", args: "[--tag <name>] [--copy] [--max-size <bytes>]", summary: "Store the stdin, eg the output of a command, as an entry.", run: handlePipe, hasFlags: true},
+		{name: "transform", args: "<name> <N>", summary: "Store the Nth entry with a transform applied, eg dedent or upper.", run: handleTransform},
+		{name: "map", args: "--filter <query> --transform <name> [--apply]", summary: "Apply a transform to every matching entry.", run: handleMap, hasFlags: true},
+		{name: "trace", args: "<N>", summary: "Show the entries the Nth entry was derived from.", run: handleTrace},
+		{name: "filters", args: "test <file|-> | packs", summary: "Check the capture filters against some text, or list the redaction packs.", run: handleFilters},
+		{name: "restore", args: "--at <time> [--out <dir>] [--apply]", summary: "Restore the history as it was at a point in time.", run: handleRestore, hasFlags: true},
+		{name: "migrate", args: "[[--from <storage>] <json|sqlite|jsonl>]", summary: "Copy the history to another storage, or without one open the migration assistant.", run: handleMigrate, hasFlags: true},
+		{name: "shards", args: "[--json]", summary: "List the monthly shards holding the entries moved out of the history.", run: handleShards, hasFlags: true},
+		{name: "compact", summary: "Rewrite the jsonl history log without the records it no longer needs.", run: noArgs("compact", handleCompact)},
+		{name: "prune", args: "[--max-age <age>]", summary: "Remove the entries older than maxAge.", run: handlePrune, hasFlags: true},
+		{name: "pause", args: "[duration]", summary: "Stop recording copies, until resume or for the duration.", run: handlePause},
+		{name: "resume", summary: "Start recording copies again.", run: noArgs("resume", handleResume)},
+		{name: "sync", args: "[--watch]", summary: "Merge the histories of the devices sharing sync.dir.", run: handleSync, hasFlags: true},
+		{name: "peers", args: "[pair [<device>] | unpair <device>]", summary: "List, pair or unpair devices on the local network.", run: handlePeers},
+		{name: "keep", summary: "Open the clipboard history, keeping it open after copying an entry.", run: noArgs("keep", launchTUI)},
+		{name: "emoji", summary: "Open the emoji picker.", run: noArgs("emoji", handleEmoji)},
+		{name: "watch", args: "[--json] [--max <n>]", summary: "Print each entry the listener records as it comes in, for scripts.", run: handleWatch, hasFlags: true, noUnlock: true},
+		{name: "prompt-segment", args: "[--max <n>]", summary: "Print the latest entry on one line for shell prompts.", run: handlePromptSegment, hasFlags: true, noUnlock: true},
+		{name: "record", args: "[--raw] [--out <file>]", summary: "Record the clipboard changes seen, to replay them when reporting a bug.", run: handleRecord, hasFlags: true},
+		{name: "replay", args: "<file>", summary: "Show what the listener would do with a recording.", run: handleReplay},
+		{name: "profile", args: "capture [--type cpu|heap|goroutine] [--output file] [duration]", summary: "Capture a profile of a listener started with --debug-pprof.", run: handleProfile},
+		{name: "enable-autostart", summary: "Run the listener as a systemd user service.", run: noArgs("enable-autostart", handleEnableAutostart)},
+		{name: "disable-autostart", summary: "Remove the listener service.", run: noArgs("disable-autostart", handleDisableAutostart)},
+		{name: "help", args: "[command]", summary: "Show the usage of clipse or of a command.", run: handleHelp, noUnlock: true},
+		{name: "version", summary: "Show the version of clipse.", run: noArgs("version", printVersion), noUnlock: true},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// runs the command named by the first arg with the rest
+func runCommand(args []string) {
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fail(utils.ExitUsage, "Unknown command %q. See `%s help` for the commands.", args[0], os.Args[0])
+	}
+	if !cmd.hasFlags && len(args) > 1 && isHelpFlag(args[1]) {
+		printCommandUsage(os.Stdout, cmd)
+		return
+	}
+	cmd.run(args[1:])
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// a flag set for the command that prints its usage for --help, exiting
+// with utils.ExitUsage for invalid flags
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.SetOutput(usageOutput)
+	fs.Usage = func() {
+		cmd, _ := findCommand(name)
+		printCommandUsage(fs.Output(), cmd)
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// exits with the usage of the command after its arguments were invalid
+func failUsage(name string) {
+	cmd, _ := findCommand(name)
+	fail(utils.ExitUsage, "Usage: %s", commandLine(cmd))
+}
+
+// wraps a command taking no arguments
+func noArgs(name string, run func()) func([]string) {
+	return func(args []string) {
+		if len(args) > 0 {
+			failUsage(name)
+		}
+		run()
+	}
+}
+
+func commandLine(cmd command) string {
+	line := os.Args[0] + " " + cmd.name
+	if cmd.args != "" {
+		line += " " + cmd.args
+	}
+	return line
+}
+
+func printCommandUsage(w io.Writer, cmd command) {
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", commandLine(cmd), cmd.summary)
+}
+
+// prints the commands and the flags, for clipse help and --help
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s [command] [args]\n\nOpens the clipboard history when run without a command.\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-18s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun `%s help <command>` for the args of a command.\n", os.Args[0])
+	fmt.Fprintln(w, "\nFlags, most of them the commands of earlier versions, kept for existing key bindings and scripts:")
+	flag.CommandLine.SetOutput(w)
+	flag.PrintDefaults()
+}
+
+func handleHelp(args []string) {
+	switch len(args) {
+	case 0:
+		printUsage(os.Stdout)
+		return
+	case 1:
+	default:
+		failUsage("help")
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fail(utils.ExitUsage, "Unknown command %q. See `%s help` for the commands.", args[0], os.Args[0])
+	}
+	if !cmd.hasFlags {
+		printCommandUsage(os.Stdout, cmd)
+		return
+	}
+	// the flags are defined by the command, which exits after printing them
+	usageOutput = os.Stdout
+	cmd.run([]string{"-help"})
+}
+
+func handleListenCmd(args []string) {
+	fs := newFlagSet("listen")
+	inShell := fs.Bool("shell", false, "Run the listener in this shell instead of the background.")
+	pprof := fs.Bool("debug-pprof", false, "Serve net/http/pprof on "+pprofAddr+", see clipse profile capture.")
+	utils.HandleError(fs.Parse(args))
+	if fs.NArg() > 0 {
+		failUsage("listen")
+	}
+
+	*debugPprof = *debugPprof || *pprof
+	displayServer := config.DisplayServer()
+	if *inShell {
+		handleListenShell(displayServer, shell.ImagesEnabled(displayServer))
+		return
+	}
+	handleListen(displayServer)
+}
+
+func handleCopyCmd(args []string) {
+	fs := newFlagSet("copy")
+	formatted := fs.Bool("formatted", false, "Copy the text/html the entry was copied with, to paste it formatted.")
+	utils.HandleError(fs.Parse(args))
+	args = fs.Args()
+
+	switch {
+	case len(args) == 1 && utils.IsInt(args[0]) && *formatted:
+		n, _ := strconv.Atoi(args[0])
+		handleCopyFormatted(n)
+	case *formatted:
+		failUsage("copy")
+	case len(args) == 1 && utils.IsInt(args[0]):
+		n, _ := strconv.Atoi(args[0])
+		handleCopyEntry(n)
+	case len(args) == 1 && args[0] == "-", len(args) == 0 && utils.StdinPiped():
+		if input := utils.GetStdin(); input != "" {
+			utils.HandleError(config.CopyText(input))
+		}
+	default:
+		failUsage("copy")
+	}
+}
+
+func handleClearCmd(args []string) {
+	fs := newFlagSet("clear")
+	all := fs.Bool("all", false, "Also remove pinned entries.")
+	images := fs.Bool("images", false, "Remove only the images, including pinned ones.")
+	text := fs.Bool("text", false, "Remove only the text entries, including pinned ones.")
+	utils.HandleError(fs.Parse(args))
+	if fs.NArg() > 0 || fs.NFlag() > 1 {
+		failUsage("clear")
+	}
+
+	clearType := "default"
+	switch {
+	case *all:
+		clearType = "all"
+	case *images:
+		clearType = "images"
+	case *text:
+		clearType = "text"
+	}
+	handleClear(clearType)
+}
+
+// prints the paths of the config file and host overlay in use, or the
+// config they add up to as JSON
+func handleConfig(args []string) {
+	switch {
+	case len(args) == 0 || len(args) == 1 && args[0] == "path":
+		file, overlay := config.ConfigFiles()
+		fmt.Println(file)
+		if overlay != "" {
+			fmt.Println(overlay)
+		}
+	case len(args) == 1 && args[0] == "show":
+		out, err := json.MarshalIndent(config.ClipseConfig, "", "    ")
+		utils.HandleError(err)
+		fmt.Println(string(out))
+	default:
+		failUsage("config")
+	}
+}
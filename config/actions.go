@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+// Action is a shell command run on an entry from the TUI action menu.
+type Action struct {
+	Name    string `json:"name"`
+	Command string `json:"command"` // run with sh, gets the entry in place of {} or on the stdin
+	Output  string `json:"output"`  // one of the Action outputs, defaults to show
+}
+
+// What the action menu does with the output of an action.
+const (
+	ActionShow   = "show"   // in the preview
+	ActionCopy   = "copy"   // to the clipboard, where the listener records it
+	ActionIgnore = "ignore" // eg for uploads printing nothing useful
+)
+
+// drops actions missing a name or command, they can't be listed or run
+func validateActions() {
+	valid := []Action{}
+	for _, action := range ClipseConfig.Actions {
+		if strings.TrimSpace(action.Name) == "" || strings.TrimSpace(action.Command) == "" {
+			utils.LogWARN(fmt.Sprintf("action %q needs a name and a command. Skipping it", action.Name))
+			continue
+		}
+		switch action.Output {
+		case ActionShow, ActionCopy, ActionIgnore:
+		case "":
+			action.Output = ActionShow
+		default:
+			utils.LogWARN(fmt.Sprintf("unknown output %q of action %s, must be %s, %s or %s. Showing it", action.Output, action.Name, ActionShow, ActionCopy, ActionIgnore))
+			action.Output = ActionShow
+		}
+		valid = append(valid, action)
+	}
+	ClipseConfig.Actions = valid
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/savedra1/clipse/transforms"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* captureTransforms names transforms, such as strip-trackers, the
+listener applies in order to copied text after the capture filters. When
+they change a copy it is put back on the clipboard as stored, so links are
+pasted without their tracking params too. A transform failing on a copy,
+eg json-format on text that isn't JSON, leaves it as it is.
+*/
+
+func validateCaptureTransforms() {
+	valid := []string{}
+	for _, name := range ClipseConfig.CaptureTransforms {
+		if _, ok := transforms.Get(name); !ok {
+			utils.LogWARN(fmt.Sprintf("unknown capture transform %q, skipping it", name))
+			continue
+		}
+		valid = append(valid, name)
+	}
+	ClipseConfig.CaptureTransforms = valid
+}
+
+// ApplyCaptureTransforms runs text through the captureTransforms, returning
+// the result and the names of those that changed it.
+func ApplyCaptureTransforms(text string) (string, []string) {
+	applied := []string{}
+	for _, name := range ClipseConfig.CaptureTransforms {
+		transform, ok := transforms.Get(name)
+		if !ok {
+			continue
+		}
+		result, err := transform(text)
+		if err != nil || result == text {
+			continue
+		}
+		text = result
+		applied = append(applied, name)
+	}
+	return text, applied
+}
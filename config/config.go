@@ -6,21 +6,135 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/savedra1/clipse/shell"
 	"github.com/savedra1/clipse/utils"
 )
 
 type Config struct {
-	AllowDuplicates bool              `json:"allowDuplicates"`
-	HistoryFilePath string            `json:"historyFile"`
-	MaxHistory      int               `json:"maxHistory"`
-	LogFilePath     string            `json:"logFile"`
-	ThemeFilePath   string            `json:"themeFile"`
-	TempDirPath     string            `json:"tempDir"`
-	KeyBindings     map[string]string `json:"keyBindings"`
-	ImageDisplay    ImageDisplay      `json:"imageDisplay"`
+	AllowDuplicates       bool              `json:"allowDuplicates"`
+	MoveDuplicatesToTop   bool              `json:"moveDuplicatesToTop"`
+	Dedup                 Dedup             `json:"dedup"` // which copies count as duplicates, see dedup.go
+	HistoryFilePath       string            `json:"historyFile"`
+	Storage               string            `json:"storage"`        // json, sqlite or jsonl, see storage.go
+	DatabasePath          string            `json:"databaseFile"`   // history database used by the sqlite storage
+	HistoryLogPath        string            `json:"historyLogFile"` // append-only history log used by the jsonl storage
+	MaxHistory            int               `json:"maxHistory"`
+	MaxAge                string            `json:"maxAge"` // unpinned entries older than this are removed, eg 72h or 30d
+	EntrySize             EntrySize         `json:"entrySize"`
+	LogFilePath           string            `json:"logFile"`
+	ThemeFilePath         string            `json:"themeFile"`
+	Theme                 string            `json:"theme"` // built-in preset used when the theme file's useCustomTheme is false
+	Contrast              Contrast          `json:"contrast"`
+	TempDirPath           string            `json:"tempDir"`
+	SearchHistoryFilePath string            `json:"searchHistoryFile"`
+	PasteStackFilePath    string            `json:"pasteStackFile"`
+	PasteQueue            bool              `json:"pasteQueue"` // each paste copies the next stacked entry, wayland only
+	DirectPaste           DirectPaste       `json:"directPaste"`
+	SnippetsFilePath      string            `json:"snippetsFile"`
+	PasswordStore         string            `json:"passwordStore"` // pass or gopass, lists its entries in the TUI
+	JournalFilePath       string            `json:"journalFile"`
+	JournalDays           int               `json:"journalDays"` // days of changes kept for clipse restore, 0 disables the journal
+	Shards                Shards            `json:"shards"`      // entries from before this month kept in a file per month, see shards.go
+	KeyBindings           map[string]string `json:"keyBindings"`
+	Confirm               map[string]bool   `json:"confirm"` // destructive actions asking for confirmation, see confirm.go
+	Actions               []Action          `json:"actions"` // commands of the TUI action menu, see actions.go
+	ImageDisplay          ImageDisplay      `json:"imageDisplay"`
+	CaptureFilters        []CaptureFilter   `json:"captureFilters"`
+	CaptureTransforms     []string          `json:"captureTransforms"` // transforms applied to copied text, see capturetransforms.go
+	IgnorePatterns        []string          `json:"ignorePatterns"`    // copies matching any of these regexes are not stored
+	AllowPatterns         []string          `json:"allowPatterns"`     // if set, only copies matching one of these regexes are stored
+	RedactionPacks        []string          `json:"redactionPacks"`
+	IgnoredApps           []string          `json:"ignoredApps"` // window classes or process names whose copies are not recorded
+	EntropyDetection      EntropyDetection  `json:"entropyDetection"`
+	SecretDetection       SecretDetection   `json:"secretDetection"`
+	Encryption            Encryption        `json:"encryption"`
+	BridgeClipboards      bool              `json:"bridgeClipboards"` // mirror the X11 and wayland clipboards in XWayland sessions
+	Locale                string            `json:"locale"`           // formatting of dates and numbers, eg de_DE, defaults to $LANG
+	Clock                 string            `json:"clock"`            // 12h or 24h, defaults to the locale's clock
+	Sandbox               bool              `json:"sandbox"`          // restrict the listener with landlock and seccomp on Linux
+	ForceOSC52            bool              `json:"forceOSC52"`       // copy through the terminal with OSC 52, eg over SSH
+	TmuxBuffers           bool              `json:"tmuxBuffers"`      // the listener loads captured text into tmux paste buffers
+	PasteFormats          map[string]string `json:"pasteFormats"`     // format HTML entries are copied in per app, see pasteformats.go
+	Hooks                 Hooks             `json:"hooks"`            // commands run on captures, copies and deletes, see hooks.go
+	WeeklyDigest          bool              `json:"weeklyDigest"`     // the listener sends a summary of the week as a notification, see digest.go
+	PrimarySelection      PrimarySelection  `json:"primarySelection"` // record the mouse selection and where copies go, see primary.go
+	Sync                  Sync              `json:"sync"`
 }
+
+// Pastes into the previous window after choosing an entry in the TUI, see
+// shell/paste.go.
+type DirectPaste struct {
+	Enabled bool   `json:"enabled"`
+	Keys    string `json:"keys"`    // eg ctrl+v, or ctrl+shift+v to paste into terminals
+	DelayMs int    `json:"delayMs"` // for the focus to move back to the previous window
+}
+
+// Limits on the size of copied text, see entrysize.go. 0 disables a limit.
+// Action is one of "truncate" or "skip".
+type EntrySize struct {
+	MinLength int    `json:"minLength"` // characters, shorter copies are not stored
+	MaxSize   int    `json:"maxSize"`   // bytes
+	Action    string `json:"action"`    // for copies over maxSize
+}
+
+// Checks the colors of custom themes are readable, see contrast.go.
+// Action is one of "adjust" or "warn".
+type Contrast struct {
+	MinRatio float64 `json:"minRatio"` // WCAG contrast ratio from 1 to 21, 0 disables the check
+	Action   string  `json:"action"`
+}
+
+// A regex rule applied to text content before it is stored.
+// Action is one of "ignore", "redact" or "replace".
+type CaptureFilter struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Action      string `json:"action"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// Masks or ignores tokens that look like generated secrets.
+// Action is one of "redact" or "ignore".
+type EntropyDetection struct {
+	Enabled        bool    `json:"enabled"`
+	Action         string  `json:"action"`
+	MinLength      int     `json:"minLength"`
+	MinEntropy     float64 `json:"minEntropy"`
+	MinCharClasses int     `json:"minCharClasses"`
+}
+
+// Flags likely secrets on capture. Action is one of "mask", to store the
+// entry hidden in the TUI until revealed, or "skip" to not store it.
+type SecretDetection struct {
+	Enabled          bool     `json:"enabled"`
+	Action           string   `json:"action"`
+	PasswordManagers bool     `json:"passwordManagers"` // clipboard content marked secret by a password manager
+	Entropy          bool     `json:"entropy"`          // uses the entropyDetection thresholds
+	Packs            []string `json:"packs"`            // built-in packs used as detectors
+}
+
+// Encrypts the history file at rest. Without a KeyFile the key is derived
+// from a passphrase prompted for on launch.
+// Syncs the history and snippets with other devices through a shared
+// folder, see sync.go.
+type Sync struct {
+	Enabled         bool   `json:"enabled"`
+	Dir             string `json:"dir"`             // folder shared by the devices, eg with Syncthing
+	Device          string `json:"device"`          // name of this device, defaults to the hostname
+	Images          bool   `json:"images"`          // also sync image entries
+	Sensitive       bool   `json:"sensitive"`       // also sync entries detected as secrets
+	MaxEntrySize    int    `json:"maxEntrySize"`    // bytes, larger entries aren't synced
+	MaxPayloadSize  int    `json:"maxPayloadSize"`  // bytes of entries shared by each device, newest first
+	MaxBytesPerHour int    `json:"maxBytesPerHour"` // written to the sync dir, 0 for no limit
+}
+
+type Encryption struct {
+	Enabled bool   `json:"enabled"`
+	KeyFile string `json:"keyFile"`
+}
+
 type ImageDisplay struct {
 	Type      string `json:"type"`
 	ScaleX    int    `json:"scaleX"`
@@ -31,6 +145,15 @@ type ImageDisplay struct {
 // Global config object, accessed and used when any configuration is needed.
 var ClipseConfig = defaultConfig()
 
+// the config file and host overlay the config was loaded from
+var loadedConfig, loadedOverlay string
+
+// ConfigFiles returns the paths of the config file and the host overlay
+// applied on top of it, empty if there is none.
+func ConfigFiles() (string, string) {
+	return loadedConfig, loadedOverlay
+}
+
 func Init() (string, string, bool, error) {
 	/*
 		Ensure $HOME/.config/clipse/clipboard_history.json OR $XDG_CONFIG_HOME
@@ -72,6 +195,7 @@ func Init() (string, string, bool, error) {
 }
 
 func loadConfig(configPath string) {
+	loadedConfig = configPath
 	_, err := os.Stat(configPath)
 
 	if os.IsNotExist(err) {
@@ -90,11 +214,116 @@ func loadConfig(configPath string) {
 		utils.LogERROR(fmt.Sprintf("failed to read config. Skipping.\nsrr: %s", err))
 	}
 
+	loadHostOverlay(configDir)
+	validateKeyBindings()
+	validateConfirm()
+	validateDirectPaste()
+	validatePasswordStore()
+	validateActions()
+	validatePasteFormats()
+
 	// Expand HistoryFile, ThemeFile, LogFile and TempDir paths
 	ClipseConfig.HistoryFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.HistoryFilePath), configDir)
+	ClipseConfig.DatabasePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.DatabasePath), configDir)
+	ClipseConfig.HistoryLogPath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.HistoryLogPath), configDir)
 	ClipseConfig.TempDirPath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.TempDirPath), configDir)
 	ClipseConfig.ThemeFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.ThemeFilePath), configDir)
 	ClipseConfig.LogFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.LogFilePath), configDir)
+	ClipseConfig.SearchHistoryFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.SearchHistoryFilePath), configDir)
+	ClipseConfig.PasteStackFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.PasteStackFilePath), configDir)
+	ClipseConfig.SnippetsFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.SnippetsFilePath), configDir)
+	ClipseConfig.JournalFilePath = utils.ExpandRel(utils.ExpandHome(ClipseConfig.JournalFilePath), configDir)
+	ClipseConfig.Shards.Dir = utils.ExpandRel(utils.ExpandHome(ClipseConfig.Shards.Dir), configDir)
+	if ClipseConfig.Sync.Dir != "" {
+		ClipseConfig.Sync.Dir = utils.ExpandRel(utils.ExpandHome(ClipseConfig.Sync.Dir), configDir)
+	}
+	if ClipseConfig.Encryption.KeyFile != "" {
+		ClipseConfig.Encryption.KeyFile = utils.ExpandRel(utils.ExpandHome(ClipseConfig.Encryption.KeyFile), configDir)
+	}
+
+	validateStorage()
+	validateMaxAge()
+	validateEntrySize()
+	validateDedup()
+	validateCaptureTransforms()
+	validatePrimarySelection()
+	validateSync()
+	validateContrast()
+	utils.SetLocale(ClipseConfig.Locale, ClipseConfig.Clock)
+}
+
+func loadHostOverlay(configDir string) {
+	/*
+		Applies config.d/<hostname>.json on top of the base config so a
+		shared config can be specialized per machine. Only the fields
+		present in the overlay are changed, keyBindings are merged.
+	*/
+	hostname, err := os.Hostname()
+	if err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to get hostname for config overlay: %s", err))
+		return
+	}
+
+	overlayPath := filepath.Join(configDir, overlayDir, hostname+".json")
+	overlayData, err := os.ReadFile(overlayPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to read config overlay %s: %s", overlayPath, err))
+		return
+	}
+
+	if err = json.Unmarshal(overlayData, &ClipseConfig); err != nil {
+		fmt.Printf("Failed to read config overlay %s. Skipping.\nErr: %s\n", overlayPath, err)
+		utils.LogERROR(fmt.Sprintf("failed to read config overlay %s: %s", overlayPath, err))
+		return
+	}
+	loadedOverlay = overlayPath
+}
+
+// Ignores unknown actions in keyBindings and binds actions left without a
+// key to their default, so a typo can't leave an action unreachable.
+func validateKeyBindings() {
+	defaults := defaultKeyBindings()
+	if ClipseConfig.KeyBindings == nil {
+		ClipseConfig.KeyBindings = defaults
+		return
+	}
+	for action := range ClipseConfig.KeyBindings {
+		if _, ok := defaults[action]; !ok {
+			utils.LogWARN(fmt.Sprintf("unknown key binding %q, ignoring it", action))
+			delete(ClipseConfig.KeyBindings, action)
+		}
+	}
+	for action, k := range defaults {
+		if ClipseConfig.KeyBindings[action] == "" {
+			if _, set := ClipseConfig.KeyBindings[action]; set {
+				utils.LogWARN(fmt.Sprintf("no key set for %q, using the default %q", action, k))
+			}
+			ClipseConfig.KeyBindings[action] = k
+		}
+	}
+}
+
+// Uses the default paste keys or delay in place of missing or negative ones.
+func validateDirectPaste() {
+	p := &ClipseConfig.DirectPaste
+	defaults := defaultConfig().DirectPaste
+	if strings.TrimSpace(p.Keys) == "" {
+		p.Keys = defaults.Keys
+	}
+	if p.DelayMs < 0 {
+		utils.LogWARN(fmt.Sprintf("directPaste delayMs must be positive, got %d. Using %d", p.DelayMs, defaults.DelayMs))
+		p.DelayMs = defaults.DelayMs
+	}
+}
+
+// CustomKeyBinding returns the key bound to action and whether it was
+// changed from the default.
+func CustomKeyBinding(action string) (string, bool) {
+	k := ClipseConfig.KeyBindings[action]
+	return k, k != defaultKeyBindings()[action]
 }
 
 func DisplayServer() string {
@@ -112,6 +341,8 @@ func DisplayServer() string {
 		return "x11"
 	case "darwin":
 		return "darwin"
+	case "windows":
+		return "windows"
 	default:
 		return "unknown"
 	}
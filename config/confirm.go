@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Destructive actions ask for confirmation first. Each can be turned off
+in the confirm config, or by answering "don't ask again", which writes it
+to the config file.
+*/
+
+// Actions asking for confirmation, the keys of the confirm config.
+const (
+	ConfirmDelete     = "delete"     // a single entry in the TUI
+	ConfirmBulkDelete = "bulkDelete" // several selected entries
+	ConfirmPinned     = "pinned"     // deletes including pinned entries
+	ConfirmSnippet    = "snippet"    // deleting a snippet
+	ConfirmClear      = "clear"      // clipse -clear and its variants
+)
+
+func defaultConfirm() map[string]bool {
+	return map[string]bool{
+		ConfirmDelete:     true,
+		ConfirmBulkDelete: true,
+		ConfirmPinned:     true,
+		ConfirmSnippet:    true,
+		ConfirmClear:      true,
+	}
+}
+
+// Ignores unknown actions in confirm, actions left out keep asking.
+func validateConfirm() {
+	if ClipseConfig.Confirm == nil {
+		ClipseConfig.Confirm = defaultConfirm()
+		return
+	}
+	defaults := defaultConfirm()
+	for action := range ClipseConfig.Confirm {
+		if _, ok := defaults[action]; !ok {
+			utils.LogWARN(fmt.Sprintf("unknown confirm action %q, ignoring it", action))
+			delete(ClipseConfig.Confirm, action)
+		}
+	}
+}
+
+// ConfirmEnabled returns whether action asks for confirmation.
+func ConfirmEnabled(action string) bool {
+	enabled, ok := ClipseConfig.Confirm[action]
+	return enabled || !ok
+}
+
+// DisableConfirm stops action asking for confirmation and saves it to the
+// config file.
+func DisableConfirm(action string) error {
+	ClipseConfig.Confirm[action] = false
+	return saveConfigObject("confirm", map[string]any{action: false})
+}
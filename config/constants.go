@@ -1,18 +1,93 @@
 package config
 
+import "time"
+
 const (
 	configFile             = "config.json"
+	overlayDir             = "config.d"
 	clipseDir              = "clipse"
 	defaultAllowDuplicates = false
+	defaultMoveDupsToTop   = true
+	defaultDedupSimilarity = 0.9
+	fuzzyMaxLength         = 500 // characters, longer texts aren't compared fuzzily
 	defaultHistoryFile     = "clipboard_history.json"
+	defaultDatabaseFile    = "clipboard_history.db"
+	defaultHistoryLogFile  = "clipboard_history.jsonl"
+	backupFileExt          = ".bak"
+	lockFileExt            = ".lock"
 	defaultMaxHist         = 100
 	defaultLogFile         = "clipse.log"
 	defaultTempDir         = "tmp_files"
 	defaultThemeFile       = "custom_theme.json"
+	defaultSearchHistFile  = "search_history.json"
+	defaultPasteStackFile  = "paste_stack.json"
+	defaultSnippetsFile    = "snippets.json"
+	defaultJournalFile     = "history_journal.jsonl"
+	defaultJournalDays     = 7
+	defaultShardsDir       = "history_shards"
+	shardFileExt           = ".json"
+	shardMonthLayout       = "2006-01"
+	syncStateFile          = "sync_state.json"
+	peersFile              = "peers.json"
+	peerKeyFile            = "peer_key"
+	pauseFile              = "capture_paused"
+	skipCopyFile           = "skip_next_copy"
+	maxSearchHistory       = 50
 	listenCmd              = "--listen-shell"
 	maxChar                = 65
 )
 
+const hookTimeout = 30 * time.Second // before a hook command is killed, see hooks.go
+
+// migration assistant, see migrations.go
+const (
+	migrationsFile     = "migrations.json"
+	legacyDir          = "clipboard_manager"  // config dir of releases before the rename to clipse
+	clipmanHistoryFile = "clipman.json"       // in $XDG_DATA_HOME
+	gpasteHistoryFile  = "gpaste/history.xml" // in $XDG_DATA_HOME
+	exportTimeout      = 30 * time.Second     // for copyq or greenclip to print their history
+)
+
+// history encryption parameters
+const (
+	encSaltLen    = 16
+	encKeyLen     = 32 // AES-256
+	kdfIterations = 600000
+)
+
+// change subscriptions
+const (
+	changePollInterval = 250 * time.Millisecond // checks for writes made by other processes
+	changeBufferSize   = 16
+)
+
+// deadlines, so a hung clipboard tool or a process holding the history
+// lock can't freeze the listener or the TUI
+const (
+	ClipboardTimeout = 3 * time.Second  // before a clipboard tool is killed
+	storeTimeout     = 10 * time.Second // waiting for the history lock
+)
+
+// history journal
+const (
+	journalCompactSize = 4 << 20 // bytes
+	maxJournalLine     = 16 << 20
+)
+
+// sync limits, in bytes
+const (
+	defaultSyncMaxEntry   = 256 << 10
+	defaultSyncMaxPayload = 4 << 20
+	defaultSyncMaxHourly  = 16 << 20
+	syncImagesDir         = "images"
+)
+
+// jsonl history log
+const (
+	logCompactRatio = 4    // compacted once it holds this many records per entry
+	logCompactMin   = 1000 // records, small logs are left alone
+)
+
 // Initialize default key bindings
 func defaultKeyBindings() map[string]string {
 	return map[string]string{
@@ -23,36 +98,161 @@ func defaultKeyBindings() map[string]string {
 		"remove":        "x",
 		"togglePin":     "p",
 		"togglePinned":  "tab",
-		"preview":       " ",
+		"preview":       "s",
 		"selectDown":    "ctrl+down",
 		"selectUp":      "ctrl+up",
-		"selectSingle":  "s",
+		"selectSingle":  "m",
+		"leader":        " ",
 		"clearSelected": "S",
 		"yankFilter":    "ctrl+s",
+		"pasteStack":    "Y",
+		"undo":          "u",
+		"sortUsed":      "O",
+		"saveSnippet":   "n",
+		"snippets":      "N",
+		"prevQuery":     "up",
+		"nextQuery":     "down",
+		"pinQuery":      "ctrl+p",
+		"copyLink":      "L",
+		"copyFormatted": "F",
+		"reveal":        "r",
+		"splitView":     "v",
+		"splitUp":       "K",
+		"splitDown":     "J",
 		"up":            "up",
 		"down":          "down",
 		"nextPage":      "right",
 		"prevPage":      "left",
 		"home":          "home",
 		"end":           "end",
+		"pageUp":        "pgup",
+		"pageDown":      "pgdown",
+		"cancel":        "esc",
+		"keepLeft":      "h",
+		"keepRight":     "l",
+		"keepBoth":      "b",
+		"pauseCapture":  "P",
+		"switchDevice":  "D",
+		"switchSource":  "A",
+		"notifications": "M",
+		"passwords":     "W",
+		"openURL":       "o",
+		"emoji":         ":",
+		"qrCode":        "Q",
+		"actions":       "a",
+		"inspect":       "i",
+		"copyEscaped":   "e",
+		"transform":     "t",
+		"generate":      "+",
+		"tmuxBuffer":    "T",
 	}
 }
 
 // Because Go does not support constant Structs :(
 func defaultConfig() Config {
 	return Config{
-		HistoryFilePath: defaultHistoryFile,
-		MaxHistory:      defaultMaxHist,
-		AllowDuplicates: defaultAllowDuplicates,
-		TempDirPath:     defaultTempDir,
-		LogFilePath:     defaultLogFile,
-		ThemeFilePath:   defaultThemeFile,
-		KeyBindings:     defaultKeyBindings(),
+		HistoryFilePath:       defaultHistoryFile,
+		Storage:               StorageJSON,
+		DatabasePath:          defaultDatabaseFile,
+		HistoryLogPath:        defaultHistoryLogFile,
+		MaxHistory:            defaultMaxHist,
+		MaxAge:                "",
+		AllowDuplicates:       defaultAllowDuplicates,
+		MoveDuplicatesToTop:   defaultMoveDupsToTop,
+		TempDirPath:           defaultTempDir,
+		LogFilePath:           defaultLogFile,
+		ThemeFilePath:         defaultThemeFile,
+		Theme:                 "",
+		SearchHistoryFilePath: defaultSearchHistFile,
+		PasteStackFilePath:    defaultPasteStackFile,
+		PasteQueue:            false,
+		SnippetsFilePath:      defaultSnippetsFile,
+		PasswordStore:         "",
+		JournalFilePath:       defaultJournalFile,
+		JournalDays:           defaultJournalDays,
+		KeyBindings:           defaultKeyBindings(),
+		Confirm:               defaultConfirm(),
+		Actions:               []Action{},
 		ImageDisplay: ImageDisplay{
 			Type:      "basic",
 			ScaleX:    9,
 			ScaleY:    9,
 			HeightCut: 2,
 		},
+		CaptureFilters: []CaptureFilter{},
+		IgnorePatterns: []string{},
+		AllowPatterns:  []string{},
+		RedactionPacks: []string{},
+		IgnoredApps:    []string{},
+		PasteFormats:   map[string]string{},
+		EntropyDetection: EntropyDetection{
+			Enabled:        false,
+			Action:         "redact",
+			MinLength:      20,
+			MinEntropy:     3.5,
+			MinCharClasses: 3,
+		},
+		SecretDetection: SecretDetection{
+			Enabled:          false,
+			Action:           "mask",
+			PasswordManagers: true,
+			Entropy:          true,
+			Packs:            []string{"aws", "gcp", "ssh", "credit-cards"},
+		},
+		Encryption: Encryption{
+			Enabled: false,
+			KeyFile: "",
+		},
+		BridgeClipboards: false,
+		Locale:           "",
+		Clock:            "",
+		Sandbox:          false,
+		ForceOSC52:       false,
+		TmuxBuffers:      false,
+		WeeklyDigest:     false,
+		PrimarySelection: PrimarySelection{
+			Record: false,
+			CopyTo: SelectionClipboard,
+		},
+		Shards: Shards{
+			Enabled: false,
+			Dir:     defaultShardsDir,
+		},
+		Sync: Sync{
+			Enabled:         false,
+			Dir:             "",
+			Device:          "",
+			Images:          false,
+			Sensitive:       false,
+			MaxEntrySize:    defaultSyncMaxEntry,
+			MaxPayloadSize:  defaultSyncMaxPayload,
+			MaxBytesPerHour: defaultSyncMaxHourly,
+		},
+		Contrast: Contrast{
+			MinRatio: 3,
+			Action:   ContrastAdjust,
+		},
+		Dedup: Dedup{
+			Strategy:   DedupExact,
+			Similarity: defaultDedupSimilarity,
+		},
+		EntrySize: EntrySize{
+			MinLength: 0,
+			MaxSize:   0,
+			Action:    EntryTruncate,
+		},
+		DirectPaste: DirectPaste{
+			Enabled: false,
+			Keys:    "ctrl+v",
+			DelayMs: 150,
+		},
 	}
 }
+
+// digest, see digest.go
+const (
+	digestFile        = "digest.json"
+	digestTopN        = 3                   // sources and biggest entries listed
+	digestKeepSamples = 60 * 24 * time.Hour // of the storage size
+	DigestPeriod      = 7 * 24 * time.Hour
+)
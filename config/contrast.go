@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Custom themes are checked for text that is hard to read against the
+terminal background, or the title against its background, when the TUI
+starts. A color below contrast.minRatio is raised just enough by making it
+lighter or darker when the action is "adjust", or only logged with "warn".
+The dimmed colors are meant to be faint so aren't checked, nor are colors
+given as ANSI numbers, which depend on the terminal's palette.
+*/
+
+// Actions for colors below the minimum contrast.
+const (
+	ContrastAdjust = "adjust"
+	ContrastWarn   = "warn"
+)
+
+const maxContrastRatio = 21 // black on white
+
+func validateContrast() {
+	c := &ClipseConfig.Contrast
+	if c.MinRatio < 0 || c.MinRatio > maxContrastRatio {
+		utils.LogWARN(fmt.Sprintf("contrast minRatio must be from 1 to %d or 0 to disable the check, got %g. Disabling it", maxContrastRatio, c.MinRatio))
+		c.MinRatio = 0
+	}
+	if c.Action != ContrastAdjust && c.Action != ContrastWarn {
+		utils.LogWARN(fmt.Sprintf("unknown contrast action %q, must be %s or %s. Using %s", c.Action, ContrastAdjust, ContrastWarn, ContrastAdjust))
+		c.Action = ContrastAdjust
+	}
+}
+
+// EnforceContrast checks the colors of a custom theme against background,
+// the terminal's, adjusting or logging those below the minimum contrast.
+func (t *CustomTheme) EnforceContrast(background string) {
+	minRatio := ClipseConfig.Contrast.MinRatio
+	if !t.UseCustom || minRatio == 0 {
+		return
+	}
+
+	text := []struct {
+		name  string
+		color *string
+	}{
+		{"NormalTitle", &t.NormalTitle},
+		{"SelectedTitle", &t.SelectedTitle},
+		{"NormalDesc", &t.NormalDesc},
+		{"SelectedDesc", &t.SelectedDesc},
+		{"StatusMsg", &t.StatusMsg},
+		{"FilteredMatch", &t.FilteredMatch},
+		{"FilterPrompt", &t.FilterPrompt},
+		{"FilterText", &t.FilterText},
+		{"HelpKey", &t.HelpKey},
+		{"PreviewedText", &t.PreviewedText},
+	}
+	for _, c := range text {
+		enforceContrast(c.name, c.color, background, minRatio)
+	}
+	enforceContrast("TitleFore", &t.TitleFore, t.TitleBack, minRatio)
+}
+
+func enforceContrast(name string, color *string, background string, minRatio float64) {
+	ratio, ok := utils.ContrastRatio(*color, background)
+	if !ok || ratio >= minRatio {
+		return
+	}
+	if ClipseConfig.Contrast.Action == ContrastWarn {
+		utils.LogWARN(fmt.Sprintf("theme color %s %s has a contrast of %.1f against %s, below the minimum of %g", name, *color, ratio, background, minRatio))
+		return
+	}
+	adjusted := utils.RaiseContrast(*color, background, minRatio)
+	utils.LogINFO(fmt.Sprintf("theme color %s %s has a contrast of %.1f against %s, adjusted to %s", name, *color, ratio, background, adjusted))
+	*color = adjusted
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Unless allowDuplicates is set, copying text already in the history
+moves the entry to the top, or drops the copy without moveDuplicatesToTop.
+dedup.strategy sets which texts count as the same: "exact" bytes only,
+"whitespace" ignoring differences in spacing and line breaks, "case"
+ignoring case too, or "fuzzy" texts at least dedup.similarity alike,
+measured by their edit distance. Fuzzy comparison is quadratic in the
+length of the texts, so texts over fuzzyMaxLength characters are compared
+as with "whitespace". Images are always compared by their content.
+*/
+
+// Dedup strategies.
+const (
+	DedupExact      = "exact"
+	DedupWhitespace = "whitespace"
+	DedupCase       = "case"
+	DedupFuzzy      = "fuzzy"
+)
+
+// Which texts count as duplicates, see dedup.go.
+type Dedup struct {
+	Strategy   string  `json:"strategy"`
+	Similarity float64 `json:"similarity"` // from 0 to 1, for the fuzzy strategy
+}
+
+func validateDedup() {
+	d := &ClipseConfig.Dedup
+	switch d.Strategy {
+	case DedupExact, DedupWhitespace, DedupCase, DedupFuzzy:
+	default:
+		utils.LogWARN(fmt.Sprintf("unknown dedup strategy %q, must be %s, %s, %s or %s. Using %s", d.Strategy, DedupExact, DedupWhitespace, DedupCase, DedupFuzzy, DedupExact))
+		d.Strategy = DedupExact
+	}
+	if d.Similarity <= 0 || d.Similarity > 1 {
+		utils.LogWARN(fmt.Sprintf("dedup similarity must be above 0 and at most 1, got %g. Using %g", d.Similarity, defaultDedupSimilarity))
+		d.Similarity = defaultDedupSimilarity
+	}
+}
+
+// reports whether two texts are duplicates by the dedup strategy
+func sameText(a, b string) bool {
+	if a == b {
+		return true
+	}
+	switch ClipseConfig.Dedup.Strategy {
+	case DedupWhitespace:
+		return normalizeSpace(a) == normalizeSpace(b)
+	case DedupCase:
+		return strings.EqualFold(normalizeSpace(a), normalizeSpace(b))
+	case DedupFuzzy:
+		return fuzzyDuplicate([]rune(a), []rune(b), ClipseConfig.Dedup.Similarity)
+	}
+	return false
+}
+
+// collapses runs of whitespace into single spaces, trimming both ends
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func fuzzyDuplicate(a, b []rune, similarity float64) bool {
+	longest := max(len(a), len(b))
+	if longest > fuzzyMaxLength {
+		return normalizeSpace(string(a)) == normalizeSpace(string(b))
+	}
+	// the most edits texts this alike can be apart
+	allowed := int(float64(longest) * (1 - similarity))
+	return editDistance(a, b, allowed) <= allowed
+}
+
+// the Levenshtein distance of a and b, or limit+1 once it is sure to
+// exceed limit. Only the cells within limit of the diagonal can stay
+// within it, so the others aren't computed.
+func editDistance(a, b []rune, limit int) int {
+	over := limit + 1
+	if abs(len(a)-len(b)) > limit {
+		return over
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = min(j, over)
+	}
+	for i := 1; i <= len(a); i++ {
+		lo, hi := max(1, i-limit), min(len(b), i+limit)
+		curr[0] = min(i, over)
+		if lo > 1 {
+			curr[lo-1] = over
+		}
+		rowMin := curr[0]
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost, over)
+			rowMin = min(rowMin, curr[j])
+		}
+		if hi < len(b) {
+			curr[hi+1] = over
+		}
+		if rowMin >= over {
+			return over
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* The digest sums up a stretch of the history, a week by default: how many
+copies were recorded, the windows most copied from, the biggest entries
+and how much the storage grew, to help tune maxHistory and maxAge and spot
+unusual activity. `clipse digest` prints it, and with weeklyDigest set the
+listener sends it as a desktop notification once a week. The storage size
+is sampled at most daily into digest.json next to the history, along with
+when the last notification was sent, so the growth can be measured over
+the same stretch.
+*/
+
+// DigestReport sums up the history recorded from From to To.
+type DigestReport struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Copies  int           `json:"copies"`      // entries recorded on this device
+	Synced  int           `json:"synced"`      // entries received from other devices
+	Sources []SourceCount `json:"sources"`     // windows most copied from, most first
+	Biggest []DigestEntry `json:"biggest"`     // biggest entries, biggest first
+	Storage int64         `json:"storage"`     // bytes of the history and its images
+	Growth  int64         `json:"growth"`      // of the storage since Since
+	Since   time.Time     `json:"growthSince"` // of the oldest size sample in the stretch, zero if there is none
+}
+
+// SourceCount is how many copies were recorded from a window.
+type SourceCount struct {
+	Source string `json:"source"`
+	Copies int    `json:"copies"`
+}
+
+// DigestEntry is an entry of the digest with its size in bytes.
+type DigestEntry struct {
+	ClipboardItem
+	Size int64 `json:"size"`
+}
+
+type digestState struct {
+	Notified string          `json:"notified,omitempty"` // when the last weekly digest was sent
+	Sizes    []storageSample `json:"sizes,omitempty"`    // oldest first
+}
+
+type storageSample struct {
+	Time string `json:"time"`
+	Size int64  `json:"size"`
+}
+
+func digestPath() string {
+	return filepath.Join(filepath.Dir(ClipseConfig.HistoryFilePath), digestFile)
+}
+
+func readDigestState() digestState {
+	var state digestState
+	content, err := os.ReadFile(digestPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWARN(fmt.Sprintf("failed to read %s: %s", digestPath(), err))
+		}
+		return state
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to parse %s: %s", digestPath(), err))
+	}
+	return state
+}
+
+func writeDigestState(state digestState) error {
+	content, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(digestPath(), content, 0644)
+}
+
+// the bytes taken by the history, its shards and the images in the temp
+// dir
+func storageSize() int64 {
+	size := shardsSize()
+	if info, err := os.Stat(HistoryPath()); err == nil {
+		size += info.Size()
+	}
+	entries, err := os.ReadDir(ClipseConfig.TempDirPath)
+	if err != nil {
+		return size
+	}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// SampleStorageSize records the storage size unless it was sampled less
+// than a day ago, dropping the samples older than digestKeepSamples.
+func SampleStorageSize(now time.Time) error {
+	state := readDigestState()
+	if n := len(state.Sizes); n > 0 {
+		if last, err := utils.ParseTimeStamp(state.Sizes[n-1].Time); err == nil && now.Sub(last) < 24*time.Hour {
+			return nil
+		}
+	}
+	state.Sizes = append(state.Sizes, storageSample{Time: utils.FormatTime(now), Size: storageSize()})
+
+	cutoff := now.Add(-digestKeepSamples)
+	kept := state.Sizes[:0]
+	for _, s := range state.Sizes {
+		if t, err := utils.ParseTimeStamp(s.Time); err == nil && !t.Before(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	state.Sizes = kept
+	return writeDigestState(state)
+}
+
+// DigestDue reports whether a week has passed since the last weekly digest.
+// The first check only starts the week, so the first digest sums up a
+// whole one.
+func DigestDue(now time.Time) (bool, error) {
+	state := readDigestState()
+	if state.Notified == "" {
+		state.Notified = utils.FormatTime(now)
+		return false, writeDigestState(state)
+	}
+	notified, err := utils.ParseTimeStamp(state.Notified)
+	if err != nil {
+		return true, nil
+	}
+	return now.Sub(notified) >= DigestPeriod, nil
+}
+
+// DigestSent remembers the weekly digest was sent at now.
+func DigestSent(now time.Time) error {
+	state := readDigestState()
+	state.Notified = utils.FormatTime(now)
+	return writeDigestState(state)
+}
+
+// BuildDigest sums up the entries recorded from from to to. Entries since
+// removed from the history are counted too while the journal still has
+// them.
+func BuildDigest(from, to time.Time) DigestReport {
+	report := DigestReport{From: from, To: to, Storage: storageSize(), Sources: []SourceCount{}, Biggest: []DigestEntry{}}
+
+	recorded := map[string]ClipboardItem{}
+	entries, err := func() ([]journalEntry, error) {
+		unlock, err := lockHistory(false)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		return readJournal()
+	}()
+	if err != nil {
+		utils.LogWARN(fmt.Sprintf("digest without the journal: %s", err))
+	} else {
+		for _, e := range entries {
+			if e.Before == nil && e.After != nil {
+				recorded[e.After.Recorded] = *e.After
+			}
+		}
+	}
+	if shardsEnabled() {
+		archived, err := ArchivedItems(from, to)
+		if err != nil {
+			utils.LogWARN(fmt.Sprintf("digest without the shards: %s", err))
+		}
+		for _, item := range archived {
+			recorded[item.Recorded] = item
+		}
+	}
+	for _, item := range GetHistory() {
+		recorded[item.Recorded] = item
+	}
+
+	sources := map[string]int{}
+	for _, item := range recorded {
+		t, err := utils.ParseTimeStamp(item.Recorded)
+		if err != nil || t.Before(from) || t.After(to) {
+			continue
+		}
+		if item.Device != "" {
+			report.Synced++
+			continue
+		}
+		report.Copies++
+		if item.Source != "" {
+			sources[item.Source]++
+		}
+		report.Biggest = append(report.Biggest, DigestEntry{ClipboardItem: item, Size: itemSize(item)})
+	}
+
+	for source, n := range sources {
+		report.Sources = append(report.Sources, SourceCount{Source: source, Copies: n})
+	}
+	sort.Slice(report.Sources, func(i, j int) bool {
+		a, b := report.Sources[i], report.Sources[j]
+		return a.Copies > b.Copies || a.Copies == b.Copies && a.Source < b.Source
+	})
+	report.Sources = report.Sources[:min(len(report.Sources), digestTopN)]
+
+	sort.Slice(report.Biggest, func(i, j int) bool {
+		return report.Biggest[i].Size > report.Biggest[j].Size
+	})
+	report.Biggest = report.Biggest[:min(len(report.Biggest), digestTopN)]
+
+	for _, s := range readDigestState().Sizes {
+		// a sample from the last day shows no growth worth reporting
+		if t, err := utils.ParseTimeStamp(s.Time); err == nil && !t.Before(from) && t.Before(to.Add(-24*time.Hour)) {
+			report.Since, report.Growth = t, report.Storage-s.Size
+			break
+		}
+	}
+	return report
+}
+
+// bytes of the text, or of the image file
+func itemSize(item ClipboardItem) int64 {
+	if item.FilePath == "null" || item.FilePath == "" {
+		return int64(len(item.Value))
+	}
+	if info, err := os.Stat(item.FilePath); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// Summary is the digest in a few short lines, for notifications.
+func (r DigestReport) Summary() string {
+	lines := []string{utils.FormatCount(r.Copies) + " " + pluralize(r.Copies, "copy", "copies") + " recorded"}
+	if len(r.Sources) > 0 {
+		top := make([]string, len(r.Sources))
+		for i, s := range r.Sources {
+			top[i] = fmt.Sprintf("%s (%s)", s.Source, utils.FormatCount(s.Copies))
+		}
+		lines[0] += ", mostly from " + strings.Join(top, ", ")
+	}
+	if len(r.Biggest) > 0 {
+		lines = append(lines, "Biggest entry: "+utils.FormatSize(r.Biggest[0].Size))
+	}
+	storage := "Storage: " + utils.FormatSize(r.Storage)
+	if !r.Since.IsZero() {
+		storage += fmt.Sprintf(", %s since %s", FormatGrowth(r.Growth), utils.FormatDate(r.Since))
+	}
+	return strings.Join(append(lines, storage), "\n")
+}
+
+// FormatGrowth formats a change of size in bytes, eg +1.2 MB.
+func FormatGrowth(n int64) string {
+	if n < 0 {
+		return "-" + utils.FormatSize(-n)
+	}
+	return "+" + utils.FormatSize(n)
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
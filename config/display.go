@@ -0,0 +1,45 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/savedra1/clipse/shell"
+)
+
+/* Without a display server, eg on a headless host reached over SSH or in a
+container, there is no clipboard to read or write. clipse still opens the
+history to browse, search and export it, copies text through the terminal
+with OSC 52, and the listener serves its control socket and sync without
+polling a clipboard that isn't there.
+*/
+
+var ErrNoDisplay = errors.New("no display server, $DISPLAY and $WAYLAND_DISPLAY are unset")
+
+// NoDisplay reports whether clipse runs on Linux without a display server.
+func NoDisplay() bool {
+	return DisplayServer() == "x11" && os.Getenv("DISPLAY") == ""
+}
+
+// ReadClipboard returns the text on the clipboard, failing after
+// ClipboardTimeout if the clipboard tool hangs.
+func ReadClipboard() (string, error) {
+	if NoDisplay() {
+		return "", ErrNoDisplay
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ClipboardTimeout)
+	defer cancel()
+	return shell.ReadClipboard(ctx)
+}
+
+// CopyImage puts the image file on the clipboard, which OSC 52 can't do
+// without a display server.
+func CopyImage(path string) error {
+	if NoDisplay() {
+		return ErrNoDisplay
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ClipboardTimeout)
+	defer cancel()
+	return shell.CopyImage(ctx, path, DisplayServer())
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Optional encryption of the history file at rest. Files are stored as:
+	magic | salt | nonce | AES-256-GCM ciphertext
+The key is derived from a passphrase, entered when clipse starts, or from
+the contents of a key file. The secret is only ever kept in memory.
+*/
+
+var (
+	encMagic = []byte("CLIPSE-ENC1\n")
+
+	ErrHistoryLocked  = utils.NewExitError(utils.ExitPermission, "history is encrypted and has not been unlocked")
+	ErrWrongKey       = utils.NewExitError(utils.ExitPermission, "failed to decrypt history: incorrect passphrase or key file")
+	errNotEncrypted   = errors.New("history file is not encrypted")
+	errCorruptEncFile = errors.New("encrypted history file is truncated")
+)
+
+// Derived keys are cached per salt so the KDF only runs once per process.
+var historyKey struct {
+	sync.Mutex
+	secret  []byte
+	salt    []byte
+	derived map[string][]byte
+}
+
+// Returns true if the history should be encrypted on write.
+func EncryptionEnabled() bool {
+	return ClipseConfig.Encryption.Enabled
+}
+
+// Returns true if a passphrase is required to read or write the history:
+// no key file is configured and the history is, or is to be, encrypted.
+// A history left encrypted after disabling encryption still needs the
+// passphrase once, it is then written back as plaintext.
+func NeedsPassphrase() bool {
+	if ClipseConfig.Encryption.KeyFile != "" || HistoryUnlocked() {
+		return false
+	}
+	return EncryptionEnabled() || HistoryEncrypted()
+}
+
+// Returns true if the history is unlocked with a passphrase rather than a
+// key file, so a new process can't read it without being handed it.
+func PassphraseProtected() bool {
+	return ClipseConfig.Encryption.KeyFile == "" && (EncryptionEnabled() || HistoryEncrypted())
+}
+
+// Returns true if a passphrase or key file has been successfully loaded.
+func HistoryUnlocked() bool {
+	historyKey.Lock()
+	defer historyKey.Unlock()
+	return historyKey.secret != nil
+}
+
+// Returns true if the history file on disk is encrypted.
+func HistoryEncrypted() bool {
+	content, err := os.ReadFile(ClipseConfig.HistoryFilePath)
+	return err == nil && isEncrypted(content)
+}
+
+// Sets the passphrase used for the history encryption key and checks it
+// against the history file. A plaintext history, snippets file and journal
+// are encrypted straight away. A nil passphrase uses the configured key file.
+func Unlock(passphrase []byte) error {
+	setSecret(passphrase)
+
+	unlock, err := lockHistory(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	content, err := os.ReadFile(ClipseConfig.HistoryFilePath)
+	if err != nil {
+		return err
+	}
+	if !isEncrypted(content) {
+		data, err := readHistoryFile(ClipseConfig.HistoryFilePath)
+		if err != nil {
+			return err
+		}
+		if err := checkSchema(data.Schema); err != nil {
+			return err
+		}
+		if err := writeHistory(data); err != nil {
+			return err
+		}
+		if err := encryptSnippets(); err != nil {
+			return err
+		}
+		if err := encryptJournal(); err != nil {
+			return err
+		}
+		return encryptShards()
+	}
+	if _, err := decryptHistory(content); err != nil {
+		setSecret(nil)
+		return err
+	}
+	return nil
+}
+
+func setSecret(secret []byte) {
+	historyKey.Lock()
+	defer historyKey.Unlock()
+	historyKey.secret = secret
+	historyKey.derived = nil
+}
+
+// returns the configured secret, reading the key file if one is set
+func encryptionSecret() ([]byte, error) {
+	historyKey.Lock()
+	secret := historyKey.secret
+	historyKey.Unlock()
+	if secret != nil {
+		return secret, nil
+	}
+
+	if ClipseConfig.Encryption.KeyFile == "" {
+		return nil, ErrHistoryLocked
+	}
+	secret, err := os.ReadFile(ClipseConfig.Encryption.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	secret = bytes.TrimSpace(secret)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("key file %s is empty", ClipseConfig.Encryption.KeyFile)
+	}
+	setSecret(secret)
+	return secret, nil
+}
+
+func deriveKey(salt []byte) ([]byte, error) {
+	secret, err := encryptionSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	historyKey.Lock()
+	defer historyKey.Unlock()
+	if key, ok := historyKey.derived[string(salt)]; ok {
+		return key, nil
+	}
+	key := pbkdf2.Key(secret, salt, kdfIterations, encKeyLen, sha256.New)
+	if historyKey.derived == nil {
+		historyKey.derived = make(map[string][]byte)
+	}
+	historyKey.derived[string(salt)] = key
+	return key, nil
+}
+
+// Returns true if content was written by encryptHistory.
+func isEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, encMagic)
+}
+
+func encryptHistory(plaintext []byte) ([]byte, error) {
+	// the salt is generated once per process, a fresh nonce is used per write
+	historyKey.Lock()
+	if historyKey.salt == nil {
+		historyKey.salt = make([]byte, encSaltLen)
+		if _, err := rand.Read(historyKey.salt); err != nil {
+			historyKey.Unlock()
+			return nil, err
+		}
+	}
+	salt := historyKey.salt
+	historyKey.Unlock()
+
+	gcm, err := historyCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{}, encMagic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, encMagic), nil
+}
+
+func decryptHistory(content []byte) ([]byte, error) {
+	if !isEncrypted(content) {
+		return nil, errNotEncrypted
+	}
+	content = content[len(encMagic):]
+	if len(content) < encSaltLen {
+		return nil, errCorruptEncFile
+	}
+	salt, content := content[:encSaltLen], content[encSaltLen:]
+
+	gcm, err := historyCipher(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < gcm.NonceSize() {
+		return nil, errCorruptEncFile
+	}
+	nonce, ciphertext := content[:gcm.NonceSize()], content[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, encMagic)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plaintext, nil
+}
+
+func historyCipher(salt []byte) (cipher.AEAD, error) {
+	key, err := deriveKey(salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
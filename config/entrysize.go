@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Copied text shorter than entrySize.minLength characters, eg a single
+character copied by accident, is not stored. Text over entrySize.maxSize
+bytes is cut down to its start with the "truncate" action, or not stored
+with "skip", so huge copies don't bloat the history and slow every save.
+Images are not limited.
+*/
+
+// Actions for text over the maximum entry size.
+const (
+	EntryTruncate = "truncate"
+	EntrySkip     = "skip"
+)
+
+func validateEntrySize() {
+	e := &ClipseConfig.EntrySize
+	if e.MinLength < 0 {
+		utils.LogWARN(fmt.Sprintf("entrySize minLength must be positive or 0 to store any length, got %d. Storing any length", e.MinLength))
+		e.MinLength = 0
+	}
+	if e.MaxSize < 0 {
+		utils.LogWARN(fmt.Sprintf("entrySize maxSize must be positive or 0 to store any size, got %d. Storing any size", e.MaxSize))
+		e.MaxSize = 0
+	}
+	if e.Action != EntryTruncate && e.Action != EntrySkip {
+		utils.LogWARN(fmt.Sprintf("unknown entrySize action %q, must be %s or %s. Using %s", e.Action, EntryTruncate, EntrySkip, EntryTruncate))
+		e.Action = EntryTruncate
+	}
+}
+
+// LimitEntrySize applies the entrySize limits to copied text, returning
+// the text to store and false if it must not be stored, with the reason.
+func LimitEntrySize(value string) (string, bool, string) {
+	e := ClipseConfig.EntrySize
+	if n := utf8.RuneCountInString(strings.TrimSpace(value)); n < e.MinLength {
+		return value, false, fmt.Sprintf("%d characters, shorter than entrySize.minLength", n)
+	}
+	if e.MaxSize == 0 || len(value) <= e.MaxSize {
+		return value, true, ""
+	}
+	if e.Action == EntrySkip {
+		return value, false, fmt.Sprintf("%s, larger than entrySize.maxSize", utils.FormatSize(int64(len(value))))
+	}
+
+	// cut at a character boundary
+	end := e.MaxSize
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end], true, ""
+}
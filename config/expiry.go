@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Entries older than maxAge, eg "72h" or "30d", are removed from the
+history whenever it is saved, along with those beyond maxHistory. Pinned
+entries never expire. `clipse prune` removes them straight away, eg after
+lowering maxAge, as the listener only prunes once something is copied.
+Sharded entries expire too, see shards.go.
+*/
+
+// parsed from ClipseConfig.MaxAge, 0 keeps entries however old
+var maxAge time.Duration
+
+var ErrNoMaxAge = errors.New("no maxAge is set")
+
+func validateMaxAge() {
+	maxAge = 0
+	if ClipseConfig.MaxAge == "" {
+		return
+	}
+	d, err := ParseMaxAge(ClipseConfig.MaxAge)
+	if err != nil {
+		utils.LogWARN(fmt.Sprintf("%s, keeping entries however old", err))
+		return
+	}
+	maxAge = d
+}
+
+// ParseMaxAge reads a maxAge duration, eg 72h or 30d.
+func ParseMaxAge(s string) (time.Duration, error) {
+	d, err := utils.ParseDuration(s)
+	if err == nil && d <= 0 {
+		err = errors.New("must be positive")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid max age %q, must be a duration such as 72h or 30d: %w", s, err)
+	}
+	return d, nil
+}
+
+// removes the unpinned entries recorded more than age ago
+func dropExpired(history []ClipboardItem, age time.Duration, now time.Time) ([]ClipboardItem, []ClipboardItem) {
+	if age <= 0 {
+		return history, nil
+	}
+	cutoff := now.Add(-age)
+	kept := []ClipboardItem{}
+	expired := []ClipboardItem{}
+	for _, item := range history {
+		recorded, err := utils.ParseTimeStamp(item.Recorded)
+		if item.Pinned || err != nil || !recorded.Before(cutoff) {
+			kept = append(kept, item)
+			continue
+		}
+		expired = append(expired, item)
+	}
+	return kept, expired
+}
+
+// PruneHistory removes the unpinned entries recorded more than age ago,
+// the configured maxAge if age is 0, and returns how many were removed.
+func PruneHistory(age time.Duration) (int, error) {
+	if age <= 0 {
+		age = maxAge
+	}
+	if age <= 0 {
+		return 0, ErrNoMaxAge
+	}
+
+	var expired, remaining []ClipboardItem
+	err := Update(func(tx *Tx) error {
+		now := time.Now()
+		remaining, expired = dropExpired(tx.Items(), age, now)
+		if len(expired) > 0 {
+			tx.SetItems(remaining)
+		}
+		sharded, err := pruneShards(age, now)
+		expired = append(expired, sharded...)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleteImageFiles(unusedImages(expired, remaining))
+	return len(expired), nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for exporting the history and snippets as JSON, CSV
+or markdown. JSON and CSV exports can be read back with clipse import,
+markdown is meant for reading.
+*/
+
+const (
+	ExportJSON     = "json"
+	ExportCSV      = "csv"
+	ExportMarkdown = "markdown"
+)
+
+// columns of a CSV export of the history, value is the only one required
+// to import it
+var csvColumns = []string{
+	"value", "recorded", "filePath", "pinned", "sensitive", "tag", "source",
+	"parent", "transform", "lastUsed", "pastes", "device", "selection", "html",
+}
+
+var snippetColumns = []string{"name", "value", "created"}
+
+// ValidateExportFormat returns an error unless format is json, csv or
+// markdown.
+func ValidateExportFormat(format string) error {
+	switch format {
+	case ExportJSON, ExportCSV, ExportMarkdown:
+		return nil
+	}
+	return utils.NewExitError(utils.ExitUsage, fmt.Sprintf("unknown export format %q, must be one of json, csv, markdown", format))
+}
+
+// ExportItems writes the entries to w in the format.
+func ExportItems(w io.Writer, items []ClipboardItem, format string) error {
+	switch format {
+	case ExportJSON:
+		return writeJSON(w, items)
+	case ExportCSV:
+		rows := [][]string{}
+		for _, item := range items {
+			rows = append(rows, []string{
+				item.Value, item.Recorded, item.FilePath, strconv.FormatBool(item.Pinned),
+				strconv.FormatBool(item.Sensitive), item.Tag, item.Source, item.Parent,
+				item.Transform, item.LastUsed, strconv.Itoa(item.Pastes), item.Device,
+				item.Selection, item.HTML,
+			})
+		}
+		return writeCSV(w, csvColumns, rows)
+	case ExportMarkdown:
+		fmt.Fprintf(w, "# Clipboard history\n\nExported %s, %s entries.\n", utils.FormatDateTime(time.Now()), utils.FormatCount(len(items)))
+		for n, item := range items {
+			fmt.Fprintf(w, "\n## %d. %s\n\n", n+1, markdownItemInfo(item))
+			if item.FilePath != "null" {
+				fmt.Fprintf(w, "![%s](<%s>)\n", item.Value, item.FilePath)
+				continue
+			}
+			writeCodeBlock(w, item.Value)
+		}
+		return nil
+	}
+	return ValidateExportFormat(format)
+}
+
+// ExportSnippets writes the snippets to w in the format.
+func ExportSnippets(w io.Writer, snippets []Snippet, format string) error {
+	switch format {
+	case ExportJSON:
+		return writeJSON(w, Snippets{Snippets: snippets})
+	case ExportCSV:
+		rows := [][]string{}
+		for _, s := range snippets {
+			rows = append(rows, []string{s.Name, s.Value, s.Created})
+		}
+		return writeCSV(w, snippetColumns, rows)
+	case ExportMarkdown:
+		fmt.Fprintf(w, "# Snippets\n\nExported %s, %s snippets.\n", utils.FormatDateTime(time.Now()), utils.FormatCount(len(snippets)))
+		for _, s := range snippets {
+			fmt.Fprintf(w, "\n## %s\n\n", s.Name)
+			writeCodeBlock(w, s.Value)
+		}
+		return nil
+	}
+	return ValidateExportFormat(format)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// the heading of an entry, eg "02/01/2006 15:04 · pinned · tag build-log"
+func markdownItemInfo(item ClipboardItem) string {
+	info := []string{item.Recorded}
+	if t, err := utils.ParseTimeStamp(item.Recorded); err == nil {
+		info[0] = utils.FormatDateTime(t)
+	}
+	if item.Pinned {
+		info = append(info, "pinned")
+	}
+	if item.Sensitive {
+		info = append(info, "sensitive")
+	}
+	if item.Tag != "" {
+		info = append(info, "tag "+item.Tag)
+	}
+	if item.Source != "" {
+		info = append(info, "from "+item.Source)
+	}
+	return strings.Join(info, " · ")
+}
+
+// fences text with more backticks than it contains in a row
+func writeCodeBlock(w io.Writer, text string) {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	fmt.Fprintf(w, "%s\n%s\n%s\n", fence, strings.TrimSuffix(text, "\n"), fence)
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,142 +17,343 @@ import (
 */
 
 type ClipboardItem struct {
-	Value    string `json:"value"`
-	Recorded string `json:"recorded"`
-	FilePath string `json:"filePath"`
-	Pinned   bool   `json:"pinned"`
+	Value     string `json:"value"`
+	Recorded  string `json:"recorded"`
+	FilePath  string `json:"filePath"`
+	Pinned    bool   `json:"pinned"`
+	Sensitive bool   `json:"sensitive,omitempty"` // hidden in the TUI until revealed
+	Parent    string `json:"parent,omitempty"`    // recorded timestamp of the entry this was derived from
+	Transform string `json:"transform,omitempty"` // name of the transform applied to the parent
+	Tag       string `json:"tag,omitempty"`       // label given to piped command output, eg build-log
+	LastUsed  string `json:"lastUsed,omitempty"`  // when the entry was last copied out of the history
+	Pastes    int    `json:"pastes,omitempty"`    // times copied out of the history
+	Device    string `json:"device,omitempty"`    // sync device the entry was copied on, empty for this one
+	Source    string `json:"source,omitempty"`    // class or title of the window the entry was copied from
+	Selection string `json:"selection,omitempty"` // primary for entries recorded from the primary selection, empty for the clipboard
+	HTML      string `json:"html,omitempty"`      // text/html the entry was copied with, Value holding its plain text
+}
+
+// UnmarshalJSON converts timestamps stored in the legacy local time layout
+// to utils.TimeLayout, so old and new entries order and compare correctly.
+func (item *ClipboardItem) UnmarshalJSON(data []byte) error {
+	type plain ClipboardItem
+	if err := json.Unmarshal(data, (*plain)(item)); err != nil {
+		return err
+	}
+	item.Recorded = utils.NormalizeTimeStamp(item.Recorded)
+	item.Parent = utils.NormalizeTimeStamp(item.Parent)
+	item.LastUsed = utils.NormalizeTimeStamp(item.LastUsed)
+	return nil
 }
 
 type ClipboardHistory struct {
+	Schema           int             `json:"schema,omitempty"` // version the file was written with, see schema.go
 	ClipboardHistory []ClipboardItem `json:"clipboardHistory"`
 }
 
-func initHistoryFile() error {
-	/* Used to create the clipboard_history.json file
-	in relative path.
-	*/
-	_, err := os.Stat(ClipseConfig.HistoryFilePath) // File already exist?
-	if os.IsNotExist(err) {
-		baseConfig := ClipboardHistory{
-			ClipboardHistory: []ClipboardItem{},
-		}
+// Entries are addressed by their recorded timestamp, which is unique within
+// the history, so changing one never touches another with the same value.
+var (
+	ErrItemNotFound = utils.NewExitError(utils.ExitNotFound, "item not found in history")
+	ErrHistoryEmpty = utils.NewExitError(utils.ExitEmpty, "history is empty")
+	ErrHistoryBusy  = errors.New("history is locked by another process")
+)
 
-		jsonData, err := json.MarshalIndent(baseConfig, "", "    ")
-		if err != nil {
-			return err
-		}
-		if err = os.WriteFile(ClipseConfig.HistoryFilePath, jsonData, 0644); err != nil {
-			utils.LogERROR(fmt.Sprintf("Failed to create %s", ClipseConfig.HistoryFilePath))
-			return err
-		}
-		return nil
+// ItemPatch is a partial update of an entry, nil fields are left unchanged.
+type ItemPatch struct {
+	Value    *string
+	Pinned   *bool
+	LastUsed *string
+	Pastes   *int
+}
+
+// Apply returns the item with the patched fields updated.
+func (p ItemPatch) Apply(item ClipboardItem) ClipboardItem {
+	if p.Value != nil {
+		item.Value = *p.Value
+	}
+	if p.Pinned != nil {
+		item.Pinned = *p.Pinned
+	}
+	if p.LastUsed != nil {
+		item.LastUsed = *p.LastUsed
 	}
+	if p.Pastes != nil {
+		item.Pastes = *p.Pastes
+	}
+	return item
+}
 
+func initHistoryFile() error {
+	/* Used to create the history file, or the database or
+	log of the other storages, if it does not exist.
+	*/
+	unlock, err := lockHistory(true)
 	if err != nil {
-		utils.LogERROR("Unable to check if history file exists. Please update binary permissions.")
 		return err
 	}
-	return nil
+	defer unlock()
+
+	return historyStorage().init()
 }
 
 func GetHistory() []ClipboardItem {
 	/* returns the clipboardHistory array from the
 	clipboard_history.json file
 	*/
-	file, err := os.OpenFile(ClipseConfig.HistoryFilePath, os.O_RDWR|os.O_CREATE, 0644)
-	utils.HandleError(err)
+	// writes replace the file whole, so it can still be read unlocked
+	unlock, err := lockHistory(false)
+	if err != nil {
+		utils.LogWARN(fmt.Sprintf("reading the history unlocked: %s", err))
+		unlock = func() {}
+	}
+	defer unlock()
 
-	var data ClipboardHistory
+	return fileContents().ClipboardHistory
+}
 
-	utils.HandleError(json.NewDecoder(file).Decode(&data))
+// Acquires the advisory lock shared by the TUI and listener processes,
+// shared for reads and exclusive for read-modify-write operations. Returns
+// the func used to release it, or ErrHistoryBusy if another process holds
+// it past storeTimeout, eg a hung one. Carries on without the lock if the
+// file can't be locked at all.
+func lockHistory(exclusive bool) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	unlock, err := lockHistoryContext(ctx, exclusive)
+	if errors.Is(err, ErrHistoryBusy) {
+		return nil, err
+	}
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to lock history file: %s", err))
+		return func() {}, nil
+	}
+	return unlock, nil
+}
 
-	return data.ClipboardHistory
+// like lockHistory, but gives up with ErrHistoryBusy once ctx is done
+func lockHistoryContext(ctx context.Context, exclusive bool) (func(), error) {
+	lock, err := utils.LockFileContext(ctx, ClipseConfig.HistoryFilePath+lockFileExt, exclusive)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHistoryBusy, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			utils.LogWARN(fmt.Sprintf("failed to unlock history file: %s", err))
+		}
+	}, nil
 }
 
 func fileContents() ClipboardHistory {
-	file, err := os.OpenFile(ClipseConfig.HistoryFilePath, os.O_RDWR|os.O_CREATE, 0644)
+	data, err := historyStorage().load()
 	utils.HandleError(err)
+	return data
+}
 
+func readHistoryFile(path string) (ClipboardHistory, error) {
 	var data ClipboardHistory
 
-	utils.HandleError(json.NewDecoder(file).Decode(&data))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return data, err
+	}
+	if isEncrypted(content) {
+		if content, err = decryptHistory(content); err != nil {
+			return data, err
+		}
+	}
+	return decodeHistory(content)
+}
 
-	return data
+// path of the last known good copy of the history file
+func backupPath() string {
+	return ClipseConfig.HistoryFilePath + backupFileExt
 }
 
 func WriteUpdate(data ClipboardHistory) error {
-	updatedJSON, err := json.Marshal(data)
+	return Update(func(tx *Tx) error {
+		tx.SetItems(data.ClipboardHistory)
+		return nil
+	})
+}
+
+// writes the history file, callers must hold the exclusive history lock
+func writeHistory(data ClipboardHistory) error {
+	updatedJSON, err := encodeHistory(data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return err
 	}
 
-	if err := os.WriteFile(ClipseConfig.HistoryFilePath, updatedJSON, 0644); err != nil {
+	if err := utils.WriteFileAtomic(ClipseConfig.HistoryFilePath, updatedJSON, 0644); err != nil {
 		return fmt.Errorf("failed writing to file: %w", err)
 	}
 
+	// keep a copy of the last good write to recover from on load
+	if err := utils.WriteFileAtomic(backupPath(), updatedJSON, 0644); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to write history backup: %s", err))
+	}
+	rotateBackups(updatedJSON)
+
 	return nil
 }
 
+// the history file content, encrypted when encryption is enabled
+func encodeHistory(data ClipboardHistory) ([]byte, error) {
+	data.Schema = HistorySchema
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if EncryptionEnabled() {
+		if content, err = encryptHistory(content); err != nil {
+			return nil, fmt.Errorf("failed to encrypt history: %w", err)
+		}
+	}
+	return content, nil
+}
+
 func DeleteItems(timeStamps []string) error {
-	data := fileContents()
-	updatedData := []ClipboardItem{}
+	return ApplyChanges(context.Background(), Changes{Delete: timeStamps})
+}
 
-	toDelete := make(map[string]bool)
-	for _, ts := range timeStamps {
-		toDelete[ts] = true
+// Changes is a batch of mutations applied to the history in a single write,
+// entries are addressed by their recorded timestamp.
+type Changes struct {
+	Delete     []string
+	Patch      map[string]ItemPatch
+	Restore    []ClipboardItem // deleted entries to put back in place
+	KeepImages bool            // leave the image files of deleted entries so they can be restored
+}
+
+// ApplyChanges writes the changes in one go. Timestamps no longer in the
+// history are skipped, as are restored entries that are still there. It
+// gives up without changes if ctx is cancelled before the history is
+// written. Image files are only removed once the updated history has been
+// saved, and only if no remaining entry uses them.
+func ApplyChanges(ctx context.Context, c Changes) error {
+	var deleted, remaining []ClipboardItem
+	err := UpdateContext(ctx, func(tx *Tx) error {
+		deleted = tx.Delete(c.Delete...)
+		for ts, patch := range c.Patch {
+			tx.Patch(ts, patch)
+		}
+		for _, item := range c.Restore {
+			tx.Restore(item)
+		}
+		remaining = tx.Items()
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	for _, item := range data.ClipboardHistory {
-		if toDelete[item.Recorded] {
-			if item.FilePath == "null" {
-				continue
-			}
-			if err := shell.DeleteImage(item.FilePath); err != nil {
-				utils.LogERROR(fmt.Sprintf("failed to delete image file | %s", item.FilePath))
-			}
-			continue
+
+	// reads the images of the deleted entries for the hooks before they go
+	RunHook(HookDelete, deleted...)
+	if !c.KeepImages {
+		deleteImageFiles(unusedImages(deleted, remaining))
+	}
+	return nil
+}
+
+// RestoreItem puts a deleted entry back in the history at the position of
+// its recorded timestamp, history being ordered newest first. Entries
+// already in the history are left as they are.
+func RestoreItem(history []ClipboardItem, item ClipboardItem) []ClipboardItem {
+	if itemIndex(history, item.Recorded) >= 0 {
+		return history
+	}
+	index := len(history)
+	for i, entry := range history {
+		if entry.Recorded < item.Recorded {
+			index = i
+			break
+		}
+	}
+	history = append(history, ClipboardItem{})
+	copy(history[index+1:], history[index:])
+	history[index] = item
+	return history
+}
+
+// Deletes the image files that are no longer used by any entry, for image
+// files kept by ApplyChanges.
+func DeleteUnusedImages(paths []string) {
+	inUse := make(map[string]bool)
+	for _, item := range GetHistory() {
+		inUse[item.FilePath] = true
+	}
+	unused := []string{}
+	for _, fp := range paths {
+		if !inUse[fp] {
+			unused = append(unused, fp)
 		}
-		updatedData = append(updatedData, item)
 	}
-	updatedFile := ClipboardHistory{
-		ClipboardHistory: updatedData,
+	deleteImageFiles(unused)
+}
+
+func deleteImageFiles(paths []string) {
+	for _, fp := range paths {
+		if err := shell.DeleteImage(fp); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to delete image file | %s", fp))
+		}
 	}
-	return WriteUpdate(updatedFile)
+}
 
+// returns the image files of the deleted entries not used by any remaining
+// entry, duplicates can share a file when allowDuplicates is set
+func unusedImages(deleted, remaining []ClipboardItem) []string {
+	inUse := make(map[string]bool)
+	for _, item := range remaining {
+		inUse[item.FilePath] = true
+	}
+	images := []string{}
+	for _, item := range deleted {
+		if item.FilePath != "null" && !inUse[item.FilePath] {
+			inUse[item.FilePath] = true // only delete each file once
+			images = append(images, item.FilePath)
+		}
+	}
+	return images
 }
 
+// ClearHistory removes the entries of clearType from the history and its
+// shards. The shards and image files are only cleared once the history
+// has been saved, so a failed save loses nothing.
 func ClearHistory(clearType string) error {
-	var data ClipboardHistory
-	switch clearType {
-	case "all":
-		data = ClipboardHistory{
-			ClipboardHistory: []ClipboardItem{},
+	err := Update(func(tx *Tx) error {
+		history := tx.Items()
+		switch clearType {
+		case "all":
+			tx.SetItems([]ClipboardItem{})
+		case "images":
+			tx.SetItems(textItems(history))
+		case "text":
+			tx.SetItems(imageItems(history))
+		default:
+			tx.SetItems(pinnedItems(history))
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := clearShards(clearType); err != nil {
+		return fmt.Errorf("failed to clear the shards: %w", err)
+	}
+	if clearType == "all" || clearType == "images" {
 		if err := shell.DeleteAllImages(ClipseConfig.TempDirPath); err != nil {
 			utils.LogERROR(fmt.Sprintf("could not delete all images: %s", err))
 		}
-	case "images":
-		data = ClipboardHistory{
-			ClipboardHistory: TextItems(),
-		}
-		if err := shell.DeleteAllImages(ClipseConfig.TempDirPath); err != nil {
-			utils.LogERROR(fmt.Sprintf("could not read file dir: %s", err))
-		}
-	case "text":
-		data = ClipboardHistory{
-			ClipboardHistory: imageItems(),
-		}
-	default:
-		data = ClipboardHistory{
-			ClipboardHistory: pinnedItems(),
-		}
 	}
-	return WriteUpdate(data)
-
+	return nil
 }
 
-func pinnedItems() []ClipboardItem {
+func pinnedItems(history []ClipboardItem) []ClipboardItem {
 	pinnedItems := []ClipboardItem{}
-	history := GetHistory()
 	for _, item := range history {
 		if item.Pinned {
 			pinnedItems = append(pinnedItems, item)
@@ -159,9 +362,8 @@ func pinnedItems() []ClipboardItem {
 	return pinnedItems
 }
 
-func imageItems() []ClipboardItem {
+func imageItems(history []ClipboardItem) []ClipboardItem {
 	images := []ClipboardItem{}
-	history := GetHistory()
 	for _, item := range history {
 		if item.FilePath != "null" {
 			images = append(images, item)
@@ -171,8 +373,11 @@ func imageItems() []ClipboardItem {
 }
 
 func TextItems() []ClipboardItem {
+	return textItems(GetHistory())
+}
+
+func textItems(history []ClipboardItem) []ClipboardItem {
 	textItems := []ClipboardItem{}
-	history := GetHistory()
 	for _, item := range history {
 		if item.FilePath == "null" {
 			textItems = append(textItems, item)
@@ -181,34 +386,204 @@ func TextItems() []ClipboardItem {
 	return textItems
 }
 
-func AddClipboardItem(text, fp string) error {
-	data := fileContents()
-	item := ClipboardItem{
+// Patches existing entries, keyed by their recorded timestamp. Entries no
+// longer in the history are skipped.
+func PatchItems(patches map[string]ItemPatch) error {
+	return ApplyChanges(context.Background(), Changes{Patch: patches})
+}
+
+// Returns the nth most recent history item, starting at 1.
+func NthItem(n int) (ClipboardItem, error) {
+	history := GetHistory()
+	if len(history) == 0 {
+		return ClipboardItem{}, ErrHistoryEmpty
+	}
+	if n < 1 || n > len(history) {
+		return ClipboardItem{}, fmt.Errorf("%w: no entry %d, history has %d entries", ErrItemNotFound, n, len(history))
+	}
+	return history[n-1], nil
+}
+
+func AddClipboardItem(ctx context.Context, text, fp, source string) (ClipboardItem, error) {
+	return addItem(ctx, ClipboardItem{
 		Value:    text,
 		Recorded: utils.GetTime(),
 		FilePath: fp,
 		Pinned:   false,
-	}
+		Source:   source,
+	})
+}
+
+// Adds a text entry flagged as sensitive, which the TUI keeps hidden
+// until it is explicitly revealed.
+func AddSensitiveItem(text string) error {
+	_, err := addItem(context.Background(), ClipboardItem{
+		Value:     text,
+		Recorded:  utils.GetTime(),
+		FilePath:  "null",
+		Pinned:    false,
+		Sensitive: true,
+	})
+	return err
+}
+
+// Adds an entry labelled with tag, eg command output stored by clipse pipe,
+// and the application it was copied from if known.
+func AddTaggedItem(ctx context.Context, text, fp, tag, source string, sensitive bool) (ClipboardItem, error) {
+	return addItem(ctx, ClipboardItem{
+		Value:     text,
+		Recorded:  utils.GetTime(),
+		FilePath:  fp,
+		Pinned:    false,
+		Sensitive: sensitive,
+		Tag:       tag,
+		Source:    source,
+	})
+}
+
+// Adds text copied with formatting along with its text/html, see
+// pasteformats.go. Without html it is added as AddTaggedItem does.
+func AddFormattedItem(ctx context.Context, text, html, source string) (ClipboardItem, error) {
+	return addItem(ctx, ClipboardItem{
+		Value:    text,
+		Recorded: utils.GetTime(),
+		FilePath: "null",
+		Pinned:   false,
+		Source:   source,
+		HTML:     html,
+	})
+}
 
-	if !ClipseConfig.AllowDuplicates {
-		duplicates, isPinned := duplicateItems(data.ClipboardHistory, item)
-		data.ClipboardHistory = removeDuplicates(data.ClipboardHistory, duplicates)
-		item.Pinned = isPinned
+// Adds text recorded from the primary selection, see primary.go.
+func AddPrimaryItem(ctx context.Context, text, source string, sensitive bool) (ClipboardItem, error) {
+	return addItem(ctx, ClipboardItem{
+		Value:     text,
+		Recorded:  utils.GetTime(),
+		FilePath:  "null",
+		Pinned:    false,
+		Sensitive: sensitive,
+		Source:    source,
+		Selection: SelectionPrimary,
+	})
+}
+
+// Adds an entry produced by applying transform to parent, recording the
+// parent so the entry can be traced back and re-derived.
+func AddDerivedItem(text string, parent ClipboardItem, transform string) error {
+	_, err := addItem(context.Background(), ClipboardItem{
+		Value:     text,
+		Recorded:  utils.GetTime(),
+		FilePath:  "null",
+		Pinned:    false,
+		Parent:    parent.Recorded,
+		Transform: transform,
+	})
+	return err
+}
+
+// Returns the entry recorded at timeStamp followed by each of its
+// ancestors, stopping when a parent is no longer in the history.
+func ProvenanceChain(timeStamp string) []ClipboardItem {
+	history := GetHistory()
+	byTimeStamp := make(map[string]ClipboardItem)
+	for _, item := range history {
+		byTimeStamp[item.Recorded] = item
 	}
 
-	// Append the new item to the beginning of the array to appear at top of list
-	data.ClipboardHistory = append([]ClipboardItem{item}, data.ClipboardHistory...)
+	chain := []ClipboardItem{}
+	seen := make(map[string]bool)
+	for ts := timeStamp; ts != "" && !seen[ts]; {
+		item, ok := byTimeStamp[ts]
+		if !ok {
+			break
+		}
+		seen[ts] = true
+		chain = append(chain, item)
+		ts = item.Parent
+	}
+	return chain
+}
 
-	if len(data.ClipboardHistory) > ClipseConfig.MaxHistory {
-		for i := len(data.ClipboardHistory) - 1; i >= 0; i-- {
-			// remove the first unpinned entry starting with the oldest
-			if !data.ClipboardHistory[i].Pinned {
-				data.ClipboardHistory = append(data.ClipboardHistory[:i], data.ClipboardHistory[i+1:]...)
-				break
+// adds the item, returning it as it was added, or a zero item if it was
+// dropped as a duplicate
+func addItem(ctx context.Context, item ClipboardItem) (ClipboardItem, error) {
+	var kept, dropped []ClipboardItem
+	err := UpdateContext(ctx, func(tx *Tx) error {
+		history := tx.Items()
+		fp := item.FilePath
+
+		if !ClipseConfig.AllowDuplicates {
+			duplicates, isPinned := duplicateItems(history, item)
+			if len(duplicates) > 0 && !ClipseConfig.MoveDuplicatesToTop {
+				// keep the existing entry where it is and drop the new copy
+				if fp != "null" {
+					if err := shell.DeleteImage(fp); err != nil {
+						utils.LogERROR(fmt.Sprintf("failed to delete duplicate image | %s | %s", fp, err))
+					}
+				}
+				item = ClipboardItem{}
+				return nil
 			}
+			// move the existing entry to the top with an updated timestamp
+			item = carryOverProvenance(history, duplicates, item)
+			item = carryOverUsage(history, duplicates, item)
+			history = removeDuplicates(history, duplicates)
+			item.Pinned = isPinned
+		}
+
+		// Append the new item to the beginning of the array to appear at top of list
+		history = append([]ClipboardItem{item}, history...)
+
+		kept, dropped = trimHistory(history)
+		tx.SetItems(kept)
+		return nil
+	})
+	if err != nil {
+		return ClipboardItem{}, err
+	}
+	deleteImageFiles(unusedImages(dropped, kept))
+	return item, nil
+}
+
+// Points entries derived from the duplicates at the new item, and keeps the
+// duplicate's own provenance if the new item has none.
+func carryOverProvenance(history []ClipboardItem, duplicates []string, item ClipboardItem) ClipboardItem {
+	isDuplicate := make(map[string]bool)
+	for _, ts := range duplicates {
+		isDuplicate[ts] = true
+	}
+	if isDuplicate[item.Parent] {
+		// the transform was a no-op, this is just the parent moving to the top
+		item.Parent, item.Transform = "", ""
+	}
+	for i, entry := range history {
+		if isDuplicate[entry.Recorded] && item.Parent == "" && entry.Parent != "" {
+			item.Parent, item.Transform = entry.Parent, entry.Transform
+		}
+		if isDuplicate[entry.Parent] {
+			history[i].Parent = item.Recorded
 		}
 	}
-	return WriteUpdate(data)
+	return item
+}
+
+// Keeps the paste count and last use of the duplicates, copying an entry
+// out of the TUI records it again as a duplicate.
+func carryOverUsage(history []ClipboardItem, duplicates []string, item ClipboardItem) ClipboardItem {
+	isDuplicate := make(map[string]bool)
+	for _, ts := range duplicates {
+		isDuplicate[ts] = true
+	}
+	for _, entry := range history {
+		if !isDuplicate[entry.Recorded] {
+			continue
+		}
+		item.Pastes += entry.Pastes
+		if entry.LastUsed > item.LastUsed {
+			item.LastUsed = entry.LastUsed
+		}
+	}
+	return item
 }
 
 func duplicateItems(currentHistory []ClipboardItem, newItem ClipboardItem) ([]string, bool) {
@@ -229,7 +604,7 @@ func duplicateItems(currentHistory []ClipboardItem, newItem ClipboardItem) ([]st
 
 func isItemDuplicate(item, newItem ClipboardItem) bool {
 	if item.FilePath == "null" && newItem.FilePath == "null" {
-		return item.Value == newItem.Value
+		return sameText(item.Value, newItem.Value)
 	}
 	if item.FilePath != "null" && newItem.FilePath != "null" {
 		return utils.GetImgIdentifier(item.Value) == utils.GetImgIdentifier(newItem.Value)
@@ -258,22 +633,52 @@ func removeDuplicates(clipboardHistory []ClipboardItem, duplicates []string) []C
 	return updatedHistory
 }
 
-// This pins and unpins an item in the clipboard
+// Records that the entry was copied out of the history, updating its last
+// use and paste count.
+func MarkUsed(timeStamps ...string) error {
+	var copied []ClipboardItem
+	err := Update(func(tx *Tx) error {
+		used := utils.GetTime()
+		for _, ts := range timeStamps {
+			item, ok := tx.Get(ts)
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrItemNotFound, ts)
+			}
+			pastes := item.Pastes + 1
+			tx.Patch(ts, ItemPatch{LastUsed: &used, Pastes: &pastes})
+			copied = append(copied, item)
+		}
+		return nil
+	})
+	if err == nil {
+		RunHook(HookCopy, copied...)
+	}
+	return err
+}
+
+// This pins and unpins an item in the clipboard, returning its previous
+// pinned state
 func TogglePinClipboardItem(timeStamp string) (bool, error) {
-	data := fileContents()
 	var pinned bool
+	err := Update(func(tx *Tx) error {
+		item, ok := tx.Get(timeStamp)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrItemNotFound, timeStamp)
+		}
+		pinned = item.Pinned
+		toggled := !pinned
+		tx.Patch(timeStamp, ItemPatch{Pinned: &toggled})
+		return nil
+	})
+	return pinned, err
+}
 
-	for i, item := range data.ClipboardHistory {
+// returns the index of the entry recorded at timeStamp, or -1
+func itemIndex(history []ClipboardItem, timeStamp string) int {
+	for i, item := range history {
 		if item.Recorded == timeStamp {
-			// Toggle the pinned state
-			data.ClipboardHistory[i].Pinned = !item.Pinned
-			pinned = item.Pinned
-			break
+			return i
 		}
 	}
-
-	if err := WriteUpdate(data); err != nil {
-		return pinned, err
-	}
-	return pinned, nil
+	return -1
 }
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* The jsonl storage keeps the history as an append-only log, one record
+per line. A new or changed entry appends the entry, a deleted one appends
+a tombstone, so a copy costs a few lines however long the history is. The
+history is rebuilt by replaying the log, later records replacing earlier
+ones for the same entry.
+
+Superseded records pile up over time. The log is rewritten with only the
+current entries once it holds logCompactRatio records per entry, or by
+`clipse compact`.
+*/
+
+type logRecord struct {
+	Schema  int            `json:"schema,omitempty"`  // header, the version the log was written with
+	Item    *ClipboardItem `json:"item,omitempty"`    // entry added or changed
+	Deleted string         `json:"deleted,omitempty"` // tombstone, recorded timestamp of a deleted entry
+}
+
+type logStorage struct {
+	file string
+}
+
+// records in the log as of its last load or write, used to decide when to
+// compact it
+var logRecords int
+
+// schema of the log as of its last load or write, a log with an older one
+// is compacted on the next save
+var logSchema int
+
+func (s logStorage) path() string {
+	return s.file
+}
+
+func (s logStorage) init() error {
+	_, err := os.Stat(s.file)
+	if os.IsNotExist(err) {
+		return s.compact([]ClipboardItem{})
+	}
+	return err
+}
+
+func (s logStorage) load() (ClipboardHistory, error) {
+	var data ClipboardHistory
+	f, err := os.Open(s.file)
+	if err != nil {
+		return data, err
+	}
+	defer f.Close()
+
+	entries := make(map[string]ClipboardItem)
+	records := 0
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxJournalLine)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		// the header comes first, the entries after it are migrated from
+		// its schema
+		r, err := decodeLogRecord(line, data.Schema)
+		if err != nil {
+			// eg cut short by a crash mid write, the records after it are intact
+			utils.LogWARN(fmt.Sprintf("skipping corrupt line %d of the history log: %s", lineNo, err))
+			continue
+		}
+		records++
+
+		switch {
+		case r.Item != nil:
+			entries[r.Item.Recorded] = *r.Item
+		case r.Deleted != "":
+			delete(entries, r.Deleted)
+		default:
+			data.Schema = r.Schema
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return data, err
+	}
+
+	data.ClipboardHistory = make([]ClipboardItem, 0, len(entries))
+	for _, item := range entries {
+		data.ClipboardHistory = append(data.ClipboardHistory, item)
+	}
+	sort.Slice(data.ClipboardHistory, func(i, j int) bool {
+		return data.ClipboardHistory[i].Recorded > data.ClipboardHistory[j].Recorded
+	})
+	logRecords = records
+	logSchema = data.Schema
+	return data, nil
+}
+
+func (s logStorage) save(items []ClipboardItem, change Change) error {
+	current := itemsByTimeStamp(items)
+	records := []logRecord{}
+	for _, ts := range change.Deleted {
+		records = append(records, logRecord{Deleted: ts})
+	}
+	for _, ts := range append(change.Added, change.Updated...) {
+		item := current[ts]
+		records = append(records, logRecord{Item: &item})
+	}
+
+	if logSchema < HistorySchema || logRecords+len(records) > logCompactMin && logRecords+len(records) > logCompactRatio*len(items) {
+		return s.compact(items)
+	}
+
+	content, err := encodeLog(records)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.file, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if !endsWithNewline(f) {
+		// keep the records apart from a line left incomplete by a crash
+		content = append([]byte{'\n'}, content...)
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	logRecords += len(records)
+	return nil
+}
+
+func endsWithNewline(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return true
+	}
+	last := make([]byte, 1)
+	_, err = f.ReadAt(last, info.Size()-1)
+	return err != nil || last[0] == '\n'
+}
+
+// rewrites the log with only the current entries, oldest first
+func (s logStorage) compact(items []ClipboardItem) error {
+	records := []logRecord{{Schema: HistorySchema}}
+	for i := len(items) - 1; i >= 0; i-- {
+		records = append(records, logRecord{Item: &items[i]})
+	}
+	content, err := encodeLog(records)
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFileAtomic(s.file, content, 0644); err != nil {
+		return err
+	}
+	logRecords = len(records)
+	logSchema = HistorySchema
+	return nil
+}
+
+// decodes a line of a log written with schema, migrating its entry
+func decodeLogRecord(line []byte, schema int) (logRecord, error) {
+	var r logRecord
+	if schema >= HistorySchema {
+		err := json.Unmarshal(line, &r)
+		return r, err
+	}
+
+	var old struct {
+		logRecord
+		Item json.RawMessage `json:"item,omitempty"`
+	}
+	if err := json.Unmarshal(line, &old); err != nil {
+		return r, err
+	}
+	r = old.logRecord
+	if len(old.Item) > 0 {
+		item, err := migrateEntry(old.Item, schema)
+		if err != nil {
+			return r, err
+		}
+		r.Item = &item
+	}
+	return r, nil
+}
+
+func encodeLog(records []logRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+var ErrNotLogStorage = errors.New("only the jsonl storage needs compacting")
+
+// CompactHistory rewrites the jsonl history log with only the current
+// entries, returning the number of records before and after.
+func CompactHistory() (before, after int, err error) {
+	s, ok := historyStorage().(logStorage)
+	if !ok {
+		return 0, 0, ErrNotLogStorage
+	}
+
+	unlock, err := lockHistory(true)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+
+	data, err := s.load()
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := checkSchema(data.Schema); err != nil {
+		return 0, 0, err
+	}
+	before = logRecords
+	if err := s.compact(data.ClipboardHistory); err != nil {
+		return 0, 0, err
+	}
+	return before, logRecords, nil
+}
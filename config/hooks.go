@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Hooks are user commands run with sh on events of the history: onCapture
+for each entry the listener records, onCopy for entries copied out of the
+history and onDelete for entries deleted from it. The entry is written to
+the command's stdin, image entries as their file, and described by the
+CLIPSE_* variables of hookEnv. The value of sensitive entries is withheld.
+Image files are read before the hook starts, as onDelete runs while the
+file is removed, so CLIPSE_FILE may already be gone. Hooks run in the
+background of the process the event happens in, which waits for them with
+WaitHooks before exiting.
+*/
+
+// Hooks are the commands run on events of the history, empty for none.
+type Hooks struct {
+	OnCapture string `json:"onCapture"`
+	OnCopy    string `json:"onCopy"`
+	OnDelete  string `json:"onDelete"`
+}
+
+const (
+	HookCapture = "capture"
+	HookCopy    = "copy"
+	HookDelete  = "delete"
+)
+
+var runningHooks sync.WaitGroup
+
+func (h Hooks) command(event string) string {
+	switch event {
+	case HookCapture:
+		return h.OnCapture
+	case HookCopy:
+		return h.OnCopy
+	case HookDelete:
+		return h.OnDelete
+	}
+	return ""
+}
+
+// RunHook runs the hook of the event, if any, on each of the items in the
+// background.
+func RunHook(event string, items ...ClipboardItem) {
+	command := strings.TrimSpace(ClipseConfig.Hooks.command(event))
+	if command == "" {
+		return
+	}
+	for _, item := range items {
+		stdin, err := hookInput(item)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("%s hook failed for the entry recorded %s: %s", event, item.Recorded, err))
+			continue
+		}
+		runningHooks.Add(1)
+		go func(item ClipboardItem) {
+			defer runningHooks.Done()
+			if err := runHook(command, event, item, stdin); err != nil {
+				utils.LogERROR(fmt.Sprintf("%s hook failed for the entry recorded %s: %s", event, item.Recorded, err))
+			}
+		}(item)
+	}
+}
+
+// the stdin of the entry's hook, read now so the image file can be
+// removed once RunHook returns
+func hookInput(item ClipboardItem) (io.Reader, error) {
+	switch {
+	case item.Sensitive:
+		return nil, nil
+	case item.FilePath != "null" && item.FilePath != "":
+		content, err := os.ReadFile(item.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(content), nil
+	default:
+		return strings.NewReader(item.Value), nil
+	}
+}
+
+func runHook(command, event string, item ClipboardItem, stdin io.Reader) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	return shell.RunHook(ctx, command, stdin, hookEnv(event, item))
+}
+
+// the variables describing the entry to its hook
+func hookEnv(event string, item ClipboardItem) []string {
+	dataType, file := "text", ""
+	if item.FilePath != "null" && item.FilePath != "" {
+		dataType, file = strings.TrimPrefix(filepath.Ext(item.FilePath), "."), item.FilePath
+	}
+	return []string{
+		"CLIPSE_EVENT=" + event,
+		"CLIPSE_RECORDED=" + item.Recorded,
+		"CLIPSE_TYPE=" + dataType,
+		"CLIPSE_FILE=" + file,
+		"CLIPSE_SOURCE=" + item.Source,
+		"CLIPSE_TAG=" + item.Tag,
+		"CLIPSE_DEVICE=" + item.Device,
+		"CLIPSE_SELECTION=" + item.Selection,
+		"CLIPSE_PINNED=" + strconv.FormatBool(item.Pinned),
+		"CLIPSE_SENSITIVE=" + strconv.FormatBool(item.Sensitive),
+	}
+}
+
+// WaitHooks waits for the hooks still running, each stopped after
+// hookTimeout.
+func WaitHooks() {
+	runningHooks.Wait()
+}
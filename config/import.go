@@ -0,0 +1,396 @@
+package config
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for importing entries back into the history, eg
+from `clipse export`, `clipse -output-all json` or a copy of
+clipboard_history.json. Imports are validated as a whole first, so a file
+with a bad entry adds nothing.
+
+Restoring the same backup more than once would otherwise bloat the
+history with copies of every entry, so imported entries that are soft
+duplicates of an existing one are merged into it instead.
+*/
+
+var ErrInvalidImport = errors.New("invalid import")
+
+const maxImportProblems = 5 // listed in the error of an invalid import
+
+type ImportResult struct {
+	Added   int // new entries
+	Merged  int // entries merged into an existing one
+	Dropped int // unpinned entries removed to stay within maxHistory and maxAge
+}
+
+// ReadExport reads the entries of a JSON export, either a list of entries
+// or a history file, or of a CSV export, and validates them.
+func ReadExport(path string) ([]ClipboardItem, error) {
+	if isCSV(path) {
+		items, problems, err := readCSVItems(path)
+		if err != nil {
+			return nil, err
+		}
+		return items, importProblems(append(problems, validateImport(items)...))
+	}
+	items, err := readJSONItems(path)
+	if err != nil {
+		return nil, err
+	}
+	return items, importProblems(validateImport(items))
+}
+
+func readJSONItems(path string) ([]ClipboardItem, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []ClipboardItem
+	if err := json.Unmarshal(content, &items); err == nil {
+		return items, nil
+	}
+	data, err := decodeHistory(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return data.ClipboardHistory, nil
+}
+
+// ReadSnippetExport reads the snippets of a JSON export, or snippets file,
+// or of a CSV export with name and value columns.
+func ReadSnippetExport(path string) ([]Snippet, error) {
+	var snippets []Snippet
+	if isCSV(path) {
+		rows, err := readCSV(path, snippetColumns, "name", "value")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			snippets = append(snippets, Snippet{Name: row["name"], Value: row["value"], Created: row["created"]})
+		}
+	} else {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var data Snippets
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		snippets = data.Snippets
+	}
+
+	problems := []string{}
+	for n, s := range snippets {
+		if strings.TrimSpace(s.Name) == "" {
+			problems = append(problems, fmt.Sprintf("snippet %d has no name", n+1))
+		}
+	}
+	return snippets, importProblems(problems)
+}
+
+// ImportSnippets saves the snippets in a single write, replacing the
+// values of those with the same name. Returns the number added and
+// replaced.
+func ImportSnippets(snippets []Snippet) (int, int, error) {
+	data, err := readSnippetsFile()
+	if err != nil {
+		return 0, 0, err
+	}
+	added, replaced := 0, 0
+	for _, s := range snippets {
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Created == "" {
+			s.Created = utils.GetTime()
+		}
+		found := false
+		for i := range data.Snippets {
+			if data.Snippets[i].Name == s.Name {
+				data.Snippets[i].Value = s.Value
+				found = true
+			}
+		}
+		if found {
+			replaced++
+			continue
+		}
+		data.Snippets = append(data.Snippets, s)
+		added++
+	}
+	return added, replaced, writeSnippets(data)
+}
+
+// ImportItems merges the items into the history in a single write.
+func ImportItems(items []ClipboardItem) (ImportResult, error) {
+	var result ImportResult
+	var trimmed, dropped []ClipboardItem
+	err := Update(func(tx *Tx) error {
+		var history []ClipboardItem
+		history, result = mergeImported(tx.Items(), items)
+
+		trimmed, dropped = trimHistory(history)
+		result.Dropped = len(dropped)
+		tx.SetItems(trimmed)
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	deleteImageFiles(unusedImages(dropped, trimmed))
+	return result, nil
+}
+
+func isCSV(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
+
+// reads a CSV file with a header row of known columns, including the
+// required ones, into a map per row
+func readCSV(path string, known []string, required ...string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrInvalidImport, path)
+	}
+
+	header := records[0]
+	for _, column := range header {
+		if !slices.Contains(known, column) {
+			return nil, fmt.Errorf("%w: unknown column %q, columns are %s", ErrInvalidImport, column, strings.Join(known, ", "))
+		}
+	}
+	for _, column := range required {
+		if !slices.Contains(header, column) {
+			return nil, fmt.Errorf("%w: missing the %s column", ErrInvalidImport, column)
+		}
+	}
+
+	rows := []map[string]string{}
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// reads a CSV export of the history, see csvColumns, returning the values
+// that could not be read as problems
+func readCSVItems(path string) ([]ClipboardItem, []string, error) {
+	rows, err := readCSV(path, csvColumns, "value")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := []ClipboardItem{}
+	problems := []string{}
+	for n, row := range rows {
+		item := ClipboardItem{
+			Value:     row["value"],
+			Recorded:  utils.NormalizeTimeStamp(row["recorded"]),
+			FilePath:  row["filePath"],
+			Tag:       row["tag"],
+			Source:    row["source"],
+			Parent:    row["parent"],
+			Transform: row["transform"],
+			LastUsed:  row["lastUsed"],
+			Device:    row["device"],
+			Selection: row["selection"],
+			HTML:      row["html"],
+		}
+		for _, flag := range []struct {
+			column string
+			field  *bool
+		}{{"pinned", &item.Pinned}, {"sensitive", &item.Sensitive}} {
+			if v := row[flag.column]; v != "" {
+				b, err := strconv.ParseBool(v)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("entry %d: %s is %q, must be true or false", n+1, flag.column, v))
+				}
+				*flag.field = b
+			}
+		}
+		if v := row["pastes"]; v != "" {
+			pastes, err := strconv.Atoi(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("entry %d: pastes is %q, must be a number", n+1, v))
+			}
+			item.Pastes = pastes
+		}
+		items = append(items, item)
+	}
+	return items, problems, nil
+}
+
+// returns the problems that keep the entries from being added to the
+// history as they are
+func validateImport(items []ClipboardItem) []string {
+	problems := []string{}
+	for n, item := range items {
+		entry := fmt.Sprintf("entry %d", n+1)
+		if _, err := utils.ParseTimeStamp(item.Recorded); item.Recorded != "" && err != nil {
+			problems = append(problems, fmt.Sprintf("%s: recorded time %q is not a timestamp", entry, item.Recorded))
+		}
+		if _, err := utils.ParseTimeStamp(item.LastUsed); item.LastUsed != "" && err != nil {
+			problems = append(problems, fmt.Sprintf("%s: lastUsed time %q is not a timestamp", entry, item.LastUsed))
+		}
+		switch {
+		case item.FilePath == "" || item.FilePath == "null":
+			if item.Value == "" {
+				problems = append(problems, entry+": has no value")
+			}
+		case !fileExists(item.FilePath):
+			problems = append(problems, fmt.Sprintf("%s: image %s does not exist", entry, item.FilePath))
+		}
+		if item.Pastes < 0 {
+			problems = append(problems, entry+": pastes must not be negative")
+		}
+		if item.Selection != "" && item.Selection != SelectionPrimary {
+			problems = append(problems, fmt.Sprintf("%s: selection is %q, must be empty or %s", entry, item.Selection, SelectionPrimary))
+		}
+	}
+	return problems
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// joins the problems found in an import into an ErrInvalidImport, nil if
+// there are none
+func importProblems(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	shown := problems[:min(len(problems), maxImportProblems)]
+	msg := strings.Join(shown, "\n  ")
+	if len(problems) > len(shown) {
+		msg += fmt.Sprintf("\n  and %d more", len(problems)-len(shown))
+	}
+	return fmt.Errorf("%w:\n  %s", ErrInvalidImport, msg)
+}
+
+// Adds the imported items to the history, newest first. An item recorded
+// at the same time as an entry is that entry, eg from a backup of this
+// history, and its paste count is not added twice. Other soft duplicates
+// are merged keeping the earliest recorded time and latest use and summing
+// their paste counts.
+func mergeImported(history, imported []ClipboardItem) ([]ClipboardItem, ImportResult) {
+	var result ImportResult
+	merged := append([]ClipboardItem{}, history...)
+	renamed := make(map[string]string) // recorded time of merged entries to the one they were merged into
+
+	for _, item := range imported {
+		if item.Recorded == "" {
+			item.Recorded = utils.GetTime()
+		}
+		if item.FilePath == "" {
+			item.FilePath = "null"
+		}
+
+		if index := itemIndex(merged, item.Recorded); index >= 0 {
+			merged[index] = mergeSoftDuplicate(merged[index], item, false)
+			result.Merged++
+			continue
+		}
+		if index := softDuplicateIndex(merged, item); index >= 0 {
+			previous := merged[index].Recorded
+			merged[index] = mergeSoftDuplicate(merged[index], item, true)
+			renamed[item.Recorded] = merged[index].Recorded
+			renamed[previous] = merged[index].Recorded
+			result.Merged++
+			continue
+		}
+		merged = append(merged, item)
+		result.Added++
+	}
+
+	for i, item := range merged {
+		if to, ok := renamed[item.Parent]; ok && to != item.Recorded {
+			merged[i].Parent = to
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Recorded > merged[j].Recorded
+	})
+	return merged, result
+}
+
+// entries are soft duplicates if they only differ in their timestamps,
+// paste count and pinned state
+func isSoftDuplicate(a, b ClipboardItem) bool {
+	return isItemDuplicate(a, b) && a.Tag == b.Tag && a.Transform == b.Transform
+}
+
+func softDuplicateIndex(history []ClipboardItem, item ClipboardItem) int {
+	for i, entry := range history {
+		if isSoftDuplicate(entry, item) {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeSoftDuplicate(entry, item ClipboardItem, sumPastes bool) ClipboardItem {
+	if item.Recorded < entry.Recorded {
+		entry.Recorded = item.Recorded
+	}
+	if item.LastUsed > entry.LastUsed {
+		entry.LastUsed = item.LastUsed
+	}
+	if sumPastes {
+		entry.Pastes += item.Pastes
+	} else if item.Pastes > entry.Pastes {
+		entry.Pastes = item.Pastes
+	}
+	if entry.Parent == "" {
+		entry.Parent, entry.Transform = item.Parent, item.Transform
+	}
+	entry.Pinned = entry.Pinned || item.Pinned
+	entry.Sensitive = entry.Sensitive || item.Sensitive
+	return entry
+}
+
+// removes the unpinned entries older than maxAge and the oldest ones
+// beyond maxHistory, returning the entries kept and those removed, whose
+// image files callers delete once the history is saved, see unusedImages
+func trimHistory(history []ClipboardItem) ([]ClipboardItem, []ClipboardItem) {
+	kept, dropped := dropExpired(history, maxAge, time.Now())
+	if shardsEnabled() {
+		return kept, dropped // the oldest are moved into shards instead, see archiveOld
+	}
+	excess := len(kept) - ClipseConfig.MaxHistory
+	for i := len(kept) - 1; i >= 0 && excess > 0; i-- {
+		if !kept[i].Pinned {
+			dropped = append(dropped, kept[i])
+			kept = append(kept[:i:i], kept[i+1:]...)
+			excess--
+		}
+	}
+	return kept, dropped
+}
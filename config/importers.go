@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for importing the history of other clipboard
+managers with `clipse import --from`. CopyQ and greenclip keep their
+history in binary files, so theirs is read from the output of their own
+commands instead:
+
+	copyq eval 'for (var i = 0; i < size(); ++i) print(str(read(i)) + "\0")' > copyq.txt
+	greenclip print > greenclip.txt
+
+Only gpaste's history has timestamps, and those are in seconds, so entries
+are given distinct recorded times that keep their order.
+*/
+
+const (
+	ImportCopyQ     = "copyq"
+	ImportGPaste    = "gpaste"
+	ImportClipman   = "clipman"
+	ImportGreenclip = "greenclip"
+)
+
+// a clipboard entry of another manager, recorded at a zero time if it has
+// none
+type foreignEntry struct {
+	value     string
+	recorded  time.Time
+	sensitive bool
+}
+
+// ValidateImportSource returns an error unless from is one of the clipboard
+// managers that can be imported from.
+func ValidateImportSource(from string) error {
+	switch from {
+	case ImportCopyQ, ImportGPaste, ImportClipman, ImportGreenclip:
+		return nil
+	}
+	return utils.NewExitError(utils.ExitUsage, fmt.Sprintf("unknown clipboard manager %q, must be one of copyq, gpaste, clipman, greenclip", from))
+}
+
+// ReadForeignHistory reads the text entries of another clipboard manager's
+// history from path. Returns the entries and the number of entries skipped
+// as they are not text, eg gpaste's images.
+func ReadForeignHistory(from, path string) ([]ClipboardItem, int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseForeignHistory(from, path, content)
+}
+
+// parses the content of another clipboard manager's history, read from
+// the source named in errors
+func parseForeignHistory(from, source string, content []byte) ([]ClipboardItem, int, error) {
+	var entries []foreignEntry
+	var err error
+	skipped := 0
+	switch from {
+	case ImportCopyQ:
+		entries = readCopyQ(content)
+	case ImportGPaste:
+		entries, skipped, err = readGPaste(content)
+	case ImportClipman:
+		entries, err = readClipman(content)
+	case ImportGreenclip:
+		entries = readGreenclip(content)
+	default:
+		return nil, 0, ValidateImportSource(from)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	if len(entries) == 0 && skipped == 0 {
+		return nil, 0, fmt.Errorf("%w: no %s entries found in %s", ErrInvalidImport, from, source)
+	}
+	return foreignItems(entries, time.Now()), skipped, nil
+}
+
+// the NUL separated entries of the copyq command above, newest first
+func readCopyQ(content []byte) []foreignEntry {
+	var entries []foreignEntry
+	for _, value := range strings.Split(string(content), "\x00") {
+		if strings.TrimSpace(value) != "" {
+			entries = append(entries, foreignEntry{value: value})
+		}
+	}
+	return entries
+}
+
+// the output of greenclip print, an entry per line newest first, with the
+// line breaks of an entry shown as non-breaking spaces
+func readGreenclip(content []byte) []foreignEntry {
+	var entries []foreignEntry
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) != "" {
+			entries = append(entries, foreignEntry{value: strings.ReplaceAll(line, "\u00a0", "\n")})
+		}
+	}
+	return entries
+}
+
+// clipman's history file, a JSON list of strings oldest first
+func readClipman(content []byte) ([]foreignEntry, error) {
+	var values []string
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, err
+	}
+	var entries []foreignEntry
+	for i := len(values) - 1; i >= 0; i-- {
+		if strings.TrimSpace(values[i]) != "" {
+			entries = append(entries, foreignEntry{value: values[i]})
+		}
+	}
+	return entries, nil
+}
+
+// gpaste's history.xml, newest first. The value of an item is in a value
+// element since version 2 of the file and its text before that.
+type gpasteHistory struct {
+	Items []struct {
+		Kind  string  `xml:"kind,attr"`
+		Date  string  `xml:"date,attr"`
+		Value *string `xml:"value"`
+		Text  string  `xml:",chardata"`
+	} `xml:"item"`
+}
+
+func readGPaste(content []byte) ([]foreignEntry, int, error) {
+	var history gpasteHistory
+	if err := xml.Unmarshal(content, &history); err != nil {
+		return nil, 0, err
+	}
+
+	var entries []foreignEntry
+	skipped := 0
+	for _, item := range history.Items {
+		value := item.Text
+		if item.Value != nil {
+			value = *item.Value
+		}
+		if item.Kind != "Text" && item.Kind != "Uris" && item.Kind != "Password" || strings.TrimSpace(value) == "" {
+			skipped++
+			continue
+		}
+		entry := foreignEntry{value: value, sensitive: item.Kind == "Password"}
+		if seconds, err := strconv.ParseInt(item.Date, 10, 64); err == nil {
+			entry.recorded = time.Unix(seconds, 0)
+		} else if t, err := time.Parse(time.RFC3339, item.Date); err == nil {
+			entry.recorded = t
+		}
+		entries = append(entries, entry)
+	}
+	return entries, skipped, nil
+}
+
+// converts the entries, newest first, to history items. Entries without a
+// time are recorded a second before the next newer one, and entries
+// recorded at the same time as or after a newer one just before it, so
+// that none are merged for sharing a recorded time.
+func foreignItems(entries []foreignEntry, now time.Time) []ClipboardItem {
+	items := make([]ClipboardItem, 0, len(entries))
+	newer := now.Add(time.Second)
+	for _, entry := range entries {
+		recorded := entry.recorded
+		switch {
+		case recorded.IsZero():
+			recorded = newer.Add(-time.Second)
+		case !recorded.Before(newer):
+			recorded = newer.Add(-time.Millisecond)
+		}
+		newer = recorded
+		items = append(items, ClipboardItem{
+			Value:     entry.value,
+			Recorded:  utils.FormatTime(recorded),
+			FilePath:  "null",
+			Sensitive: entry.sensitive,
+		})
+	}
+	return items
+}
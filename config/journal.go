@@ -0,0 +1,274 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains the history journal, an append-only log of the changes
+written to the history. Each change is logged per entry with its state
+before and after, so the history at an earlier point in time can be
+rebuilt by undoing the changes made since, see HistoryAt. The journal
+keeps journalDays of changes, older ones are dropped once it grows past
+journalCompactSize.
+*/
+
+var ErrJournalTooShort = errors.New("the history journal does not go back that far")
+
+// A journalEntry with neither Before nor After marks the start of the
+// journal, changes made before it are unknown.
+type journalEntry struct {
+	Time   time.Time      `json:"time"`
+	Before *ClipboardItem `json:"before,omitempty"` // nil when the entry was added
+	After  *ClipboardItem `json:"after,omitempty"`  // nil when the entry was deleted
+}
+
+func journalEnabled() bool {
+	return ClipseConfig.JournalDays > 0
+}
+
+// logs the change made by a transaction, callers must hold the exclusive
+// history lock
+func appendJournal(before, after []ClipboardItem, change Change) error {
+	path := ClipseConfig.JournalFilePath
+	if !journalEnabled() {
+		// changes are no longer logged, a later journal must not rely on it
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	now := time.Now()
+	entries := []journalEntry{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		entries = append(entries, journalEntry{Time: now})
+	}
+
+	previous := itemsByTimeStamp(before)
+	current := itemsByTimeStamp(after)
+	for _, ts := range change.Added {
+		item := current[ts]
+		entries = append(entries, journalEntry{Time: now, After: &item})
+	}
+	for _, ts := range change.Updated {
+		prev, item := previous[ts], current[ts]
+		entries = append(entries, journalEntry{Time: now, Before: &prev, After: &item})
+	}
+	for _, ts := range change.Deleted {
+		prev := previous[ts]
+		entries = append(entries, journalEntry{Time: now, Before: &prev})
+	}
+
+	content, err := encodeJournal(entries)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > journalCompactSize {
+		return compactJournal(now.AddDate(0, 0, -ClipseConfig.JournalDays))
+	}
+	return nil
+}
+
+// drops the changes made before the cutoff
+func compactJournal(cutoff time.Time) error {
+	entries, err := readJournal()
+	if err != nil {
+		return err
+	}
+	kept := []journalEntry{{Time: cutoff}}
+	for _, e := range entries {
+		if e.Time.After(cutoff) && (e.Before != nil || e.After != nil) {
+			kept = append(kept, e)
+		}
+	}
+	content, err := encodeJournal(kept)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(ClipseConfig.JournalFilePath, content, 0644)
+}
+
+// one entry per line, each encrypted separately when encryption is enabled
+func encodeJournal(entries []journalEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		if EncryptionEnabled() {
+			encrypted, err := encryptHistory(line)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt journal: %w", err)
+			}
+			line = []byte(base64.StdEncoding.EncodeToString(encrypted))
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func readJournal() ([]journalEntry, error) {
+	entries := []journalEntry{}
+
+	f, err := os.Open(ClipseConfig.JournalFilePath)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxJournalLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' {
+			encrypted, err := base64.StdEncoding.DecodeString(string(line))
+			if err != nil {
+				return nil, fmt.Errorf("corrupt journal line: %w", err)
+			}
+			if line, err = decryptHistory(encrypted); err != nil {
+				return nil, err
+			}
+		}
+		var e journalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("corrupt journal line: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// rewrites a plaintext journal so it is encrypted
+func encryptJournal() error {
+	entries, err := readJournal()
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	content, err := encodeJournal(entries)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(ClipseConfig.JournalFilePath, content, 0644)
+}
+
+// HistoryAt rebuilds the history as it was at t by undoing the changes
+// logged in the journal since. Returns ErrJournalTooShort if t is before
+// the start of the journal.
+func HistoryAt(t time.Time) ([]ClipboardItem, error) {
+	unlock, err := lockHistory(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := readJournal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	if len(entries) == 0 || t.Before(entries[0].Time) {
+		return nil, journalStartErr(entries)
+	}
+
+	history := fileContents().ClipboardHistory
+	for i := len(entries) - 1; i >= 0 && entries[i].Time.After(t); i-- {
+		history = undoJournalEntry(history, entries[i])
+	}
+	return history, nil
+}
+
+func journalStartErr(entries []journalEntry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: no changes have been logged yet", ErrJournalTooShort)
+	}
+	return fmt.Errorf("%w: it starts at %s", ErrJournalTooShort, utils.FormatDateTime(entries[0].Time))
+}
+
+func undoJournalEntry(history []ClipboardItem, e journalEntry) []ClipboardItem {
+	switch {
+	case e.Before == nil && e.After != nil:
+		if index := itemIndex(history, e.After.Recorded); index >= 0 {
+			history = append(history[:index], history[index+1:]...)
+		}
+	case e.Before != nil && e.After == nil:
+		history = RestoreItem(history, *e.Before)
+	case e.Before != nil:
+		if index := itemIndex(history, e.Before.Recorded); index >= 0 {
+			history[index] = *e.Before
+		}
+	}
+	return history
+}
+
+func itemsByTimeStamp(items []ClipboardItem) map[string]ClipboardItem {
+	byTimeStamp := make(map[string]ClipboardItem)
+	for _, item := range items {
+		byTimeStamp[item.Recorded] = item
+	}
+	return byTimeStamp
+}
+
+// WriteRestoreProfile writes the items as the history of a new config dir
+// under dir, to be opened with XDG_CONFIG_HOME=<dir> clipse. The profile
+// shares the theme, log and image dir of the current config but keeps its
+// own history, snippets and other state files.
+func WriteRestoreProfile(dir string, items []ClipboardItem) error {
+	profileDir := filepath.Join(dir, clipseDir)
+	if _, err := os.Stat(profileDir); err == nil {
+		return fmt.Errorf("%s already exists", profileDir)
+	}
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		return err
+	}
+
+	profile := ClipseConfig
+	profile.HistoryFilePath = defaultHistoryFile
+	profile.Storage = StorageJSON
+	profile.SearchHistoryFilePath = defaultSearchHistFile
+	profile.PasteStackFilePath = defaultPasteStackFile
+	profile.SnippetsFilePath = defaultSnippetsFile
+	profile.JournalFilePath = defaultJournalFile
+
+	configJSON, err := json.MarshalIndent(profile, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(profileDir, configFile), configJSON, 0644); err != nil {
+		return err
+	}
+
+	historyJSON, err := encodeHistory(ClipboardHistory{ClipboardHistory: items})
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(filepath.Join(profileDir, defaultHistoryFile), historyJSON, 0644)
+}
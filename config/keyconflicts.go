@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Two actions bound to the same key only conflict if they are active at
+the same time, eg up both moves through the list and recalls queries in
+the filter prompt. A conflict found at startup opens a resolver in the TUI,
+which writes the fixed keyBindings back to the config file that set them.
+*/
+
+// actions active at the same time, by screen
+var keyBindingGroups = [][]string{
+	{ // history list
+		"filter", "quit", "more", "choose", "remove", "togglePin", "togglePinned", "preview",
+		"selectDown", "selectUp", "selectSingle", "clearSelected", "pasteStack", "undo", "sortUsed",
+		"pauseCapture", "switchDevice", "switchSource", "notifications", "saveSnippet", "snippets", "passwords", "emoji", "generate",
+		"copyLink", "copyFormatted", "openURL", "qrCode", "actions", "transform", "tmuxBuffer", "reveal", "splitView", "splitUp", "splitDown", "leader",
+		"up", "down", "nextPage", "prevPage", "home", "end",
+	},
+	{"choose", "cancel", "yankFilter", "prevQuery", "nextQuery", "pinQuery"}, // filter prompt
+	{ // preview
+		"up", "down", "pageDown", "pageUp", "preview", "inspect", "copyEscaped", "actions", "quit",
+	},
+	{"keepLeft", "keepRight", "keepBoth", "cancel", "quit"}, // sync conflicts
+	{"up", "down", "notifications", "cancel", "quit"},       // notifications
+	{"choose", "filter", "passwords", "quit"},               // passwords
+	{"choose", "filter", "emoji", "quit"},                   // emoji picker
+	{"qrCode", "cancel", "quit"},                            // qr code
+	{"choose", "filter", "actions", "quit"},                 // action menu
+	{"choose", "filter", "transform", "quit"},               // transform menu
+	{"choose", "filter", "generate", "quit"},                // generate menu
+}
+
+// KeyConflict is a key bound to more than one action of the same screen.
+type KeyConflict struct {
+	Key     string
+	Actions []string
+}
+
+// KeyConflicts returns the conflicts between the given key bindings, in
+// the order the screens and actions are listed.
+func KeyConflicts(bindings map[string]string) []KeyConflict {
+	conflicts := []KeyConflict{}
+	seen := make(map[string]bool)
+	for _, group := range keyBindingGroups {
+		byKey := make(map[string][]string)
+		keys := []string{}
+		for _, action := range group {
+			k := bindings[action]
+			if len(byKey[k]) == 0 {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], action)
+		}
+		for _, k := range keys {
+			actions := byKey[k]
+			id := k + "\x00" + strings.Join(actions, "\x00")
+			if len(actions) < 2 || seen[id] {
+				continue
+			}
+			seen[id] = true
+			conflicts = append(conflicts, KeyConflict{Key: k, Actions: actions})
+		}
+	}
+	return conflicts
+}
+
+// KeyBindingConflicts reports whether binding action to k would conflict
+// with another action, returning the action it conflicts with.
+func KeyBindingConflicts(bindings map[string]string, action, k string) (string, bool) {
+	for _, group := range keyBindingGroups {
+		if !containsAction(group, action) {
+			continue
+		}
+		for _, other := range group {
+			if other != action && bindings[other] == k {
+				return other, true
+			}
+		}
+	}
+	return "", false
+}
+
+func containsAction(group []string, action string) bool {
+	for _, a := range group {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultKeyBinding returns the key action is bound to by default.
+func DefaultKeyBinding(action string) string {
+	return defaultKeyBindings()[action]
+}
+
+// SaveKeyBindings binds the actions to new keys and writes them to the
+// config file, or to the host overlay if it sets the action's key.
+func SaveKeyBindings(changes map[string]string) error {
+	values := map[string]any{}
+	for action, k := range changes {
+		ClipseConfig.KeyBindings[action] = k
+		values[action] = k
+	}
+	return saveConfigObject("keyBindings", values)
+}
+
+// writes changes to the keys of an object setting, eg keyBindings, to the
+// config file, or to the host overlay for the keys it sets
+func saveConfigObject(field string, changes map[string]any) error {
+	overlayKeys := map[string]json.RawMessage{}
+	if loadedOverlay != "" {
+		var err error
+		if overlayKeys, err = fileConfigObject(loadedOverlay, field); err != nil {
+			return err
+		}
+	}
+
+	base, overlay := map[string]any{}, map[string]any{}
+	for k, v := range changes {
+		if _, ok := overlayKeys[k]; ok {
+			overlay[k] = v
+		} else {
+			base[k] = v
+		}
+	}
+
+	if err := writeFileConfigObject(loadedConfig, field, base); err != nil {
+		return err
+	}
+	return writeFileConfigObject(loadedOverlay, field, overlay)
+}
+
+func fileConfigObject(path, field string) (map[string]json.RawMessage, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	object := make(map[string]json.RawMessage)
+	if raw, ok := file[field]; ok {
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, fmt.Errorf("failed to read %s in %s: %w", field, path, err)
+		}
+	}
+	return object, nil
+}
+
+// updates the keys of an object setting in a config file, leaving its
+// other settings as they are
+func writeFileConfigObject(path, field string, changes map[string]any) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	object := make(map[string]json.RawMessage)
+	if raw, ok := file[field]; ok {
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return fmt.Errorf("failed to read %s in %s: %w", field, path, err)
+		}
+	}
+	for k, v := range changes {
+		if object[k], err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	if file[field], err = json.Marshal(object); err != nil {
+		return err
+	}
+
+	content, err = json.MarshalIndent(file, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return utils.WriteFileAtomic(path, content, 0644)
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for the migration assistant the TUI opens after an
+upgrade. It looks for data to carry over into the current layout: the
+~/.config/clipboard_manager dir of releases from before the rename to
+clipse, a history file written with an older schema, and the histories of
+other clipboard managers where they keep them by default. Each source is
+migrated or declined once, which is remembered in migrations.json next to
+the config so the assistant doesn't ask about it again.
+*/
+
+const (
+	MigrateLegacy  = "legacy"  // the clipboard_manager dir
+	MigrateSchema  = "schema"  // the history file, written with an older schema
+	MigrateForeign = "foreign" // the history of another clipboard manager
+)
+
+// MigrationSource is data found that can be migrated.
+type MigrationSource struct {
+	Kind   string
+	Path   string // file or dir, the command for histories read from one
+	From   string // clipboard manager of a foreign history, eg ImportClipman
+	Schema int    // of the history file
+}
+
+// MigrationResult is what migrating a source did.
+type MigrationResult struct {
+	ImportResult
+	Images  int // image files copied
+	Skipped int // entries not migrated, eg images whose file is gone
+	Theme   bool
+}
+
+// MigrationProgress is told how many of the steps of a migration are done.
+type MigrationProgress func(done, total int)
+
+// handled sources, by key
+type migrationState struct {
+	Migrated []string `json:"migrated,omitempty"`
+	Declined []string `json:"declined,omitempty"`
+}
+
+func (s MigrationSource) key() string {
+	if s.Kind == MigrateSchema {
+		return fmt.Sprintf("%s:%d:%s", s.Kind, s.Schema, s.Path)
+	}
+	return s.Kind + ":" + s.Path
+}
+
+func migrationsPath() string {
+	return filepath.Join(filepath.Dir(loadedConfig), migrationsFile)
+}
+
+func readMigrationState() migrationState {
+	var state migrationState
+	content, err := os.ReadFile(migrationsPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWARN(fmt.Sprintf("failed to read %s: %s", migrationsPath(), err))
+		}
+		return state
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to parse %s: %s", migrationsPath(), err))
+	}
+	return state
+}
+
+func (s migrationState) handled(key string) bool {
+	return slices.Contains(s.Migrated, key) || slices.Contains(s.Declined, key)
+}
+
+// PendingMigrations returns the sources found that weren't migrated yet,
+// nor declined unless withDeclined is set.
+func PendingMigrations(withDeclined bool) []MigrationSource {
+	state := readMigrationState()
+	var pending []MigrationSource
+	for _, source := range findMigrations() {
+		key := source.key()
+		if !slices.Contains(state.Migrated, key) && (withDeclined || !slices.Contains(state.Declined, key)) {
+			pending = append(pending, source)
+		}
+	}
+	return pending
+}
+
+func findMigrations() []MigrationSource {
+	var found []MigrationSource
+
+	if userConfig, err := os.UserConfigDir(); err == nil {
+		dir := filepath.Join(userConfig, legacyDir)
+		if _, err := os.Stat(readLegacyConfig(dir).HistoryFile); err == nil {
+			found = append(found, MigrationSource{Kind: MigrateLegacy, Path: dir})
+		}
+	}
+
+	if ClipseConfig.Storage == StorageJSON {
+		if data, err := readHistoryFile(ClipseConfig.HistoryFilePath); err == nil && data.Schema < HistorySchema && len(data.ClipboardHistory) > 0 {
+			found = append(found, MigrationSource{Kind: MigrateSchema, Path: ClipseConfig.HistoryFilePath, Schema: max(data.Schema, 1)})
+		}
+	}
+
+	if dataHome, err := dataDir(); err == nil {
+		for _, f := range []struct{ from, path string }{
+			{ImportClipman, filepath.Join(dataHome, clipmanHistoryFile)},
+			{ImportGPaste, filepath.Join(dataHome, gpasteHistoryFile)},
+		} {
+			if _, err := os.Stat(f.path); err == nil {
+				found = append(found, MigrationSource{Kind: MigrateForeign, Path: f.path, From: f.from})
+			}
+		}
+	}
+	for _, from := range []string{ImportCopyQ, ImportGreenclip} {
+		if shell.HasHistoryExport(from) {
+			found = append(found, MigrationSource{Kind: MigrateForeign, Path: from, From: from})
+		}
+	}
+	return found
+}
+
+// $XDG_DATA_HOME or ~/.local/share
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// DeclineMigrations remembers not to ask about the sources again.
+func DeclineMigrations(sources []MigrationSource) error {
+	state := readMigrationState()
+	for _, source := range sources {
+		if !state.handled(source.key()) {
+			state.Declined = append(state.Declined, source.key())
+		}
+	}
+	return writeMigrationState(state)
+}
+
+func writeMigrationState(state migrationState) error {
+	content, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(migrationsPath(), content, 0644)
+}
+
+// Migrate carries the source over into the history, then remembers it was
+// migrated.
+func Migrate(ctx context.Context, source MigrationSource, progress MigrationProgress) (MigrationResult, error) {
+	var result MigrationResult
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	var err error
+	switch source.Kind {
+	case MigrateLegacy:
+		result, err = migrateLegacy(ctx, source.Path, progress)
+	case MigrateSchema:
+		err = upgradeHistoryFile()
+		progress(1, 1)
+	case MigrateForeign:
+		result, err = migrateForeign(ctx, source, progress)
+	default:
+		err = fmt.Errorf("unknown migration %q", source.Kind)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	state := readMigrationState()
+	state.Migrated = append(state.Migrated, source.key())
+	return result, writeMigrationState(state)
+}
+
+// the paths of the legacy config, which may have moved its history, theme
+// and images
+type legacyConfig struct {
+	HistoryFile string `json:"historyFile"`
+	ThemeFile   string `json:"themeFile"`
+	TempDir     string `json:"tempDir"`
+}
+
+func readLegacyConfig(dir string) legacyConfig {
+	conf := legacyConfig{
+		HistoryFile: defaultHistoryFile,
+		ThemeFile:   defaultThemeFile,
+		TempDir:     defaultTempDir,
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, configFile)); err == nil {
+		if err := json.Unmarshal(content, &conf); err != nil {
+			utils.LogWARN(fmt.Sprintf("failed to parse the legacy config in %s: %s", dir, err))
+		}
+	}
+	conf.HistoryFile = utils.ExpandRel(utils.ExpandHome(conf.HistoryFile), dir)
+	conf.ThemeFile = utils.ExpandRel(utils.ExpandHome(conf.ThemeFile), dir)
+	conf.TempDir = utils.ExpandRel(utils.ExpandHome(conf.TempDir), dir)
+	return conf
+}
+
+// imports the history of the legacy dir, copying the image files of its
+// entries to the temp dir, and its theme unless one exists
+func migrateLegacy(ctx context.Context, dir string, progress MigrationProgress) (MigrationResult, error) {
+	var result MigrationResult
+	conf := readLegacyConfig(dir)
+
+	data, err := readHistoryFile(conf.HistoryFile)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", conf.HistoryFile, err)
+	}
+
+	// a step per image, one for the theme and one for the import
+	total := 2
+	for _, item := range data.ClipboardHistory {
+		if item.FilePath != "null" && item.FilePath != "" {
+			total++
+		}
+	}
+	done := 0
+	step := func() {
+		done++
+		progress(done, total)
+	}
+
+	items := make([]ClipboardItem, 0, len(data.ClipboardHistory))
+	for _, item := range data.ClipboardHistory {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if item.FilePath == "null" || item.FilePath == "" {
+			items = append(items, item)
+			continue
+		}
+		src := utils.ExpandRel(item.FilePath, conf.TempDir)
+		dst := filepath.Join(ClipseConfig.TempDirPath, filepath.Base(src))
+		if err := utils.CopyFile(src, dst, 0644); err != nil {
+			utils.LogWARN(fmt.Sprintf("not migrating image %s: %s", src, err))
+			result.Skipped++
+		} else {
+			item.FilePath = dst
+			items = append(items, item)
+			result.Images++
+		}
+		step()
+	}
+
+	if _, err := os.Stat(ClipseConfig.ThemeFilePath); errors.Is(err, os.ErrNotExist) {
+		if err := utils.CopyFile(conf.ThemeFile, ClipseConfig.ThemeFilePath, 0644); err == nil {
+			result.Theme = true
+		}
+	}
+	step()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	result.ImportResult, err = ImportItems(items)
+	step()
+	return result, err
+}
+
+// rewrites the history file with the current schema, which otherwise
+// happens on its next change
+func upgradeHistoryFile() error {
+	unlock, err := lockHistory(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data := fileContents()
+	if data.Schema >= HistorySchema {
+		return nil
+	}
+	return writeHistory(data)
+}
+
+// imports the history of another clipboard manager, from its file or the
+// output of its command
+func migrateForeign(ctx context.Context, source MigrationSource, progress MigrationProgress) (MigrationResult, error) {
+	var result MigrationResult
+	var content []byte
+	var err error
+	if source.From == ImportCopyQ || source.From == ImportGreenclip {
+		exportCtx, cancel := context.WithTimeout(ctx, exportTimeout)
+		content, err = shell.ExportHistory(exportCtx, source.From)
+		cancel()
+	} else {
+		content, err = os.ReadFile(source.Path)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to read the %s history: %w", source.From, err)
+	}
+	progress(1, 2)
+
+	items, skipped, err := parseForeignHistory(source.From, source.Path, content)
+	if err != nil {
+		return result, err
+	}
+	result.Skipped = skipped
+	result.ImportResult, err = ImportItems(items)
+	progress(2, 2)
+	return result, err
+}
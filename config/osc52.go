@@ -0,0 +1,56 @@
+package config
+
+import (
+	"context"
+	"os"
+
+	"github.com/savedra1/clipse/shell"
+)
+
+// UseOSC52 reports whether text is copied with an OSC 52 escape sequence
+// instead of the system clipboard: always with forceOSC52 set or without a
+// display server, otherwise in SSH sessions without a display to reach a
+// clipboard through.
+func UseOSC52() bool {
+	if ClipseConfig.ForceOSC52 || NoDisplay() {
+		return true
+	}
+	if os.Getenv("SSH_CONNECTION") == "" && os.Getenv("SSH_TTY") == "" {
+		return false
+	}
+	switch DisplayServer() {
+	case "wayland":
+		return false
+	case "x11":
+		return os.Getenv("DISPLAY") == "" // no X11 forwarding
+	}
+	// pbcopy and the Windows clipboard belong to the remote machine
+	return true
+}
+
+// CopyText puts text on the clipboard, through the terminal when UseOSC52
+// says the system clipboard is out of reach, and on the primary selection
+// as primarySelection.copyTo picks. Fails after ClipboardTimeout if the
+// clipboard tool hangs.
+func CopyText(text string) error {
+	if UseOSC52() {
+		return shell.CopyOSC52(text)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ClipboardTimeout)
+	defer cancel()
+	copyTo := ClipseConfig.PrimarySelection.CopyTo
+	if copyTo == SelectionPrimary {
+		return shell.WritePrimary(ctx, text)
+	}
+	if err := writeClipboard(ctx, text); err != nil || copyTo != SelectionBoth {
+		return err
+	}
+	return shell.WritePrimary(ctx, text)
+}
+
+func writeClipboard(ctx context.Context, text string) error {
+	if copied, err := copyForTarget(ctx, text); copied {
+		return err
+	}
+	return shell.WriteClipboard(ctx, text)
+}
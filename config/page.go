@@ -0,0 +1,93 @@
+package config
+
+import "strings"
+
+/* File contains logic for reading the history a page at a time, so the
+TUI stays light with histories of 100k entries. Only the sqlite storage
+reads just the entries asked for, the others load the whole history and
+return part of it.
+*/
+
+// storages that can read part of the history without loading all of it
+type pagedStorage interface {
+	loadPage(offset, limit int) ([]ClipboardItem, int, error) // entries newest first and the total number
+	loadPinned() ([]ClipboardItem, error)
+	find(terms []string, limit int) ([]ClipboardItem, error) // entries containing every term, ignoring case
+}
+
+// PagedHistory reports whether the configured storage reads pages of the
+// history without loading all of it.
+func PagedHistory() bool {
+	_, ok := historyStorage().(pagedStorage)
+	return ok
+}
+
+// HistoryPage returns up to limit entries starting at offset, newest
+// first, and the number of entries in the history.
+func HistoryPage(offset, limit int) ([]ClipboardItem, int, error) {
+	unlock, err := lockHistory(false)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer unlock()
+
+	if s, ok := historyStorage().(pagedStorage); ok {
+		return s.loadPage(offset, limit)
+	}
+	history := fileContents().ClipboardHistory
+	start := min(offset, len(history))
+	return history[start:min(start+limit, len(history))], len(history), nil
+}
+
+// PinnedItems returns the pinned entries, newest first.
+func PinnedItems() ([]ClipboardItem, error) {
+	unlock, err := lockHistory(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if s, ok := historyStorage().(pagedStorage); ok {
+		return s.loadPinned()
+	}
+	pinned := []ClipboardItem{}
+	for _, item := range fileContents().ClipboardHistory {
+		if item.Pinned {
+			pinned = append(pinned, item)
+		}
+	}
+	return pinned, nil
+}
+
+// FindItems returns up to limit entries whose value contains every term,
+// ignoring case, newest first. Sensitive entries are not matched.
+func FindItems(terms []string, limit int) ([]ClipboardItem, error) {
+	unlock, err := lockHistory(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if s, ok := historyStorage().(pagedStorage); ok {
+		return s.find(terms, limit)
+	}
+	found := []ClipboardItem{}
+	for _, item := range fileContents().ClipboardHistory {
+		if len(found) == limit {
+			break
+		}
+		if !item.Sensitive && containsAll(strings.ToLower(item.Value), terms) {
+			found = append(found, item)
+		}
+	}
+	return found, nil
+}
+
+func containsAll(value string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(value, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
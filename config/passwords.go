@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+// Password stores whose entries can be listed in the TUI.
+const (
+	PasswordStorePass   = "pass"
+	PasswordStoreGopass = "gopass"
+)
+
+func validatePasswordStore() {
+	switch ClipseConfig.PasswordStore {
+	case "", PasswordStorePass, PasswordStoreGopass:
+	default:
+		utils.LogWARN(fmt.Sprintf("unknown passwordStore %q, must be %s or %s. Not listing passwords", ClipseConfig.PasswordStore, PasswordStorePass, PasswordStoreGopass))
+		ClipseConfig.PasswordStore = ""
+	}
+}
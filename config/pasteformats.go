@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/transforms"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Entries holding HTML markup, eg copied from a page's source or a web
+editor, can be pasted as the text it shows or formatted. pasteFormats maps
+applications to the format such entries are copied in while one of their
+windows is focused, "text" or "html". Applications are compared like
+ignoredApps, ignoring case, with the class of the window and the name of
+its process. Other applications, and copies with OSC 52, get the markup as
+it is.
+
+Entries copied with formatting, eg from a browser, keep the text/html
+offered along with the text in their HTML field. They are copied as plain
+text, or formatted with CopyFormatted and into applications set to html.
+*/
+
+const (
+	PasteText = "text" // the text the markup shows, eg for terminals
+	PasteHTML = "html" // text/html, pasted formatted by rich text editors
+)
+
+// drops the pasteFormats entries with an unknown format
+func validatePasteFormats() {
+	for app, format := range ClipseConfig.PasteFormats {
+		if format != PasteText && format != PasteHTML {
+			utils.LogWARN(fmt.Sprintf("unknown paste format %q for %s, must be text or html. Ignoring it", format, app))
+			delete(ClipseConfig.PasteFormats, app)
+		}
+	}
+}
+
+// PasteFormat returns the format configured for the window's application,
+// empty if there is none.
+func PasteFormat(w shell.Window) string {
+	apps := make([]string, 0, len(ClipseConfig.PasteFormats))
+	for app := range ClipseConfig.PasteFormats {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps) // the same entry wins if the class and process match different ones
+	for _, app := range apps {
+		name := strings.TrimSpace(app)
+		if name != "" && (strings.EqualFold(name, w.Class) || strings.EqualFold(name, w.Process)) {
+			return ClipseConfig.PasteFormats[app]
+		}
+	}
+	return ""
+}
+
+// copies HTML markup in the format of the focused application, returning
+// false if it isn't markup or the application has no format
+func copyForTarget(ctx context.Context, text string) (bool, error) {
+	if len(ClipseConfig.PasteFormats) == 0 || !transforms.IsHTML(text) {
+		return false, nil
+	}
+	ds := DisplayServer()
+	switch PasteFormat(shell.ActiveWindow(ctx, ds)) {
+	case PasteText:
+		return true, shell.WriteClipboard(ctx, transforms.HTMLToText(text))
+	case PasteHTML:
+		if err := shell.CopyHTML(ctx, text, ds); err != nil {
+			utils.LogWARN(fmt.Sprintf("copying the markup as text instead of HTML: %s", err))
+			return false, nil
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// CopyEntry copies the text of an entry, or the text/html it was copied
+// with when the focused application's paste format is html.
+func CopyEntry(text, html string) error {
+	if html == "" || len(ClipseConfig.PasteFormats) == 0 || UseOSC52() {
+		return CopyText(text)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ClipboardTimeout)
+	defer cancel()
+	ds := DisplayServer()
+	if PasteFormat(shell.ActiveWindow(ctx, ds)) != PasteHTML {
+		return CopyText(text)
+	}
+	if err := shell.CopyHTML(ctx, html, ds); err != nil {
+		utils.LogWARN(fmt.Sprintf("copying the entry as text instead of HTML: %s", err))
+		return CopyText(text)
+	}
+	return nil
+}
+
+// CopyFormatted puts the text/html an entry was copied with on the
+// clipboard, for rich text editors to paste it formatted.
+func CopyFormatted(html string) error {
+	if UseOSC52() {
+		return shell.ErrHTMLUnsupported // OSC 52 only carries text
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ClipboardTimeout)
+	defer cancel()
+	return shell.CopyHTML(ctx, html, DisplayServer())
+}
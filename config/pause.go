@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Capture can be paused, eg while handling passwords, with `clipse pause`
+or the pauseCapture key in the TUI. The pause is a file next to the
+history holding the time it ends, or nothing if it lasts until resumed.
+The listeners check it on every clipboard change, which works the same
+for the wayland listener that stores each change from a new process.
+*/
+
+func pausePath() string {
+	return filepath.Join(filepath.Dir(ClipseConfig.HistoryFilePath), pauseFile)
+}
+
+// PauseCapture stops the listener recording clipboard changes, for d or
+// until resumed if d is 0.
+func PauseCapture(d time.Duration) error {
+	until := ""
+	if d > 0 {
+		until = utils.FormatTime(time.Now().Add(d))
+	}
+	return utils.WriteFileAtomic(pausePath(), []byte(until), 0644)
+}
+
+// ResumeCapture restarts recording, returning false if it wasn't paused.
+func ResumeCapture() (bool, error) {
+	err := os.Remove(pausePath())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CapturePaused returns whether recording is paused and when the pause
+// ends, the zero time if it lasts until resumed.
+func CapturePaused() (bool, time.Time) {
+	content, err := os.ReadFile(pausePath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.LogERROR("failed to read capture pause: " + err.Error())
+		}
+		return false, time.Time{}
+	}
+
+	until := strings.TrimSpace(string(content))
+	if until == "" {
+		return true, time.Time{}
+	}
+	t, err := utils.ParseTimeStamp(until)
+	if err != nil {
+		utils.LogWARN("invalid capture pause end " + until + ", treating it as paused until resumed")
+		return true, time.Time{}
+	}
+	if time.Now().After(t) {
+		if _, err := ResumeCapture(); err != nil {
+			utils.LogERROR("failed to end capture pause: " + err.Error())
+		}
+		return false, time.Time{}
+	}
+	return true, t
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains the identity of this device on the local network and the
+peers paired with it, see the peers package for discovery and pairing.
+Each device has an ed25519 key made on first use, peers are remembered by
+their public key once paired and nothing is exchanged with a device that
+isn't paired.
+*/
+
+type Peer struct {
+	Device string `json:"device"`
+	Key    string `json:"key"`    // base64 ed25519 public key
+	Paired string `json:"paired"` // when it was paired, utils.TimeLayout
+}
+
+type peersFileData struct {
+	Peers []Peer `json:"peers"`
+}
+
+// DeviceName names this device to its peers: the sync device name or
+// the hostname.
+func DeviceName() string {
+	if ClipseConfig.Sync.Device != "" {
+		return ClipseConfig.Sync.Device
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "clipse"
+	}
+	return hostname
+}
+
+func peerKeyPath() string {
+	return filepath.Join(filepath.Dir(ClipseConfig.HistoryFilePath), peerKeyFile)
+}
+
+func peersPath() string {
+	return filepath.Join(filepath.Dir(ClipseConfig.HistoryFilePath), peersFile)
+}
+
+// PeerIdentity returns the key of this device, making it on first use.
+func PeerIdentity() (ed25519.PrivateKey, error) {
+	content, err := os.ReadFile(peerKeyPath())
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(string(content))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid device key in %s", peerKeyPath())
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key.Seed())
+	if err := utils.WriteFileAtomic(peerKeyPath(), []byte(encoded), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save device key: %w", err)
+	}
+	return key, nil
+}
+
+// KeyFingerprint returns a short hex id of a public key, shown when
+// listing peers.
+func KeyFingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PairedPeers returns the peers paired with this device.
+func PairedPeers() ([]Peer, error) {
+	data, err := readPeersFile()
+	return data.Peers, err
+}
+
+// SavePeer pairs the peer, replacing any earlier pairing with the device.
+func SavePeer(peer Peer) error {
+	data, err := readPeersFile()
+	if err != nil {
+		return err
+	}
+	peers := []Peer{peer}
+	for _, p := range data.Peers {
+		if p.Device != peer.Device {
+			peers = append(peers, p)
+		}
+	}
+	data.Peers = peers
+	return writePeersFile(data)
+}
+
+// RemovePeer unpairs the device, returning false if it wasn't paired.
+func RemovePeer(device string) (bool, error) {
+	data, err := readPeersFile()
+	if err != nil {
+		return false, err
+	}
+	peers := []Peer{}
+	for _, p := range data.Peers {
+		if p.Device != device {
+			peers = append(peers, p)
+		}
+	}
+	if len(peers) == len(data.Peers) {
+		return false, nil
+	}
+	data.Peers = peers
+	return true, writePeersFile(data)
+}
+
+func readPeersFile() (peersFileData, error) {
+	data := peersFileData{Peers: []Peer{}}
+	content, err := os.ReadFile(peersPath())
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return data, err
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return data, fmt.Errorf("failed to read %s: %w", peersPath(), err)
+	}
+	return data, nil
+}
+
+func writePeersFile(data peersFileData) error {
+	content, err := json.MarshalIndent(data, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return utils.WriteFileAtomic(peersPath(), content, 0600)
+}
@@ -0,0 +1,124 @@
+package config
+
+import "sort"
+
+/* Built-in theme presets, selected with `"theme": "<name>"` in config.json.
+Each preset is a palette mapped onto the theme's color slots.
+*/
+
+type themePalette struct {
+	text        string // titles, filter input and previews
+	dim         string // descriptions and dimmed items
+	subtle      string // help keys
+	titleFore   string
+	titleBack   string
+	accent      string // borders, dots and info text
+	highlight   string // selected item
+	status      string // status messages and filter prompt
+	pin         string // pin indicator and cursor
+	titleStyle  string
+	borderStyle string
+}
+
+var themePresets = map[string]themePalette{
+	"nord": {
+		text:        "#ECEFF4",
+		dim:         "#4C566A",
+		subtle:      "#D8DEE9",
+		titleFore:   "#2E3440",
+		titleBack:   "#88C0D0",
+		accent:      "#81A1C1",
+		highlight:   "#8FBCBB",
+		status:      "#A3BE8C",
+		pin:         "#EBCB8B",
+		titleStyle:  "bold",
+		borderStyle: "normal",
+	},
+	"dracula": {
+		text:        "#F8F8F2",
+		dim:         "#6272A4",
+		subtle:      "#BFBFBF",
+		titleFore:   "#282A36",
+		titleBack:   "#BD93F9",
+		accent:      "#8BE9FD",
+		highlight:   "#FF79C6",
+		status:      "#50FA7B",
+		pin:         "#F1FA8C",
+		titleStyle:  "bold",
+		borderStyle: "thick",
+	},
+	"gruvbox": {
+		text:        "#EBDBB2",
+		dim:         "#928374",
+		subtle:      "#D5C4A1",
+		titleFore:   "#282828",
+		titleBack:   "#FE8019",
+		accent:      "#83A598",
+		highlight:   "#FABD2F",
+		status:      "#B8BB26",
+		pin:         "#FB4934",
+		titleStyle:  "bold",
+		borderStyle: "thick",
+	},
+	"solarized": {
+		text:        "#93A1A1",
+		dim:         "#586E75",
+		subtle:      "#839496",
+		titleFore:   "#FDF6E3",
+		titleBack:   "#268BD2",
+		accent:      "#2AA198",
+		highlight:   "#D33682",
+		status:      "#859900",
+		pin:         "#B58900",
+		titleStyle:  "",
+		borderStyle: "normal",
+	},
+}
+
+// PresetTheme returns the built-in theme called name.
+func PresetTheme(name string) (CustomTheme, bool) {
+	p, ok := themePresets[name]
+	if !ok {
+		return CustomTheme{}, false
+	}
+	return CustomTheme{
+		UseCustom:          false,
+		TitleFore:          p.titleFore,
+		TitleBack:          p.titleBack,
+		TitleInfo:          p.accent,
+		NormalTitle:        p.text,
+		DimmedTitle:        p.dim,
+		SelectedTitle:      p.highlight,
+		NormalDesc:         p.dim,
+		DimmedDesc:         p.dim,
+		SelectedDesc:       p.highlight,
+		StatusMsg:          p.status,
+		PinIndicatorColor:  p.pin,
+		SelectedBorder:     p.accent,
+		SelectedDescBorder: p.accent,
+		FilteredMatch:      p.text,
+		FilterPrompt:       p.status,
+		FilterInfo:         p.accent,
+		FilterText:         p.text,
+		FilterCursor:       p.pin,
+		HelpKey:            p.subtle,
+		HelpDesc:           p.dim,
+		PageActiveDot:      p.accent,
+		PageInactiveDot:    p.dim,
+		DividerDot:         p.accent,
+		PreviewedText:      p.text,
+		PreviewBorder:      p.accent,
+		TitleStyle:         p.titleStyle,
+		BorderStyle:        p.borderStyle,
+	}, true
+}
+
+// ThemePresets returns the names of the built-in themes.
+func ThemePresets() []string {
+	names := []string{}
+	for name := range themePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* The primary selection holds the text last selected with the mouse on
+X11 and most wayland compositors, and is pasted with a middle click. With
+primarySelection.record set the listener records it too, once a selection
+settles, tagging those entries with Selection "primary". copyTo picks the
+selections entries copied out of the history are written to. macOS and
+Windows have no primary selection.
+*/
+
+// PrimarySelection records the primary selection and picks where copies go,
+// see primary.go.
+type PrimarySelection struct {
+	Record bool   `json:"record"`
+	CopyTo string `json:"copyTo"` // clipboard, primary or both
+}
+
+const (
+	SelectionClipboard = "clipboard"
+	SelectionPrimary   = "primary"
+	SelectionBoth      = "both"
+)
+
+func validatePrimarySelection() {
+	p := &ClipseConfig.PrimarySelection
+	switch p.CopyTo {
+	case SelectionClipboard, SelectionPrimary, SelectionBoth:
+	default:
+		utils.LogWARN(fmt.Sprintf("unknown primarySelection copyTo %q, must be %s, %s or %s. Using %s", p.CopyTo, SelectionClipboard, SelectionPrimary, SelectionBoth, SelectionClipboard))
+		p.CopyTo = SelectionClipboard
+	}
+	if !hasPrimarySelection() && (p.Record || p.CopyTo != SelectionClipboard) {
+		utils.LogWARN(fmt.Sprintf("%s has no primary selection, ignoring primarySelection", runtime.GOOS))
+		p.Record, p.CopyTo = false, SelectionClipboard
+	}
+}
+
+func hasPrimarySelection() bool {
+	return runtime.GOOS != "darwin" && runtime.GOOS != "windows"
+}
+
+// RecordPrimary reports whether the listener records the primary selection.
+func RecordPrimary() bool {
+	return ClipseConfig.PrimarySelection.Record && hasPrimarySelection()
+}
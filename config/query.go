@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Item queries select history entries from the CLI, eg:
+	type:url pinned:false tag:build-log source:firefox selection:primary github
+Terms without a key match the entry value as a case-insensitive substring.
+*/
+
+type ItemQuery struct {
+	itemType string // text | image | url
+	pinned   *bool
+	tag      string
+	source   string // matched case-insensitively
+	primary  *bool  // recorded from the primary selection
+	terms    []string
+}
+
+func ParseItemQuery(s string) (ItemQuery, error) {
+	var q ItemQuery
+	for _, field := range strings.Fields(s) {
+		k, v, found := strings.Cut(field, ":")
+		switch {
+		case found && k == "type":
+			if v != "text" && v != "image" && v != "url" {
+				return q, fmt.Errorf("invalid type %q, must be one of text, image, url", v)
+			}
+			q.itemType = v
+		case found && k == "pinned":
+			if v != "true" && v != "false" {
+				return q, fmt.Errorf("invalid pinned value %q, must be true or false", v)
+			}
+			pinned := v == "true"
+			q.pinned = &pinned
+		case found && k == "tag":
+			q.tag = v
+		case found && k == "source":
+			q.source = v
+		case found && k == "selection":
+			if v != SelectionClipboard && v != SelectionPrimary {
+				return q, fmt.Errorf("invalid selection %q, must be %s or %s", v, SelectionClipboard, SelectionPrimary)
+			}
+			primary := v == SelectionPrimary
+			q.primary = &primary
+		default:
+			q.terms = append(q.terms, strings.ToLower(field))
+		}
+	}
+	return q, nil
+}
+
+func (q ItemQuery) Matches(item ClipboardItem) bool {
+	isImage := item.FilePath != "null"
+	switch q.itemType {
+	case "text":
+		if isImage {
+			return false
+		}
+	case "image":
+		if !isImage {
+			return false
+		}
+	case "url":
+		if isImage || !utils.IsURL(item.Value) {
+			return false
+		}
+	}
+
+	if q.pinned != nil && item.Pinned != *q.pinned {
+		return false
+	}
+	if q.tag != "" && item.Tag != q.tag {
+		return false
+	}
+	if q.source != "" && !strings.EqualFold(item.Source, q.source) {
+		return false
+	}
+	if q.primary != nil && (item.Selection == SelectionPrimary) != *q.primary {
+		return false
+	}
+
+	value := strings.ToLower(item.Value)
+	for _, term := range q.terms {
+		if !strings.Contains(value, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the history entries matching the query.
+func QueryItems(q ItemQuery) []ClipboardItem {
+	matches := []ClipboardItem{}
+	for _, item := range GetHistory() {
+		if q.Matches(item) {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
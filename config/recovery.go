@@ -0,0 +1,190 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for repairing a history file that can't be parsed,
+eg after a disk filled up or a crash mid-write. Every save also writes a
+.bak copy of the history, and every backupRotateEvery saves of a process
+that copy is rotated into .bak.1 to .bak.<backupRotations>, so a bad
+history that made it into the .bak doesn't leave nothing to go back to.
+
+The corrupt file is moved aside rather than overwritten. The entries that
+can still be read from it are merged with the newest backup that can be
+read, and clipse carries on with the result, an empty history if nothing
+could be salvaged.
+*/
+
+const (
+	backupRotations   = 3  // older backups kept
+	backupRotateEvery = 25 // saves between rotations
+	corruptFileExt    = ".corrupt"
+)
+
+var savesSinceRotation int // by this process
+
+// path of the nth older backup of the history file, 1 being the newest
+func rotatedBackupPath(n int) string {
+	return fmt.Sprintf("%s.%d", backupPath(), n)
+}
+
+// rotates the older backups and writes the content as the newest one every
+// backupRotateEvery saves, or when there is none yet
+func rotateBackups(content []byte) {
+	savesSinceRotation++
+	if _, err := os.Stat(rotatedBackupPath(1)); err == nil && savesSinceRotation < backupRotateEvery {
+		return
+	}
+	savesSinceRotation = 0
+
+	for n := backupRotations; n > 1; n-- {
+		err := os.Rename(rotatedBackupPath(n-1), rotatedBackupPath(n))
+		if err != nil && !os.IsNotExist(err) {
+			utils.LogWARN(fmt.Sprintf("failed to rotate history backup: %s", err))
+		}
+	}
+	if err := utils.WriteFileAtomic(rotatedBackupPath(1), content, 0644); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to write history backup: %s", err))
+	}
+}
+
+// repairs the history file after it failed to parse with parseErr, and
+// returns the recovered history
+func recoverHistory(parseErr error) (ClipboardHistory, error) {
+	path := ClipseConfig.HistoryFilePath
+	utils.LogERROR(fmt.Sprintf("failed to parse history file, attempting recovery: %s", parseErr))
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ClipboardHistory{}, fmt.Errorf("%w: %w", ErrHistoryCorrupt, err)
+	}
+	salvaged := salvageHistory(content)
+
+	data, backup := newestBackup()
+	if backup != "" {
+		utils.LogINFO(fmt.Sprintf("read %d entries from history backup %s", len(data.ClipboardHistory), backup))
+	}
+	if data.Schema == 0 {
+		data.Schema = salvaged.Schema
+	}
+	data.ClipboardHistory = mergeRecovered(salvaged.ClipboardHistory, data.ClipboardHistory)
+
+	// a newer binary's history is left for it to read, rewriting it here
+	// would drop the data this one doesn't know about
+	if err := checkSchema(data.Schema); err != nil {
+		return data, err
+	}
+
+	// another process may have repaired the file since it was read
+	if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, content) {
+		return readHistoryFile(path)
+	}
+	aside := fmt.Sprintf("%s%s-%s", path, corruptFileExt, time.Now().Format("20060102-150405"))
+	if err := os.Rename(path, aside); err != nil {
+		return data, fmt.Errorf("%w, failed to move it aside: %w", ErrHistoryCorrupt, err)
+	}
+	if err := writeHistory(data); err != nil {
+		return data, fmt.Errorf("%w, failed to write the recovered history: %w", ErrHistoryCorrupt, err)
+	}
+
+	utils.LogINFO(fmt.Sprintf(
+		"recovered %d entries, %d of them from the corrupt history file moved to %s",
+		len(data.ClipboardHistory), len(salvaged.ClipboardHistory), aside,
+	))
+	return data, nil
+}
+
+// the entries of a history file that can still be decoded, those before
+// the point where it is truncated or broken
+func salvageHistory(content []byte) ClipboardHistory {
+	var data ClipboardHistory
+	if isEncrypted(content) {
+		var err error
+		if content, err = decryptHistory(content); err != nil {
+			return data
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(content))
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return data
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return data
+		}
+		switch key {
+		case "schema":
+			if err := dec.Decode(&data.Schema); err != nil {
+				return data
+			}
+		case "clipboardHistory":
+			if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+				return data
+			}
+			var entries []json.RawMessage
+			for dec.More() {
+				var entry json.RawMessage
+				if err := dec.Decode(&entry); err != nil {
+					break
+				}
+				entries = append(entries, entry)
+			}
+			// the schema is written before the entries
+			data.ClipboardHistory, _ = migrateEntries(entries, data.Schema)
+			if _, err := dec.Token(); err != nil {
+				return data
+			}
+		default:
+			var skipped json.RawMessage
+			if err := dec.Decode(&skipped); err != nil {
+				return data
+			}
+		}
+	}
+	return data
+}
+
+// the newest backup of the history that can be read, and its path, empty
+// if there is none
+func newestBackup() (ClipboardHistory, string) {
+	paths := []string{backupPath()}
+	for n := 1; n <= backupRotations; n++ {
+		paths = append(paths, rotatedBackupPath(n))
+	}
+	for _, path := range paths {
+		data, err := readHistoryFile(path)
+		if err == nil {
+			return data, path
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.LogWARN(fmt.Sprintf("failed to read history backup %s: %s", path, err))
+		}
+	}
+	return ClipboardHistory{ClipboardHistory: []ClipboardItem{}}, ""
+}
+
+// the salvaged entries along with the backed up ones they don't include,
+// newest first
+func mergeRecovered(salvaged, backup []ClipboardItem) []ClipboardItem {
+	merged := append([]ClipboardItem{}, salvaged...)
+	for _, item := range backup {
+		if itemIndex(merged, item.Recorded) < 0 {
+			merged = append(merged, item)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Recorded > merged[j].Recorded
+	})
+	return merged
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+// SandboxPaths returns the paths the listener needs to write to, its data
+// files, temp dirs and the wayland runtime dir, and the paths it needs to
+// read: the config, key file, X11 auth file and system dirs.
+func SandboxPaths() (writable, readable []string) {
+	writable = []string{
+		filepath.Dir(ClipseConfig.HistoryFilePath),
+		filepath.Dir(ClipseConfig.DatabasePath),
+		filepath.Dir(ClipseConfig.HistoryLogPath),
+		filepath.Dir(ClipseConfig.JournalFilePath),
+		filepath.Dir(ClipseConfig.LogFilePath),
+		ClipseConfig.TempDirPath,
+		os.TempDir(),
+		"/dev/null",
+		"/dev/tty",
+	}
+	if ClipseConfig.Shards.Enabled {
+		writable = append(writable, ClipseConfig.Shards.Dir)
+	}
+	if ClipseConfig.Sync.Enabled {
+		writable = append(writable, ClipseConfig.Sync.Dir)
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		writable = append(writable, runtimeDir)
+	}
+
+	readable = utils.SandboxSystemPaths()
+	if userConfig, err := os.UserConfigDir(); err == nil {
+		readable = append(readable, filepath.Join(userConfig, clipseDir))
+	}
+	if ClipseConfig.Encryption.KeyFile != "" {
+		readable = append(readable, ClipseConfig.Encryption.KeyFile)
+	}
+	if xauth := os.Getenv("XAUTHORITY"); xauth != "" {
+		readable = append(readable, xauth)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		readable = append(readable, filepath.Join(home, ".Xauthority"))
+	}
+	return writable, readable
+}
+
+// SandboxAllowsTCP reports whether the X11 display is reached over TCP, eg
+// with SSH X forwarding, so the sandbox can't block TCP connections.
+func SandboxAllowsTCP() bool {
+	host, _, found := strings.Cut(os.Getenv("DISPLAY"), ":")
+	return found && host != "" && host != "unix"
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* The history file records the version of the schema it was written with.
+A binary only understands the schemas up to its own, rewriting a newer file
+would silently drop the fields it doesn't know about, eg after rolling back
+to an older release. Such writes are refused unless forced.
+
+Entries of an older history are upgraded as they are read, by running the
+entryMigrations after the schema they were written with in order, on the
+entries as decoded JSON so a migration can rename or convert fields the
+current ClipboardItem no longer has. The history file is rewritten with the
+current schema on the next save, the original is kept alongside it first.
+The jsonl log is compacted on its next save, as records appended with the
+current schema can't follow a header with an older one. The sqlite
+storage upgrades its table when opened, see sqlite.go.
+
+Schema versions:
+1 - no schema field, local timestamps
+2 - RFC3339 timestamps in UTC, usage stats
+3 - device of entries synced from other devices
+4 - text/html of entries copied with formatting
+5 - source window of entries in the sqlite storage
+6 - selection of entries in the sqlite storage
+*/
+
+const HistorySchema = 6
+
+var ErrNewerSchema = errors.New("history file was written by a newer version of clipse")
+
+// ForceSchema allows writing over a history file with a newer schema, set by
+// the --force flag.
+var ForceSchema bool
+
+// returns ErrNewerSchema if a history written with schema can't be safely
+// rewritten by this binary
+func checkSchema(schema int) error {
+	if schema <= HistorySchema || ForceSchema {
+		return nil
+	}
+	return fmt.Errorf(
+		"%w (schema %d, this binary supports up to %d): upgrade clipse or pass --force to rewrite it and drop newer data",
+		ErrNewerSchema, schema, HistorySchema,
+	)
+}
+
+// entryMigration upgrades an entry stored with the schema before version
+// to version.
+type entryMigration struct {
+	version int
+	migrate func(entry map[string]any)
+}
+
+// in order of version, a new schema adds one even if its entries need no
+// converting
+var entryMigrations = []entryMigration{
+	{2, utcTimeStamps},
+	{3, nil}, // device is a new field
+	{4, nil}, // html is a new field
+	{5, nil}, // source was only kept by the json and jsonl storages
+	{6, nil}, // as was selection
+}
+
+// converts timestamps stored in the legacy local time layout to
+// utils.TimeLayout
+func utcTimeStamps(entry map[string]any) {
+	for _, field := range []string{"recorded", "parent", "lastUsed"} {
+		if ts, ok := entry[field].(string); ok {
+			entry[field] = utils.NormalizeTimeStamp(ts)
+		}
+	}
+}
+
+// copies a history file written with an older schema, once, before it is
+// rewritten with the current one
+func keepOriginal(schema int) {
+	schema = max(schema, 1) // files without a schema field
+	original := fmt.Sprintf("%s.schema%d", ClipseConfig.HistoryFilePath, schema)
+	if _, err := os.Stat(original); err == nil {
+		return
+	}
+	if err := utils.CopyFile(ClipseConfig.HistoryFilePath, original, 0644); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to keep the history file before upgrading it: %s", err))
+		return
+	}
+	utils.LogINFO(fmt.Sprintf("upgrading the history from schema %d to %d, the original is kept in %s", schema, HistorySchema, original))
+}
+
+// decodes the content of a history file, migrating its entries if it was
+// written with an older schema. The schema it was written with is kept in
+// the result.
+func decodeHistory(content []byte) (ClipboardHistory, error) {
+	var data ClipboardHistory
+	var header struct {
+		Schema int `json:"schema"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return data, err
+	}
+	if header.Schema >= HistorySchema {
+		err := json.Unmarshal(content, &data)
+		return data, err
+	}
+
+	var old struct {
+		ClipboardHistory []json.RawMessage `json:"clipboardHistory"`
+	}
+	if err := json.Unmarshal(content, &old); err != nil {
+		return data, err
+	}
+	items, err := migrateEntries(old.ClipboardHistory, header.Schema)
+	return ClipboardHistory{Schema: header.Schema, ClipboardHistory: items}, err
+}
+
+// decodes entries stored with schema, returning those before the first one
+// that can't be decoded along with its error
+func migrateEntries(entries []json.RawMessage, schema int) ([]ClipboardItem, error) {
+	items := make([]ClipboardItem, 0, len(entries))
+	for _, entry := range entries {
+		item, err := migrateEntry(entry, schema)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// decodes an entry stored with schema, running the migrations after it
+func migrateEntry(entry json.RawMessage, schema int) (ClipboardItem, error) {
+	var item ClipboardItem
+	if schema >= HistorySchema {
+		err := json.Unmarshal(entry, &item)
+		return item, err
+	}
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(entry))
+	dec.UseNumber() // keep counts exact through the round trip
+	if err := dec.Decode(&fields); err != nil {
+		return item, err
+	}
+	for _, m := range entryMigrations {
+		if m.version > schema && m.migrate != nil {
+			m.migrate(fields)
+		}
+	}
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return item, err
+	}
+	err = json.Unmarshal(migrated, &item)
+	return item, err
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for persisting the filter queries used in the TUI
+so they can be recalled in later sessions, similar to shell history.
+*/
+
+type SearchHistory struct {
+	Recent []string `json:"recent"`
+	Pinned []string `json:"pinned"`
+}
+
+func GetSearchHistory() SearchHistory {
+	var data SearchHistory
+
+	content, err := os.ReadFile(ClipseConfig.SearchHistoryFilePath)
+	if os.IsNotExist(err) {
+		return data
+	}
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to read search history: %s", err))
+		return data
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to parse search history: %s", err))
+	}
+
+	return data
+}
+
+// Adds the query to the front of the recent queries, removing any previous
+// occurrence and trimming the list to maxSearchHistory.
+func AddSearchQuery(query string) error {
+	if query == "" {
+		return nil
+	}
+	data := GetSearchHistory()
+
+	recent := []string{query}
+	for _, q := range data.Recent {
+		if q != query && len(recent) < maxSearchHistory {
+			recent = append(recent, q)
+		}
+	}
+	data.Recent = recent
+
+	return writeSearchHistory(data)
+}
+
+// Pins or unpins the query, returning the new pinned state.
+func TogglePinSearchQuery(query string) (bool, error) {
+	data := GetSearchHistory()
+
+	pinned := []string{}
+	for _, q := range data.Pinned {
+		if q != query {
+			pinned = append(pinned, q)
+		}
+	}
+
+	isPinned := len(pinned) == len(data.Pinned)
+	if isPinned {
+		pinned = append(pinned, query)
+	}
+	data.Pinned = pinned
+
+	return isPinned, writeSearchHistory(data)
+}
+
+// Returns the queries in recall order: recent queries newest first, followed
+// by any pinned queries that have fallen out of the recent list.
+func (sh SearchHistory) Queries() []string {
+	seen := make(map[string]bool)
+	queries := []string{}
+	for _, q := range append(sh.Recent, sh.Pinned...) {
+		if !seen[q] {
+			seen[q] = true
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+func writeSearchHistory(data SearchHistory) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := utils.WriteFileAtomic(ClipseConfig.SearchHistoryFilePath, content, 0644); err != nil {
+		return fmt.Errorf("failed writing to file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* With shards enabled the history is split by month, for keeping it
+without limit. The live history, which every copy reads and rewrites,
+keeps the entries recorded this month up to maxHistory, and the pinned
+ones. Older entries, and those over maxHistory, are moved into a file per
+month in shards.dir instead of being dropped, so day-to-day operations
+stay as fast as with a small history. Shards are only read on demand, by
+clipse search --archive, clipse shards and the digest. They are written
+under the history lock, with the schema and encryption of the json
+history file whichever storage holds the live history. maxAge and clipse
+clear apply to them too.
+*/
+
+// Shards keeps the entries from before this month in a file per month,
+// see shards.go.
+type Shards struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"`
+}
+
+// ShardInfo describes the shard of a month.
+type ShardInfo struct {
+	Month   string `json:"month"` // eg 2024-05
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+	Size    int64  `json:"size"` // bytes of the file
+}
+
+func shardsEnabled() bool {
+	return ClipseConfig.Shards.Enabled
+}
+
+func shardPath(month string) string {
+	return filepath.Join(ClipseConfig.Shards.Dir, month+shardFileExt)
+}
+
+// the month an entry is sharded into, in local time. Entries without a
+// valid timestamp stay in the live history.
+func itemMonth(item ClipboardItem) (string, bool) {
+	t, err := utils.ParseTimeStamp(item.Recorded)
+	if err != nil {
+		return "", false
+	}
+	return t.Local().Format(shardMonthLayout), true
+}
+
+// ShardOf returns the month of the shard an entry is moved into, eg
+// 2024-05.
+func ShardOf(item ClipboardItem) string {
+	month, _ := itemMonth(item)
+	return month
+}
+
+// splits off the entries to move into shards: the unpinned ones recorded
+// before this month, then the oldest unpinned ones over maxHistory
+func splitShards(items []ClipboardItem, now time.Time) (live, archived []ClipboardItem) {
+	current := now.Format(shardMonthLayout)
+	live = []ClipboardItem{}
+	for _, item := range items {
+		if month, ok := itemMonth(item); ok && month < current && !item.Pinned {
+			archived = append(archived, item)
+			continue
+		}
+		live = append(live, item)
+	}
+	for i := len(live) - 1; i >= 0 && len(live) > ClipseConfig.MaxHistory; i-- {
+		if _, ok := itemMonth(live[i]); ok && !live[i].Pinned {
+			archived = append(archived, live[i])
+			live = append(live[:i], live[i+1:]...)
+		}
+	}
+	return live, archived
+}
+
+// moves the entries of the transaction that no longer belong in the live
+// history into shards, dropping the expired ones from the shards once a
+// month passes
+func archiveOld(tx *Tx) error {
+	now := time.Now()
+	live, archived := splitShards(tx.items, now)
+	if len(archived) == 0 {
+		return nil
+	}
+	created, err := archiveItems(archived)
+	if err != nil {
+		return err
+	}
+	tx.items = live
+	if created && maxAge > 0 {
+		expired, err := pruneShards(maxAge, now)
+		if err != nil {
+			return err
+		}
+		deleteImageFiles(unusedImages(expired, live))
+	}
+	return nil
+}
+
+// adds the entries to the shards of their months, callers must hold the
+// exclusive history lock. Returns whether a shard was created.
+func archiveItems(items []ClipboardItem) (bool, error) {
+	byMonth := make(map[string][]ClipboardItem)
+	for _, item := range items {
+		month, _ := itemMonth(item)
+		byMonth[month] = append(byMonth[month], item)
+	}
+	if err := os.MkdirAll(ClipseConfig.Shards.Dir, 0755); err != nil {
+		return false, err
+	}
+
+	created := false
+	for month, added := range byMonth {
+		existing, err := readShard(month)
+		if errors.Is(err, os.ErrNotExist) {
+			created = true
+		} else if err != nil {
+			return created, err
+		}
+		if err := writeShard(month, mergeShard(existing, added)); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// the entries of both, once each, newest first
+func mergeShard(existing, added []ClipboardItem) []ClipboardItem {
+	byTimeStamp := itemsByTimeStamp(existing)
+	for _, item := range added {
+		byTimeStamp[item.Recorded] = item
+	}
+	merged := make([]ClipboardItem, 0, len(byTimeStamp))
+	for _, item := range byTimeStamp {
+		merged = append(merged, item)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Recorded > merged[j].Recorded
+	})
+	return merged
+}
+
+func readShard(month string) ([]ClipboardItem, error) {
+	data, err := readHistoryFile(shardPath(month))
+	if err != nil {
+		return nil, err
+	}
+	// rewriting a newer shard would lose the data this version doesn't know about
+	if err := checkSchema(data.Schema); err != nil {
+		return nil, fmt.Errorf("shard %s: %w", month, err)
+	}
+	return data.ClipboardHistory, nil
+}
+
+func writeShard(month string, items []ClipboardItem) error {
+	content, err := encodeHistory(ClipboardHistory{ClipboardHistory: items})
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(shardPath(month), content, 0644)
+}
+
+// the months with a shard, oldest first
+func shardMonths() ([]string, error) {
+	entries, err := os.ReadDir(ClipseConfig.Shards.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	months := []string{}
+	for _, e := range entries {
+		month, ok := strings.CutSuffix(e.Name(), shardFileExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(shardMonthLayout, month); err == nil {
+			months = append(months, month)
+		}
+	}
+	sort.Strings(months)
+	return months, nil
+}
+
+// ShardList returns the shards, oldest first.
+func ShardList() ([]ShardInfo, error) {
+	unlock, err := lockHistory(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	months, err := shardMonths()
+	if err != nil {
+		return nil, err
+	}
+	shards := []ShardInfo{}
+	for _, month := range months {
+		info := ShardInfo{Month: month, Path: shardPath(month)}
+		if stat, err := os.Stat(info.Path); err == nil {
+			info.Size = stat.Size()
+		}
+		items, err := readShard(month)
+		if err != nil {
+			return nil, err
+		}
+		info.Entries = len(items)
+		shards = append(shards, info)
+	}
+	return shards, nil
+}
+
+// ArchivedItems returns the entries in the shards recorded from from to
+// to, newest first. Zero times leave that end open, only the shards of
+// the months in between are read.
+func ArchivedItems(from, to time.Time) ([]ClipboardItem, error) {
+	unlock, err := lockHistory(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	months, err := shardMonths()
+	if err != nil {
+		return nil, err
+	}
+	items := []ClipboardItem{}
+	for i := len(months) - 1; i >= 0; i-- {
+		month := months[i]
+		if (!from.IsZero() && month < from.Local().Format(shardMonthLayout)) ||
+			(!to.IsZero() && month > to.Local().Format(shardMonthLayout)) {
+			continue
+		}
+		shard, err := readShard(month)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range shard {
+			t, err := utils.ParseTimeStamp(item.Recorded)
+			if err != nil || (!from.IsZero() && t.Before(from)) || (!to.IsZero() && t.After(to)) {
+				continue
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// rewrites the shards of the months with fn applied to their entries,
+// removing those left empty, and returns the entries fn removed. Callers
+// must hold the exclusive history lock.
+func rewriteShards(months []string, fn func([]ClipboardItem) []ClipboardItem) ([]ClipboardItem, error) {
+	removed := []ClipboardItem{}
+	for _, month := range months {
+		items, err := readShard(month)
+		if err != nil {
+			return removed, err
+		}
+		kept := fn(items)
+		if len(kept) == len(items) {
+			continue
+		}
+		keptByTimeStamp := itemsByTimeStamp(kept)
+		for _, item := range items {
+			if _, ok := keptByTimeStamp[item.Recorded]; !ok {
+				removed = append(removed, item)
+			}
+		}
+		if len(kept) == 0 {
+			err = os.Remove(shardPath(month))
+		} else {
+			err = writeShard(month, kept)
+		}
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// removes the sharded entries recorded more than age ago, reading only the
+// shards of the months before the cutoff. Callers must hold the exclusive
+// history lock.
+func pruneShards(age time.Duration, now time.Time) ([]ClipboardItem, error) {
+	if !shardsEnabled() || age <= 0 {
+		return nil, nil
+	}
+	months, err := shardMonths()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := now.Add(-age).Local().Format(shardMonthLayout)
+	old := []string{}
+	for _, month := range months {
+		if month <= cutoff {
+			old = append(old, month)
+		}
+	}
+	return rewriteShards(old, func(items []ClipboardItem) []ClipboardItem {
+		kept, _ := dropExpired(items, age, now)
+		return kept
+	})
+}
+
+// clears the shards as ClearHistory clears the live history, under the
+// exclusive history lock. Sharded entries are never pinned.
+func clearShards(clearType string) error {
+	if !shardsEnabled() {
+		return nil
+	}
+	unlock, err := lockHistory(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	months, err := shardMonths()
+	if err != nil {
+		return err
+	}
+	_, err = rewriteShards(months, func(items []ClipboardItem) []ClipboardItem {
+		switch clearType {
+		case "images":
+			return textItems(items)
+		case "text":
+			return imageItems(items)
+		}
+		return []ClipboardItem{}
+	})
+	return err
+}
+
+// rewrites plaintext shards so they are encrypted
+func encryptShards() error {
+	months, err := shardMonths()
+	if err != nil {
+		return err
+	}
+	for _, month := range months {
+		content, err := os.ReadFile(shardPath(month))
+		if err != nil {
+			return err
+		}
+		if isEncrypted(content) {
+			continue
+		}
+		items, err := readShard(month)
+		if err != nil {
+			return err
+		}
+		if err := writeShard(month, items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the bytes taken by the shards
+func shardsSize() int64 {
+	months, err := shardMonths()
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, month := range months {
+		if info, err := os.Stat(shardPath(month)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
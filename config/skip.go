@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Copies clipse makes itself that must not be recorded, eg passwords
+copied from the password store. Like the capture pause, it is a file next
+to the history holding when it expires, so it works for the wayland
+listener storing each change from a new process. Only the next clipboard
+change is skipped.
+*/
+
+func skipCopyPath() string {
+	return filepath.Join(filepath.Dir(ClipseConfig.HistoryFilePath), skipCopyFile)
+}
+
+// SkipNextCopy stops the listener recording the next clipboard change if
+// it happens within d.
+func SkipNextCopy(d time.Duration) error {
+	until := utils.FormatTime(time.Now().Add(d))
+	return utils.WriteFileAtomic(skipCopyPath(), []byte(until), 0644)
+}
+
+// CancelSkipCopy records the next change again, eg when the copy failed.
+func CancelSkipCopy() error {
+	err := os.Remove(skipCopyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// TakeSkippedCopy returns whether the change just seen must not be
+// recorded, after which the change following it is recorded again.
+func TakeSkippedCopy() bool {
+	content, err := os.ReadFile(skipCopyPath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			utils.LogERROR("failed to read skipped copy: " + err.Error())
+		}
+		return false
+	}
+	if err := CancelSkipCopy(); err != nil {
+		utils.LogERROR("failed to remove skipped copy: " + err.Error())
+	}
+	t, err := utils.ParseTimeStamp(strings.TrimSpace(string(content)))
+	return err == nil && time.Now().Before(t)
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for snippets: named values saved from the history
+that are kept in their own file, so they are never evicted by maxHistory
+or removed by the clear commands. The file is encrypted along with the
+history when encryption is enabled.
+*/
+
+var ErrSnippetName = errors.New("snippet name cannot be empty")
+
+type Snippet struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Created string `json:"created"`
+}
+
+type Snippets struct {
+	Snippets []Snippet `json:"snippets"`
+}
+
+// Returns the saved snippets sorted by name.
+func GetSnippets() []Snippet {
+	data, err := readSnippetsFile()
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to read snippets: %s", err))
+		return []Snippet{}
+	}
+	sort.Slice(data.Snippets, func(i, j int) bool {
+		return strings.ToLower(data.Snippets[i].Name) < strings.ToLower(data.Snippets[j].Name)
+	})
+	return data.Snippets
+}
+
+// Saves value as a snippet, replacing the value of any snippet with the
+// same name. Returns true if an existing snippet was replaced.
+func SaveSnippet(name, value string) (bool, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false, ErrSnippetName
+	}
+	data, err := readSnippetsFile()
+	if err != nil {
+		return false, err
+	}
+
+	for i, s := range data.Snippets {
+		if s.Name == name {
+			data.Snippets[i].Value = value
+			return true, writeSnippets(data)
+		}
+	}
+	data.Snippets = append(data.Snippets, Snippet{
+		Name:    name,
+		Value:   value,
+		Created: utils.GetTime(),
+	})
+	return false, writeSnippets(data)
+}
+
+func DeleteSnippet(name string) error {
+	data, err := readSnippetsFile()
+	if err != nil {
+		return err
+	}
+	snippets := []Snippet{}
+	for _, s := range data.Snippets {
+		if s.Name != name {
+			snippets = append(snippets, s)
+		}
+	}
+	data.Snippets = snippets
+	return writeSnippets(data)
+}
+
+func readSnippetsFile() (Snippets, error) {
+	var data Snippets
+
+	content, err := os.ReadFile(ClipseConfig.SnippetsFilePath)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return data, err
+	}
+	if isEncrypted(content) {
+		if content, err = decryptHistory(content); err != nil {
+			return data, err
+		}
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+func writeSnippets(data Snippets) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if EncryptionEnabled() {
+		if content, err = encryptHistory(content); err != nil {
+			return fmt.Errorf("failed to encrypt snippets: %w", err)
+		}
+	}
+	if err := utils.WriteFileAtomic(ClipseConfig.SnippetsFilePath, content, 0644); err != nil {
+		return fmt.Errorf("failed writing to file: %w", err)
+	}
+	return nil
+}
+
+// rewrites a plaintext snippets file so it is encrypted
+func encryptSnippets() error {
+	content, err := os.ReadFile(ClipseConfig.SnippetsFilePath)
+	if os.IsNotExist(err) || (err == nil && isEncrypted(content)) {
+		return nil
+	}
+	data, err := readSnippetsFile()
+	if err != nil {
+		return err
+	}
+	return writeSnippets(data)
+}
@@ -0,0 +1,264 @@
+package config
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite" // registers the pure Go sqlite driver
+)
+
+/* The sqlite storage keeps one row per entry, indexed by the recorded
+timestamp, which sorts by time as it is fixed width, and by a hash of the
+value so duplicates can be looked up without comparing values. A write
+only touches the rows of the entries it changed.
+*/
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS items (
+	recorded  TEXT PRIMARY KEY,
+	hash      TEXT NOT NULL,
+	value     TEXT NOT NULL,
+	file_path TEXT NOT NULL,
+	pinned    INTEGER NOT NULL,
+	sensitive INTEGER NOT NULL,
+	parent    TEXT NOT NULL,
+	transform TEXT NOT NULL,
+	tag       TEXT NOT NULL,
+	last_used TEXT NOT NULL,
+	pastes    INTEGER NOT NULL,
+	device    TEXT NOT NULL DEFAULT '',
+	html      TEXT NOT NULL DEFAULT '',
+	source    TEXT NOT NULL DEFAULT '',
+	selection TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS items_hash ON items (hash);
+`
+
+const sqliteItemColumns = `recorded, hash, value, file_path, pinned, sensitive, parent, transform, tag, last_used, pastes, device, html, source, selection`
+
+type sqliteStorage struct {
+	file string
+}
+
+// the database is opened once per process
+var sqliteDB struct {
+	sync.Mutex
+	file string
+	db   *sql.DB
+}
+
+func (s sqliteStorage) open() (*sql.DB, error) {
+	sqliteDB.Lock()
+	defer sqliteDB.Unlock()
+
+	if sqliteDB.db != nil && sqliteDB.file == s.file {
+		return sqliteDB.db, nil
+	}
+	db, err := sql.Open("sqlite", s.file+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database %s: %w", s.file, err)
+	}
+	if err := addColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade database %s: %w", s.file, err)
+	}
+	if sqliteDB.db != nil {
+		sqliteDB.db.Close()
+	}
+	sqliteDB.file, sqliteDB.db = s.file, db
+	return db, nil
+}
+
+// columns added to the items table after it was created, by the schema
+// that added them
+var sqliteAddedColumns = []struct{ name, definition string }{
+	{"device", `TEXT NOT NULL DEFAULT ''`},    // schema 3
+	{"html", `TEXT NOT NULL DEFAULT ''`},      // schema 4
+	{"source", `TEXT NOT NULL DEFAULT ''`},    // schema 5
+	{"selection", `TEXT NOT NULL DEFAULT ''`}, // schema 6
+}
+
+// adds the columns databases created with an older schema lack
+func addColumns(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('items')`)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, column := range sqliteAddedColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE items ADD COLUMN ` + column.name + ` ` + column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s sqliteStorage) path() string {
+	return s.file
+}
+
+func (s sqliteStorage) init() error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT OR IGNORE INTO meta (key, value) VALUES ('schema', ?)`, strconv.Itoa(HistorySchema))
+	return err
+}
+
+func (s sqliteStorage) load() (ClipboardHistory, error) {
+	var data ClipboardHistory
+	db, err := s.open()
+	if err != nil {
+		return data, err
+	}
+
+	var schema string
+	err = db.QueryRow(`SELECT value FROM meta WHERE key = 'schema'`).Scan(&schema)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return data, err
+	default:
+		if data.Schema, err = strconv.Atoi(schema); err != nil {
+			return data, fmt.Errorf("invalid schema version %q in %s", schema, s.file)
+		}
+	}
+
+	data.ClipboardHistory, err = queryItems(db, `SELECT `+sqliteItemColumns+` FROM items ORDER BY recorded DESC`)
+	return data, err
+}
+
+func (s sqliteStorage) loadPage(offset, limit int) ([]ClipboardItem, int, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	items, err := queryItems(db, `SELECT `+sqliteItemColumns+` FROM items ORDER BY recorded DESC LIMIT ? OFFSET ?`, limit, offset)
+	return items, total, err
+}
+
+func (s sqliteStorage) loadPinned() ([]ClipboardItem, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	return queryItems(db, `SELECT `+sqliteItemColumns+` FROM items WHERE pinned = 1 ORDER BY recorded DESC`)
+}
+
+// sensitive entries are not searched by their value, as in the TUI
+func (s sqliteStorage) find(terms []string, limit int) ([]ClipboardItem, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT ` + sqliteItemColumns + ` FROM items WHERE sensitive = 0`
+	args := []any{}
+	for _, term := range terms {
+		query += ` AND instr(lower(value), ?) > 0`
+		args = append(args, strings.ToLower(term))
+	}
+	return queryItems(db, query+` ORDER BY recorded DESC LIMIT ?`, append(args, limit)...)
+}
+
+func queryItems(db *sql.DB, query string, args ...any) ([]ClipboardItem, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ClipboardItem{}
+	for rows.Next() {
+		var item ClipboardItem
+		var hash string
+		err := rows.Scan(
+			&item.Recorded, &hash, &item.Value, &item.FilePath, &item.Pinned, &item.Sensitive,
+			&item.Parent, &item.Transform, &item.Tag, &item.LastUsed, &item.Pastes, &item.Device, &item.HTML, &item.Source, &item.Selection,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s sqliteStorage) save(items []ClipboardItem, change Change) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op once committed
+
+	for _, ts := range change.Deleted {
+		if _, err := tx.Exec(`DELETE FROM items WHERE recorded = ?`, ts); err != nil {
+			return err
+		}
+	}
+
+	changed := make(map[string]bool)
+	for _, ts := range append(change.Added, change.Updated...) {
+		changed[ts] = true
+	}
+	for _, item := range items {
+		if !changed[item.Recorded] {
+			continue
+		}
+		_, err := tx.Exec(
+			`INSERT OR REPLACE INTO items (`+sqliteItemColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			item.Recorded, valueHash(item.Value), item.Value, item.FilePath, item.Pinned, item.Sensitive,
+			item.Parent, item.Transform, item.Tag, item.LastUsed, item.Pastes, item.Device, item.HTML, item.Source, item.Selection,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO meta (key, value) VALUES ('schema', ?)`, strconv.Itoa(HistorySchema)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func valueHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
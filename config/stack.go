@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains logic for the paste stack: history items queued from the
+TUI that `clipse -pop` copies to the clipboard one at a time. Only the
+recorded timestamps are stored so values never leave the history file,
+which may be encrypted.
+*/
+
+var ErrPasteStackEmpty = utils.NewExitError(utils.ExitEmpty, "paste stack is empty")
+
+type PasteStack struct {
+	Queued []string `json:"queued"`
+}
+
+// Replaces the paste stack with the items recorded at timeStamps, in order.
+func SetPasteStack(timeStamps []string) error {
+	return writePasteStack(PasteStack{Queued: timeStamps})
+}
+
+// Removes the next queued item from the paste stack and returns it along
+// with the number of items left. Queued items deleted from the history
+// since are skipped.
+func PopPasteStack() (ClipboardItem, int, error) {
+	stack := getPasteStack()
+
+	history := make(map[string]ClipboardItem)
+	for _, item := range GetHistory() {
+		history[item.Recorded] = item
+	}
+
+	for len(stack.Queued) > 0 {
+		ts := stack.Queued[0]
+		stack.Queued = stack.Queued[1:]
+		if item, ok := history[ts]; ok {
+			return item, len(stack.Queued), writePasteStack(stack)
+		}
+	}
+
+	if err := writePasteStack(stack); err != nil {
+		return ClipboardItem{}, 0, err
+	}
+	return ClipboardItem{}, 0, ErrPasteStackEmpty
+}
+
+func getPasteStack() PasteStack {
+	var stack PasteStack
+
+	content, err := os.ReadFile(ClipseConfig.PasteStackFilePath)
+	if os.IsNotExist(err) {
+		return stack
+	}
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to read paste stack: %s", err))
+		return stack
+	}
+	if err := json.Unmarshal(content, &stack); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to parse paste stack: %s", err))
+	}
+	for i, ts := range stack.Queued {
+		stack.Queued[i] = utils.NormalizeTimeStamp(ts)
+	}
+
+	return stack
+}
+
+func writePasteStack(stack PasteStack) error {
+	content, err := json.Marshal(stack)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := utils.WriteFileAtomic(ClipseConfig.PasteStackFilePath, content, 0644); err != nil {
+		return fmt.Errorf("failed writing to file: %w", err)
+	}
+	return nil
+}
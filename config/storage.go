@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains the storages the history can be kept in, selected with
+the storage option:
+- json: the whole history in a single file, rewritten on every change.
+- sqlite: a database where only the entries that changed are written, so
+copying stays fast with histories of many thousands of entries.
+- jsonl: an append-only log, see historylog.go.
+All are accessed under the history lock, see lockHistory.
+*/
+
+const (
+	StorageJSON   = "json"
+	StorageSQLite = "sqlite"
+	StorageJSONL  = "jsonl"
+)
+
+var storageNames = []string{StorageJSON, StorageSQLite, StorageJSONL}
+
+type storage interface {
+	path() string                                    // file written on every change, polled for changes by other processes
+	init() error                                     // creates the storage if it does not exist
+	load() (ClipboardHistory, error)                 // all entries, newest first
+	save(items []ClipboardItem, change Change) error // change lists the entries changed since load
+}
+
+func historyStorage() storage {
+	return storageFor(ClipseConfig.Storage)
+}
+
+// HistoryPath returns the file the history is stored in by the configured
+// storage.
+func HistoryPath() string {
+	return historyStorage().path()
+}
+
+func storageFor(name string) storage {
+	switch name {
+	case StorageSQLite:
+		return sqliteStorage{file: ClipseConfig.DatabasePath}
+	case StorageJSONL:
+		return logStorage{file: ClipseConfig.HistoryLogPath}
+	default:
+		return jsonStorage{}
+	}
+}
+
+func validStorage(name string) bool {
+	for _, n := range storageNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func unknownStorageErr(name string) error {
+	return fmt.Errorf("unknown storage %q, must be one of %s", name, strings.Join(storageNames, ", "))
+}
+
+// Falls back to the json storage if the configured one can't be used.
+func validateStorage() {
+	switch ClipseConfig.Storage {
+	case StorageJSON:
+	case StorageSQLite, StorageJSONL:
+		if EncryptionEnabled() {
+			utils.LogWARN(fmt.Sprintf("the %s storage does not support encryption, using json", ClipseConfig.Storage))
+			ClipseConfig.Storage = StorageJSON
+		}
+	default:
+		utils.LogWARN(unknownStorageErr(ClipseConfig.Storage).Error())
+		ClipseConfig.Storage = StorageJSON
+	}
+}
+
+type jsonStorage struct{}
+
+func (jsonStorage) path() string {
+	return ClipseConfig.HistoryFilePath
+}
+
+func (jsonStorage) init() error {
+	_, err := os.Stat(ClipseConfig.HistoryFilePath) // File already exist?
+	if os.IsNotExist(err) {
+		baseConfig := ClipboardHistory{
+			Schema:           HistorySchema,
+			ClipboardHistory: []ClipboardItem{},
+		}
+
+		jsonData, err := json.MarshalIndent(baseConfig, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err = utils.WriteFileAtomic(ClipseConfig.HistoryFilePath, jsonData, 0644); err != nil {
+			utils.LogERROR(fmt.Sprintf("Failed to create %s", ClipseConfig.HistoryFilePath))
+			return err
+		}
+		return nil
+	}
+
+	if err != nil {
+		utils.LogERROR("Unable to check if history file exists. Please update binary permissions.")
+		return err
+	}
+	return nil
+}
+
+func (jsonStorage) load() (ClipboardHistory, error) {
+	data, err := readHistoryFile(ClipseConfig.HistoryFilePath)
+	if err == nil {
+		if data.Schema < HistorySchema {
+			keepOriginal(data.Schema)
+		}
+		return data, nil
+	}
+	if errors.Is(err, ErrHistoryLocked) || errors.Is(err, ErrWrongKey) {
+		// the backup is encrypted with the same key, recovering won't help
+		return data, err
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return data, err
+	}
+
+	return recoverHistory(err)
+}
+
+func (jsonStorage) save(items []ClipboardItem, _ Change) error {
+	return writeHistory(ClipboardHistory{ClipboardHistory: items})
+}
+
+var ErrHistoryCorrupt = utils.NewExitError(utils.ExitCorrupt, "history file is corrupt")
+
+var ErrUnencryptedStorage = errors.New("only the json storage supports encryption, the history would be stored in plaintext")
+
+// MigrateStorage copies the history from one storage into another,
+// replacing the entries stored there, and returns the number of entries
+// copied. The source is left as it is.
+func MigrateStorage(from, to string) (int, error) {
+	for _, name := range []string{from, to} {
+		if !validStorage(name) {
+			return 0, unknownStorageErr(name)
+		}
+	}
+	if from == to {
+		return 0, fmt.Errorf("the history is already in the %s storage", to)
+	}
+	if to != StorageJSON && (EncryptionEnabled() || HistoryEncrypted()) {
+		return 0, ErrUnencryptedStorage
+	}
+
+	unlock, err := lockHistory(true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	source, target := storageFor(from), storageFor(to)
+	if _, err := os.Stat(source.path()); err != nil {
+		return 0, fmt.Errorf("no history to migrate: %w", err)
+	}
+	data, err := source.load()
+	if err != nil {
+		return 0, err
+	}
+	// a newer history would lose the data this version doesn't know about
+	if err := checkSchema(data.Schema); err != nil {
+		return 0, err
+	}
+
+	if err := target.init(); err != nil {
+		return 0, err
+	}
+	existing, err := target.load()
+	if err != nil {
+		return 0, err
+	}
+	if err := checkSchema(existing.Schema); err != nil {
+		return 0, err
+	}
+	change := diffHistory(existing.ClipboardHistory, data.ClipboardHistory)
+	if err := target.save(data.ClipboardHistory, change); err != nil {
+		return 0, err
+	}
+	return len(data.ClipboardHistory), nil
+}
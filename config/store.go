@@ -0,0 +1,257 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains the transaction and change notification layer of the
+history store. All writes to the history go through Update so they are
+made under the exclusive lock in a single write, and everything that
+needs to follow the history (the TUI, the ipc server and sync) subscribes
+to the changes rather than watching the file.
+*/
+
+// Tx is a read-modify-write of the history made while holding the
+// exclusive lock, see Update. Entries are addressed by their recorded
+// timestamp.
+type Tx struct {
+	items []ClipboardItem
+}
+
+// Items returns the entries as modified so far, newest first.
+func (tx *Tx) Items() []ClipboardItem { return tx.items }
+
+// SetItems replaces all entries.
+func (tx *Tx) SetItems(items []ClipboardItem) { tx.items = items }
+
+// Get returns the entry recorded at timeStamp.
+func (tx *Tx) Get(timeStamp string) (ClipboardItem, bool) {
+	if index := itemIndex(tx.items, timeStamp); index >= 0 {
+		return tx.items[index], true
+	}
+	return ClipboardItem{}, false
+}
+
+// Delete removes the entries, returning those that were found.
+func (tx *Tx) Delete(timeStamps ...string) []ClipboardItem {
+	toDelete := make(map[string]bool)
+	for _, ts := range timeStamps {
+		toDelete[ts] = true
+	}
+	deleted := []ClipboardItem{}
+	remaining := []ClipboardItem{}
+	for _, item := range tx.items {
+		if toDelete[item.Recorded] {
+			deleted = append(deleted, item)
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	tx.items = remaining
+	return deleted
+}
+
+// Patch updates the entry, returning false if it is not in the history.
+func (tx *Tx) Patch(timeStamp string, patch ItemPatch) bool {
+	index := itemIndex(tx.items, timeStamp)
+	if index < 0 {
+		return false
+	}
+	tx.items[index] = patch.Apply(tx.items[index])
+	return true
+}
+
+// Restore puts a deleted entry back in place, see RestoreItem.
+func (tx *Tx) Restore(item ClipboardItem) {
+	tx.items = RestoreItem(tx.items, item)
+}
+
+// Update runs fn as a transaction: the history is read, modified by fn and
+// written once. Nothing is written if fn returns an error or makes no
+// changes. Subscribers are notified once the changes are written.
+func Update(fn func(tx *Tx) error) error {
+	return UpdateContext(context.Background(), fn)
+}
+
+// UpdateContext is Update giving up without changes if ctx is done before
+// the history is written. Waiting for the history lock also gives up after
+// storeTimeout, with ErrHistoryBusy.
+func UpdateContext(ctx context.Context, fn func(tx *Tx) error) error {
+	lockCtx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	unlock, err := lockHistoryContext(lockCtx, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data := fileContents()
+	if err := checkSchema(data.Schema); err != nil {
+		return err
+	}
+	before := data.ClipboardHistory
+	tx := &Tx{items: append([]ClipboardItem{}, before...)}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if shardsEnabled() {
+		if err := archiveOld(tx); err != nil {
+			return fmt.Errorf("failed to move entries into shards: %w", err)
+		}
+	}
+
+	change := diffHistory(before, tx.items)
+	if change.empty() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := historyStorage().save(tx.items, change); err != nil {
+		return err
+	}
+	if err := appendJournal(before, tx.items, change); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to update history journal: %s", err))
+	}
+	publish(change)
+	return nil
+}
+
+// Change is a mutation of the history. Changes made by another process,
+// eg the listener, are External and don't say which entries changed.
+type Change struct {
+	Added    []string // recorded timestamps of new entries
+	Deleted  []string
+	Updated  []string
+	External bool
+}
+
+func (c Change) empty() bool {
+	return !c.External && len(c.Added)+len(c.Deleted)+len(c.Updated) == 0
+}
+
+func diffHistory(before, after []ClipboardItem) Change {
+	var change Change
+
+	previous := make(map[string]ClipboardItem)
+	for _, item := range before {
+		previous[item.Recorded] = item
+	}
+	current := make(map[string]bool)
+	for _, item := range after {
+		current[item.Recorded] = true
+		prev, ok := previous[item.Recorded]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, item.Recorded)
+		case prev != item:
+			change.Updated = append(change.Updated, item.Recorded)
+		}
+	}
+	for _, item := range before {
+		if !current[item.Recorded] {
+			change.Deleted = append(change.Deleted, item.Recorded)
+		}
+	}
+	return change
+}
+
+var subscribers = struct {
+	sync.Mutex
+	next    int
+	chans   map[int]chan Change
+	lastMod time.Time // modification time of the history after the last change seen
+	stop    chan struct{}
+}{chans: make(map[int]chan Change)}
+
+// Subscribe returns a channel receiving the changes made to the history
+// until cancel is called. Changes made by this process are sent as they
+// are written, changes made by other processes are picked up by polling
+// the history file. A subscriber that falls behind misses changes while
+// its channel is full, it should reload the whole history on any change.
+func Subscribe() (<-chan Change, func()) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	ch := make(chan Change, changeBufferSize)
+	id := subscribers.next
+	subscribers.next++
+	subscribers.chans[id] = ch
+
+	if subscribers.stop == nil {
+		subscribers.lastMod = historyModTime()
+		subscribers.stop = make(chan struct{})
+		go pollHistory(subscribers.stop)
+	}
+
+	cancel := func() {
+		subscribers.Lock()
+		defer subscribers.Unlock()
+		if _, ok := subscribers.chans[id]; !ok {
+			return
+		}
+		delete(subscribers.chans, id)
+		close(ch)
+		if len(subscribers.chans) == 0 {
+			close(subscribers.stop)
+			subscribers.stop = nil
+		}
+	}
+	return ch, cancel
+}
+
+func publish(change Change) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	if !change.External {
+		// our own write, don't report it again when polling
+		subscribers.lastMod = historyModTime()
+	}
+	for _, ch := range subscribers.chans {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// reports writes to the history made by other processes
+func pollHistory(stop chan struct{}) {
+	ticker := time.NewTicker(changePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		modTime := historyModTime()
+		subscribers.Lock()
+		changed := modTime.After(subscribers.lastMod)
+		if changed {
+			subscribers.lastMod = modTime
+		}
+		subscribers.Unlock()
+
+		if changed {
+			publish(Change{External: true})
+		}
+	}
+}
+
+func historyModTime() time.Time {
+	info, err := os.Stat(historyStorage().path())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,808 @@
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* File contains the sync of the history and snippets between devices
+through a folder they share, eg with Syncthing or Nextcloud. Each device
+writes its own entries and its snippets to <dir>/<device>.json and reads
+the files of the other devices:
+
+- Entries copied on other devices are added to the history, marked with
+the device they came from, and are not written out again.
+- Snippets are merged against the other device's snippets seen on the
+last sync and the ones this device last published, so an edit made on one
+side is applied to the other. A snippet edited differently on both sides since, eg renamed
+to different names, is a conflict kept until it is resolved in the TUI.
+
+So sync doesn't saturate a metered connection, entries over maxEntrySize
+are left out, each device shares at most maxPayloadSize of its newest
+entries and writes at most maxBytesPerHour to the sync dir, skipping
+writes until the hour is up. Images are only synced if enabled, copied to
+<dir>/images/<device>/, and so are entries detected as secrets.
+
+Only the files of devices paired with this one are read, see peers.go.
+Each device signs its file with its peer key, and the file of a paired
+device is dropped unless its signature checks against the key remembered
+when pairing, so anyone else able to write to the shared folder can't add
+to the history.
+*/
+
+type syncPayload struct {
+	Device   string            `json:"device"`
+	Schema   int               `json:"schema"`
+	Items    []ClipboardItem   `json:"items"`
+	Snippets []Snippet         `json:"snippets"`
+	Images   map[string]string `json:"images,omitempty"` // sha256 of each image shared, by name
+}
+
+// signedPayload is the file a device writes to the sync dir.
+type signedPayload struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"` // base64 ed25519 signature of Payload by the device's peer key
+}
+
+var errBadSignature = errors.New("signature doesn't match the key the device was paired with")
+
+// kept next to the history
+type syncState struct {
+	Imported  map[string]string    `json:"imported"`  // newest recorded timestamp imported from each device
+	Snippets  map[string][]Snippet `json:"snippets"`  // snippets of each device as of the last sync
+	Conflicts []SnippetConflict    `json:"conflicts"` // waiting to be resolved
+	Window    string               `json:"window"`    // start of the hour uploads are counted in
+	Uploaded  int                  `json:"uploaded"`  // bytes written to the sync dir in the window
+}
+
+// SnippetConflict is a snippet changed differently on this device and
+// another one since they last synced. A nil side was deleted.
+type SnippetConflict struct {
+	Device string   `json:"device"`
+	Local  *Snippet `json:"local"`
+	Remote *Snippet `json:"remote"`
+}
+
+// ID returns the created timestamp identifying the snippet in conflict.
+func (c SnippetConflict) ID() string {
+	if c.Local != nil {
+		return c.Local.Created
+	}
+	return c.Remote.Created
+}
+
+type SyncResult struct {
+	Devices   int // other devices synced with
+	Imported  int // entries added to the history
+	Snippets  int // snippets added, changed or deleted
+	Conflicts int // conflicts waiting to be resolved
+}
+
+// Resolutions of a sync conflict.
+const (
+	KeepLocal  = "local"
+	KeepRemote = "remote"
+	KeepBoth   = "both"
+)
+
+func SyncEnabled() bool {
+	return ClipseConfig.Sync.Enabled
+}
+
+// Disables sync if it can't be used and names the device after the host
+// if no name is set.
+func validateSync() {
+	s := &ClipseConfig.Sync
+	if !s.Enabled {
+		return
+	}
+	if s.Dir == "" {
+		utils.LogWARN("sync is enabled but no sync dir is set, disabling it")
+		s.Enabled = false
+		return
+	}
+	if s.Device == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			utils.LogWARN(fmt.Sprintf("failed to get hostname for the sync device name, disabling sync: %s", err))
+			s.Enabled = false
+			return
+		}
+		s.Device = hostname
+	}
+	if strings.ContainsAny(s.Device, `/\`) || strings.HasPrefix(s.Device, ".") {
+		utils.LogWARN(fmt.Sprintf("invalid sync device name %q, disabling sync", s.Device))
+		s.Enabled = false
+	}
+}
+
+func syncStatePath() string {
+	return filepath.Join(filepath.Dir(ClipseConfig.HistoryFilePath), syncStateFile)
+}
+
+func syncPayloadPath(device string) string {
+	return filepath.Join(ClipseConfig.Sync.Dir, device+".json")
+}
+
+func syncImagesPath(device string) string {
+	return filepath.Join(ClipseConfig.Sync.Dir, syncImagesDir, device)
+}
+
+// SyncDevices exchanges entries and snippets with the other devices through the
+// sync dir.
+func SyncDevices() (SyncResult, error) {
+	var result SyncResult
+	if err := os.MkdirAll(ClipseConfig.Sync.Dir, 0755); err != nil {
+		return result, err
+	}
+
+	lock, err := utils.LockFile(syncStatePath()+lockFileExt, true)
+	if err != nil {
+		return result, fmt.Errorf("failed to lock sync state: %w", err)
+	}
+	defer lock.Unlock()
+
+	state, err := readSyncState()
+	if err != nil {
+		return result, err
+	}
+	remotes, err := readRemotePayloads()
+	if err != nil {
+		return result, err
+	}
+	snippets, err := readSnippetsFile()
+	if err != nil {
+		return result, err
+	}
+	published, _ := readPayload(syncPayloadPath(ClipseConfig.Sync.Device))
+
+	imported := []ClipboardItem{}
+	created := make(map[string]bool) // image files written by this sync
+	for _, remote := range remotes {
+		result.Devices++
+		for _, item := range remote.Items {
+			if item.Recorded <= state.Imported[remote.Device] {
+				continue
+			}
+			if item.FilePath != "null" {
+				if !ClipseConfig.Sync.Images {
+					continue
+				}
+				path, isNew, err := importSyncedImage(remote.Device, item.FilePath, remote.Images[filepath.Base(item.FilePath)])
+				if err != nil {
+					utils.LogWARN(fmt.Sprintf("failed to import image from %s: %s", remote.Device, err))
+					continue
+				}
+				item.FilePath = path
+				if isNew {
+					created[path] = true
+				}
+			} else if overEntrySize(len(item.Value)) {
+				continue
+			}
+			item.Device = remote.Device
+			imported = append(imported, item)
+		}
+
+		var conflicts []SnippetConflict
+		var changed int
+		snippets.Snippets, conflicts, changed = mergeSnippets(
+			snippets.Snippets, published.Snippets, state.Snippets[remote.Device], remote.Snippets, remote.Device,
+		)
+		result.Snippets += changed
+		for _, c := range conflicts {
+			state.addConflict(c)
+		}
+		state.Snippets[remote.Device] = remote.Snippets
+	}
+
+	if len(imported) > 0 {
+		if result.Imported, err = importSynced(imported, created); err != nil {
+			return result, err
+		}
+		for _, item := range imported {
+			if item.Recorded > state.Imported[item.Device] {
+				state.Imported[item.Device] = item.Recorded
+			}
+		}
+	}
+	if result.Snippets > 0 {
+		if err := writeSnippets(snippets); err != nil {
+			return result, err
+		}
+	}
+	result.Conflicts = len(state.Conflicts)
+	if err := writeLocalPayload(&state, publishedSnippets(snippets.Snippets, state.Conflicts)); err != nil {
+		return result, err
+	}
+	return result, writeSyncState(state)
+}
+
+func overEntrySize(size int) bool {
+	return ClipseConfig.Sync.MaxEntrySize > 0 && size > ClipseConfig.Sync.MaxEntrySize
+}
+
+// copies an image shared by another device to the temp dir, returning its
+// path there and whether it was written now, rather than already there
+// from an earlier sync. sum is the sha256 of the image in the device's
+// signed file.
+func importSyncedImage(device, name, sum string) (string, bool, error) {
+	dst := filepath.Join(ClipseConfig.TempDirPath, device+"-"+filepath.Base(name))
+	if content, err := os.ReadFile(dst); err == nil && imageSum(content) == sum {
+		return dst, false, nil
+	}
+
+	src := filepath.Join(syncImagesPath(device), filepath.Base(name))
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", false, err
+	}
+	if overEntrySize(int(info.Size())) {
+		return "", false, fmt.Errorf("%s is over the max entry size", name)
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return "", false, err
+	}
+	if content, err = decodeSyncBytes(content); err != nil {
+		return "", false, err
+	}
+	if imageSum(content) != sum {
+		return "", false, fmt.Errorf("%s doesn't match the image the device shared", name)
+	}
+	return dst, true, utils.WriteFileAtomic(dst, content, 0644)
+}
+
+// adds the entries from other devices that aren't in the history yet.
+// Once the history is saved, the image files in created left unused, as
+// their entry was already there, are removed.
+func importSynced(items []ClipboardItem, created map[string]bool) (int, error) {
+	var added int
+	var kept, dropped []ClipboardItem
+	err := Update(func(tx *Tx) error {
+		added, dropped = 0, []ClipboardItem{}
+		history := tx.Items()
+		for _, item := range items {
+			if duplicates, _ := duplicateItems(history, item); len(duplicates) > 0 || itemIndex(history, item.Recorded) >= 0 {
+				if created[item.FilePath] {
+					dropped = append(dropped, item)
+				}
+				continue
+			}
+			history = RestoreItem(history, item)
+			added++
+		}
+		var trimmed []ClipboardItem
+		kept, trimmed = trimHistory(history)
+		dropped = append(dropped, trimmed...)
+		tx.SetItems(kept)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleteImageFiles(unusedImages(dropped, kept))
+	return added, nil
+}
+
+// Merges the remote snippets into the local ones, keyed by their created
+// time. A side changed a snippet if it matches neither the remote version
+// seen on the last sync nor the one this device last published, which the
+// other device may have taken since. A snippet only changed remotely takes
+// the remote version, one changed on both sides is a conflict and keeps
+// its local version. Returns the merged snippets, the conflicts and the
+// number of local snippets changed.
+func mergeSnippets(local, published, base, remote []Snippet, device string) ([]Snippet, []SnippetConflict, int) {
+	localByID, publishedByID := snippetsByID(local), snippetsByID(published)
+	baseByID, remoteByID := snippetsByID(base), snippetsByID(remote)
+
+	ids := []string{}
+	seen := make(map[string]bool)
+	for _, snippets := range [][]Snippet{local, remote} {
+		for _, s := range snippets {
+			if !seen[s.Created] {
+				seen[s.Created] = true
+				ids = append(ids, s.Created)
+			}
+		}
+	}
+
+	merged := []Snippet{}
+	conflicts := []SnippetConflict{}
+	changed := 0
+	for _, id := range ids {
+		l, p, b, r := localByID[id], publishedByID[id], baseByID[id], remoteByID[id]
+		remoteChanged := !sameSnippet(r, b) && !sameSnippet(r, p)
+		localChanged := !sameSnippet(l, p) && !sameSnippet(l, b)
+		switch {
+		case !remoteChanged, sameSnippet(l, r):
+		case !localChanged:
+			l = r
+			changed++
+		default:
+			conflicts = append(conflicts, SnippetConflict{Device: device, Local: l, Remote: r})
+		}
+		if l != nil {
+			merged = append(merged, *l)
+		}
+	}
+	return merged, conflicts, changed
+}
+
+func snippetsByID(snippets []Snippet) map[string]*Snippet {
+	byID := make(map[string]*Snippet)
+	for i := range snippets {
+		byID[snippets[i].Created] = &snippets[i]
+	}
+	return byID
+}
+
+func sameSnippet(a, b *Snippet) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Name == b.Name && a.Value == b.Value
+}
+
+// Snippets in conflict are published with their remote version until
+// resolved, so the other device doesn't take the local one as an edit.
+func publishedSnippets(snippets []Snippet, conflicts []SnippetConflict) []Snippet {
+	remotes := make(map[string]*Snippet)
+	for _, c := range conflicts {
+		remotes[c.ID()] = c.Remote
+	}
+
+	published := []Snippet{}
+	for _, s := range snippets {
+		remote, inConflict := remotes[s.Created]
+		switch {
+		case !inConflict:
+			published = append(published, s)
+		case remote != nil:
+			published = append(published, *remote)
+		}
+		delete(remotes, s.Created)
+	}
+	for _, c := range conflicts {
+		if remote, left := remotes[c.ID()]; left && remote != nil {
+			published = append(published, *remote) // deleted locally
+			delete(remotes, c.ID())
+		}
+	}
+	return published
+}
+
+// replaces any earlier conflict over the same snippet with the device
+func (s *syncState) addConflict(c SnippetConflict) {
+	for i, existing := range s.Conflicts {
+		if existing.Device == c.Device && existing.ID() == c.ID() {
+			s.Conflicts[i] = c
+			return
+		}
+	}
+	s.Conflicts = append(s.Conflicts, c)
+}
+
+// SyncConflicts returns the sync conflicts waiting to be resolved.
+func SyncConflicts() []SnippetConflict {
+	state, err := readSyncState()
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to read sync state: %s", err))
+		return []SnippetConflict{}
+	}
+	return state.Conflicts
+}
+
+// ResolveConflict settles the conflict by keeping the local or remote
+// version of the snippet, or both, the remote one under a new name if
+// they share one.
+func ResolveConflict(c SnippetConflict, keep string) error {
+	lock, err := utils.LockFile(syncStatePath()+lockFileExt, true)
+	if err != nil {
+		return fmt.Errorf("failed to lock sync state: %w", err)
+	}
+	defer lock.Unlock()
+
+	state, err := readSyncState()
+	if err != nil {
+		return err
+	}
+	data, err := readSnippetsFile()
+	if err != nil {
+		return err
+	}
+
+	index := -1
+	for i, s := range data.Snippets {
+		if s.Created == c.ID() {
+			index = i
+		}
+	}
+
+	switch keep {
+	case KeepLocal:
+	case KeepRemote:
+		switch {
+		case c.Remote == nil && index >= 0:
+			data.Snippets = append(data.Snippets[:index], data.Snippets[index+1:]...)
+		case c.Remote != nil && index >= 0:
+			data.Snippets[index] = *c.Remote
+		case c.Remote != nil:
+			data.Snippets = append(data.Snippets, *c.Remote)
+		}
+	case KeepBoth:
+		if c.Remote != nil {
+			remote := *c.Remote
+			if index >= 0 {
+				// the local version keeps the snippet's identity
+				remote.Created = utils.GetTime()
+				if remote.Name == data.Snippets[index].Name {
+					remote.Name = fmt.Sprintf("%s (%s)", remote.Name, c.Device)
+				}
+			}
+			data.Snippets = append(data.Snippets, remote)
+		}
+	default:
+		return fmt.Errorf("unknown resolution %q", keep)
+	}
+
+	if err := writeSnippets(data); err != nil {
+		return err
+	}
+	conflicts := []SnippetConflict{}
+	for _, existing := range state.Conflicts {
+		if existing.Device != c.Device || existing.ID() != c.ID() {
+			conflicts = append(conflicts, existing)
+		}
+	}
+	state.Conflicts = conflicts
+	if err := writeLocalPayload(&state, publishedSnippets(data.Snippets, state.Conflicts)); err != nil {
+		return err
+	}
+	return writeSyncState(state)
+}
+
+func readSyncState() (syncState, error) {
+	state := syncState{}
+	content, err := os.ReadFile(syncStatePath())
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return state, err
+	default:
+		if err := decodeSyncFile(content, &state); err != nil {
+			return state, fmt.Errorf("failed to read sync state: %w", err)
+		}
+	}
+	if state.Imported == nil {
+		state.Imported = make(map[string]string)
+	}
+	if state.Snippets == nil {
+		state.Snippets = make(map[string][]Snippet)
+	}
+	if state.Conflicts == nil {
+		state.Conflicts = []SnippetConflict{}
+	}
+	return state, nil
+}
+
+func writeSyncState(state syncState) error {
+	content, err := encodeSyncFile(state)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(syncStatePath(), content, 0644)
+}
+
+// devices whose files were skipped for not being paired, logged once
+var unpairedLogged = make(map[string]bool)
+
+// reads the files of the paired devices in the sync dir, skipping copies
+// made by the sync tool on conflicting writes, eg laptop.sync-conflict-*.json
+func readRemotePayloads() ([]syncPayload, error) {
+	paths, err := filepath.Glob(filepath.Join(ClipseConfig.Sync.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	peers, err := PairedPeers()
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]string)
+	for _, p := range peers {
+		keys[p.Device] = p.Key
+	}
+
+	payloads := []syncPayload{}
+	for _, path := range paths {
+		device := strings.TrimSuffix(filepath.Base(path), ".json")
+		if device == ClipseConfig.Sync.Device {
+			continue
+		}
+		key, paired := keys[device]
+		if !paired {
+			if !unpairedLogged[device] && !strings.Contains(device, ".sync-conflict-") {
+				utils.LogINFO(fmt.Sprintf("not syncing with %s, it isn't paired with this device", device))
+				unpairedLogged[device] = true
+			}
+			continue
+		}
+		payload, err := readRemotePayload(path, key)
+		if err != nil {
+			utils.LogWARN(fmt.Sprintf("failed to read sync file %s: %s", path, err))
+			continue
+		}
+		if payload.Device != device {
+			continue
+		}
+		if err := checkSchema(payload.Schema); err != nil {
+			utils.LogWARN(fmt.Sprintf("not syncing with %s: %s", device, err))
+			continue
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// writes the newest entries copied on this device and the snippets to the
+// sync dir within the size limits, leaving the file alone if they haven't
+// changed or the hourly limit is reached
+func writeLocalPayload(state *syncState, snippets []Snippet) error {
+	payload := syncPayload{
+		Device:   ClipseConfig.Sync.Device,
+		Schema:   HistorySchema,
+		Items:    []ClipboardItem{},
+		Snippets: snippets,
+		Images:   make(map[string]string),
+	}
+	imagesDir := syncImagesPath(payload.Device)
+	images := make(map[string]string) // name in the sync dir to path in the temp dir
+	total := 0
+	for _, item := range GetHistory() {
+		if item.Device != "" || (item.Sensitive && !ClipseConfig.Sync.Sensitive) {
+			continue
+		}
+		size := len(item.Value)
+		if item.FilePath != "null" {
+			if !ClipseConfig.Sync.Images {
+				continue
+			}
+			info, err := os.Stat(item.FilePath)
+			if err != nil {
+				continue
+			}
+			size = int(info.Size())
+			images[filepath.Base(item.FilePath)] = item.FilePath
+			item.FilePath = filepath.Base(item.FilePath)
+		}
+		if overEntrySize(size) {
+			delete(images, item.FilePath)
+			continue
+		}
+		if limit := ClipseConfig.Sync.MaxPayloadSize; limit > 0 && total+size > limit {
+			delete(images, item.FilePath)
+			break
+		}
+		if src, ok := images[item.FilePath]; ok {
+			image, err := os.ReadFile(src)
+			if err != nil {
+				delete(images, item.FilePath)
+				continue
+			}
+			payload.Images[item.FilePath] = imageSum(image)
+		}
+		total += size
+		payload.Items = append(payload.Items, item)
+	}
+
+	path := syncPayloadPath(payload.Device)
+	if previous, err := readPayload(path); err == nil && samePayload(previous, payload) {
+		return nil
+	}
+	content, err := signPayload(payload)
+	if err != nil {
+		return err
+	}
+
+	upload := len(content)
+	newImages := []string{}
+	for name, src := range images {
+		if _, err := os.Stat(filepath.Join(imagesDir, name)); err == nil {
+			continue
+		}
+		if info, err := os.Stat(src); err == nil {
+			upload += int(info.Size())
+			newImages = append(newImages, name)
+		}
+	}
+	if !state.allowUpload(upload) {
+		utils.LogINFO("sync upload limit reached for this hour, not publishing changes yet")
+		return nil
+	}
+
+	if len(newImages) > 0 {
+		if err := os.MkdirAll(imagesDir, 0755); err != nil {
+			return err
+		}
+	}
+	for _, name := range newImages {
+		image, err := os.ReadFile(images[name])
+		if err != nil {
+			return err
+		}
+		if image, err = encodeSyncBytes(image); err != nil {
+			return err
+		}
+		if err := utils.WriteFileAtomic(filepath.Join(imagesDir, name), image, 0644); err != nil {
+			return err
+		}
+	}
+	if err := utils.WriteFileAtomic(path, content, 0644); err != nil {
+		return err
+	}
+	removeUnsharedImages(imagesDir, images)
+	return nil
+}
+
+// removes the images this device no longer shares from the sync dir
+func removeUnsharedImages(dir string, shared map[string]string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if _, ok := shared[entry.Name()]; !ok {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				utils.LogWARN(fmt.Sprintf("failed to remove synced image %s: %s", entry.Name(), err))
+			}
+		}
+	}
+}
+
+// Counts n bytes written to the sync dir against the hourly limit,
+// returning false if they would go over it. A write bigger than the limit
+// on its own is let through at the start of an hour so it isn't held back
+// forever.
+func (s *syncState) allowUpload(n int) bool {
+	limit := ClipseConfig.Sync.MaxBytesPerHour
+	if limit <= 0 {
+		return true
+	}
+	now := time.Now()
+	start, err := time.Parse(utils.TimeLayout, s.Window)
+	if err != nil || now.Sub(start) >= time.Hour {
+		s.Window = now.UTC().Format(utils.TimeLayout)
+		s.Uploaded = 0
+	}
+	if s.Uploaded > 0 && s.Uploaded+n > limit {
+		return false
+	}
+	s.Uploaded += n
+	return true
+}
+
+// the sync file of the payload, signed with this device's peer key
+func signPayload(payload syncPayload) ([]byte, error) {
+	key, err := PeerIdentity()
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return encodeSyncFile(signedPayload{
+		Payload:   content,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, content)),
+	})
+}
+
+// reads the sync file of a paired device, checking its signature against
+// the device's base64 public key
+func readRemotePayload(path, key string) (syncPayload, error) {
+	var payload syncPayload
+	signed, err := readSignedPayload(path)
+	if err != nil {
+		return payload, err
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return payload, fmt.Errorf("invalid key in %s", peersPath())
+	}
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil || !ed25519.Verify(publicKey, signed.Payload, signature) {
+		return payload, errBadSignature
+	}
+	return payload, json.Unmarshal(signed.Payload, &payload)
+}
+
+// reads the sync file this device wrote, which is trusted without checking
+// its signature, and may have been written unsigned by an older version
+func readPayload(path string) (syncPayload, error) {
+	var payload syncPayload
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return payload, err
+	}
+	if content, err = decodeSyncBytes(content); err != nil {
+		return payload, err
+	}
+	var signed signedPayload
+	if err := json.Unmarshal(content, &signed); err == nil && len(signed.Payload) > 0 {
+		content = signed.Payload
+	}
+	return payload, json.Unmarshal(content, &payload)
+}
+
+func readSignedPayload(path string) (signedPayload, error) {
+	var signed signedPayload
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return signed, err
+	}
+	if err := decodeSyncFile(content, &signed); err != nil {
+		return signed, err
+	}
+	if len(bytes.TrimSpace(signed.Payload)) == 0 || signed.Signature == "" {
+		return signed, errors.New("the file isn't signed, the device may need to update clipse")
+	}
+	return signed, nil
+}
+
+func imageSum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func samePayload(a, b syncPayload) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// sync files are encrypted along with the history
+func encodeSyncFile(v any) ([]byte, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	return encodeSyncBytes(content)
+}
+
+func decodeSyncFile(content []byte, v any) error {
+	content, err := decodeSyncBytes(content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+func encodeSyncBytes(content []byte) ([]byte, error) {
+	if !EncryptionEnabled() {
+		return content, nil
+	}
+	content, err := encryptHistory(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt sync file: %w", err)
+	}
+	return content, nil
+}
+
+func decodeSyncBytes(content []byte) ([]byte, error) {
+	if isEncrypted(content) {
+		return decryptHistory(content)
+	}
+	return content, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/savedra1/clipse/utils"
 )
@@ -35,6 +36,8 @@ type CustomTheme struct {
 	DividerDot         string `json:"DividerDot"`
 	PreviewedText      string `json:"PreviewedText"`
 	PreviewBorder      string `json:"PreviewBorder"`
+	TitleStyle         string `json:"TitleStyle"`  // comma separated: bold, italic, underline
+	BorderStyle        string `json:"BorderStyle"` // normal, rounded, thick, double or hidden
 }
 
 func GetTheme() CustomTheme {
@@ -42,7 +45,7 @@ func GetTheme() CustomTheme {
 	if os.IsNotExist(err) {
 		if err = initDefaultTheme(); err != nil {
 			utils.LogERROR(fmt.Sprintf("could not initialize theme: %s", err))
-			return defaultTheme()
+			return baseTheme()
 		}
 	}
 
@@ -62,6 +65,22 @@ func GetTheme() CustomTheme {
 		)
 	}
 	if !theme.UseCustom {
+		return baseTheme()
+	}
+	return theme
+}
+
+// the preset selected with the theme config option, or the default theme
+func baseTheme() CustomTheme {
+	if ClipseConfig.Theme == "" {
+		return defaultTheme()
+	}
+	theme, ok := PresetTheme(ClipseConfig.Theme)
+	if !ok {
+		utils.LogWARN(fmt.Sprintf(
+			"unknown theme preset %q, must be one of %s. Using the default theme",
+			ClipseConfig.Theme, strings.Join(ThemePresets(), ", "),
+		))
 		return defaultTheme()
 	}
 	return theme
@@ -120,5 +139,7 @@ func defaultTheme() CustomTheme {
 		DividerDot:         "#3498db",
 		PreviewedText:      "#ffffff",
 		PreviewBorder:      "#3498db",
+		TitleStyle:         "",
+		BorderStyle:        "normal",
 	}
 }
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/handlers"
+	"github.com/savedra1/clipse/ipc"
+	"github.com/savedra1/clipse/utils"
+)
+
+func handleAdd() {
+	var input string
+	switch {
+	case flag.NArg() == 0:
+		input = utils.GetStdin()
+	default:
+		input = flag.Arg(0)
+	}
+	addToHistory(input)
+}
+
+func handleAddCmd(args []string) {
+	var input string
+	switch {
+	case len(args) > 0:
+		input = strings.Join(args, " ")
+	case utils.StdinPiped():
+		input = utils.GetStdin()
+	default:
+		failUsage("add")
+	}
+	addToHistory(input)
+}
+
+// stores input in the history without touching the system clipboard, by
+// the listener if it is running
+func addToHistory(input string) {
+	if input == "" {
+		return
+	}
+	err := ipc.Add(input)
+	if errors.Is(err, ipc.ErrNotRunning) {
+		err = handlers.StoreText(context.Background(), input, "")
+	}
+	utils.HandleError(err)
+}
+
+// the kind of entries removed by the -clear flags
+func flagClearType() string {
+	switch {
+	case *clearImages:
+		return "images"
+	case *clearAll:
+		return "all"
+	case *clearText:
+		return "text"
+	default:
+		return "default"
+	}
+}
+
+func handleClear(clearType string) {
+	if !confirmClear(clearType) {
+		fmt.Println("Nothing was cleared.")
+		return
+	}
+
+	if err := config.CopyText(""); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to reset clipboard buffer value: %s", err))
+	}
+
+	err := ipc.Clear(clearType)
+	if errors.Is(err, ipc.ErrNotRunning) {
+		err = config.ClearHistory(clearType)
+	}
+	utils.HandleError(err)
+}
+
+// asks before clearing when run from a terminal, scripts and key bindings
+// clear straight away
+func confirmClear(clearType string) bool {
+	if !config.ConfirmEnabled(config.ConfirmClear) || *quiet || !term.IsTerminal(os.Stdin.Fd()) {
+		return true
+	}
+	question := "Clear the history, keeping pinned entries?"
+	switch clearType {
+	case "all":
+		question = "Clear the whole history, including pinned entries?"
+	case "images":
+		question = "Remove all images, including pinned ones?"
+	case "text":
+		question = "Remove all text entries, including pinned ones?"
+	}
+
+	fmt.Printf("%s [y/N, a for yes and don't ask again] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y":
+		return true
+	case "a":
+		if err := config.DisableConfirm(config.ConfirmClear); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to save confirm setting: %s", err))
+			fmt.Fprintf(os.Stderr, "Could not save don't ask again: %s\n", err)
+		}
+		return true
+	}
+	return false
+}
+
+func handleCopy() {
+	var input string
+	switch {
+	case len(os.Args) < 3:
+		input = utils.GetStdin()
+	default:
+		input = os.Args[2]
+	}
+	if input != "" {
+		fmt.Println(input)
+		utils.HandleError(config.CopyText(input))
+	}
+}
+
+func handlePaste() {
+	if flag.NArg() > 0 {
+		n, err := strconv.Atoi(flag.Arg(0))
+		if err != nil {
+			fail(utils.ExitUsage, "Invalid entry number: %s", flag.Arg(0))
+		}
+		handlePrintEntry(n)
+		return
+	}
+
+	currentItem, err := config.ReadClipboard()
+	if errors.Is(err, config.ErrNoDisplay) {
+		fail(utils.ExitFailure, "Can't read the clipboard: %s. Print history entries with %s -p <N> instead.", err, os.Args[0])
+	}
+	utils.HandleError(err)
+	if currentItem != "" {
+		fmt.Println(currentItem)
+	}
+}
+
+func handleForceClose() {
+	if len(os.Args) < 3 {
+		fail(utils.ExitUsage, "No PPID provided. Usage: %s -fc $PPID", os.Args[0])
+	}
+
+	if len(os.Args) > 3 {
+		fail(utils.ExitUsage, "Too many args. Usage: %s -fc $PPID", os.Args[0])
+	}
+
+	if !utils.IsInt(os.Args[2]) {
+		fail(utils.ExitUsage, "Invalid PPID supplied: %s\nPPID must be integer. use var `$PPID` as the arg.", os.Args[2])
+	}
+
+	launchTUI()
+}
+
+func handleOutputAll(format string) {
+	items := config.TextItems()
+
+	if format == "raw" {
+		for _, v := range items {
+			fmt.Printf("%q\n", v.Value)
+		}
+	} else if format == "unescaped" {
+		for _, v := range items {
+			fmt.Println(v.Value)
+		}
+	} else if format == "json" {
+		out, err := json.MarshalIndent(items, "", "  ")
+		utils.HandleError(err)
+		fmt.Println(string(out))
+	} else {
+		fail(utils.ExitUsage, "Invalid argument to -output-all\nSee %s --help for usage", os.Args[0])
+	}
+}
+
+func handlePrintEntry(n int) {
+	item, err := config.NthItem(n)
+	if err != nil {
+		failErr(err)
+	}
+	if item.FilePath != "null" {
+		fmt.Println(item.FilePath)
+		return
+	}
+	fmt.Println(item.Value)
+}
+
+func handleCopyEntry(n int) {
+	item, err := config.NthItem(n)
+	if err != nil {
+		failErr(err)
+	}
+	copyItem(item)
+}
+
+// copies the text/html of the Nth entry, which must have been copied with
+// formatting
+func handleCopyFormatted(n int) {
+	item, err := config.NthItem(n)
+	if err != nil {
+		failErr(err)
+	}
+	if item.HTML == "" {
+		fail(utils.ExitFailure, "Entry %d was not copied with formatting.", n)
+	}
+	if err := config.MarkUsed(item.Recorded); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to record use of entry: %s", err))
+	}
+	if err := config.CopyFormatted(item.HTML); err != nil {
+		fail(utils.ExitFailure, "Can't copy with formatting: %s", err)
+	}
+}
+
+func handlePop() {
+	item, _, err := config.PopPasteStack()
+	if errors.Is(err, config.ErrPasteStackEmpty) {
+		fail(utils.ExitEmpty, "Paste stack is empty. Select entries in the TUI and stack them first.")
+	}
+	utils.HandleError(err)
+	copyItem(item)
+}
+
+// copies the entry to the system clipboard, images by their file
+func copyItem(item config.ClipboardItem) {
+	if err := config.MarkUsed(item.Recorded); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to record use of entry: %s", err))
+	}
+	if item.FilePath != "null" {
+		utils.HandleError(config.CopyImage(item.FilePath))
+		return
+	}
+	utils.HandleError(config.CopyEntry(item.Value, item.HTML))
+}
+
+// shown in place of sensitive entries in menu listings
+const menuMasked = "•••••••• (hidden)"
+
+// prints the history for picking an entry with dmenu, rofi or wofi. Lines
+// are numbered so duplicates shortened to the same text can be told apart.
+func handleListMenu(sep string, full bool) {
+	for n, item := range config.GetHistory() {
+		fmt.Print(menuLine(n+1, item, full), sep)
+	}
+}
+
+func menuLine(n int, item config.ClipboardItem, full bool) string {
+	return fmt.Sprintf("%d %s", n, menuText(item, full))
+}
+
+func menuText(item config.ClipboardItem, full bool) string {
+	switch {
+	case item.Sensitive:
+		return menuMasked
+	case full:
+		return item.Value
+	default:
+		return utils.Shorten(item.Value)
+	}
+}
+
+// copies the entry picked from a --list-newline or --list-null listing. The
+// line is matched against the current history, falling back to the text
+// alone in case entries were added since it was listed.
+func handleSelect() {
+	line := trimMenuLine(utils.GetStdin())
+	if line == "" {
+		os.Exit(utils.ExitFailure) // nothing picked, eg the menu was closed
+	}
+
+	history := config.GetHistory()
+	for n, item := range history {
+		if line == trimMenuLine(menuLine(n+1, item, false)) || line == trimMenuLine(menuLine(n+1, item, true)) {
+			copyItem(item)
+			return
+		}
+	}
+	if _, text, ok := strings.Cut(line, " "); ok {
+		for _, item := range history {
+			if item.Sensitive {
+				continue // all masked the same, only the line number tells them apart
+			}
+			if text == trimMenuLine(menuText(item, false)) || text == trimMenuLine(menuText(item, true)) {
+				copyItem(item)
+				return
+			}
+		}
+	}
+	fail(utils.ExitNotFound, "Selected entry is no longer in the history.")
+}
+
+// menus may drop the trailing separator
+func trimMenuLine(s string) string {
+	return strings.TrimRight(s, "\n\x00")
+}
+
+func handlePipe(args []string) {
+	fs := newFlagSet("pipe")
+	tag := fs.String("tag", "", "Label stored with the entry, eg `build-log`. Find tagged entries with the tag:<name> query.")
+	copyToClipboard := fs.Bool("copy", false, "Also copy the input to the system clipboard.")
+	maxSize := fs.Int("max-size", handlers.PipeMaxSize, "Text longer than this many bytes is cut down to its end. Larger images are not stored.")
+	utils.HandleError(fs.Parse(args))
+
+	if !utils.StdinPiped() {
+		fail(utils.ExitUsage, "Nothing to store. Usage: somecmd | %s pipe [--tag <name>] [--copy]", os.Args[0])
+	}
+	input, err := io.ReadAll(os.Stdin)
+	utils.HandleError(err)
+	if len(input) == 0 {
+		return
+	}
+
+	if len(input) > *maxSize {
+		if !utf8.Valid(input) {
+			fail(
+				utils.ExitFailure, "Input is %s, larger than --max-size %s. Not stored.",
+				utils.FormatSize(int64(len(input))), utils.FormatSize(int64(*maxSize)),
+			)
+		}
+		input = handlers.TruncateText(input, *maxSize)
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Input is larger than --max-size, stored the last %s.\n", utils.FormatSize(int64(len(input))))
+		}
+	}
+
+	dt, filePath, err := handlers.StorePiped(context.Background(), input, *tag)
+	if errors.Is(err, handlers.ErrBinaryInput) {
+		fail(utils.ExitFailure, "Not stored: %s", err)
+	}
+	utils.HandleError(err)
+
+	if *copyToClipboard {
+		if dt != handlers.Text {
+			utils.HandleError(config.CopyImage(filePath))
+			return
+		}
+		utils.HandleError(config.CopyText(string(input)))
+	}
+}
@@ -0,0 +1,24 @@
+package filters
+
+import (
+	"strings"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+)
+
+// IgnoredApp reports whether copies from the window must not be recorded,
+// its class or process name being in the ignoredApps config, returning the
+// entry that matched. Names are compared case-insensitively.
+func IgnoredApp(w shell.Window) (string, bool) {
+	for _, app := range config.ClipseConfig.IgnoredApps {
+		app = strings.TrimSpace(app)
+		if app == "" {
+			continue
+		}
+		if strings.EqualFold(app, w.Class) || strings.EqualFold(app, w.Process) {
+			return app, true
+		}
+	}
+	return "", false
+}
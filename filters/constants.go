@@ -0,0 +1,15 @@
+package filters
+
+const (
+	Ignore      = "ignore"  // drop the content, nothing is stored
+	Redact      = "redact"  // mask each match
+	Replace     = "replace" // replace each match with the rule's replacement
+	redactedStr = "[REDACTED]"
+
+	entropyRuleName     = "entropy/high-entropy-token"
+	passwordManagerName = "password-manager"
+	allowRuleName       = "allowPatterns"
+
+	Mask = "mask" // store the secret but hide it in the TUI until revealed
+	Skip = "skip" // do not store the secret at all
+)
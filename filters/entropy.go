@@ -0,0 +1,90 @@
+package filters
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"unicode"
+
+	"github.com/savedra1/clipse/config"
+)
+
+// entropyRule flags tokens that look like generated secrets: long, made of
+// several character classes and with high Shannon entropy. This catches
+// API tokens and passwords that none of the regex packs know about.
+type entropyRule struct {
+	action         string
+	candidates     *regexp.Regexp
+	minEntropy     float64
+	minCharClasses int
+}
+
+func newEntropyRule(opts config.EntropyDetection) (*entropyRule, error) {
+	action := opts.Action
+	if action == "" {
+		action = Redact
+	}
+	if action != Redact && action != Ignore {
+		return nil, fmt.Errorf("unknown action %q, must be %s or %s", action, Redact, Ignore)
+	}
+	if opts.MinLength < 1 {
+		return nil, fmt.Errorf("minLength must be greater than 0")
+	}
+
+	return &entropyRule{
+		action:         action,
+		candidates:     regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/_\-.~!@#$%%^&*]{%d,}`, opts.MinLength)),
+		minEntropy:     opts.MinEntropy,
+		minCharClasses: opts.MinCharClasses,
+	}, nil
+}
+
+func (r *entropyRule) Name() string   { return entropyRuleName }
+func (r *entropyRule) Action() string { return r.action }
+
+func (r *entropyRule) Apply(value string) (string, bool) {
+	matched := false
+	updated := r.candidates.ReplaceAllStringFunc(value, func(token string) string {
+		if charClasses(token) < r.minCharClasses || shannonEntropy(token) < r.minEntropy {
+			return token
+		}
+		matched = true
+		return redactedStr
+	})
+	return updated, matched
+}
+
+// bits of entropy per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// number of classes out of lower, upper, digit and symbol used in s
+func charClasses(s string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
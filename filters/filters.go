@@ -0,0 +1,145 @@
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Capture filters run over text content before it is stored in
+the clipboard history. Each rule can ignore the content entirely,
+redact matches or transform them.
+*/
+
+type Rule interface {
+	Name() string
+	Action() string
+	// Apply returns the resulting value and whether the rule matched.
+	Apply(value string) (string, bool)
+}
+
+type Match struct {
+	Rule   string
+	Action string
+}
+
+type Result struct {
+	Value   string  // the value that would be stored
+	Ignored bool    // true if a rule dropped the content
+	Matches []Match // the rules that matched, in order
+}
+
+var (
+	rules     []Rule
+	rulesOnce sync.Once
+)
+
+// Apply runs value through the rules configured in config.json.
+func Apply(value string) Result {
+	rulesOnce.Do(func() {
+		rules = ConfiguredRules()
+	})
+	return Run(value, rules)
+}
+
+// Run passes value through each rule in order, stopping at the first
+// rule that ignores it.
+func Run(value string, rules []Rule) Result {
+	res := Result{Value: value}
+	for _, rule := range rules {
+		updated, matched := rule.Apply(res.Value)
+		if !matched {
+			continue
+		}
+		res.Matches = append(res.Matches, Match{Rule: rule.Name(), Action: rule.Action()})
+		if rule.Action() == Ignore {
+			res.Ignored = true
+			res.Value = ""
+			return res
+		}
+		res.Value = updated
+	}
+	return res
+}
+
+// ConfiguredRules returns the rules of the allowPatterns, ignorePatterns,
+// enabled redaction packs and the entropy detector followed by the
+// captureFilters from the config. Invalid rules are logged and skipped.
+func ConfiguredRules() []Rule {
+	configured := append(patternRules(), enabledPackRules()...)
+
+	if opts := config.ClipseConfig.EntropyDetection; opts.Enabled {
+		rule, err := newEntropyRule(opts)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("skipping entropy detection: %s", err))
+		} else {
+			configured = append(configured, rule)
+		}
+	}
+
+	for _, f := range config.ClipseConfig.CaptureFilters {
+		rule, err := newRegexRule(f)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("skipping capture filter %q: %s", f.Name, err))
+			continue
+		}
+		configured = append(configured, rule)
+	}
+	return configured
+}
+
+type regexRule struct {
+	name        string
+	action      string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func newRegexRule(f config.CaptureFilter) (*regexRule, error) {
+	pattern, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	replacement := f.Replacement
+	switch f.Action {
+	case Ignore, Replace:
+	case Redact:
+		if replacement == "" {
+			replacement = redactedStr
+		}
+	default:
+		return nil, fmt.Errorf("unknown action %q", f.Action)
+	}
+
+	name := f.Name
+	if name == "" {
+		name = f.Pattern
+	}
+
+	return &regexRule{
+		name:        name,
+		action:      f.Action,
+		pattern:     pattern,
+		replacement: replacement,
+	}, nil
+}
+
+func (r *regexRule) Name() string   { return r.name }
+func (r *regexRule) Action() string { return r.action }
+
+func (r *regexRule) Apply(value string) (string, bool) {
+	if !r.pattern.MatchString(value) {
+		return value, false
+	}
+	switch r.action {
+	case Redact:
+		return r.pattern.ReplaceAllLiteralString(value, r.replacement), true
+	case Replace:
+		return r.pattern.ReplaceAllString(value, r.replacement), true
+	}
+	return value, true
+}
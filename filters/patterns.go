@@ -0,0 +1,66 @@
+package filters
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* ignorePatterns and allowPatterns are shorthands for the most common
+capture filters: copies matching any ignore pattern are not stored, and
+when allow patterns are set only copies matching one of them are. They run
+before the other rules, on the content as it was copied.
+*/
+
+// allowRule ignores content matching none of its patterns.
+type allowRule struct {
+	patterns []*regexp.Regexp
+}
+
+func (r *allowRule) Name() string   { return allowRuleName }
+func (r *allowRule) Action() string { return Ignore }
+
+func (r *allowRule) Apply(value string) (string, bool) {
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(value) {
+			return value, false
+		}
+	}
+	return value, true
+}
+
+// the rules of the allowPatterns and ignorePatterns, invalid patterns are
+// logged and skipped
+func patternRules() []Rule {
+	rules := []Rule{}
+
+	if allowed := config.ClipseConfig.AllowPatterns; len(allowed) > 0 {
+		allow := &allowRule{}
+		for _, p := range allowed {
+			pattern, err := regexp.Compile(p)
+			if err != nil {
+				utils.LogERROR(fmt.Sprintf("skipping allow pattern %q: %s", p, err))
+				continue
+			}
+			allow.patterns = append(allow.patterns, pattern)
+		}
+		// an allowlist with no valid pattern would ignore every copy
+		if len(allow.patterns) > 0 {
+			rules = append(rules, allow)
+		} else {
+			utils.LogERROR("no valid allow pattern, the allowlist is disabled and every copy is recorded")
+		}
+	}
+
+	for _, p := range config.ClipseConfig.IgnorePatterns {
+		rule, err := newRegexRule(config.CaptureFilter{Pattern: p, Action: Ignore})
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("skipping ignore pattern %q: %s", p, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
@@ -0,0 +1,64 @@
+package filters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+var (
+	detectors     []Rule
+	detectorsOnce sync.Once
+)
+
+// DetectSecret reports whether value looks like a secret according to the
+// secretDetection config, returning the name of the detector that fired.
+// The password manager hint is only checked when a displayServer is given,
+// as it is read from the live system clipboard, giving up on it once ctx is
+// done.
+func DetectSecret(ctx context.Context, value, displayServer string) (string, bool) {
+	opts := config.ClipseConfig.SecretDetection
+	if !opts.Enabled {
+		return "", false
+	}
+
+	if opts.PasswordManagers && displayServer != "" && shell.PasswordManagerHint(ctx, displayServer) {
+		return passwordManagerName, true
+	}
+
+	detectorsOnce.Do(func() {
+		detectors = secretDetectors(opts)
+	})
+	for _, detector := range detectors {
+		if _, matched := detector.Apply(value); matched {
+			return detector.Name(), true
+		}
+	}
+	return "", false
+}
+
+func secretDetectors(opts config.SecretDetection) []Rule {
+	rules := []Rule{}
+	for _, name := range opts.Packs {
+		pack, ok := findPack(name)
+		if !ok {
+			utils.LogERROR(fmt.Sprintf("unknown secret detection pack %q", name))
+			continue
+		}
+		rules = append(rules, pack.Rules...)
+	}
+
+	if opts.Entropy {
+		rule, err := newEntropyRule(config.ClipseConfig.EntropyDetection)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("skipping entropy secret detection: %s", err))
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
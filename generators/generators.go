@@ -0,0 +1,127 @@
+package generators
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* Generators create new entries from nothing, eg a UUID or some random
+bytes. Some take a size, the number of bytes or words to generate.
+*/
+
+type Func func(size int) (string, error)
+
+type generator struct {
+	fn          Func
+	description string
+	size        int // default size, 0 if the generator takes none
+}
+
+var registry = map[string]generator{
+	"uuid":         {uuidV4, "random UUID, version 4", 0},
+	"unix-time":    {unixTime, "seconds since 1970", 0},
+	"unix-time-ms": {unixTimeMs, "milliseconds since 1970", 0},
+	"hex":          {randomHex, "random bytes as hex", 16},
+	"base64":       {randomBase64, "random bytes as base64", 32},
+	"lorem":        {lorem, "lorem ipsum placeholder text, in words", 50},
+}
+
+// MaxSize caps the size asked for, entries are meant to be pasted.
+const MaxSize = 1 << 16
+
+// Get returns the generator registered under name.
+func Get(name string) (Func, bool) {
+	g, ok := registry[name]
+	return g.fn, ok
+}
+
+// Names returns all registered generator names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Description returns what the generator creates.
+func Description(name string) string {
+	return registry[name].description
+}
+
+// DefaultSize returns the size used when none is given, 0 if the
+// generator takes no size.
+func DefaultSize(name string) int {
+	return registry[name].size
+}
+
+func randomBytes(n int) ([]byte, error) {
+	if n < 1 || n > MaxSize {
+		return nil, fmt.Errorf("size must be from 1 to %d", MaxSize)
+	}
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+func uuidV4(int) (string, error) {
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[:8], h[8:12], h[12:16], h[16:20], h[20:]), nil
+}
+
+func unixTime(int) (string, error) {
+	return strconv.FormatInt(time.Now().Unix(), 10), nil
+}
+
+func unixTimeMs(int) (string, error) {
+	return strconv.FormatInt(time.Now().UnixMilli(), 10), nil
+}
+
+func randomHex(size int) (string, error) {
+	b, err := randomBytes(size)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func randomBase64(size int) (string, error) {
+	b, err := randomBytes(size)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+var loremWords = strings.Fields(`lorem ipsum dolor sit amet consectetur adipiscing
+elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua ut enim ad
+minim veniam quis nostrud exercitation ullamco laboris nisi ut aliquip ex ea
+commodo consequat duis aute irure dolor in reprehenderit in voluptate velit esse
+cillum dolore eu fugiat nulla pariatur excepteur sint occaecat cupidatat non
+proident sunt in culpa qui officia deserunt mollit anim id est laborum`)
+
+// the classic passage, repeated to make up size words
+func lorem(size int) (string, error) {
+	if size < 1 || size > MaxSize {
+		return "", fmt.Errorf("size must be from 1 to %d", MaxSize)
+	}
+	words := make([]string, size)
+	for i := range words {
+		words[i] = loremWords[i%len(loremWords)]
+	}
+	text := strings.Join(words, " ")
+	return strings.ToUpper(text[:1]) + text[1:] + ".", nil
+}
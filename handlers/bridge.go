@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+/*
+In XWayland sessions some compositors keep separate X11 and wayland
+clipboards, so content copied in an X11 app cannot be pasted in a
+wayland one. With bridgeClipboards enabled the listener mirrors changes
+both ways. X11 changes are only copied to the wayland clipboard, where
+the listener records them, so each copy is recorded once.
+*/
+
+func bridgeEnabled(displayServer string) bool {
+	return config.ClipseConfig.BridgeClipboards && displayServer == "wayland" && shell.BridgeAvailable()
+}
+
+// RunBridge copies X11 clipboard changes to the wayland clipboard until
+// ctx is done.
+func RunBridge(ctx context.Context) {
+	// only changes made from now on, don't overwrite the wayland clipboard on login
+	readCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	prev, _, _ := shell.ReadX11(readCtx)
+	cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(bridgePollInterval):
+		}
+		prev = mirrorToWayland(ctx, prev)
+	}
+}
+
+// copies the X11 clipboard to the wayland clipboard if it changed from
+// prev, returning its content
+func mirrorToWayland(ctx context.Context, prev []byte) []byte {
+	ctx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+
+	data, mime, err := shell.ReadX11(ctx)
+	if err != nil || len(data) == 0 || bytes.Equal(data, prev) {
+		return prev
+	}
+	if current, _, err := shell.ReadWayland(ctx); err == nil && bytes.Equal(current, data) {
+		return data // mirrored from wayland
+	}
+	if err := shell.WriteWayland(ctx, data, mime); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to mirror X11 clipboard to wayland: %s", err))
+	}
+	return data
+}
+
+// copies a wayland clipboard change of data type dt to the X11 clipboard
+func mirrorToX11(ctx context.Context, data []byte, dt string) {
+	ctx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+
+	if current, _, err := shell.ReadX11(ctx); err == nil && bytes.Equal(current, data) {
+		return // mirrored from X11
+	}
+	mime := ""
+	switch dt {
+	case PNG:
+		mime = "image/png"
+	case JPEG, JPG:
+		mime = "image/jpeg"
+	}
+	if err := shell.WriteX11(ctx, data, mime); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to mirror wayland clipboard to X11: %s", err))
+	}
+}
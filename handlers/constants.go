@@ -6,8 +6,26 @@ const (
 	imgIcon             = "📷" // alternatives: ["🎨",  "🖼️"] // rotation based on file type?
 	defaultPollInterval = 10 * time.Millisecond
 	mediaPollInterval   = 500 * time.Millisecond
+	bridgePollInterval  = 500 * time.Millisecond
+	pbChangeInterval    = 100 * time.Millisecond // change counter checks on macOS and Windows
+	syncInterval        = 30 * time.Second       // checks for changes written by the other devices
+	syncSettleDelay     = 2 * time.Second        // after a change to the history, so a burst syncs once
+	digestInterval      = time.Hour              // storage size samples and weekly digest checks
+	echoSkipWindow      = 5 * time.Second        // for the listener to see a transformed copy put back
+	primaryPollInterval = 250 * time.Millisecond
+	primarySettleDelay  = 750 * time.Millisecond // a selection is recorded once unchanged for this long
+	digestTitle         = "clipse weekly digest"
 	Text                = "text"
 	PNG                 = "png"
 	JPEG                = "jpeg"
 	JPG                 = "jpg"
+	PipeMaxSize         = 1 << 20 // default --max-size of clipse pipe, in bytes
+)
+
+// clipse record and replay, see replay.go
+const (
+	recordPollInterval = 5 * time.Millisecond // faster than the listener to see what it misses
+	recordingVersion   = 1
+	eventHashBytes     = 8 // of the sha256 of the content kept in recorded events
+	eventError         = "error"
 )
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/ipc"
+	"github.com/savedra1/clipse/utils"
+)
+
+// serves the control socket for the listener, returning a channel closed
+// when it is asked to stop. Fails if another listener is running, a
+// listener that can't create the socket runs without it.
+func serveControl(ctx context.Context, displayServer string) (<-chan struct{}, func(), error) {
+	shutdown := make(chan struct{})
+	server, err := ipc.Listen(ipc.Daemon{
+		DisplayServer: displayServer,
+		Add:           func(value string) error { return StoreText(ctx, value, "") },
+		Shutdown:      func() { close(shutdown) },
+	})
+	switch {
+	case errors.Is(err, ipc.ErrRunning):
+		return nil, nil, err
+	case err != nil:
+		utils.LogWARN(fmt.Sprintf("running the listener without a control socket: %s", err))
+		return shutdown, func() {}, nil
+	}
+	return shutdown, server.Close, nil
+}
+
+// RunControl serves the control socket on wayland, where wl-paste runs
+// the listener as a new process for each change, and sends the digest,
+// until asked to stop or ctx is done.
+func RunControl(ctx context.Context, displayServer string) error {
+	shutdown, closeControl, err := serveControl(ctx, displayServer)
+	if err != nil {
+		return err
+	}
+	defer closeControl()
+	go RunDigest(ctx)
+	if config.RecordPrimary() && !config.NoDisplay() {
+		go RunPrimary(ctx, displayServer)
+	}
+
+	select {
+	case <-shutdown:
+	case <-ctx.Done():
+	}
+	return nil
+}
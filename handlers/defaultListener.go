@@ -1,16 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strconv"
-	"syscall"
 	"time"
 
-	"github.com/atotto/clipboard"
-
 	"github.com/savedra1/clipse/config"
 	"github.com/savedra1/clipse/shell"
 	"github.com/savedra1/clipse/utils"
@@ -27,30 +25,43 @@ runListener is essentially a while loop to be created as a system background pro
 var prevClipboardContent string // used to store clipboard content to avoid re-checking media data unnecessarily
 var dataType string             // used to determine which poll interval to use based on current clipboard data format
 
-func RunListener(displayServer string, imgEnabled bool) error {
-	// Listen for SIGINT (Ctrl+C) and SIGTERM signals to properly close the program
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+// RunListener records clipboard changes until ctx is done or the listener
+// is asked to stop through its control socket.
+func RunListener(ctx context.Context, displayServer string, imgEnabled bool) error {
+	shutdown, closeControl, err := serveControl(ctx, displayServer)
+	if err != nil {
+		return err
+	}
+	defer closeControl()
 
 	// channel to pass clipboard events to
 	clipboardData := make(chan string, 1)
 
+	bridge := bridgeEnabled(displayServer)
+	if bridge {
+		go RunBridge(ctx)
+	}
+	if config.SyncEnabled() {
+		go RunSync()
+	}
+	go RunDigest(ctx)
+
 	// Goroutine to monitor clipboard
 	go func() {
-		for {
-			input, err := clipboard.ReadAll()
-			if err != nil {
-				time.Sleep(1 * time.Second) // wait for boot
-			}
-			if input != prevClipboardContent {
-				clipboardData <- input       // Pass clipboard data to main goroutine
-				prevClipboardContent = input // update previous content
-			}
-			if dataType == Text {
-				time.Sleep(defaultPollInterval)
-				continue
-			}
-			time.Sleep(mediaPollInterval)
+		if config.NoDisplay() {
+			// the control socket and sync still work
+			utils.LogWARN("not recording copies: " + config.ErrNoDisplay.Error())
+			return
+		}
+		if config.RecordPrimary() {
+			go RunPrimary(ctx, displayServer)
+		}
+		if displayServer == "darwin" || displayServer == "windows" {
+			watchClipboard(ctx, clipboardData)
+		}
+		for ctx.Err() == nil {
+			checkClipboard(ctx, clipboardData)
+			time.Sleep(pollInterval(dataType))
 		}
 	}()
 
@@ -62,30 +73,116 @@ MainLoop:
 				continue
 			}
 			dataType = utils.DataType(input)
+			paused, _ := config.CapturePaused()
 			switch dataType {
 			case Text:
-				if err := config.AddClipboardItem(input, "null"); err != nil {
+				if bridge {
+					mirrorToX11(ctx, []byte(input), Text)
+				}
+				if paused {
+					break
+				}
+				if err := StoreText(ctx, input, displayServer); err != nil {
 					utils.LogERROR(fmt.Sprintf("failed to add new item `( %s )` | %s", input, err))
 				}
 			case PNG, JPEG:
-				if imgEnabled {
+				if imgEnabled && !paused {
+					window := activeWindow(ctx, displayServer)
+					if ignoredWindow(window) {
+						break
+					}
 					fileName := fmt.Sprintf("%s-%s.%s", strconv.Itoa(len(input)), utils.GetTimeStamp(), dataType)
 					itemTitle := fmt.Sprintf("%s %s", imgIcon, fileName)
 					filePath := filepath.Join(config.ClipseConfig.TempDirPath, fileName)
 
-					if err := shell.SaveImage(filePath, displayServer); err != nil {
+					if err := saveImage(ctx, filePath, displayServer); err != nil {
 						utils.LogERROR(fmt.Sprintf("failed to save image | %s", err))
 						break
 					}
-					if err := config.AddClipboardItem(itemTitle, filePath); err != nil {
+					if bridge {
+						if data, err := os.ReadFile(filePath); err == nil {
+							mirrorToX11(ctx, data, dataType)
+						}
+					}
+					if err := captured(config.AddClipboardItem(ctx, itemTitle, filePath, window.Name())); err != nil {
 						utils.LogERROR(fmt.Sprintf("failed to save image | %s", err))
 					}
 				}
 			}
-		case <-interrupt:
+		case <-ctx.Done():
+			break MainLoop
+		case <-shutdown:
 			break MainLoop
 		}
 	}
 
 	return nil
 }
+
+// images are polled less often as reading them is slow
+func pollInterval(dataType string) time.Duration {
+	if dataType == Text {
+		return defaultPollInterval
+	}
+	return mediaPollInterval
+}
+
+func checkClipboard(ctx context.Context, clipboardData chan<- string) {
+	readCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	input, err := shell.ReadClipboard(readCtx)
+	cancel()
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			utils.LogWARN(fmt.Sprintf("clipboard read killed after %s, the clipboard tool stopped responding", config.ClipboardTimeout))
+		}
+		time.Sleep(1 * time.Second) // wait for boot
+		return
+	}
+	if input != prevClipboardContent {
+		select {
+		case clipboardData <- input: // Pass clipboard data to main goroutine
+		case <-ctx.Done():
+			return
+		}
+		prevClipboardContent = input // update previous content
+	}
+}
+
+// the focused window, empty if it isn't found within
+// config.ClipboardTimeout
+func activeWindow(ctx context.Context, displayServer string) shell.Window {
+	ctx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+	return shell.ActiveWindow(ctx, displayServer)
+}
+
+// saves the image on the clipboard to the file, failing if it takes longer
+// than config.ClipboardTimeout
+func saveImage(ctx context.Context, imagePath, displayServer string) error {
+	ctx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+	return shell.SaveImage(ctx, imagePath, displayServer)
+}
+
+// reads the clipboard only when the macOS pasteboard changeCount or the
+// Windows clipboard sequence number changes. Returns if the watcher cannot
+// be started or stops, to fall back to polling.
+func watchClipboard(ctx context.Context, clipboardData chan<- string) {
+	changes, err := shell.WatchClipboard(pbChangeInterval)
+	if err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to watch the clipboard, polling it instead: %s", err))
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				utils.LogWARN("clipboard watcher stopped, polling the clipboard instead")
+				return
+			}
+			checkClipboard(ctx, clipboardData)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// RunDigest samples the storage size for clipse digest every
+// digestInterval until ctx is done, and with weeklyDigest set sends the
+// digest of the past week as a notification once one is due.
+func RunDigest(ctx context.Context) {
+	ticker := time.NewTicker(digestInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		if err := config.SampleStorageSize(now); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to sample the storage size: %s", err))
+		}
+		if config.ClipseConfig.WeeklyDigest {
+			notifyDigest(ctx, now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func notifyDigest(ctx context.Context, now time.Time) {
+	due, err := config.DigestDue(now)
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to check the weekly digest: %s", err))
+	}
+	if !due {
+		return
+	}
+
+	report := config.BuildDigest(now.Add(-config.DigestPeriod), now)
+	notifyCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+	if err := shell.Notify(notifyCtx, digestTitle, report.Summary()); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to send the weekly digest: %s", err))
+		return
+	}
+	if err := config.DigestSent(now); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to save when the weekly digest was sent: %s", err))
+	}
+}
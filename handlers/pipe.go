@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+var ErrBinaryInput = errors.New("input is neither text nor a PNG or JPEG image")
+
+// StorePiped stores command output read from the stdin as a history entry
+// labelled with tag, see `clipse pipe`. Images are saved to the temp dir
+// like copied images, text goes through the capture filters. Returns the
+// detected data type and the image file path, "null" for text.
+func StorePiped(ctx context.Context, input []byte, tag string) (string, string, error) {
+	dt := detectType(input)
+	if dt == Text {
+		if !utf8.Valid(input) || bytes.IndexByte(input, 0) >= 0 {
+			return dt, "null", ErrBinaryInput
+		}
+		return dt, "null", storeText(ctx, string(input), "", tag, "")
+	}
+
+	fileName := fmt.Sprintf("%s.%s", utils.GetTimeStamp(), dt)
+	filePath := filepath.Join(config.ClipseConfig.TempDirPath, fileName)
+	if err := os.WriteFile(filePath, input, 0644); err != nil {
+		return dt, "", fmt.Errorf("failed to create img file: %w", err)
+	}
+	updatedFileName, updatedFilePath, err := renameImgFile(filePath, fileName, dt)
+	if err != nil {
+		return dt, "", fmt.Errorf("failed to rename new image file: %w", err)
+	}
+
+	itemTitle := fmt.Sprintf("%s %s", imgIcon, updatedFileName)
+	_, err = config.AddTaggedItem(ctx, itemTitle, updatedFilePath, tag, "", false)
+	return dt, updatedFilePath, err
+}
+
+// TruncateText keeps the last maxSize bytes of the text, the end of command
+// output being the most useful part, without splitting a character.
+func TruncateText(input []byte, maxSize int) []byte {
+	if len(input) <= maxSize {
+		return input
+	}
+	tail := input[len(input)-maxSize:]
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	return tail
+}
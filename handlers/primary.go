@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// RunPrimary records the primary selection until ctx is done, see
+// config/primary.go. A selection is recorded once it stays unchanged for
+// primarySettleDelay, so dragging over text doesn't record every step of
+// it.
+func RunPrimary(ctx context.Context, displayServer string) {
+	ticker := time.NewTicker(primaryPollInterval)
+	defer ticker.Stop()
+
+	var recorded, pending string
+	var changed time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+		value, err := shell.ReadPrimary(readCtx)
+		cancel()
+		switch {
+		case errors.Is(err, shell.ErrNoPrimary):
+			utils.LogWARN("not recording the primary selection: " + err.Error())
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			utils.LogWARN(fmt.Sprintf("primary selection read killed after %s, the clipboard tool stopped responding", config.ClipboardTimeout))
+			continue
+		case err != nil:
+			continue // nothing selected
+		}
+
+		if value != pending {
+			pending, changed = value, time.Now()
+			continue
+		}
+		if pending == recorded || time.Since(changed) < primarySettleDelay {
+			continue
+		}
+		recorded = pending
+		if paused, _ := config.CapturePaused(); paused || strings.TrimSpace(pending) == "" || isLatest(pending) {
+			continue
+		}
+		if err := storeText(ctx, pending, displayServer, "", config.SelectionPrimary); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to add the primary selection | %s", err))
+		}
+	}
+}
+
+// reports whether text is the newest entry, eg selected and then copied,
+// or copied out of the history onto the primary selection
+func isLatest(text string) bool {
+	item, err := config.NthItem(1)
+	return err == nil && item.Value == text
+}
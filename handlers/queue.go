@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// ServePasteQueue copies the entries on the paste stack in turn, each once
+// the one before has been pasted, until the stack is empty or something
+// else is copied. The last entry stays on the clipboard. Wayland only, see
+// shell.PasteOnce.
+func ServePasteQueue() {
+	shell.StopWLWatchers()
+	defer func() {
+		// also when a queue replaced by this one had stopped them
+		if shell.WaylandListenerRunning() {
+			shell.StartWLWatchers()
+		}
+	}()
+
+	for {
+		item, remaining, err := config.PopPasteStack()
+		if errors.Is(err, config.ErrPasteStackEmpty) {
+			return
+		}
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to read paste stack: %s", err))
+			return
+		}
+		data, mime, err := queuedContent(item)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to read queued image: %s", err))
+			continue
+		}
+		if err := config.MarkUsed(item.Recorded); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to record use of entry: %s", err))
+		}
+
+		if remaining == 0 {
+			ctx, cancel := context.WithTimeout(context.Background(), config.ClipboardTimeout)
+			err := shell.WriteWayland(ctx, data, mime)
+			cancel()
+			if err != nil {
+				utils.LogERROR(fmt.Sprintf("failed to copy last queued entry: %s", err))
+			}
+			return
+		}
+		pasted, err := shell.PasteOnce(data, mime)
+		if err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to copy queued entry: %s", err))
+			return
+		}
+		if !pasted {
+			utils.LogINFO(fmt.Sprintf("paste queue stopped by a new copy with %d entries left, run clipse -pop for the next", remaining))
+			return
+		}
+	}
+}
+
+// the content of an entry and its mime type, empty for text
+func queuedContent(item config.ClipboardItem) ([]byte, string, error) {
+	if item.FilePath == "null" {
+		return []byte(item.Value), "", nil
+	}
+	data, err := os.ReadFile(item.FilePath)
+	switch strings.TrimPrefix(filepath.Ext(item.FilePath), ".") {
+	case JPEG, JPG:
+		return data, "image/jpeg", err
+	default:
+		return data, "image/png", err
+	}
+}
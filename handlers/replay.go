@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/filters"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Clipboard event traces for bug reports about missed captures. clipse
+record reads the clipboard far more often than the listener and writes each
+change as a line of JSON: when it happened, its type, size and a hash, and
+with --raw the content itself. clipse replay runs a trace against a fake
+clipboard that returns the recorded content at the recorded times, reading
+it the way the listener does on the recorded display server, and reports
+which changes the listener would have stored, missed or ignored. Nothing
+is written to the history.
+*/
+
+// Recording is the first line of a trace.
+type Recording struct {
+	Version       int    `json:"version"`
+	DisplayServer string `json:"displayServer"`
+	Started       string `json:"started"`
+	Raw           bool   `json:"raw"` // whether the events carry their content
+}
+
+// CaptureEvent is a change of the clipboard, or a failure to read it.
+type CaptureEvent struct {
+	Offset int64  `json:"offsetMs"` // since the recording started
+	Type   string `json:"type"`     // text, png, jpg or error
+	Size   int    `json:"size"`
+	Hash   string `json:"hash,omitempty"` // tells repeated copies apart without their content
+	Data   string `json:"data,omitempty"` // base64 content, recorded with --raw
+	Error  string `json:"error,omitempty"`
+}
+
+// RecordEvents writes the clipboard changes to w until ctx is done, with
+// their content if raw is set.
+func RecordEvents(ctx context.Context, w io.Writer, displayServer string, raw bool) error {
+	if config.NoDisplay() {
+		return config.ErrNoDisplay
+	}
+
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+
+	start := time.Now()
+	err := enc.Encode(Recording{
+		Version:       recordingVersion,
+		DisplayServer: displayServer,
+		Started:       utils.FormatTime(start),
+		Raw:           raw,
+	})
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	var prev, prevErr string
+	ticker := time.NewTicker(recordPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+		input, err := shell.ReadClipboard(readCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		event := CaptureEvent{Offset: time.Since(start).Milliseconds()}
+		switch {
+		case err != nil:
+			if err.Error() == prevErr {
+				continue
+			}
+			prevErr = err.Error()
+			event.Type, event.Error = eventError, prevErr
+		case input == prev:
+			continue
+		default:
+			prev, prevErr = input, ""
+			sum := sha256.Sum256([]byte(input))
+			event.Type = utils.DataType(input)
+			event.Size = len(input)
+			event.Hash = hex.EncodeToString(sum[:eventHashBytes])
+			if raw {
+				event.Data = base64.StdEncoding.EncodeToString([]byte(input))
+			}
+		}
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+		// keep the trace usable if the recording is killed
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadRecording reads a trace written by RecordEvents.
+func ReadRecording(path string) (Recording, []CaptureEvent, error) {
+	var rec Recording
+	file, err := os.Open(path)
+	if err != nil {
+		return rec, nil, err
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	if err := dec.Decode(&rec); err != nil {
+		return rec, nil, fmt.Errorf("not a clipse recording: %w", err)
+	}
+	if rec.Version != recordingVersion {
+		return rec, nil, fmt.Errorf("unsupported recording version %d", rec.Version)
+	}
+	events := []CaptureEvent{}
+	for {
+		var event CaptureEvent
+		err := dec.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			return rec, events, nil
+		}
+		if err != nil {
+			// a recording killed mid write ends with a partial line
+			utils.LogWARN(fmt.Sprintf("recording %s ends with an invalid event: %s", path, err))
+			return rec, events, nil
+		}
+		events = append(events, event)
+	}
+}
+
+// ReplayResult is what the listener would have done with an event.
+type ReplayResult struct {
+	Event   CaptureEvent
+	Outcome string // one of the Replay outcomes
+	Detail  string // eg the filter that ignored the content
+}
+
+// Outcomes of replayed events.
+const (
+	ReplayStored   = "stored"
+	ReplayRepeated = "duplicate" // same content as an earlier capture
+	ReplayMissed   = "missed"    // not seen by the listener
+	ReplayIgnored  = "ignored"   // dropped by the capture filters
+	ReplayError    = "read failed"
+)
+
+// ReplayEvents reads the recorded clipboard the way the listener does on
+// the recording's display server. Time is simulated, so the result is the
+// same on every run and doesn't depend on the machine replaying it.
+func ReplayEvents(rec Recording, events []CaptureEvent) []ReplayResult {
+	results := []ReplayResult{}
+	reads := listenerReads(rec.DisplayServer, events)
+	seen := make(map[string]bool)
+	lastRead := ""
+
+	for i, event := range events {
+		result := ReplayResult{Event: event}
+		switch {
+		case event.Type == eventError:
+			result.Outcome, result.Detail = ReplayError, event.Error
+		case !reads[i]:
+			result.Outcome = ReplayMissed
+			if next, ok := nextChange(events, i); ok {
+				result.Detail = fmt.Sprintf("replaced %dms later, before the listener read the clipboard", next.Offset-event.Offset)
+			}
+		case event.Hash == lastRead:
+			// changed back to what the listener read last, eg A B A with B missed
+			result.Outcome, result.Detail = ReplayMissed, "same as the last content the listener read"
+		default:
+			lastRead = event.Hash
+			result.Outcome = ReplayStored
+			if rule, ignored := replayFilters(rec, event); ignored {
+				result.Outcome, result.Detail = ReplayIgnored, rule
+			} else if seen[event.Hash] && !config.ClipseConfig.AllowDuplicates {
+				result.Outcome = ReplayRepeated
+			}
+			seen[event.Hash] = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// reports, by event index, which changes the listener reads. wl-paste
+// --watch hands over every change, the change counters on macOS and
+// Windows are checked at a fixed interval and other display servers are
+// polled, less often after an image.
+func listenerReads(displayServer string, events []CaptureEvent) map[int]bool {
+	reads := make(map[int]bool)
+	if displayServer == "wayland" {
+		for i := range events {
+			reads[i] = true
+		}
+		return reads
+	}
+
+	var readAt time.Duration
+	current := -1    // the change on the clipboard at readAt
+	dataType := Text // assumes the listener was running before with text copied
+	for i, event := range events {
+		if event.Type == eventError {
+			continue
+		}
+		at := time.Duration(event.Offset) * time.Millisecond
+		for readAt < at {
+			if current >= 0 && !reads[current] {
+				reads[current] = true
+				dataType = events[current].Type
+			}
+			switch displayServer {
+			case "darwin", "windows":
+				readAt += pbChangeInterval
+			default:
+				readAt += pollInterval(dataType)
+			}
+		}
+		current = i
+	}
+	if current >= 0 {
+		reads[current] = true // read once the recording has ended
+	}
+	return reads
+}
+
+func nextChange(events []CaptureEvent, i int) (CaptureEvent, bool) {
+	for _, event := range events[i+1:] {
+		if event.Type != eventError {
+			return event, true
+		}
+	}
+	return CaptureEvent{}, false
+}
+
+// runs recorded text through the capture filters, which needs its content
+func replayFilters(rec Recording, event CaptureEvent) (string, bool) {
+	if !rec.Raw || event.Type != Text {
+		return "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(event.Data)
+	if err != nil {
+		return "", false
+	}
+	res := filters.Apply(string(data))
+	if !res.Ignored {
+		return "", false
+	}
+	return res.Matches[len(res.Matches)-1].Rule, true
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/filters"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// StoreText runs captured text through the capture filters and secret
+// detection before adding it to the history, with the application it was
+// copied from. displayServer may be empty when the text did not come from
+// the system clipboard. Gives up without storing the text once ctx is done.
+func StoreText(ctx context.Context, input, displayServer string) error {
+	return storeText(ctx, input, displayServer, "", "")
+}
+
+// selection is config.SelectionPrimary for text from the primary selection,
+// which is never skipped, transformed or mirrored as copies are.
+func storeText(ctx context.Context, input, displayServer, tag, selection string) error {
+	primary := selection == config.SelectionPrimary
+	if displayServer != "" && !primary && config.TakeSkippedCopy() {
+		utils.LogINFO("skipped copy made by clipse that must not be recorded")
+		return nil
+	}
+
+	source := ""
+	if displayServer != "" {
+		window := activeWindow(ctx, displayServer)
+		if ignoredWindow(window) {
+			return nil
+		}
+		source = window.Name()
+	}
+
+	res := filters.Apply(input)
+	if res.Ignored {
+		return nil
+	}
+	var transformed []string
+	if displayServer != "" && !primary {
+		res.Value, transformed = config.ApplyCaptureTransforms(res.Value)
+	}
+	value, keep, reason := config.LimitEntrySize(res.Value)
+	if !keep {
+		utils.LogINFO(fmt.Sprintf("skipped copy of %s", reason))
+		return nil
+	}
+	res.Value = value
+
+	// only copies the listener took from the clipboard run the onCapture
+	// hook, not eg the output of clipse pipe
+	recorded := func(item config.ClipboardItem, err error) error {
+		if displayServer == "" {
+			return err
+		}
+		return captured(item, err)
+	}
+
+	hintCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	detector, isSecret := filters.DetectSecret(hintCtx, res.Value, displayServer)
+	cancel()
+	if primary {
+		if isSecret && config.ClipseConfig.SecretDetection.Action == filters.Skip {
+			utils.LogINFO(fmt.Sprintf("skipped likely secret detected by %s", detector))
+			return nil
+		}
+		return captured(config.AddPrimaryItem(ctx, res.Value, source, isSecret))
+	}
+	if !isSecret {
+		var item config.ClipboardItem
+		var err error
+		if html := copiedHTML(ctx, input, res.Value, displayServer); html != "" {
+			item, err = config.AddFormattedItem(ctx, res.Value, html, source)
+		} else {
+			item, err = config.AddTaggedItem(ctx, res.Value, "null", tag, source, false)
+		}
+		if err := recorded(item, err); err != nil {
+			return err
+		}
+		mirrorToTmux(res.Value, displayServer)
+		echoTransformed(ctx, res.Value, transformed)
+		return nil
+	}
+
+	if config.ClipseConfig.SecretDetection.Action == filters.Skip {
+		utils.LogINFO(fmt.Sprintf("skipped likely secret detected by %s", detector))
+		return nil
+	}
+	if err := recorded(config.AddTaggedItem(ctx, res.Value, "null", tag, source, true)); err != nil {
+		return err
+	}
+	echoTransformed(ctx, res.Value, transformed)
+	return nil
+}
+
+// runs the onCapture hook on an entry the listener recorded, unless it was
+// dropped as a duplicate
+func captured(item config.ClipboardItem, err error) error {
+	if err == nil && item.Recorded != "" {
+		config.RunHook(config.HookCapture, item)
+	}
+	return err
+}
+
+// the text/html the copy was made with, only kept when the text is stored
+// as copied, so the markup can't bring back what the filters removed
+func copiedHTML(ctx context.Context, input, value, displayServer string) string {
+	if displayServer == "" || value != input {
+		return ""
+	}
+	readCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+	html, err := shell.ReadHTML(readCtx, displayServer)
+	if err != nil {
+		if !errors.Is(err, shell.ErrHTMLUnsupported) {
+			utils.LogWARN(fmt.Sprintf("storing the copy without its formatting: %s", err))
+		}
+		return ""
+	}
+	if maxSize := config.ClipseConfig.EntrySize.MaxSize; maxSize > 0 && len(html) > maxSize {
+		return "" // cut short it would no longer be valid markup
+	}
+	return html
+}
+
+// puts a copy the capture transforms changed back on the clipboard as it
+// was stored, skipping the change that makes
+func echoTransformed(ctx context.Context, value string, transformed []string) {
+	if len(transformed) == 0 {
+		return
+	}
+	utils.LogINFO(fmt.Sprintf("applied capture transforms %s", strings.Join(transformed, ", ")))
+	if err := config.SkipNextCopy(echoSkipWindow); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to skip the transformed copy: %s", err))
+		return
+	}
+	writeCtx, cancel := context.WithTimeout(ctx, config.ClipboardTimeout)
+	defer cancel()
+	if err := shell.WriteClipboard(writeCtx, value); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to put the transformed copy on the clipboard: %s", err))
+		if err := config.CancelSkipCopy(); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to remove skipped copy: %s", err))
+		}
+	}
+}
+
+// loads a captured copy into a tmux paste buffer when tmuxBuffers is set.
+// Secrets are never mirrored.
+func mirrorToTmux(value, displayServer string) {
+	if displayServer == "" || !config.ClipseConfig.TmuxBuffers {
+		return
+	}
+	if err := shell.LoadTmuxBuffer(value); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to mirror copy into tmux: %s", err))
+	}
+}
+
+// reports whether copies from the window are not recorded, see
+// filters.IgnoredApp
+func ignoredWindow(w shell.Window) bool {
+	app, ignored := filters.IgnoredApp(w)
+	if ignored {
+		utils.LogINFO(fmt.Sprintf("skipped copy from ignored app %s", app))
+	}
+	return ignored
+}
+
+// returns the type of clipboard data, Text unless it is a PNG or JPEG image
+func detectType(input []byte) string {
+	switch {
+	case len(input) > 3 && input[0] == 0x89 && string(input[1:4]) == "PNG":
+		return PNG
+	case len(input) > 10 && string(input[6:10]) == "JFIF":
+		return JPEG
+	default:
+		return Text
+	}
+}
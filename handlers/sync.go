@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// RunSync syncs the history and snippets with the other devices until the
+// process exits: shortly after each change to the history, so copies
+// reach them straight away, and every syncInterval to take in what the
+// other devices wrote to the sync dir.
+func RunSync() {
+	changes, cancel := config.Subscribe()
+	defer cancel()
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	conflicts := 0
+	for {
+		result, err := config.SyncDevices()
+		switch {
+		case err != nil:
+			utils.LogERROR(fmt.Sprintf("failed to sync: %s", err))
+		case result.Conflicts > conflicts:
+			utils.LogWARN(fmt.Sprintf("%d sync conflicts waiting, run `clipse sync` to resolve them", result.Conflicts))
+		}
+		if err == nil {
+			conflicts = result.Conflicts
+		}
+
+		select {
+		case <-changes:
+			time.Sleep(syncSettleDelay)
+			drainChanges(changes)
+		case <-ticker.C:
+		}
+	}
+}
+
+// drops the changes already waiting, the next sync covers them
+func drainChanges(changes <-chan config.Change) {
+	for {
+		select {
+		case <-changes:
+		default:
+			return
+		}
+	}
+}
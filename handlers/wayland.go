@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -13,22 +14,22 @@ import (
 	"strings"
 
 	"github.com/savedra1/clipse/config"
-	"github.com/savedra1/clipse/shell"
 	"github.com/savedra1/clipse/utils"
 )
 
-func StoreWLData() {
+func StoreWLData(ctx context.Context) {
 	input, err := io.ReadAll(os.Stdin)
 	if err != nil {
 		utils.LogERROR(fmt.Sprintf("failed to read stdin: %s", err))
 		return
 	}
 
-	dt := Text
-	if len(input) > 0 && input[0] == 0x89 && string(input[1:4]) == "PNG" {
-		dt = PNG
-	} else if len(input) > 10 && string(input[6:10]) == "JFIF" {
-		dt = JPEG
+	dt := detectType(input)
+	if bridgeEnabled("wayland") {
+		mirrorToX11(ctx, input, dt)
+	}
+	if paused, _ := config.CapturePaused(); paused {
+		return
 	}
 
 	switch dt {
@@ -37,7 +38,7 @@ func StoreWLData() {
 		if inputStr == "" {
 			return
 		}
-		if err := config.AddClipboardItem(inputStr, "null"); err != nil {
+		if err := StoreText(ctx, inputStr, "wayland"); err != nil {
 			utils.LogERROR(fmt.Sprintf("failed to add new item `( %s )` | %s", input, err))
 		}
 
@@ -53,6 +54,11 @@ func StoreWLData() {
 			as non-wayland specific data.
 		*/
 
+		window := activeWindow(ctx, "wayland")
+		if ignoredWindow(window) {
+			return
+		}
+
 		fileName := fmt.Sprintf("%s.%s", utils.GetTimeStamp(), "png")
 		filePath := filepath.Join(config.ClipseConfig.TempDirPath, fileName)
 
@@ -65,7 +71,7 @@ func StoreWLData() {
 				the image is then created using `wl-paste -t image/png <path>`
 			*/
 
-			if err = shell.SaveImage(filePath, "wayland"); err != nil {
+			if err = saveImage(ctx, filePath, "wayland"); err != nil {
 				utils.LogERROR(fmt.Sprintf("failed to save new image: %s", err))
 				return
 			}
@@ -78,7 +84,7 @@ func StoreWLData() {
 
 			itemTitle := fmt.Sprintf("%s %s", imgIcon, updatedFileName)
 
-			if err := config.AddClipboardItem(itemTitle, updatedFilePath); err != nil {
+			if err := captured(config.AddClipboardItem(ctx, itemTitle, updatedFilePath, window.Name())); err != nil {
 				utils.LogERROR(fmt.Sprintf("failed to save image | %s", err))
 			}
 
@@ -117,7 +123,7 @@ func StoreWLData() {
 
 		itemTitle := fmt.Sprintf("%s %s", imgIcon, updatedFileName)
 
-		if err := config.AddClipboardItem(itemTitle, updatedFilePath); err != nil {
+		if err := captured(config.AddClipboardItem(ctx, itemTitle, updatedFilePath, window.Name())); err != nil {
 			utils.LogERROR(fmt.Sprintf("failed to save image | %s", err))
 		}
 	}
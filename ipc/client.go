@@ -0,0 +1,186 @@
+package ipc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// ErrNotRunning is returned by the client calls when no listener serves
+// the control socket, the caller should then do the work itself.
+var ErrNotRunning = utils.NewExitError(utils.ExitNoDaemon, "no clipse listener is running")
+
+func dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("unix", socketPath(), dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	return conn, nil
+}
+
+func send(req Request) (Response, error) {
+	conn, err := dial()
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Running reports whether a listener serves the control socket.
+func Running() bool {
+	conn, err := dial()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// GetStatus returns the status of the running listener.
+func GetStatus() (Status, error) {
+	resp, err := send(Request{Command: CmdStatus})
+	if err != nil || resp.Status == nil {
+		return Status{}, err
+	}
+	return *resp.Status, nil
+}
+
+// Latest returns the most recent entry, nil if the history is empty, with
+// the status of the listener. It doesn't read the history so is fast enough
+// to run from a shell prompt.
+func Latest() (*config.ClipboardItem, Status, error) {
+	resp, err := send(Request{Command: CmdLatest})
+	if err != nil || resp.Status == nil {
+		return nil, Status{}, err
+	}
+	return resp.Latest, *resp.Status, nil
+}
+
+// Pause asks the listener to stop recording for d, or until resumed if d
+// is 0.
+func Pause(d time.Duration) error {
+	req := Request{Command: CmdPause}
+	if d > 0 {
+		req.Duration = d.String()
+	}
+	_, err := send(req)
+	return err
+}
+
+// Resume asks the listener to restart recording, returning false if it
+// wasn't paused.
+func Resume() (bool, error) {
+	resp, err := send(Request{Command: CmdResume})
+	return resp.Changed, err
+}
+
+// Add has the listener store text as if it was copied.
+func Add(value string) error {
+	_, err := send(Request{Command: CmdAdd, Value: value})
+	return err
+}
+
+// Clear has the listener clear the history, see config.ClearHistory.
+func Clear(clearType string) error {
+	_, err := send(Request{Command: CmdClear, Value: clearType})
+	return err
+}
+
+// Shutdown stops the listener, waiting for it to release the socket.
+func Shutdown() error {
+	if _, err := send(Request{Command: CmdShutdown}); err != nil {
+		return err
+	}
+	for deadline := time.Now().Add(shutdownTimeout); time.Now().Before(deadline); {
+		if !Running() {
+			return nil
+		}
+		time.Sleep(shutdownPoll)
+	}
+	return errors.New("the listener did not stop in time")
+}
+
+// Subscribe returns a channel receiving the events of the listener until
+// cancel is called. The channel is closed if the listener stops.
+func Subscribe() (<-chan string, func(), error) {
+	return subscribe(func(resp Response) (string, bool) {
+		return resp.Event, resp.Event != "" && resp.Event != EventCapture
+	})
+}
+
+// Captures returns a channel receiving the entries the listener records,
+// or anyone else adds as the latest, until cancel is called. The channel
+// is closed if the listener stops.
+func Captures() (<-chan config.ClipboardItem, func(), error) {
+	return subscribe(func(resp Response) (config.ClipboardItem, bool) {
+		if resp.Event != EventCapture || resp.Entry == nil {
+			return config.ClipboardItem{}, false
+		}
+		return *resp.Entry, true
+	})
+}
+
+// subscribes to the events of the listener, sending those pick keeps
+func subscribe[T any](pick func(Response) (T, bool)) (<-chan T, func(), error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+	if err := json.NewEncoder(conn).Encode(Request{Command: CmdSubscribe}); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	var resp Response
+	line, err := reader.ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &resp)
+	}
+	if err == nil && !resp.OK {
+		err = errors.New(resp.Error)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	events := make(chan T)
+	go func() {
+		defer close(events)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			var resp Response
+			if json.Unmarshal(line, &resp) != nil {
+				continue
+			}
+			if event, ok := pick(resp); ok {
+				events <- event
+			}
+		}
+	}()
+	return events, func() { conn.Close() }, nil
+}
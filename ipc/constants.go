@@ -0,0 +1,30 @@
+package ipc
+
+import "time"
+
+const (
+	socketFile      = "clipse.sock" // next to the history
+	dialTimeout     = time.Second
+	requestTimeout  = 10 * time.Second // to send a request and read the response
+	shutdownTimeout = 3 * time.Second  // for the listener to release the socket once asked to stop
+	shutdownPoll    = 50 * time.Millisecond
+)
+
+// Commands served by the listener.
+const (
+	CmdStatus    = "status"
+	CmdLatest    = "latest"
+	CmdPause     = "pause"
+	CmdResume    = "resume"
+	CmdAdd       = "add"
+	CmdClear     = "clear"
+	CmdShutdown  = "shutdown"
+	CmdSubscribe = "subscribe"
+)
+
+// Events sent to subscribers.
+const (
+	EventHistory = "history" // the history changed
+	EventPause   = "pause"   // recording was paused or resumed
+	EventCapture = "capture" // an entry was recorded, sent after EventHistory
+)
@@ -0,0 +1,306 @@
+package ipc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Package ipc is the control socket of the listener. The listener serves
+it next to the history while it runs, so only one listener can run at a
+time, and the CLI and TUI talk to it to pause or resume recording, add
+entries, clear the history or stop it, falling back to doing it
+themselves when no listener is running. The TUI subscribes to it to
+refresh as soon as the history changes or recording is paused, and clipse
+watch to print the entries recorded as they come in.
+
+Each connection carries one JSON request and one JSON response, except a
+subscription which is followed by an event per line until it is closed.
+*/
+
+type Request struct {
+	Command  string `json:"command"`
+	Value    string `json:"value,omitempty"`    // entry to add or what to clear, see config.ClearHistory
+	Duration string `json:"duration,omitempty"` // of a pause, until resumed if empty
+}
+
+type Response struct {
+	OK      bool                  `json:"ok"`
+	Error   string                `json:"error,omitempty"`
+	Status  *Status               `json:"status,omitempty"`
+	Latest  *config.ClipboardItem `json:"latest,omitempty"`  // most recent entry, nil if the history is empty
+	Changed bool                  `json:"changed,omitempty"` // for resume, whether recording was paused
+	Event   string                `json:"event,omitempty"`   // sent to subscribers
+	Entry   *config.ClipboardItem `json:"entry,omitempty"`   // recorded, sent with EventCapture
+}
+
+// Status describes the running listener.
+type Status struct {
+	PID           int    `json:"pid"`
+	Started       string `json:"started"`
+	DisplayServer string `json:"displayServer"`
+	Paused        bool   `json:"paused"`
+	PausedUntil   string `json:"pausedUntil,omitempty"`
+	Pprof         string `json:"pprof,omitempty"` // address of the pprof endpoints, see --debug-pprof
+}
+
+// Daemon is what the listener does for the commands it serves beyond the
+// history and pause, which the server handles itself.
+type Daemon struct {
+	DisplayServer string
+	Add           func(value string) error // stores text as if it was copied
+	Shutdown      func()                   // stops the listener, called once
+}
+
+var ErrRunning = errors.New("a clipse listener is already running, stop it with `clipse kill` first")
+
+type Server struct {
+	listener net.Listener
+	daemon   Daemon
+	started  time.Time
+
+	mu          sync.Mutex
+	subscribers map[net.Conn]bool
+	latest      *config.ClipboardItem // kept up to date so CmdLatest doesn't read the history
+	closed      bool
+	done        chan struct{}
+	shutdown    sync.Once
+}
+
+func socketPath() string {
+	return filepath.Join(filepath.Dir(config.ClipseConfig.HistoryFilePath), socketFile)
+}
+
+// Listen serves the control socket until Close is called, failing with
+// ErrRunning if another listener already serves it.
+func Listen(d Daemon) (*Server, error) {
+	path := socketPath()
+	if conn, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+		conn.Close()
+		return nil, ErrRunning
+	}
+	// left behind by a listener that didn't exit cleanly
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	s := &Server{
+		listener:    listener,
+		daemon:      d,
+		started:     time.Now(),
+		subscribers: make(map[net.Conn]bool),
+		done:        make(chan struct{}),
+	}
+	s.refreshLatest()
+	go s.accept()
+	go s.watchHistory()
+	return s, nil
+}
+
+// Close stops serving and removes the socket.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	s.listener.Close()
+	for conn := range s.subscribers {
+		conn.Close()
+	}
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				utils.LogERROR(fmt.Sprintf("control socket stopped: %s", err))
+			}
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(requestTimeout))
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		conn.Close()
+		return
+	}
+
+	if req.Command == CmdSubscribe {
+		s.subscribe(conn)
+		return
+	}
+	defer conn.Close()
+
+	resp := s.run(req)
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to answer %s on the control socket: %s", req.Command, err))
+	}
+	if req.Command == CmdShutdown && resp.OK {
+		s.shutdown.Do(s.daemon.Shutdown)
+	}
+}
+
+func (s *Server) run(req Request) Response {
+	var err error
+	resp := Response{}
+	switch req.Command {
+	case CmdStatus:
+		resp.Status = s.status()
+	case CmdLatest:
+		resp.Status = s.status()
+		s.mu.Lock()
+		resp.Latest = s.latest
+		s.mu.Unlock()
+	case CmdPause:
+		var d time.Duration
+		if req.Duration != "" {
+			if d, err = time.ParseDuration(req.Duration); err != nil {
+				break
+			}
+		}
+		if err = config.PauseCapture(d); err == nil {
+			s.broadcast(Response{OK: true, Event: EventPause})
+		}
+	case CmdResume:
+		if resp.Changed, err = config.ResumeCapture(); err == nil && resp.Changed {
+			s.broadcast(Response{OK: true, Event: EventPause})
+		}
+	case CmdAdd:
+		err = s.daemon.Add(req.Value)
+	case CmdClear:
+		err = config.ClearHistory(req.Value)
+	case CmdShutdown:
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	resp.OK = true
+	return resp
+}
+
+func (s *Server) status() *Status {
+	status := &Status{
+		PID:           os.Getpid(),
+		Started:       utils.FormatTime(s.started),
+		DisplayServer: s.daemon.DisplayServer,
+		Pprof:         utils.PprofAddr(),
+	}
+	var until time.Time
+	if status.Paused, until = config.CapturePaused(); !until.IsZero() {
+		status.PausedUntil = utils.FormatTime(until)
+	}
+	return status
+}
+
+// keeps the connection to send events to until the client closes it
+func (s *Server) subscribe(conn net.Conn) {
+	conn.SetDeadline(time.Time{})
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	s.subscribers[conn] = true
+	s.mu.Unlock()
+
+	if err := json.NewEncoder(conn).Encode(Response{OK: true}); err == nil {
+		// the client never sends anything more, this returns once it's gone
+		conn.Read(make([]byte, 1))
+	}
+
+	s.mu.Lock()
+	delete(s.subscribers, conn)
+	s.mu.Unlock()
+	conn.Close()
+}
+
+func (s *Server) broadcast(event Response) {
+	line, _ := json.Marshal(event)
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.subscribers {
+		conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+		if _, err := conn.Write(line); err != nil {
+			delete(s.subscribers, conn)
+			conn.Close()
+		}
+	}
+}
+
+// relays the changes to the history, made by the listener or anyone else,
+// to the subscribers, followed by the entries recorded since the last one
+func (s *Server) watchHistory() {
+	changes, cancel := config.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case _, ok := <-changes:
+			if !ok {
+				return
+			}
+			recorded := s.refreshLatest()
+			s.broadcast(Response{OK: true, Event: EventHistory})
+			for i := len(recorded) - 1; i >= 0; i-- {
+				s.broadcast(Response{OK: true, Event: EventCapture, Entry: &recorded[i]})
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// returns the entries recorded after the previous latest one, newest first
+func (s *Server) refreshLatest() []config.ClipboardItem {
+	history := config.GetHistory()
+	var latest *config.ClipboardItem
+	if len(history) > 0 {
+		latest = &history[0]
+	}
+	s.mu.Lock()
+	previous := s.latest
+	s.latest = latest
+	s.mu.Unlock()
+
+	if previous == nil {
+		return history
+	}
+	for i, item := range history {
+		if item.Recorded <= previous.Recorded {
+			return history[:i]
+		}
+	}
+	return history
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/handlers"
+	"github.com/savedra1/clipse/ipc"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+func handleListen(displayServer string) {
+	// a running listener is asked to stop, listeners of older versions
+	// and the wayland watchers are killed
+	if err := ipc.Shutdown(); err != nil || displayServer == "wayland" {
+		if err := shell.KillExisting(); err != nil {
+			fmt.Printf("ERROR: failed to kill existing listener process: %s", err)
+			utils.LogERROR(fmt.Sprintf("failed to kill existing listener process: %s", err))
+		}
+	}
+
+	var passphrase []byte
+	if config.NeedsPassphrase() {
+		if displayServer == "wayland" {
+			fail(utils.ExitFailure, "The wayland listener stores each entry from a new process so cannot hold a passphrase in memory. Set encryption.keyFile in your config instead.")
+		}
+		passphrase = promptPassphrase()
+	}
+	if config.NoDisplay() {
+		fmt.Printf("No display server found, the listener won't record copies. See `%s doctor`.\n", os.Args[0])
+	}
+	shell.RunNohupListener(displayServer, passphrase, config.ClipseConfig.BridgeClipboards && shell.BridgeAvailable(), config.SyncEnabled(), *debugPprof)
+}
+
+func handleListenShell(displayServer string, imgEnabled bool) {
+	if config.NeedsPassphrase() && utils.StdinPiped() {
+		// started by `clipse listen` which pipes in the passphrase
+		passphrase, err := utils.ReadPassphraseStdin()
+		utils.HandleError(err)
+		utils.HandleError(config.Unlock(passphrase))
+	}
+	unlockHistory()
+	servePprof()
+	sandboxListener()
+	ctx, stop := interruptContext()
+	defer stop()
+	runDaemon(handlers.RunListener(ctx, displayServer, imgEnabled))
+}
+
+// a context done on ctrl+c or SIGTERM, for the long running commands to
+// stop cleanly rather than be killed
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// exits cleanly if another listener is running, so a service manager
+// doesn't keep restarting this one
+func runDaemon(err error) {
+	if errors.Is(err, ipc.ErrRunning) {
+		fmt.Println(err)
+		utils.LogINFO(err.Error())
+		return
+	}
+	utils.HandleError(err)
+}
+
+// serves pprof with --debug-pprof, before the sandbox is set up as it may
+// not allow listening on a port
+func servePprof() {
+	if !*debugPprof {
+		return
+	}
+	if err := utils.ServePprof(pprofAddr); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to serve pprof: %s", err))
+		return
+	}
+	utils.LogINFO("serving pprof on http://" + pprofAddr + "/debug/pprof/")
+}
+
+// restricts the listener processes to their data files when sandbox is set
+// in the config, it keeps running unrestricted if the sandbox can't be set up
+func sandboxListener() {
+	if !config.ClipseConfig.Sandbox {
+		return
+	}
+	writable, readable := config.SandboxPaths()
+	if err := utils.Sandbox(writable, readable, config.SandboxAllowsTCP()); err != nil {
+		utils.LogWARN(fmt.Sprintf("running the listener without a sandbox: %s", err))
+	}
+}
+
+func handleKill(displayServer string) {
+	if err := ipc.Shutdown(); err == nil && displayServer != "wayland" {
+		return
+	}
+	shell.KillAll(os.Args[0])
+}
+
+func handleWLStore() {
+	if config.NeedsPassphrase() {
+		utils.LogERROR("cannot store wayland clipboard data: " + config.ErrHistoryLocked.Error())
+		return
+	}
+	sandboxListener()
+	handlers.StoreWLData(context.Background())
+}
+
+func handleBridgeX11() {
+	sandboxListener()
+	ctx, stop := interruptContext()
+	defer stop()
+	handlers.RunBridge(ctx)
+}
+
+func handleControl(displayServer string) {
+	servePprof()
+	sandboxListener()
+	ctx, stop := interruptContext()
+	defer stop()
+	runDaemon(handlers.RunControl(ctx, displayServer))
+}
+
+func handlePasteKeys(displayServer string) {
+	time.Sleep(time.Duration(config.ClipseConfig.DirectPaste.DelayMs) * time.Millisecond)
+	if err := shell.SendPasteKeys(displayServer, config.ClipseConfig.DirectPaste.Keys); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to paste: %s", err))
+	}
+}
+
+func handlePasteQueue() {
+	if config.NeedsPassphrase() {
+		utils.LogERROR("cannot serve the paste queue: " + config.ErrHistoryLocked.Error())
+		return
+	}
+	handlers.ServePasteQueue()
+}
+
+func handleEnableAutostart() {
+	if config.NeedsPassphrase() {
+		fail(utils.ExitFailure, "The autostarted listener cannot prompt for a passphrase. Set encryption.keyFile in your config instead.")
+	}
+	// the service runs its own listener
+	if err := shell.KillExisting(); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to kill existing listener process: %s", err))
+	}
+	unitPath, err := shell.EnableAutostart(os.Args[0])
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to enable autostart: %s", err)
+	}
+	fmt.Printf("Enabled the listener service: %s\n", unitPath)
+}
+
+func handleDisableAutostart() {
+	unitPath, err := shell.DisableAutostart()
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to disable autostart: %s", err)
+	}
+	fmt.Printf("Disabled and removed the listener service: %s\n", unitPath)
+}
+
+func handlePause(args []string) {
+	var d time.Duration
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		if d, err = utils.ParseDuration(args[0]); err != nil || d <= 0 {
+			fail(utils.ExitUsage, "Invalid duration %q, use eg 10m, 2h or 1d.", args[0])
+		}
+	default:
+		failUsage("pause")
+	}
+
+	err := ipc.Pause(d)
+	if errors.Is(err, ipc.ErrNotRunning) {
+		err = config.PauseCapture(d)
+	}
+	utils.HandleError(err)
+	if d == 0 {
+		fmt.Printf("Paused recording until `%s resume`.\n", os.Args[0])
+		return
+	}
+	fmt.Printf("Paused recording until %s.\n", utils.FormatDateTime(time.Now().Add(d)))
+}
+
+func handleResume() {
+	resumed, err := ipc.Resume()
+	if errors.Is(err, ipc.ErrNotRunning) {
+		resumed, err = config.ResumeCapture()
+	}
+	utils.HandleError(err)
+	if !resumed {
+		fmt.Println("Recording is not paused.")
+		return
+	}
+	fmt.Println("Resumed recording.")
+}
+
+const (
+	pprofAddr           = "localhost:6060" // served by the listener with --debug-pprof
+	defaultProfileTime  = 30 * time.Second
+	profileTimeoutSlack = 10 * time.Second // allowed beyond the profiling time for the response
+)
+
+// writes a profile of the listener, served with --debug-pprof, to a file
+// for go tool pprof
+func handleProfile(args []string) {
+	if len(args) == 0 || args[0] != "capture" {
+		failUsage("profile")
+	}
+	fs := newFlagSet("profile")
+	kind := fs.String("type", "cpu", "Profile to capture: cpu, heap or goroutine.")
+	output := fs.String("output", "", "File to write the profile to, clipse-<type>-<time>.pprof in the current directory by default.")
+	utils.HandleError(fs.Parse(args[1:]))
+	if fs.NArg() > 1 {
+		failUsage("profile")
+	}
+
+	duration := defaultProfileTime
+	if fs.NArg() == 1 {
+		d, err := utils.ParseDuration(fs.Arg(0))
+		if err != nil || d < time.Second {
+			fail(utils.ExitUsage, "Invalid duration %q, eg 30s or 2m", fs.Arg(0))
+		}
+		duration = d
+	}
+	var endpoint string
+	switch *kind {
+	case "cpu":
+		endpoint = fmt.Sprintf("profile?seconds=%d", int(duration.Round(time.Second).Seconds()))
+	case "heap", "goroutine":
+		endpoint = *kind
+		duration = 0
+	default:
+		fail(utils.ExitUsage, "Unknown profile type %q, must be one of cpu, heap, goroutine", *kind)
+	}
+
+	status, err := ipc.GetStatus()
+	switch {
+	case errors.Is(err, ipc.ErrNotRunning):
+		fail(utils.ExitNoDaemon, "The listener is not running, start it with `%s listen --debug-pprof`", os.Args[0])
+	case err != nil:
+		fail(utils.ExitFailure, "The listener is not responding: %s", err)
+	case status.Pprof == "":
+		fail(utils.ExitFailure, "The listener does not serve pprof, restart it with `%s listen --debug-pprof`", os.Args[0])
+	}
+
+	if duration > 0 {
+		fmt.Printf("Profiling the listener for %s...\n", duration)
+	}
+	client := http.Client{Timeout: duration + profileTimeoutSlack}
+	resp, err := client.Get(fmt.Sprintf("http://%s/debug/pprof/%s", status.Pprof, endpoint))
+	if err != nil {
+		fail(utils.ExitFailure, "Failed to capture the profile: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		fail(utils.ExitFailure, "Failed to capture the profile: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("clipse-%s-%s.pprof", *kind, time.Now().Format("20060102-150405"))
+	}
+	file, err := os.Create(path)
+	utils.HandleError(err)
+	defer file.Close()
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		fail(utils.ExitFailure, "Failed to write %s: %s", path, err)
+	}
+	fmt.Printf("Wrote the %s profile to %s, view it with `go tool pprof -http=: %s`\n", *kind, path, path)
+}
+
+// prints the entries recorded by the listener as they come in, a line of
+// text or JSON each, until it stops
+func handleWatch(args []string) {
+	fs := newFlagSet("watch")
+	asJSON := fs.Bool("json", false, "Print each entry as a JSON object on its own line.")
+	maxLen := fs.Int("max", 0, "Cuts the text of each entry to this many characters, 0 for all of it.")
+	utils.HandleError(fs.Parse(args))
+	if fs.NArg() > 0 {
+		failUsage("watch")
+	}
+
+	captures, cancel, err := ipc.Captures()
+	if errors.Is(err, ipc.ErrNotRunning) {
+		fail(utils.ExitNoDaemon, "The listener is not running, start it with `%s listen`", os.Args[0])
+	}
+	utils.HandleError(err)
+	defer cancel()
+
+	enc := json.NewEncoder(os.Stdout)
+	for item := range captures {
+		if item.Sensitive {
+			item.Value = ""
+		}
+		if *asJSON {
+			utils.HandleError(enc.Encode(item))
+			continue
+		}
+		text := menuText(item, true)
+		if *maxLen > 0 {
+			text = utils.Truncate(text, *maxLen)
+		} else {
+			text = strings.Join(strings.Fields(text), " ")
+		}
+		fmt.Println(text)
+	}
+	fail(utils.ExitNoDaemon, "The listener stopped.")
+}
+
+func handleRecord(args []string) {
+	fs := newFlagSet("record")
+	raw := fs.Bool("raw", false, "Also record the content copied, so replay can run it through the capture filters. Check the trace for secrets before sharing it.")
+	out := fs.String("out", "", "File to write the trace to, defaults to the stdout.")
+	utils.HandleError(fs.Parse(args))
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			failErr(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if !*quiet {
+		fmt.Fprintln(os.Stderr, "Recording clipboard changes, press ctrl+c to stop.")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	utils.HandleError(handlers.RecordEvents(ctx, w, config.DisplayServer(), *raw))
+}
+
+func handleReplay(args []string) {
+	if len(args) != 1 {
+		failUsage("replay")
+	}
+	rec, events, err := handlers.ReadRecording(args[0])
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to read %s: %s", args[0], err)
+	}
+
+	fmt.Printf("Recorded on %s at %s", backendName(rec.DisplayServer), rec.Started)
+	if !rec.Raw {
+		fmt.Print(", without content so capture filters are not checked")
+	}
+	fmt.Println()
+
+	counts := make(map[string]int)
+	for _, res := range handlers.ReplayEvents(rec, events) {
+		counts[res.Outcome]++
+		size := ""
+		if res.Outcome != handlers.ReplayError {
+			size = utils.FormatSize(int64(res.Event.Size))
+		}
+		line := fmt.Sprintf("%9.3fs  %-5s %9s  %s", float64(res.Event.Offset)/1000, res.Event.Type, size, res.Outcome)
+		if res.Detail != "" {
+			line += ": " + res.Detail
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf(
+		"\n%s changes: %s stored, %s duplicates, %s missed, %s ignored, %s failed reads.\n",
+		utils.FormatCount(len(events)-counts[handlers.ReplayError]),
+		utils.FormatCount(counts[handlers.ReplayStored]), utils.FormatCount(counts[handlers.ReplayRepeated]),
+		utils.FormatCount(counts[handlers.ReplayMissed]), utils.FormatCount(counts[handlers.ReplayIgnored]),
+		utils.FormatCount(counts[handlers.ReplayError]),
+	)
+}
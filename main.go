@@ -5,23 +5,19 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/atotto/clipboard"
-	tea "github.com/charmbracelet/bubbletea"
-
-	"github.com/savedra1/clipse/app"
 	"github.com/savedra1/clipse/config"
-	"github.com/savedra1/clipse/handlers"
-	"github.com/savedra1/clipse/shell"
 	"github.com/savedra1/clipse/utils"
 )
 
 var (
-	version     = "v1.1.0"
+	version     = "v1.1.0"  // set at build time, see .goreleaser.yaml
+	commit      = "unknown" // set at build time
+	date        = "unknown" // set at build time
 	help        = flag.Bool("help", false, "Show help message.")
 	v           = flag.Bool("v", false, "Show app version.")
 	add         = flag.Bool("a", false, "Add the following arg to the clipboard history.")
 	copyInput   = flag.Bool("c", false, "Copy the input to your systems clipboard.")
-	paste       = flag.Bool("p", false, "Prints the current clipboard content.")
+	paste       = flag.Bool("p", false, "Prints the current clipboard content, or the Nth most recent entry if N is provided. EG `clipse -p 3`")
 	listen      = flag.Bool("listen", false, "Start background process for monitoring clipboard activity on wayland/x11/macOS.")
 	listenShell = flag.Bool("listen-shell", false, "Starts a clipboard monitor process in the current shell.")
 	kill        = flag.Bool("kill", false, "Kill any existing background processes.")
@@ -31,34 +27,59 @@ var (
 	clearText   = flag.Bool("clear-text", false, "Removes all text from the clipboard history including pinned text entries.")
 	forceClose  = flag.Bool("fc", false, "Forces the terminal session to quick by taking the $PPID var as an arg. EG `clipse -fc $PPID`")
 	wlStore     = flag.Bool("wl-store", false, "Store data from the stdin directly using the wl-clipboard API.")
-	realTime    = flag.Bool("enable-real-time", false, "Enable real time updates to the TUI")
-	outputAll   = flag.String("output-all", "", "Print clipboard text content to stdout, each entry separated by a newline, possible values: (raw, unescaped)")
+	bridgeX11   = flag.Bool("bridge-x11", false, "Mirrors the X11 clipboard to wayland. Started by -listen when bridgeClipboards is enabled.")
+	control     = flag.Bool("control", false, "Serves the control socket of the wayland listener. Started by -listen on wayland.")
+	pasteQueue  = flag.Bool("paste-queue", false, "Copies the paste stack one entry per paste. Started by the TUI when pasteQueue is enabled.")
+	pasteKeys   = flag.Bool("paste-keys", false, "Types the paste keys into the focused window. Started by the TUI when directPaste is enabled.")
+	realTime    = flag.Bool("enable-real-time", false, "Deprecated: real time updates to the TUI are always enabled.")
+	outputAll   = flag.String("output-all", "", "Print clipboard text content to stdout, each entry separated by a newline, possible values: (raw, unescaped, json)")
+	link        = flag.String("link", "", "Open the TUI focused on a clipse:// link. EG `clipse -link clipse://search/foo`")
+	tmuxMode    = flag.Bool("tmux", false, "Open the TUI loading the chosen entry into a tmux paste buffer instead of the clipboard.")
+	regLinks    = flag.Bool("register-links", false, "Register clipse as the handler for clipse:// links (Linux only).")
+	printN      = flag.Int("print", 0, "Print the Nth most recent history entry to stdout.")
+	copyN       = flag.Int("copy", 0, "Copy the Nth most recent history entry to the system clipboard.")
+	pop         = flag.Bool("pop", false, "Copy the next entry queued on the paste stack to the system clipboard.")
+	listNewline = flag.Bool("list-newline", false, "Print the history one entry per line for dmenu/rofi, pipe the chosen line into clipse --select.")
+	listNull    = flag.Bool("list-null", false, "Print the full history entries separated by null bytes, eg for rofi -sep '\\0'.")
+	selectLine  = flag.Bool("select", false, "Copy the entry of the line from --list-newline or --list-null read from the stdin.")
+	force       = flag.Bool("force", false, "Allow rewriting a history file written by a newer version of clipse, dropping data this version doesn't know about.")
+	quiet       = flag.Bool("quiet", false, "Print no error messages, failures only show in the exit code. See the README for the exit codes.")
+	debugPprof  = flag.Bool("debug-pprof", false, "Serve net/http/pprof on "+pprofAddr+" from the listener started with -listen, see clipse profile capture.")
 )
 
 func main() {
+	flag.Usage = func() { printUsage(os.Stderr) }
 	flag.Parse()
+	config.ForceSchema = *force
+	utils.SetQuiet(*quiet)
 	logPath, displayServer, imgEnabled, err := config.Init()
 	utils.HandleError(err)
 	utils.SetUpLogger(logPath)
+	defer config.WaitHooks()
+
+	// prompt-segment must never prompt for a passphrase
+	cmd, _ := findCommand(flag.Arg(0))
+	if !(*help || *v || *kill || *regLinks || *listen || *listenShell || *wlStore || *bridgeX11 || *control || *pasteQueue || *pasteKeys || cmd.noUnlock) {
+		unlockHistory()
+	}
 
 	switch {
 
-	case flag.NFlag() == 0:
-		if len(os.Args) > 2 {
-			fmt.Println("Too many args provided. See usage:")
-			flag.PrintDefaults()
+	case flagCount() == 0:
+		if flag.NArg() > 0 {
+			runCommand(flag.Args())
 			return
 		}
 		launchTUI()
 
-	case flag.NFlag() > 1:
-		fmt.Printf("Too many flags provided. Use %s --help for more info.", os.Args[0])
+	case flagCount() > 1:
+		fail(utils.ExitUsage, "Too many flags provided. Use %s --help for more info.", os.Args[0])
 
 	case *help:
-		flag.PrintDefaults()
+		printUsage(os.Stdout)
 
 	case *v:
-		fmt.Println(os.Args[0], version)
+		printVersion()
 
 	case *add:
 		handleAdd()
@@ -76,16 +97,28 @@ func main() {
 		handleListenShell(displayServer, imgEnabled)
 
 	case *kill:
-		handleKill()
+		handleKill(displayServer)
 
 	case *clear, *clearAll, *clearImages, *clearText:
-		handleClear()
+		handleClear(flagClearType())
 
 	case *forceClose:
 		handleForceClose()
 
 	case *wlStore:
-		handlers.StoreWLData()
+		handleWLStore()
+
+	case *bridgeX11:
+		handleBridgeX11()
+
+	case *control:
+		handleControl(displayServer)
+
+	case *pasteKeys:
+		handlePasteKeys(displayServer)
+
+	case *pasteQueue:
+		handlePasteQueue()
 
 	case *realTime:
 		launchTUI()
@@ -93,123 +126,69 @@ func main() {
 	case *outputAll != "":
 		handleOutputAll(*outputAll)
 
-	default:
-		fmt.Printf("Command not recognized. See %s --help for usage instructions.", os.Args[0])
-	}
-}
+	case *link != "":
+		handleLink(*link)
 
-func launchTUI() {
-	shell.KillExistingFG()
-	newModel := app.NewModel()
-	p := tea.NewProgram(newModel)
-	if *realTime {
-		go newModel.ListenRealTime(p)
-	}
-	_, err := p.Run()
-	utils.HandleError(err)
-}
+	case *regLinks:
+		handleRegisterLinks()
 
-func handleAdd() {
-	var input string
-	switch {
-	case len(os.Args) < 3:
-		input = utils.GetStdin()
-	default:
-		input = os.Args[2]
-	}
-	utils.HandleError(config.AddClipboardItem(input, "null"))
-}
+	case *tmuxMode:
+		handleTmux()
 
-func handleListen(displayServer string) {
-	if err := shell.KillExisting(); err != nil {
-		fmt.Printf("ERROR: failed to kill existing listener process: %s", err)
-		utils.LogERROR(fmt.Sprintf("failed to kill existing listener process: %s", err))
-	}
-	shell.RunNohupListener(displayServer)
-}
+	case *printN != 0:
+		handlePrintEntry(*printN)
 
-func handleListenShell(displayServer string, imgEnabled bool) {
-	utils.HandleError(handlers.RunListener(displayServer, imgEnabled))
-}
+	case *copyN != 0:
+		handleCopyEntry(*copyN)
 
-func handleKill() {
-	shell.KillAll(os.Args[0])
-}
-
-func handleClear() {
-	if err := clipboard.WriteAll(""); err != nil {
-		utils.LogERROR(fmt.Sprintf("failed to reset clipboard buffer value: %s", err))
-	}
+	case *pop:
+		handlePop()
 
-	var clearType string
+	case *listNewline:
+		handleListMenu("\n", false)
 
-	switch {
-	case *clearImages:
-		clearType = "images"
-	case *clearAll:
-		clearType = "all"
-	case *clearText:
-		clearType = "text"
-	default:
-		clearType = "default"
-	}
+	case *listNull:
+		handleListMenu("\x00", true)
 
-	utils.HandleError(config.ClearHistory(clearType))
-}
+	case *selectLine:
+		handleSelect()
 
-func handleCopy() {
-	var input string
-	switch {
-	case len(os.Args) < 3:
-		input = utils.GetStdin()
 	default:
-		input = os.Args[2]
-	}
-	if input != "" {
-		fmt.Println(input)
-		utils.HandleError(clipboard.WriteAll(input))
+		fail(utils.ExitUsage, "Command not recognized. See %s --help for usage instructions.", os.Args[0])
 	}
 }
 
-func handlePaste() {
-	currentItem, err := clipboard.ReadAll()
-	utils.HandleError(err)
-	if currentItem != "" {
-		fmt.Println(currentItem)
+// number of flags set, not counting --force, --quiet and --debug-pprof
+// which modify the others
+func flagCount() int {
+	count := flag.NFlag()
+	if *force {
+		count--
 	}
-}
-
-func handleForceClose() {
-	if len(os.Args) < 3 {
-		fmt.Printf("No PPID provided. Usage: %s' -fc $PPID'", os.Args[0])
-		return
+	if *quiet {
+		count--
 	}
-
-	if len(os.Args) > 3 {
-		fmt.Printf("Too many args. Usage: %s' -fc $PPID'", os.Args[0])
-		return
+	if *debugPprof {
+		count--
 	}
+	return count
+}
 
-	if !utils.IsInt(os.Args[2]) {
-		fmt.Printf("Invalid PPID supplied: %s\nPPID must be integer. use var `$PPID` as the arg.", os.Args[2])
-		return
+// prints the message to the stderr, unless --quiet is set, and exits with
+// code, one of the utils.Exit codes
+func fail(code int, format string, a ...any) {
+	if !*quiet {
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
 	}
-
-	launchTUI()
+	os.Exit(code)
 }
 
-func handleOutputAll(format string) {
-	items := config.TextItems()
+// prints err and exits with its code
+func failErr(err error) {
+	fail(utils.ExitCode(err), "%s", err)
+}
 
-	if format == "raw" {
-		for _, v := range items {
-			fmt.Printf("%q\n", v.Value)
-		}
-	} else if format == "unescaped" {
-		for _, v := range items {
-			fmt.Println(v.Value)
-		}
-	} else {
-		fmt.Printf("Invalid argument to -output-all\nSee %s --help for usage", os.Args[0])
-	}
+func printVersion() {
+	fmt.Println(os.Args[0], version)
+	fmt.Printf("commit: %s\nbuilt: %s\nhistory schema: %d\n", commit, date, config.HistorySchema)
 }
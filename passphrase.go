@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// unlocks the encrypted history, prompting for the passphrase if no key
+// file is configured. Exits if the history cannot be unlocked.
+func unlockHistory() {
+	switch {
+	case config.HistoryUnlocked():
+		return
+	case !config.EncryptionEnabled() && !config.HistoryEncrypted():
+		return
+	case config.NeedsPassphrase():
+		promptPassphrase()
+	case config.EncryptionEnabled():
+		// check the key file up front rather than failing mid command
+		if err := config.Unlock(nil); err != nil {
+			failErr(err)
+		}
+	}
+}
+
+// reads the passphrase from the terminal and unlocks the history with it
+func promptPassphrase() []byte {
+	passphrase, err := readNewOrExistingPassphrase()
+	if err == nil {
+		err = config.Unlock(passphrase)
+	}
+	if err != nil {
+		failErr(err)
+	}
+	return passphrase
+}
+
+func readNewOrExistingPassphrase() ([]byte, error) {
+	if config.HistoryEncrypted() {
+		return utils.ReadPassphrase("Passphrase to unlock clipse history: ")
+	}
+
+	// first run with encryption enabled, confirm the new passphrase
+	passphrase, err := utils.ReadPassphrase("New passphrase to encrypt clipse history: ")
+	if err != nil {
+		return nil, err
+	}
+	confirm, err := utils.ReadPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 || string(passphrase) != string(confirm) {
+		return nil, fmt.Errorf("passphrases are empty or do not match")
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/app"
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/handlers"
+	"github.com/savedra1/clipse/peers"
+	"github.com/savedra1/clipse/utils"
+)
+
+const peerSearchTime = 3 * time.Second // how long to look for devices on the local network
+
+func handlePeers(args []string) {
+	key, err := config.PeerIdentity()
+	utils.HandleError(err)
+
+	switch {
+	case len(args) == 0:
+		listPeers(key)
+	case args[0] == "pair" && len(args) == 1:
+		fmt.Printf("Waiting to pair as %s (%s), run `%s peers pair %s` on the other device...\n",
+			config.DeviceName(), config.KeyFingerprint(key.Public().(ed25519.PublicKey)), os.Args[0], config.DeviceName())
+		savePairing(peers.Wait(key, confirmPairing))
+	case args[0] == "pair" && len(args) == 2:
+		nearby, err := peers.Find(key, args[1], peerSearchTime)
+		if err != nil {
+			failErr(err)
+		}
+		savePairing(peers.Join(key, nearby.Addr, confirmPairing))
+	case args[0] == "unpair" && len(args) == 2:
+		removed, err := config.RemovePeer(args[1])
+		utils.HandleError(err)
+		if !removed {
+			fail(utils.ExitNotFound, "%s is not paired.", args[1])
+		}
+		fmt.Printf("Unpaired %s.\n", args[1])
+	default:
+		failUsage("peers")
+	}
+}
+
+// prints the paired peers and the devices nearby waiting to pair
+func listPeers(key ed25519.PrivateKey) {
+	paired, err := config.PairedPeers()
+	utils.HandleError(err)
+	fmt.Printf("This device: %s (%s)\n", config.DeviceName(), config.KeyFingerprint(key.Public().(ed25519.PublicKey)))
+	fmt.Println("\nPaired:")
+	for _, peer := range paired {
+		when := peer.Paired
+		if t, err := utils.ParseTimeStamp(peer.Paired); err == nil {
+			when = utils.FormatDateTime(t)
+		}
+		fmt.Printf("  %s (paired %s)\n", peer.Device, when)
+	}
+	if len(paired) == 0 {
+		fmt.Println("  none")
+	}
+
+	nearby, err := peers.Browse(key, peerSearchTime)
+	if err != nil {
+		failErr(err)
+	}
+	fmt.Println("\nWaiting to pair nearby:")
+	for _, n := range nearby {
+		fmt.Printf("  %s (%s) at %s\n", n.Device, n.Fingerprint, n.Addr)
+	}
+	if len(nearby) == 0 {
+		fmt.Println("  none")
+	}
+}
+
+func confirmPairing(device, fingerprint, code string) bool {
+	fmt.Printf("\nPairing with %s (%s)\nCheck that both devices show the code: %s\nPair? [y/N] ", device, fingerprint, code)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
+
+func savePairing(peer config.Peer, err error) {
+	if err == nil {
+		err = config.SavePeer(peer)
+	}
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to pair: %s", err)
+	}
+	fmt.Printf("Paired with %s.\n", peer.Device)
+}
+
+func handleSync(args []string) {
+	fs := newFlagSet("sync")
+	watch := fs.Bool("watch", false, "Keeps syncing in the background. Started by -listen on wayland.")
+	utils.HandleError(fs.Parse(args))
+
+	if !config.SyncEnabled() {
+		fail(utils.ExitFailure, "Sync is not enabled, set sync.enabled and sync.dir in config.json.")
+	}
+	if *watch {
+		sandboxListener()
+		handlers.RunSync()
+		return
+	}
+
+	result, err := config.SyncDevices()
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to sync: %s", err)
+	}
+	fmt.Printf(
+		"Synced with %s devices: added %s entries, updated %s snippets.\n",
+		utils.FormatCount(result.Devices), utils.FormatCount(result.Imported), utils.FormatCount(result.Snippets),
+	)
+	if result.Conflicts == 0 {
+		return
+	}
+
+	resolveKeyConflicts()
+	newModel := app.NewModel()
+	newModel.OpenConflicts()
+	runTUI(newModel)
+}
@@ -0,0 +1,15 @@
+package peers
+
+import "time"
+
+const (
+	serviceType     = "_clipse._tcp"
+	serviceDomain   = "local."
+	protocolVersion = "1"
+	txtVersion      = "v"
+	txtID           = "id"
+	pairingContext  = "clipse-pair-v1" // domain separation for the pairing transcript
+	pairTimeout     = 2 * time.Minute  // to connect and for both users to confirm the code
+	nonceSize       = 32
+	codeDigits      = 1000000 // six digit pairing code
+)
@@ -0,0 +1,214 @@
+package peers
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Pairing follows the numeric comparison of Bluetooth Secure Simple
+Pairing. The joining device commits to a random nonce before seeing the
+waiting device's one, then reveals it:
+
+	joiner -> waiter: device, key, sha256(joiner nonce)
+	waiter -> joiner: device, key, waiter nonce
+	joiner -> waiter: joiner nonce
+
+Both derive the code from the keys and nonces. A device in the middle
+would have to pick its nonce before knowing the joiner's, so it has one
+chance in a million of both sides showing the same code. Each side then
+sends whether its user accepted, signed with its key to prove it holds
+the key it sent.
+*/
+
+// Confirm asks the user whether to pair with the device, showing the code
+// to compare with the other device.
+type Confirm func(device, fingerprint, code string) bool
+
+var ErrPairingRejected = errors.New("pairing was not accepted on both devices")
+
+type pairMsg struct {
+	Device string `json:"device,omitempty"`
+	Key    []byte `json:"key,omitempty"`
+	Commit []byte `json:"commit,omitempty"`
+	Nonce  []byte `json:"nonce,omitempty"`
+	Accept bool   `json:"accept,omitempty"`
+	Sig    []byte `json:"sig,omitempty"`
+}
+
+// side of the pairing exchange as seen by either device
+type pairing struct {
+	key    ed25519.PrivateKey
+	enc    *json.Encoder
+	dec    *json.Decoder
+	device string
+	remote pairMsg // the other device's hello
+}
+
+func newPairing(conn net.Conn, key ed25519.PrivateKey) *pairing {
+	return &pairing{
+		key:    key,
+		enc:    json.NewEncoder(conn),
+		dec:    json.NewDecoder(conn),
+		device: config.DeviceName(),
+	}
+}
+
+// Wait advertises this device and pairs with the first device to join,
+// returning it once both users accepted the code.
+func Wait(key ed25519.PrivateKey, confirm Confirm) (config.Peer, error) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return config.Peer{}, err
+	}
+	defer listener.Close()
+
+	stop, err := Advertise(key, listener.Addr().(*net.TCPAddr).Port)
+	if err != nil {
+		return config.Peer{}, err
+	}
+	conn, err := listener.Accept()
+	stop()
+	if err != nil {
+		return config.Peer{}, err
+	}
+	defer conn.Close()
+	utils.HandleError(conn.SetDeadline(time.Now().Add(pairTimeout)))
+
+	p := newPairing(conn, key)
+	if err := p.dec.Decode(&p.remote); err != nil {
+		return config.Peer{}, fmt.Errorf("failed to read pairing request: %w", err)
+	}
+	nonce, err := randomNonce()
+	if err != nil {
+		return config.Peer{}, err
+	}
+	if err := p.enc.Encode(p.hello(nonce, nil)); err != nil {
+		return config.Peer{}, err
+	}
+	var reveal pairMsg
+	if err := p.dec.Decode(&reveal); err != nil {
+		return config.Peer{}, fmt.Errorf("failed to read pairing nonce: %w", err)
+	}
+	if commit := sha256.Sum256(reveal.Nonce); !bytes.Equal(commit[:], p.remote.Commit) {
+		return config.Peer{}, errors.New("pairing nonce does not match its commitment")
+	}
+
+	transcript := pairingTranscript(p.remote, p.hello(nonce, nil), reveal.Nonce, nonce)
+	return p.finish(transcript, confirm)
+}
+
+// Join pairs with the device waiting at addr, returning it once both users
+// accepted the code.
+func Join(key ed25519.PrivateKey, addr string, confirm Confirm) (config.Peer, error) {
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return config.Peer{}, err
+	}
+	defer conn.Close()
+	utils.HandleError(conn.SetDeadline(time.Now().Add(pairTimeout)))
+
+	p := newPairing(conn, key)
+	nonce, err := randomNonce()
+	if err != nil {
+		return config.Peer{}, err
+	}
+	commit := sha256.Sum256(nonce)
+	hello := p.hello(nil, commit[:])
+	if err := p.enc.Encode(hello); err != nil {
+		return config.Peer{}, err
+	}
+	if err := p.dec.Decode(&p.remote); err != nil {
+		return config.Peer{}, fmt.Errorf("failed to read pairing reply: %w", err)
+	}
+	if err := p.enc.Encode(pairMsg{Nonce: nonce}); err != nil {
+		return config.Peer{}, err
+	}
+
+	transcript := pairingTranscript(hello, p.remote, nonce, p.remote.Nonce)
+	return p.finish(transcript, confirm)
+}
+
+func (p *pairing) hello(nonce, commit []byte) pairMsg {
+	return pairMsg{
+		Device: p.device,
+		Key:    p.key.Public().(ed25519.PublicKey),
+		Commit: commit,
+		Nonce:  nonce,
+	}
+}
+
+// shows the code, exchanges both users' answers and returns the peer if
+// both accepted
+func (p *pairing) finish(transcript []byte, confirm Confirm) (config.Peer, error) {
+	remoteKey := ed25519.PublicKey(p.remote.Key)
+	if len(remoteKey) != ed25519.PublicKeySize || p.remote.Device == "" {
+		return config.Peer{}, errors.New("invalid pairing request")
+	}
+
+	accepted := confirm(p.remote.Device, config.KeyFingerprint(remoteKey), pairingCode(transcript))
+	answer := pairMsg{Accept: accepted}
+	if accepted {
+		answer.Sig = ed25519.Sign(p.key, transcript)
+	}
+	if err := p.enc.Encode(answer); err != nil {
+		return config.Peer{}, err
+	}
+
+	var remoteAnswer pairMsg
+	if err := p.dec.Decode(&remoteAnswer); err != nil {
+		return config.Peer{}, fmt.Errorf("failed to read the other device's answer: %w", err)
+	}
+	if !accepted || !remoteAnswer.Accept {
+		return config.Peer{}, ErrPairingRejected
+	}
+	if !ed25519.Verify(remoteKey, transcript, remoteAnswer.Sig) {
+		return config.Peer{}, errors.New("the other device did not prove it holds its key")
+	}
+
+	return config.Peer{
+		Device: p.remote.Device,
+		Key:    base64.StdEncoding.EncodeToString(remoteKey),
+		Paired: utils.GetTime(),
+	}, nil
+}
+
+// hashes everything both devices agreed on, joiner first
+func pairingTranscript(joiner, waiter pairMsg, joinerNonce, waiterNonce []byte) []byte {
+	h := sha256.New()
+	for _, field := range [][]byte{
+		[]byte(pairingContext),
+		[]byte(joiner.Device), joiner.Key,
+		[]byte(waiter.Device), waiter.Key,
+		joinerNonce, waiterNonce,
+	} {
+		binary.Write(h, binary.BigEndian, uint32(len(field)))
+		h.Write(field)
+	}
+	return h.Sum(nil)
+}
+
+// six digits, eg "042 917"
+func pairingCode(transcript []byte) string {
+	n := binary.BigEndian.Uint32(transcript) % codeDigits
+	return fmt.Sprintf("%03d %03d", n/1000, n%1000)
+}
+
+func randomNonce() ([]byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
@@ -0,0 +1,132 @@
+package peers
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+
+	"github.com/savedra1/clipse/config"
+)
+
+/* Package peers finds other devices running clipse on the local network
+with mDNS and pairs with them, for sharing the history over the LAN.
+
+A device waiting to pair, `clipse peers pair`, advertises itself as a
+_clipse._tcp service along with the fingerprint of its key. The other
+device connects to it with `clipse peers pair <device>` and both show a
+six digit code derived from both keys, see pair.go. The pairing is only
+saved once the user has checked the code matches on both devices, so a
+device on the network can't pair itself in the middle.
+*/
+
+// Nearby is a device found on the local network.
+type Nearby struct {
+	Device      string
+	Fingerprint string // of the device's key, see config.KeyFingerprint
+	Addr        string // host:port to connect to for pairing
+}
+
+// Advertise announces this device as waiting to pair on port until the
+// returned func is called.
+func Advertise(key ed25519.PrivateKey, port int) (func(), error) {
+	txt := []string{
+		txtVersion + "=" + protocolVersion,
+		txtID + "=" + config.KeyFingerprint(key.Public().(ed25519.PublicKey)),
+	}
+	server, err := zeroconf.Register(config.DeviceName(), serviceType, serviceDomain, port, txt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advertise on the local network: %w", err)
+	}
+	return server.Shutdown, nil
+}
+
+// Browse lists the devices waiting to pair that answer within timeout,
+// leaving out this one.
+func Browse(key ed25519.PrivateKey, timeout time.Duration) ([]Nearby, error) {
+	resolver, err := zeroconf.NewResolver(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the local network: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	entries := make(chan *zeroconf.ServiceEntry)
+	if err := resolver.Browse(ctx, serviceType, serviceDomain, entries); err != nil {
+		return nil, fmt.Errorf("failed to search the local network: %w", err)
+	}
+
+	self := config.KeyFingerprint(key.Public().(ed25519.PublicKey))
+	found := []Nearby{}
+	seen := make(map[string]bool)
+	for entry := range entries {
+		nearby, ok := nearbyFromEntry(entry)
+		if !ok || nearby.Fingerprint == self || seen[nearby.Fingerprint] {
+			continue
+		}
+		seen[nearby.Fingerprint] = true
+		found = append(found, nearby)
+	}
+	return found, nil
+}
+
+// Find waits up to timeout for device to be seen waiting to pair.
+func Find(key ed25519.PrivateKey, device string, timeout time.Duration) (Nearby, error) {
+	found, err := Browse(key, timeout)
+	if err != nil {
+		return Nearby{}, err
+	}
+	for _, nearby := range found {
+		if nearby.Device == device {
+			return nearby, nil
+		}
+	}
+	return Nearby{}, fmt.Errorf("%s was not found on the local network, run `clipse peers pair` on it first", device)
+}
+
+func nearbyFromEntry(entry *zeroconf.ServiceEntry) (Nearby, bool) {
+	txt := make(map[string]string)
+	for _, field := range entry.Text {
+		if k, v, ok := strings.Cut(field, "="); ok {
+			txt[k] = v
+		}
+	}
+	if txt[txtVersion] != protocolVersion || txt[txtID] == "" {
+		return Nearby{}, false
+	}
+
+	var ip net.IP
+	switch {
+	case len(entry.AddrIPv4) > 0:
+		ip = entry.AddrIPv4[0]
+	case len(entry.AddrIPv6) > 0:
+		ip = entry.AddrIPv6[0]
+	default:
+		return Nearby{}, false
+	}
+	return Nearby{
+		Device:      unescapeInstance(entry.Instance),
+		Fingerprint: txt[txtID],
+		Addr:        net.JoinHostPort(ip.String(), strconv.Itoa(entry.Port)),
+	}, true
+}
+
+// instance names come back with DNS escaping, eg "my\ laptop"
+func unescapeInstance(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+// a match printed by clipse search --json
+type searchMatch struct {
+	Index int    `json:"index"`           // entry number for copy and the other commands taking N, 0 for sharded entries
+	Shard string `json:"shard,omitempty"` // month of the shard holding the entry, see config/shards.go
+	config.ClipboardItem
+}
+
+// prints the entries matching a filter query or regex, most recent first,
+// numbered so they can be passed to clipse copy, eg from fzf
+func handleSearch(args []string) {
+	fs := newFlagSet("search")
+	asJSON := fs.Bool("json", false, "Print the matches as a JSON array.")
+	regex := fs.Bool("regex", false, "Match the query as a regular expression against the entry text instead of as a filter query.")
+	since := fs.String("since", "", "Only entries recorded since then, eg `2h`, `3d`, `yesterday 18:00` or `2024-05-01`.")
+	limit := fs.Int("limit", 0, "Print at most this many matches, 0 for all.")
+	archive := fs.Bool("archive", false, "Also search the entries moved into shards, after those of the history.")
+	utils.HandleError(fs.Parse(args))
+	query := strings.Join(fs.Args(), " ")
+
+	match, err := searchMatcher(query, *regex)
+	if err != nil {
+		fail(utils.ExitUsage, "Invalid query: %s", err)
+	}
+	var after time.Time
+	if *since != "" {
+		if after, err = sinceTime(*since, time.Now()); err != nil {
+			fail(utils.ExitUsage, "Invalid --since: %s", err)
+		}
+	}
+
+	matches := []searchMatch{}
+	for n, item := range config.GetHistory() {
+		if *limit > 0 && len(matches) == *limit {
+			break
+		}
+		if !after.IsZero() {
+			if recorded, err := utils.ParseTimeStamp(item.Recorded); err != nil || recorded.Before(after) {
+				continue
+			}
+		}
+		if match(item) {
+			if item.Sensitive {
+				item.Value = ""
+			}
+			matches = append(matches, searchMatch{Index: n + 1, ClipboardItem: item})
+		}
+	}
+	if *archive && (*limit == 0 || len(matches) < *limit) {
+		archived, err := config.ArchivedItems(after, time.Time{})
+		if err != nil {
+			failErr(err)
+		}
+		for _, item := range archived {
+			if *limit > 0 && len(matches) == *limit {
+				break
+			}
+			if match(item) {
+				if item.Sensitive {
+					item.Value = ""
+				}
+				matches = append(matches, searchMatch{Shard: config.ShardOf(item), ClipboardItem: item})
+			}
+		}
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(matches, "", "  ")
+		utils.HandleError(err)
+		fmt.Println(string(out))
+		return
+	}
+	for _, m := range matches {
+		recorded, _ := utils.ParseTimeStamp(m.Recorded)
+		index := strconv.Itoa(m.Index)
+		if m.Shard != "" {
+			index = "-" // not in the history, so can't be copied by number
+		}
+		fmt.Printf("%s\t%s\t%s\n", index, utils.FormatDateTime(recorded), menuText(m.ClipboardItem, false))
+	}
+}
+
+// lists the shards the history was split into by month
+func handleShards(args []string) {
+	fs := newFlagSet("shards")
+	asJSON := fs.Bool("json", false, "Print the shards as a JSON array.")
+	utils.HandleError(fs.Parse(args))
+	if fs.NArg() > 0 {
+		failUsage("shards")
+	}
+
+	shards, err := config.ShardList()
+	if err != nil {
+		failErr(err)
+	}
+	if *asJSON {
+		out, err := json.MarshalIndent(shards, "", "  ")
+		utils.HandleError(err)
+		fmt.Println(string(out))
+		return
+	}
+	if len(shards) == 0 {
+		if !config.ClipseConfig.Shards.Enabled {
+			fmt.Println("No shards, set shards.enabled in config.json to split the history by month.")
+		} else {
+			fmt.Println("No shards yet, entries are moved into them once a month passes or maxHistory is reached.")
+		}
+		return
+	}
+	total := 0
+	for _, shard := range shards {
+		fmt.Printf("%s  %8s entries  %9s  %s\n", shard.Month, utils.FormatCount(shard.Entries), utils.FormatSize(shard.Size), shard.Path)
+		total += shard.Entries
+	}
+	fmt.Printf("\n%s entries in %s shards, search them with `%s search --archive`.\n", utils.FormatCount(total), utils.FormatCount(len(shards)), os.Args[0])
+}
+
+// returns whether an entry matches the query. Hidden sensitive entries are
+// only matched by their masked text, as in the TUI.
+func searchMatcher(query string, regex bool) (func(config.ClipboardItem) bool, error) {
+	if regex {
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, err
+		}
+		return func(item config.ClipboardItem) bool {
+			return item.FilePath == "null" && !item.Sensitive && re.MatchString(item.Value)
+		}, nil
+	}
+	q, err := config.ParseItemQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return func(item config.ClipboardItem) bool {
+		if item.Sensitive {
+			item.Value = menuMasked
+		}
+		return q.Matches(item)
+	}, nil
+}
+
+// reads a duration back from now, eg 2h or 3d, or a point in time as taken
+// by clipse restore
+func sinceTime(spec string, now time.Time) (time.Time, error) {
+	if d, err := utils.ParseDuration(spec); err == nil {
+		return now.Add(-d), nil
+	}
+	return utils.ParseTime(spec, now)
+}
@@ -0,0 +1,56 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RunAction runs a user command from the action menu with sh and returns
+// what it printed. The entry replaces each {} in the command, quoted, or
+// is written to its stdin if there is none.
+func RunAction(ctx context.Context, command, value string, stdin io.Reader) (string, error) {
+	if strings.Contains(command, "{}") {
+		command = strings.ReplaceAll(command, "{}", shellQuote(value))
+		stdin = nil
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RunHook runs a user command hooked to an event of the history with sh,
+// the entry on its stdin and its metadata in env on top of the environment.
+// Returns what it printed to its stderr when it fails.
+func RunHook(ctx context.Context, command string, stdin io.Reader, env []string) error {
+	var stderr bytes.Buffer
+	cmd := commandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = stdin
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func EnableAutostart(bin string) (string, error) {
+	/*
+		Installs and enables a service starting the listener on
+		login, returning the path of the service file written. Uses
+		a systemd user unit on Linux and a launchd agent on macOS.
+	*/
+	binPath, err := binaryPath(bin)
+	if err != nil {
+		return "", err
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		return enableSystemd(binPath)
+	case "darwin":
+		return enableLaunchd(binPath)
+	default:
+		return "", fmt.Errorf("autostart is not supported on %s", runtime.GOOS)
+	}
+}
+
+func DisableAutostart() (string, error) {
+	/*
+		Stops and removes the service installed by EnableAutostart,
+		returning the path of the service file removed.
+	*/
+	switch runtime.GOOS {
+	case "linux":
+		return disableSystemd()
+	case "darwin":
+		return disableLaunchd()
+	default:
+		return "", fmt.Errorf("autostart is not supported on %s", runtime.GOOS)
+	}
+}
+
+func enableSystemd(binPath string) (string, error) {
+	unitPath, err := systemdUnitPath()
+	if err != nil {
+		return "", err
+	}
+	unit := fmt.Sprintf(systemdUnit, binPath, listenCmd)
+	if err := writeServiceFile(unitPath, unit); err != nil {
+		return "", err
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return unitPath, err
+	}
+	return unitPath, systemctl("enable", "--now", systemdUnitName)
+}
+
+func disableSystemd() (string, error) {
+	unitPath, err := systemdUnitPath()
+	if err != nil {
+		return "", err
+	}
+	if err := checkServiceFile(unitPath); err != nil {
+		return "", err
+	}
+
+	if err := systemctl("disable", "--now", systemdUnitName); err != nil {
+		return unitPath, err
+	}
+	if err := os.Remove(unitPath); err != nil {
+		return unitPath, err
+	}
+	return unitPath, systemctl("daemon-reload")
+}
+
+func enableLaunchd(binPath string) (string, error) {
+	plistPath, err := launchdPlistPath()
+	if err != nil {
+		return "", err
+	}
+	plist := fmt.Sprintf(launchdPlist, launchdLabel, binPath, listenCmd)
+	if err := writeServiceFile(plistPath, plist); err != nil {
+		return "", err
+	}
+
+	// replace a previously loaded agent, failing if none is loaded is fine
+	_ = launchctl("bootout", launchdDomain(), plistPath)
+	return plistPath, launchctl("bootstrap", launchdDomain(), plistPath)
+}
+
+func disableLaunchd() (string, error) {
+	plistPath, err := launchdPlistPath()
+	if err != nil {
+		return "", err
+	}
+	if err := checkServiceFile(plistPath); err != nil {
+		return "", err
+	}
+
+	if err := launchctl("bootout", launchdDomain(), plistPath); err != nil {
+		return plistPath, err
+	}
+	return plistPath, os.Remove(plistPath)
+}
+
+// $XDG_CONFIG_HOME/systemd/user/clipse.service
+func systemdUnitPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "systemd", "user", systemdUnitName), nil
+}
+
+// ~/Library/LaunchAgents/com.savedra1.clipse.plist
+func launchdPlistPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+}
+
+// the launchd domain of the logged in user's GUI session
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+func writeServiceFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func checkServiceFile(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("autostart is not enabled, %s does not exist", path)
+	}
+	return nil
+}
+
+func systemctl(args ...string) error {
+	return runServiceCmd(systemctlCmd, append([]string{"--user"}, args...)...)
+}
+
+func launchctl(args ...string) error {
+	return runServiceCmd(launchctlCmd, args...)
+}
+
+func runServiceCmd(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// resolves the absolute path of the running binary
+func binaryPath(bin string) (string, error) {
+	binPath, err := exec.LookPath(bin)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve clipse binary path: %w", err)
+	}
+	return filepath.Abs(binPath)
+}
@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+/* Reading and writing the X11 and wayland clipboards side by side, used
+to mirror changes between them in XWayland sessions. An empty mime type
+stands for text.
+*/
+
+// BridgeAvailable reports whether both an X11 and a wayland clipboard
+// can be used, as in XWayland sessions.
+func BridgeAvailable() bool {
+	if os.Getenv("DISPLAY") == "" || os.Getenv("WAYLAND_DISPLAY") == "" {
+		return false
+	}
+	for _, bin := range []string{xclipBin, wlCopyBin, wlPasteHandler} {
+		if _, err := exec.LookPath(bin); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadX11 returns the X11 clipboard content and its mime type.
+func ReadX11(ctx context.Context) ([]byte, string, error) {
+	mime := ""
+	if types, err := commandContext(ctx, "sh", "-c", xListTypesCmd).Output(); err == nil {
+		mime = imageMime(string(types))
+	}
+	args := []string{"-selection", "clipboard", "-o"}
+	if mime != "" {
+		args = append(args, "-t", mime)
+	}
+	data, err := commandContext(ctx, xclipBin, args...).Output()
+	return data, mime, err
+}
+
+// ReadWayland returns the wayland clipboard content and its mime type.
+func ReadWayland(ctx context.Context) ([]byte, string, error) {
+	mime := ""
+	if types, err := commandContext(ctx, "sh", "-c", wlListTypesCmd).Output(); err == nil {
+		mime = imageMime(string(types))
+	}
+	args := []string{"--no-newline"}
+	if mime != "" {
+		args = append(args, "--type", mime)
+	}
+	data, err := commandContext(ctx, wlPasteHandler, args...).Output()
+	return data, mime, err
+}
+
+// WriteX11 sets the X11 clipboard. xclip forks to serve the selection so
+// its output is not captured, which would wait for the fork to exit.
+func WriteX11(ctx context.Context, data []byte, mime string) error {
+	args := []string{"-selection", "clipboard", "-i"}
+	if mime != "" {
+		args = append(args, "-t", mime)
+	}
+	cmd := commandContext(ctx, xclipBin, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	return cmd.Run()
+}
+
+// WriteWayland sets the wayland clipboard.
+func WriteWayland(ctx context.Context, data []byte, mime string) error {
+	args := []string{}
+	if mime != "" {
+		args = append(args, "--type", mime)
+	}
+	cmd := commandContext(ctx, wlCopyBin, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	return cmd.Run()
+}
+
+// the image type offered in a list of clipboard targets, if any
+func imageMime(types string) string {
+	for _, t := range strings.Fields(types) {
+		if t == pngMime || t == jpegMime {
+			return t
+		}
+	}
+	return ""
+}
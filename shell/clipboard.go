@@ -0,0 +1,163 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+/* Reading and writing the text clipboard with a deadline. The tools
+atotto/clipboard would run are run here instead and killed once the
+context is done, so a hung xclip, eg when the selection owner stops
+answering, can't stall the listener or the TUI. Clipboards it reads
+another way, eg the Windows API or termux, are still read by
+atotto/clipboard, only waited on until the context is done.
+*/
+
+// how long a killed command may keep its output open, eg through a child
+// xclip forked to serve the selection, before it is no longer waited on
+const killWaitDelay = 500 * time.Millisecond
+
+// how long ImagesEnabled waits for the clipboard tool to print its version
+const versionTimeout = 3 * time.Second
+
+var clipboardTools struct {
+	once                      sync.Once
+	paste, copy               []string
+	primaryPaste, primaryCopy []string // of the primary selection, nil without one
+}
+
+// the commands reading and writing the clipboard, picked as atotto/clipboard
+// does, nil if it doesn't use one that can be killed
+func clipboardCommands() ([]string, []string) {
+	clipboardTools.once.Do(func() {
+		found := func(bins ...string) bool {
+			for _, bin := range bins {
+				if _, err := exec.LookPath(bin); err != nil {
+					return false
+				}
+			}
+			return true
+		}
+		t := &clipboardTools
+		switch {
+		case runtime.GOOS == "darwin":
+			t.paste, t.copy = []string{"pbpaste"}, []string{"pbcopy"}
+		case runtime.GOOS == "windows":
+		case os.Getenv("WAYLAND_DISPLAY") != "" && found(wlCopyBin, wlPasteHandler):
+			t.paste, t.copy = []string{wlPasteHandler, "--no-newline"}, []string{wlCopyBin}
+			t.primaryPaste, t.primaryCopy = []string{wlPasteHandler, "--primary", "--no-newline"}, []string{wlCopyBin, "--primary"}
+		case found(xclipBin):
+			t.paste, t.copy = []string{xclipBin, "-out", "-selection", "clipboard"}, []string{xclipBin, "-in", "-selection", "clipboard"}
+			t.primaryPaste, t.primaryCopy = []string{xclipBin, "-out", "-selection", "primary"}, []string{xclipBin, "-in", "-selection", "primary"}
+		case found(xselBin):
+			t.paste, t.copy = []string{xselBin, "--output", "--clipboard"}, []string{xselBin, "--input", "--clipboard"}
+			t.primaryPaste, t.primaryCopy = []string{xselBin, "--output", "--primary"}, []string{xselBin, "--input", "--primary"}
+		}
+	})
+	return clipboardTools.paste, clipboardTools.copy
+}
+
+// the commands reading and writing the primary selection, nil without one
+func primaryCommands() ([]string, []string) {
+	clipboardCommands()
+	return clipboardTools.primaryPaste, clipboardTools.primaryCopy
+}
+
+// a command killed when ctx is done, which is then waited on for at most
+// killWaitDelay
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = killWaitDelay
+	return cmd
+}
+
+// runs fn, returning the error of ctx if it is done first. fn can't be
+// stopped so it keeps running in the background.
+func waitFor[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// ReadClipboard returns the text on the clipboard.
+func ReadClipboard(ctx context.Context) (string, error) {
+	pasteCmd, _ := clipboardCommands()
+	if pasteCmd == nil {
+		return waitFor(ctx, clipboard.ReadAll)
+	}
+	out, err := commandContext(ctx, pasteCmd[0], pasteCmd[1:]...).Output()
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return string(out), err
+}
+
+// WriteClipboard puts the text on the clipboard.
+func WriteClipboard(ctx context.Context, text string) error {
+	_, copyCmd := clipboardCommands()
+	if copyCmd == nil {
+		_, err := waitFor(ctx, func() (struct{}, error) {
+			return struct{}{}, clipboard.WriteAll(text)
+		})
+		return err
+	}
+	cmd := commandContext(ctx, copyCmd[0], copyCmd[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	err := cmd.Run()
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// ErrNoPrimary is returned without a tool reaching the primary selection.
+var ErrNoPrimary = errors.New("no tool found to reach the primary selection, install xclip, xsel or wl-clipboard")
+
+// ReadPrimary returns the text on the primary selection.
+func ReadPrimary(ctx context.Context) (string, error) {
+	pasteCmd, _ := primaryCommands()
+	if pasteCmd == nil {
+		return "", ErrNoPrimary
+	}
+	out, err := commandContext(ctx, pasteCmd[0], pasteCmd[1:]...).Output()
+	if err != nil && ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return string(out), err
+}
+
+// WritePrimary puts the text on the primary selection.
+func WritePrimary(ctx context.Context, text string) error {
+	_, copyCmd := primaryCommands()
+	if copyCmd == nil {
+		return ErrNoPrimary
+	}
+	cmd := commandContext(ctx, copyCmd[0], copyCmd[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	err := cmd.Run()
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
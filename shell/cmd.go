@@ -1,9 +1,12 @@
 package shell
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -38,6 +41,10 @@ func KillExistingFG() {
 		Only kill other clipboard TUI windows to prevent
 		file conflicts.
 	*/
+	if runtime.GOOS == "windows" {
+		// process args are not available to tell the TUI from the listener
+		return
+	}
 	currentPS := strconv.Itoa(syscall.Getpid())
 	cmd := exec.Command("sh", "-c", pgrepCmd)
 	output, err := cmd.Output()
@@ -56,7 +63,7 @@ func KillExistingFG() {
 
 	psList := strings.Split(string(output), "\n")
 	for _, ps := range psList {
-		if strings.Contains(ps, currentPS) || strings.Contains(ps, listenCmd) || strings.Contains(ps, wlStoreCmd) {
+		if strings.Contains(ps, currentPS) || strings.Contains(ps, listenCmd) || strings.Contains(ps, wlStoreCmd) || strings.Contains(ps, controlCmd) || strings.Contains(ps, pasteQueueCmd) || strings.Contains(ps, pasteKeysCmd) {
 			continue
 		}
 		if ps != "" {
@@ -66,6 +73,10 @@ func KillExistingFG() {
 }
 
 func KillAll(bin string) {
+	if runtime.GOOS == "windows" {
+		killAllWindows(bin)
+		return
+	}
 	cmd := exec.Command("pkill", "-f", bin)
 	err := cmd.Run() // Wait for this to finish before executing
 	if err != nil {
@@ -74,20 +85,60 @@ func KillAll(bin string) {
 	}
 }
 
-func RunNohupListener(displayServer string) {
+// Starts the background listener. If a passphrase is given it is handed to
+// the listener over a pipe to unlock the encrypted history. With bridge set
+// X11 clipboard changes are mirrored to wayland, see BridgeAvailable.
+func RunNohupListener(displayServer string, passphrase []byte, bridge, sync, pprof bool) {
 	switch displayServer {
 	case "wayland":
 		// run optimized wl-clipboard listener
 		utils.HandleError(nohupCmdWL("image/png").Start())
 		utils.HandleError(nohupCmdWL("text").Start())
+		// wl-paste stores each change from a new process, the control
+		// socket needs one that keeps running
+		utils.HandleError(exec.Command("nohup", daemonArgs(controlCmd, pprof, ">/dev/null", "2>&1", "&")...).Start())
+		if bridge {
+			utils.HandleError(exec.Command("nohup", os.Args[0], bridgeCmd, ">/dev/null", "2>&1", "&").Start())
+		}
+		if sync {
+			// the other listeners sync from the listener process
+			utils.HandleError(exec.Command("nohup", os.Args[0], syncCmd, syncWatchFlag, ">/dev/null", "2>&1", "&").Start())
+		}
+
+	case "windows":
+		// no nohup, the listener is started detached from the console
+		args := daemonArgs(listenCmd, pprof)
+		startListener(detach(exec.Command(args[0], args[1:]...)), passphrase)
 
 	default:
 		// run default poll listener
-		cmd := exec.Command("nohup", os.Args[0], listenCmd, ">/dev/null", "2>&1", "&")
-		utils.HandleError(cmd.Start())
+		startListener(exec.Command("nohup", daemonArgs(listenCmd, pprof, ">/dev/null", "2>&1", "&")...), passphrase)
 	}
 }
 
+// the command line of the long running listener process, the pprof flag
+// goes before the rest as flags after them aren't parsed
+func daemonArgs(cmd string, pprof bool, rest ...string) []string {
+	args := []string{os.Args[0], cmd}
+	if pprof {
+		args = append(args, pprofFlag)
+	}
+	return append(args, rest...)
+}
+
+func startListener(cmd *exec.Cmd, passphrase []byte) {
+	if passphrase != nil {
+		r, w, err := os.Pipe()
+		utils.HandleError(err)
+		_, err = w.Write(append(passphrase, '\n'))
+		utils.HandleError(err)
+		w.Close()
+		defer r.Close()
+		cmd.Stdin = r
+	}
+	utils.HandleError(cmd.Start())
+}
+
 func nohupCmdWL(dataType string) *exec.Cmd {
 	cmd := exec.Command(
 		"nohup",
@@ -105,8 +156,87 @@ func nohupCmdWL(dataType string) *exec.Cmd {
 }
 
 func KillProcess(ppid string) {
+	if runtime.GOOS == "windows" {
+		if err := killPID(ppid); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to kill process: %s", err))
+		}
+		return
+	}
 	cmd := exec.Command("kill", ppid)
 	if err := cmd.Run(); err != nil {
 		utils.LogERROR(fmt.Sprintf("failed to kill process: %s", err))
 	}
 }
+
+// Returns true if the current clipboard content was marked as a secret
+// by a password manager.
+func PasswordManagerHint(ctx context.Context, displayServer string) bool {
+	var cmd *exec.Cmd
+	switch displayServer {
+	case "wayland":
+		cmd = commandContext(ctx, "sh", "-c", wlListTypesCmd)
+	case "x11":
+		cmd = commandContext(ctx, "sh", "-c", xListTypesCmd)
+	default:
+		return false
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), pwManagerHint)
+}
+
+// kills the process with the Windows API, there is no kill command
+func killPID(pid string) error {
+	n, err := strconv.Atoi(pid)
+	if err != nil {
+		return err
+	}
+	p, err := os.FindProcess(n)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// pkill -f equivalent matching the executable name, eg clipse.exe
+func killAllWindows(bin string) {
+	name := strings.TrimSuffix(filepath.Base(bin), ".exe") + ".exe"
+	psList, err := ps.Processes()
+	if err != nil {
+		utils.LogERROR(fmt.Sprintf("Failed to list processes: %s", err))
+		return
+	}
+	for _, p := range psList {
+		if p.Pid() == syscall.Getpid() || !strings.EqualFold(p.Executable(), name) {
+			continue
+		}
+		if err := killPID(strconv.Itoa(p.Pid())); err != nil {
+			utils.LogERROR(fmt.Sprintf("Failed to kill process %d: %s", p.Pid(), err))
+		}
+	}
+}
+
+// MissingClipboardTools returns the commands reading and writing the
+// clipboard of the display server needs that aren't installed.
+func MissingClipboardTools(displayServer string) []string {
+	switch displayServer {
+	case "wayland":
+		missing := []string{}
+		for _, bin := range []string{wlCopyBin, wlPasteHandler} {
+			if _, err := exec.LookPath(bin); err != nil {
+				missing = append(missing, bin)
+			}
+		}
+		return missing
+	case "x11":
+		for _, bin := range []string{xclipBin, xselBin} {
+			if _, err := exec.LookPath(bin); err == nil {
+				return nil
+			}
+		}
+		return []string{xclipBin + " or " + xselBin}
+	}
+	return nil
+}
@@ -13,4 +13,115 @@ const (
 	xVersionCmd    = "xclip -v"
 	xCopyImgCmd    = "xclip -selection clipboard -t image/png -i"
 	xPasteImgCmd   = "xclip -selection clipboard -t image/png -o >"
+	xdgMimeCmd     = "xdg-mime"
+	xdgOpenBin     = "xdg-open"
+	openBin        = "open"
+	rundll32Bin    = "rundll32"
+	tmuxBin        = "tmux"
+	ttyPath        = "/dev/tty" // OSC 52 goes to the terminal even when stdout is piped
+	systemctlCmd   = "systemctl"
+	launchctlCmd   = "launchctl"
+	osascriptCmd   = "osascript"
+	wlListTypesCmd = "wl-paste --list-types"
+	xListTypesCmd  = "xclip -selection clipboard -t TARGETS -o"
+	pwManagerHint  = "x-kde-passwordManagerHint" // set by KeePassXC and others on secret copies
+	bridgeCmd      = "--bridge-x11"              // internal
+	controlCmd     = "--control"                 // internal
+	pasteQueueCmd  = "--paste-queue"             // internal
+	pasteKeysCmd   = "--paste-keys"              // internal
+	pgrepWLCmd     = "pgrep -a wl-paste"
+	syncCmd        = "sync"
+	syncWatchFlag  = "--watch"
+	pprofFlag      = "--debug-pprof"
+	xclipBin       = "xclip"
+	xselBin        = "xsel"
+	wlCopyBin      = "wl-copy"
+	xpropBin       = "xprop"
+	hyprctlBin     = "hyprctl"
+	swaymsgBin     = "swaymsg"
+	xdotoolBin     = "xdotool"
+	wtypeBin       = "wtype"
+	ydotoolBin     = "ydotool"
+	gopassBin      = "gopass"
+	copyqBin       = "copyq"
+	greenclipBin   = "greenclip"
+	notifySendBin  = "notify-send"
+	pngMime        = "image/png"
+	htmlMime       = "text/html"
+	jpegMime       = "image/jpeg"
 )
+
+const (
+	linkMimeType    = "x-scheme-handler/clipse"
+	linkDesktopFile = "clipse-link-handler.desktop"
+)
+
+const linkDesktopEntry = `[Desktop Entry]
+Name=clipse
+Comment=Open clipse:// links in the clipse TUI
+Exec=%s -link %%u
+Terminal=true
+Type=Application
+NoDisplay=true
+MimeType=%s;
+`
+
+const systemdUnitName = "clipse.service"
+
+// the display environment comes from the user manager, which desktop
+// sessions import on login, eg with `systemctl --user import-environment`
+const systemdUnit = `[Unit]
+Description=clipse clipboard listener
+Documentation=https://github.com/savedra1/clipse
+PartOf=graphical-session.target
+After=graphical-session.target
+
+[Service]
+ExecStart=%s %s
+Restart=on-failure
+RestartSec=3
+
+[Install]
+WantedBy=graphical-session.target
+`
+
+const launchdLabel = "com.savedra1.clipse"
+
+const launchdPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ProcessType</key>
+	<string>Interactive</string>
+</dict>
+</plist>
+`
+
+// prints the pasteboard changeCount whenever it changes, polling it
+// in-process is far cheaper than reading the whole clipboard each cycle.
+// console.log writes to stderr.
+const pasteboardWatchScript = `ObjC.import("AppKit");
+var pb = $.NSPasteboard.generalPasteboard;
+var last = -1;
+while (true) {
+	var count = pb.changeCount;
+	if (count !== last) {
+		last = count;
+		console.log(count);
+	}
+	delay(%g);
+}`
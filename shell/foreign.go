@@ -0,0 +1,37 @@
+package shell
+
+import (
+	"context"
+	"os/exec"
+)
+
+/* CopyQ and greenclip keep their history in binary files, the migration
+assistant reads it from the output of their own commands instead, the
+same commands the README gives for clipse import --from.
+*/
+
+// the script printing the CopyQ history, NUL separated newest first
+const copyqPrintScript = `for (var i = 0; i < size(); ++i) print(str(read(i)) + "\0")`
+
+// HasHistoryExport reports whether tool, copyq or greenclip, is installed
+// so its history can be read with ExportHistory.
+func HasHistoryExport(tool string) bool {
+	switch tool {
+	case copyqBin, greenclipBin:
+		_, err := exec.LookPath(tool)
+		return err == nil
+	}
+	return false
+}
+
+// ExportHistory returns the history of tool, copyq or greenclip, in the
+// format clipse import --from reads. CopyQ's server must be running.
+func ExportHistory(ctx context.Context, tool string) ([]byte, error) {
+	switch tool {
+	case copyqBin:
+		return commandContext(ctx, copyqBin, "eval", copyqPrintScript).Output()
+	case greenclipBin:
+		return commandContext(ctx, greenclipBin, "print").Output()
+	}
+	return nil, exec.ErrNotFound
+}
@@ -0,0 +1,55 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+var ErrHTMLUnsupported = errors.New("copying HTML needs wl-copy on wayland or xclip on X11")
+
+// CopyHTML puts the markup on the clipboard as text/html, which rich text
+// editors paste formatted.
+func CopyHTML(ctx context.Context, markup, displayServer string) error {
+	var cmd *exec.Cmd
+	switch displayServer {
+	case "wayland":
+		cmd = commandContext(ctx, wlCopyBin, "--type", htmlMime)
+	case "x11":
+		cmd = commandContext(ctx, xclipBin, "-selection", "clipboard", "-t", htmlMime)
+	default:
+		return ErrHTMLUnsupported
+	}
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return ErrHTMLUnsupported
+	}
+	cmd.Stdin = strings.NewReader(markup)
+	return cmd.Run()
+}
+
+// ReadHTML returns the text/html offered on the clipboard along with the
+// text, empty if the copy had no formatting.
+func ReadHTML(ctx context.Context, displayServer string) (string, error) {
+	var list, read *exec.Cmd
+	switch displayServer {
+	case "wayland":
+		list = commandContext(ctx, "sh", "-c", wlListTypesCmd)
+		read = commandContext(ctx, wlPasteHandler, "--no-newline", wlTypeSpec, htmlMime)
+	case "x11":
+		list = commandContext(ctx, "sh", "-c", xListTypesCmd)
+		read = commandContext(ctx, xclipBin, "-selection", "clipboard", "-t", htmlMime, "-o")
+	default:
+		return "", ErrHTMLUnsupported
+	}
+	types, err := list.Output()
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(strings.Fields(string(types)), htmlMime) {
+		return "", nil
+	}
+	out, err := read.Output()
+	return string(out), err
+}
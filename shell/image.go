@@ -1,6 +1,7 @@
 package shell
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,12 +11,15 @@ import (
 )
 
 func ImagesEnabled(displayServer string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
 	var cmd *exec.Cmd
 	switch displayServer {
 	case "wayland":
-		cmd = exec.Command("sh", "-c", wlVersionCmd)
+		cmd = commandContext(ctx, "sh", "-c", wlVersionCmd)
 	case "x11", "darwin":
-		cmd = exec.Command("sh", "-c", xVersionCmd)
+		cmd = commandContext(ctx, "sh", "-c", xVersionCmd)
 	default:
 		return false
 	}
@@ -25,23 +29,23 @@ func ImagesEnabled(displayServer string) bool {
 	return true
 }
 
-func CopyImage(imagePath, displayServer string) error {
+func CopyImage(ctx context.Context, imagePath, displayServer string) error {
 	cmd := fmt.Sprintf("%s %s", xCopyImgCmd, imagePath)
 	if displayServer == "wayland" {
 		cmd = fmt.Sprintf("%s %s", wlCopyImgCmd, imagePath)
 	}
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
+	if err := commandContext(ctx, "sh", "-c", cmd).Run(); err != nil {
 		return err
 	}
 	return nil
 }
 
-func SaveImage(imagePath, displayServer string) error {
+func SaveImage(ctx context.Context, imagePath, displayServer string) error {
 	cmd := fmt.Sprintf("%s %s", xPasteImgCmd, imagePath)
 	if displayServer == "wayland" {
 		cmd = fmt.Sprintf("%s %s", wlPasteImgCmd, imagePath)
 	}
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
+	if err := commandContext(ctx, "sh", "-c", cmd).Run(); err != nil {
 		return err
 	}
 	return nil
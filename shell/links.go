@@ -0,0 +1,92 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func RegisterLinkHandler(bin string) (string, error) {
+	/*
+		Registers clipse as the handler for clipse:// links using
+		a desktop entry and xdg-mime. Only supported on Linux.
+	*/
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("link registration is not supported on %s", runtime.GOOS)
+	}
+
+	binPath, err := binaryPath(bin)
+	if err != nil {
+		return "", err
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	appsDir := filepath.Join(dataHome, "applications")
+	if err := os.MkdirAll(appsDir, 0755); err != nil {
+		return "", err
+	}
+
+	desktopPath := filepath.Join(appsDir, linkDesktopFile)
+	entry := fmt.Sprintf(linkDesktopEntry, desktopExecArg(binPath), linkMimeType)
+	if err := os.WriteFile(desktopPath, []byte(entry), 0644); err != nil {
+		return "", err
+	}
+
+	if err := exec.Command(xdgMimeCmd, "default", linkDesktopFile, linkMimeType).Run(); err != nil {
+		return desktopPath, fmt.Errorf("failed to set default handler with %s: %w", xdgMimeCmd, err)
+	}
+
+	return desktopPath, nil
+}
+
+// quotes an argument of a desktop entry's Exec key as the Desktop Entry
+// spec asks: in double quotes if it has reserved characters, with ", `, $
+// and \ escaped by a backslash. The value of the key then escapes each
+// backslash again, and line breaks and tabs, and % is doubled as it starts
+// field codes like %u.
+func desktopExecArg(arg string) string {
+	if strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`") {
+		var b strings.Builder
+		b.WriteByte('"')
+		for _, r := range arg {
+			if strings.ContainsRune("\"`$\\", r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('"')
+		arg = b.String()
+	}
+	arg = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(arg)
+	return strings.ReplaceAll(arg, "%", "%%")
+}
+
+// OpenURL opens the URL in the default browser without waiting for it to
+// close.
+func OpenURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command(openBin, url)
+	case "windows":
+		cmd = exec.Command(rundll32Bin, "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command(xdgOpenBin, url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,33 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Notify shows a desktop notification, with notify-send on Linux and
+// osascript on macOS.
+func Notify(ctx context.Context, title, body string) error {
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		args = []string{osascriptCmd, "-e", script}
+	case "windows":
+		return fmt.Errorf("notifications are not supported on %s", runtime.GOOS)
+	default:
+		args = []string{notifySendBin, "--app-name=clipse", title, body}
+	}
+	output, err := commandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if msg := strings.TrimSpace(string(output)); err != nil && msg != "" {
+		return fmt.Errorf("%s failed: %w: %s", args[0], err, msg)
+	}
+	return err
+}
+
+// quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,29 @@
+package shell
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// CopyOSC52 asks the terminal emulator to put text on its clipboard with an
+// OSC 52 escape sequence. This reaches the local clipboard from SSH sessions
+// as long as the terminal supports OSC 52.
+func CopyOSC52(text string) error {
+	seq := osc52.New(text)
+	// tmux forwards plain sequences with set-clipboard on, screen needs
+	// them wrapped
+	if os.Getenv("TMUX") == "" && strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+
+	var out io.Writer = os.Stdout
+	if tty, err := os.OpenFile(ttyPath, os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		out = tty
+	}
+	_, err := seq.WriteTo(out)
+	return err
+}
@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/* Entries of the pass or gopass password store, listed by name only. The
+secrets are copied by the password store itself, which clears them from
+the clipboard after a while, so clipse never reads them.
+*/
+
+// ListPasswords returns the names of the entries in the password store of
+// tool, pass or gopass.
+func ListPasswords(tool string) ([]string, error) {
+	if tool == gopassBin {
+		output, err := exec.Command(gopassBin, "ls", "--flat").Output()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Fields(string(output)), nil
+	}
+
+	dir := os.Getenv("PASSWORD_STORE_DIR")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".password-store")
+	}
+	names := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != dir:
+			return filepath.SkipDir // eg .git
+		case d.IsDir() || !strings.HasSuffix(d.Name(), ".gpg"):
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(strings.TrimSuffix(rel, ".gpg")))
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// CopyPasswordCmd returns the command copying the first line of the entry
+// to the clipboard. It may ask for the key's passphrase on the terminal.
+func CopyPasswordCmd(tool, name string) *exec.Cmd {
+	return exec.Command(tool, "show", "-c", name)
+}
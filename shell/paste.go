@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+/* Direct paste types the paste keys into the window focused once the TUI
+has closed, normally the one it was opened from. The keys are sent from a
+background process that waits for the focus to move back, as the TUI's
+terminal may be closed along with it. X11 uses xdotool, wayland wtype or,
+on compositors without the virtual keyboard protocol such as GNOME,
+ydotool. macOS always pastes with cmd+v.
+*/
+
+// linux input event codes of the keys ydotool can send
+var ydotoolCodes = map[string]int{
+	"ctrl": 29, "shift": 42, "alt": 56, "super": 125, "insert": 110,
+	"q": 16, "w": 17, "e": 18, "r": 19, "t": 20, "y": 21, "u": 22, "i": 23, "o": 24, "p": 25,
+	"a": 30, "s": 31, "d": 32, "f": 33, "g": 34, "h": 35, "j": 36, "k": 37, "l": 38,
+	"z": 44, "x": 45, "c": 46, "v": 47, "b": 48, "n": 49, "m": 50,
+}
+
+// StartPaste sends the paste keys from a background process, see
+// SendPasteKeys.
+func StartPaste() error {
+	return exec.Command("nohup", os.Args[0], pasteKeysCmd, ">/dev/null", "2>&1", "&").Start()
+}
+
+// SendPasteKeys types keys, eg ctrl+v, into the focused window.
+func SendPasteKeys(displayServer, keys string) error {
+	var cmd *exec.Cmd
+	switch displayServer {
+	case "x11":
+		cmd = exec.Command(xdotoolBin, "key", "--clearmodifiers", keys)
+	case "wayland":
+		var err error
+		if cmd, err = waylandPasteCmd(keys); err != nil {
+			return err
+		}
+	case "darwin":
+		cmd = exec.Command(osascriptCmd, "-e", `tell application "System Events" to keystroke "v" using command down`)
+	default:
+		return fmt.Errorf("direct paste is not supported on %s", displayServer)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", cmd.Path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func waylandPasteCmd(keys string) (*exec.Cmd, error) {
+	parts := strings.Split(strings.ToLower(keys), "+")
+	mods, key := parts[:len(parts)-1], parts[len(parts)-1]
+
+	if _, err := exec.LookPath(wtypeBin); err == nil {
+		// eg wtype -M ctrl -k v -m ctrl
+		args := []string{}
+		for _, mod := range mods {
+			args = append(args, "-M", mod)
+		}
+		args = append(args, "-k", key)
+		for _, mod := range mods {
+			args = append(args, "-m", mod)
+		}
+		return exec.Command(wtypeBin, args...), nil
+	}
+
+	if _, err := exec.LookPath(ydotoolBin); err != nil {
+		return nil, fmt.Errorf("direct paste on wayland needs %s or %s", wtypeBin, ydotoolBin)
+	}
+	// eg ydotool key 29:1 47:1 47:0 29:0, pressing then releasing in reverse
+	presses, releases := []string{}, []string{}
+	for _, k := range parts {
+		code, ok := ydotoolCodes[k]
+		if !ok {
+			return nil, fmt.Errorf("%s can't send the key %q", ydotoolBin, k)
+		}
+		presses = append(presses, strconv.Itoa(code)+":1")
+		releases = append([]string{strconv.Itoa(code) + ":0"}, releases...)
+	}
+	return exec.Command(ydotoolBin, append([]string{"key"}, append(presses, releases...)...)...), nil
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os/exec"
+)
+
+// the process is started with nohup instead, see RunNohupListener
+func detach(cmd *exec.Cmd) *exec.Cmd {
+	return cmd
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// process creation flags, see CreateProcess
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+// starts the process without a console so it outlives the shell, in place
+// of nohup
+func detach(cmd *exec.Cmd) *exec.Cmd {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | createNewProcessGroup,
+		HideWindow:    true,
+	}
+	return cmd
+}
@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Paste queue mode serves each entry on the paste stack until it has been
+pasted once, then the next. Only wl-copy can tell a paste apart from other
+reads of the clipboard, xclip counts the requests for the clipboard's types
+that most applications make before pasting, so the queue needs wayland.
+The wl-paste watchers of the listener would read each entry as it is
+served, counting as its paste, so they are stopped while the queue runs.
+*/
+
+// PasteQueueSupported reports whether pastes can advance the paste stack.
+func PasteQueueSupported(displayServer string) bool {
+	if displayServer != "wayland" {
+		return false
+	}
+	_, err := exec.LookPath(wlCopyBin)
+	return err == nil
+}
+
+// StartPasteQueue serves the paste stack from a background process,
+// replacing the queue of an earlier stack.
+func StartPasteQueue() error {
+	killPasteQueue()
+	return exec.Command("nohup", os.Args[0], pasteQueueCmd, ">/dev/null", "2>&1", "&").Start()
+}
+
+// PasteOnce puts data on the clipboard until it is pasted, returning false
+// if the clipboard was replaced by another copy first.
+func PasteOnce(data []byte, mime string) (bool, error) {
+	args := []string{"--foreground", "--paste-once"}
+	if mime != "" {
+		args = append(args, "--type", mime)
+	}
+	cmd := exec.Command(wlCopyBin, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	if err := cmd.Run(); err != nil {
+		return false, err
+	}
+	// a pasted entry leaves the clipboard empty
+	err := exec.Command("sh", "-c", wlListTypesCmd).Run()
+	return err != nil, nil
+}
+
+// StopWLWatchers stops the wl-paste watchers of the listener.
+func StopWLWatchers() {
+	output, err := exec.Command("sh", "-c", pgrepWLCmd).Output()
+	if err != nil {
+		return // no wl-paste processes
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.Contains(line, wlStoreCmd) {
+			KillProcess(strings.Fields(line)[0])
+		}
+	}
+}
+
+// StartWLWatchers restarts the wl-paste watchers of the listener.
+func StartWLWatchers() {
+	for _, dataType := range []string{"image/png", "text"} {
+		if err := nohupCmdWL(dataType).Start(); err != nil {
+			utils.LogERROR(fmt.Sprintf("failed to restart the %s listener: %s", dataType, err))
+		}
+	}
+}
+
+// WaylandListenerRunning reports whether the control process started with
+// the wayland listener is running.
+func WaylandListenerRunning() bool {
+	output, err := exec.Command("sh", "-c", pgrepCmd).Output()
+	return err == nil && strings.Contains(string(output), controlCmd)
+}
+
+func killPasteQueue() {
+	output, err := exec.Command("sh", "-c", pgrepCmd).Output()
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.Contains(line, pasteQueueCmd) {
+			KillProcess(strings.Fields(line)[0])
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// LoadTmuxBuffer loads text into a new tmux paste buffer, the one pasted
+// next with prefix ]. It reaches the tmux server of the session clipse runs
+// in, or the default one from outside tmux.
+func LoadTmuxBuffer(text string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(tmuxBin, "load-buffer", "-")
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// InTmux reports whether clipse runs inside a tmux session.
+func InTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
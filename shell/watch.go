@@ -0,0 +1,51 @@
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// WatchClipboard reports clipboard changes without reading the clipboard
+// content, checking a change counter every interval. Supported on macOS
+// and Windows. The channel is closed if the watcher stops.
+func WatchClipboard(interval time.Duration) (<-chan struct{}, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return watchPasteboard(interval)
+	case "windows":
+		return watchSequenceNumber(interval)
+	default:
+		return nil, fmt.Errorf("clipboard watching is not supported on %s", runtime.GOOS)
+	}
+}
+
+// watches the macOS pasteboard changeCount from a JXA script, which needs
+// no cgo
+func watchPasteboard(interval time.Duration) (<-chan struct{}, error) {
+	script := fmt.Sprintf(pasteboardWatchScript, interval.Seconds())
+	cmd := exec.Command(osascriptCmd, "-l", "JavaScript", "-e", script)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			select {
+			case changes <- struct{}{}:
+			default: // a read is already pending
+			}
+		}
+		_ = cmd.Wait()
+	}()
+	return changes, nil
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package shell
+
+import (
+	"errors"
+	"time"
+)
+
+func watchSequenceNumber(_ time.Duration) (<-chan struct{}, error) {
+	return nil, errors.New("clipboard sequence numbers are only available on Windows")
+}
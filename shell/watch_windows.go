@@ -0,0 +1,35 @@
+//go:build windows
+
+package shell
+
+import (
+	"syscall"
+	"time"
+)
+
+var getClipboardSequenceNumber = syscall.NewLazyDLL("user32.dll").NewProc("GetClipboardSequenceNumber")
+
+// polls GetClipboardSequenceNumber, which Windows increments on every
+// clipboard change
+func watchSequenceNumber(interval time.Duration) (<-chan struct{}, error) {
+	if err := getClipboardSequenceNumber.Find(); err != nil {
+		return nil, err
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		var last uintptr
+		for {
+			seq, _, _ := getClipboardSequenceNumber.Call()
+			if seq != last {
+				last = seq
+				select {
+				case changes <- struct{}{}:
+				default: // a read is already pending
+				}
+			}
+			time.Sleep(interval)
+		}
+	}()
+	return changes, nil
+}
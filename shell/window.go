@@ -0,0 +1,161 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ps "github.com/mitchellh/go-ps"
+)
+
+/* The application a copy came from, taken from the window focused when the
+listener sees the new clipboard content. X11 windows are read with xprop.
+Wayland has no common way to get the focused window, so only compositors
+with an IPC exposing it are supported: Hyprland and Sway.
+*/
+
+var (
+	activeWindowRe = regexp.MustCompile(`window id # (0x[0-9a-fA-F]+)`)
+	quotedRe       = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	windowPIDRe    = regexp.MustCompile(`_NET_WM_PID\(CARDINAL\) = (\d+)`)
+)
+
+// Window is the focused window, its fields are empty when unknown.
+type Window struct {
+	Class   string // eg firefox
+	Title   string
+	Process string // executable name of the process owning the window
+}
+
+// Name returns the class of the window, or its title if it has no class.
+func (w Window) Name() string {
+	if w.Class != "" {
+		return w.Class
+	}
+	return strings.TrimSpace(w.Title)
+}
+
+// ActiveWindow returns the focused window, empty when it can't be found.
+func ActiveWindow(ctx context.Context, displayServer string) Window {
+	var w Window
+	var pid int
+	switch {
+	case displayServer == "x11":
+		w, pid = x11ActiveWindow(ctx)
+	case displayServer != "wayland":
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		w, pid = hyprlandActiveWindow(ctx)
+	case os.Getenv("SWAYSOCK") != "":
+		w, pid = swayActiveWindow(ctx)
+	}
+	if pid > 0 {
+		if p, err := ps.FindProcess(pid); err == nil && p != nil {
+			w.Process = p.Executable()
+		}
+	}
+	return w
+}
+
+func x11ActiveWindow(ctx context.Context) (Window, int) {
+	out, err := commandContext(ctx, xpropBin, "-root", "_NET_ACTIVE_WINDOW").Output()
+	if err != nil {
+		return Window{}, 0
+	}
+	id := activeWindowRe.FindStringSubmatch(string(out))
+	if id == nil || id[1] == "0x0" {
+		return Window{}, 0
+	}
+	out, err = commandContext(ctx, xpropBin, "-id", id[1], "WM_CLASS", "_NET_WM_NAME", "_NET_WM_PID").Output()
+	if err != nil {
+		return Window{}, 0
+	}
+
+	/* eg
+	WM_CLASS(STRING) = "Navigator", "firefox"
+	_NET_WM_NAME(UTF8_STRING) = "Mozilla Firefox"
+	_NET_WM_PID(CARDINAL) = 4242
+	*/
+	var w Window
+	for _, line := range strings.Split(string(out), "\n") {
+		values := quotedRe.FindAllStringSubmatch(line, -1)
+		switch {
+		case len(values) == 0:
+		case strings.HasPrefix(line, "WM_CLASS"):
+			// the instance name then the class name
+			w.Class = values[len(values)-1][1]
+		case strings.HasPrefix(line, "_NET_WM_NAME"):
+			w.Title = values[0][1]
+		}
+	}
+	pid := 0
+	if m := windowPIDRe.FindStringSubmatch(string(out)); m != nil {
+		pid, _ = strconv.Atoi(m[1])
+	}
+	return w, pid
+}
+
+func hyprlandActiveWindow(ctx context.Context) (Window, int) {
+	out, err := commandContext(ctx, hyprctlBin, "activewindow", "-j").Output()
+	if err != nil {
+		return Window{}, 0
+	}
+	var window struct {
+		Class string `json:"class"`
+		Title string `json:"title"`
+		PID   int    `json:"pid"`
+	}
+	if err := json.Unmarshal(out, &window); err != nil {
+		return Window{}, 0
+	}
+	return Window{Class: window.Class, Title: window.Title}, window.PID
+}
+
+type swayNode struct {
+	Focused          bool   `json:"focused"`
+	Name             string `json:"name"`
+	AppID            string `json:"app_id"`
+	PID              int    `json:"pid"`
+	WindowProperties struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+func swayActiveWindow(ctx context.Context) (Window, int) {
+	out, err := commandContext(ctx, swaymsgBin, "-t", "get_tree").Output()
+	if err != nil {
+		return Window{}, 0
+	}
+	var root swayNode
+	if err := json.Unmarshal(out, &root); err != nil {
+		return Window{}, 0
+	}
+	focused := root.focused()
+	if focused == nil {
+		return Window{}, 0
+	}
+	w := Window{Class: focused.AppID, Title: focused.Name}
+	if w.Class == "" {
+		// XWayland windows have a class instead of an app id
+		w.Class = focused.WindowProperties.Class
+	}
+	return w, focused.PID
+}
+
+func (n *swayNode) focused() *swayNode {
+	if n.Focused {
+		return n
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if f := children[i].focused(); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/ipc"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+// names of the display servers shown by clipse status
+var backendNames = map[string]string{
+	"wayland": "Wayland",
+	"x11":     "X11",
+	"darwin":  "macOS",
+	"windows": "Windows",
+}
+
+func backendName(displayServer string) string {
+	if name, ok := backendNames[displayServer]; ok {
+		return name
+	}
+	return displayServer
+}
+
+// checks what clipse can do where it runs, eg without a display server or
+// clipboard tools, and lists the problems found. Exits with
+// utils.ExitFailure if there are any.
+func handleDoctor() {
+	ds := config.DisplayServer()
+	problems := []string{}
+
+	display := backendName(ds)
+	if config.NoDisplay() {
+		display = "none, $DISPLAY and $WAYLAND_DISPLAY are unset"
+		problems = append(problems, "No display server: copies aren't recorded and images can't be copied. "+
+			"The history can still be browsed, searched and exported, and text is copied through the terminal with OSC 52, if it supports it.")
+	}
+	fmt.Printf("Display:      %s\n", display)
+
+	clipboardTools := "found"
+	switch missing := shell.MissingClipboardTools(ds); {
+	case config.NoDisplay():
+		clipboardTools = "not used"
+	case len(missing) > 0:
+		clipboardTools = "missing " + strings.Join(missing, ", ")
+		problems = append(problems, fmt.Sprintf("Install %s to read and write the clipboard.", strings.Join(missing, " and ")))
+	}
+	fmt.Printf("Clipboard:    %s\n", clipboardTools)
+
+	copying := "system clipboard"
+	if config.UseOSC52() {
+		copying = "OSC 52 through the terminal"
+	}
+	fmt.Printf("Copying:      %s\n", copying)
+
+	images := "supported"
+	if !shell.ImagesEnabled(ds) {
+		images = "not supported"
+		if !config.NoDisplay() && (ds == "x11" || ds == "wayland") {
+			problems = append(problems, "Images are not recorded, install xclip on X11 or wl-clipboard on wayland.")
+		}
+	}
+	fmt.Printf("Images:       %s\n", images)
+
+	listener := "not running"
+	if status, err := ipc.GetStatus(); err == nil {
+		listener = fmt.Sprintf("running, PID %d", status.PID)
+	} else if !config.NoDisplay() {
+		problems = append(problems, fmt.Sprintf("The listener is not running, start it with `%s listen`.", os.Args[0]))
+	}
+	fmt.Printf("Listener:     %s\n", listener)
+
+	fmt.Printf("History:      %s entries, %s storage\n", utils.FormatCount(len(config.GetHistory())), config.ClipseConfig.Storage)
+
+	if len(problems) == 0 {
+		fmt.Println("\nNo problems found.")
+		return
+	}
+	fmt.Println("\nProblems:")
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	os.Exit(utils.ExitFailure)
+}
+
+// reports the listener and history, to check why nothing is being
+// recorded. Exits with utils.ExitNoDaemon if the listener isn't running.
+func handleStatus() {
+	now := time.Now()
+	status, err := ipc.GetStatus()
+	switch {
+	case errors.Is(err, ipc.ErrNotRunning):
+		fmt.Printf("Listener:     not running, start it with `%s listen`\n", os.Args[0])
+		status.DisplayServer = config.DisplayServer()
+		defer os.Exit(utils.ExitNoDaemon)
+	case err != nil:
+		fmt.Printf("Listener:     not responding: %s\n", err)
+		status.DisplayServer = config.DisplayServer()
+		defer os.Exit(utils.ExitFailure)
+	default:
+		listener := fmt.Sprintf("running, PID %d", status.PID)
+		if started, err := utils.ParseTimeStamp(status.Started); err == nil {
+			listener += fmt.Sprintf(", up %s", now.Sub(started).Round(time.Second))
+		}
+		fmt.Printf("Listener:     %s\n", listener)
+	}
+
+	fmt.Printf("Backend:      %s\n", backendName(status.DisplayServer))
+
+	recording := "on"
+	if paused, until := config.CapturePaused(); paused {
+		recording = fmt.Sprintf("paused until `%s resume`", os.Args[0])
+		if !until.IsZero() {
+			recording = "paused until " + utils.FormatDateTime(until)
+		}
+	}
+	fmt.Printf("Recording:    %s\n", recording)
+	if status.Pprof != "" {
+		fmt.Printf("Profiling:    http://%s/debug/pprof/\n", status.Pprof)
+	}
+
+	history := config.GetHistory()
+	path := config.HistoryPath()
+	size := ""
+	if info, err := os.Stat(path); err == nil {
+		size = ", " + utils.FormatSize(info.Size())
+	}
+	fmt.Printf("History:      %s entries%s, %s storage\n", utils.FormatCount(len(history)), size, config.ClipseConfig.Storage)
+	fmt.Printf("History file: %s\n", path)
+
+	last := ""
+	for _, item := range history {
+		if item.Device == "" && item.Recorded > last {
+			last = item.Recorded
+		}
+	}
+	if t, err := utils.ParseTimeStamp(last); err == nil {
+		fmt.Printf("Last capture: %s (%s)\n", utils.RelativeTime(t, now), utils.FormatDateTime(t))
+	} else {
+		fmt.Println("Last capture: none")
+	}
+}
+
+const digestPreviewLen = 50 // characters of the biggest entries shown by clipse digest
+
+func handleDigest(args []string) {
+	fs := newFlagSet("digest")
+	days := fs.Int("days", 7, "Sums up this many days back from now.")
+	asJSON := fs.Bool("json", false, "Print the digest as JSON.")
+	notify := fs.Bool("notify", false, "Send the digest as a desktop notification instead, eg from a timer.")
+	utils.HandleError(fs.Parse(args))
+	if fs.NArg() > 0 || *days <= 0 {
+		failUsage("digest")
+	}
+
+	now := time.Now()
+	if err := config.SampleStorageSize(now); err != nil {
+		utils.LogWARN(fmt.Sprintf("failed to sample the storage size: %s", err))
+	}
+	report := config.BuildDigest(now.AddDate(0, 0, -*days), now)
+	for i := range report.Biggest {
+		if report.Biggest[i].Sensitive {
+			report.Biggest[i].Value = ""
+		}
+	}
+
+	switch {
+	case *notify:
+		ctx, cancel := context.WithTimeout(context.Background(), config.ClipboardTimeout)
+		defer cancel()
+		if err := shell.Notify(ctx, fmt.Sprintf("clipse digest, %s days", utils.FormatCount(*days)), report.Summary()); err != nil {
+			fail(utils.ExitFailure, "Failed to send the digest: %s", err)
+		}
+		return
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		utils.HandleError(enc.Encode(report))
+		return
+	}
+
+	fmt.Printf("Digest:       %s to %s\n", utils.FormatDate(report.From), utils.FormatDate(report.To))
+	copies := utils.FormatCount(report.Copies) + " recorded"
+	if report.Synced > 0 {
+		copies += fmt.Sprintf(", %s synced from other devices", utils.FormatCount(report.Synced))
+	}
+	fmt.Printf("Copies:       %s\n", copies)
+
+	sources := "none known"
+	if len(report.Sources) > 0 {
+		top := make([]string, len(report.Sources))
+		for i, source := range report.Sources {
+			top[i] = fmt.Sprintf("%s (%s)", source.Source, utils.FormatCount(source.Copies))
+		}
+		sources = strings.Join(top, ", ")
+	}
+	fmt.Printf("Top sources:  %s\n", sources)
+
+	if len(report.Biggest) == 0 {
+		fmt.Println("Biggest:      none")
+	}
+	for i, entry := range report.Biggest {
+		label := "Biggest:"
+		if i > 0 {
+			label = ""
+		}
+		fmt.Printf("%-13s %9s  %s\n", label, utils.FormatSize(entry.Size), utils.Truncate(strings.Join(strings.Fields(menuText(entry.ClipboardItem, false)), " "), digestPreviewLen))
+	}
+
+	storage := utils.FormatSize(report.Storage)
+	if !report.Since.IsZero() {
+		storage += fmt.Sprintf(", %s since %s", config.FormatGrowth(report.Growth), utils.FormatDate(report.Since))
+	}
+	fmt.Printf("Storage:      %s\n", storage)
+}
+
+const (
+	defaultSegmentLen = 24 // characters of the latest entry shown by clipse prompt-segment
+	segmentPaused     = "⏸ paused"
+)
+
+// prints the latest entry on one line, or that recording is paused, for
+// shell prompts. Asks the listener, which keeps the latest entry in memory,
+// and only reads the history itself if no listener is running.
+func handlePromptSegment(args []string) {
+	fs := newFlagSet("prompt-segment")
+	maxLen := fs.Int("max", defaultSegmentLen, "Cuts the entry to this many characters.")
+	utils.HandleError(fs.Parse(args))
+
+	latest, status, err := ipc.Latest()
+	if errors.Is(err, ipc.ErrNotRunning) {
+		if config.NeedsPassphrase() {
+			return
+		}
+		unlockHistory()
+		if history := config.GetHistory(); len(history) > 0 {
+			latest = &history[0]
+		}
+		status.Paused, _ = config.CapturePaused()
+		err = nil
+	}
+	utils.HandleError(err)
+
+	switch {
+	case status.Paused:
+		fmt.Println(segmentPaused)
+	case latest == nil:
+	case latest.Sensitive:
+		fmt.Println(utils.Truncate(menuMasked, *maxLen))
+	default:
+		fmt.Println(utils.Truncate(latest.Value, *maxLen))
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/savedra1/clipse/app"
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/utils"
+)
+
+func handleRestore(args []string) {
+	fs := newFlagSet("restore")
+	at := fs.String("at", "", "Point in time to restore, eg `yesterday 18:00`, `2h ago` or `2024-05-01 12:00`.")
+	out := fs.String("out", "", "Dir to write the restored profile to. Defaults to restore-<time> in the config dir.")
+	apply := fs.Bool("apply", false, "Roll the current history back instead of writing a new profile.")
+	utils.HandleError(fs.Parse(args))
+
+	if *at == "" {
+		fail(utils.ExitUsage, "Missing --at. Usage: %s restore --at \"yesterday 18:00\" [--out <dir>] [--apply]", os.Args[0])
+	}
+	now := time.Now()
+	t, err := utils.ParseTime(*at, now)
+	if err != nil {
+		fail(utils.ExitUsage, "%s", err)
+	}
+
+	items, err := config.HistoryAt(t)
+	if err != nil {
+		fail(utils.ExitCode(err), "Cannot restore the history at %s: %s", utils.FormatDateTime(t), err)
+	}
+	if missing := missingImages(items); missing > 0 {
+		fmt.Printf("%d restored image entries point to image files that have since been deleted.\n", missing)
+	}
+
+	if *apply {
+		utils.HandleError(config.WriteUpdate(config.ClipboardHistory{ClipboardHistory: items}))
+		fmt.Printf("Rolled the history back to %s (%s entries).\n", utils.FormatDateTime(t), utils.FormatCount(len(items)))
+		fmt.Printf("Undo with: %s restore --at \"%s\" --apply\n", os.Args[0], now.Format(time.DateTime))
+		return
+	}
+
+	dir := *out
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(config.ClipseConfig.HistoryFilePath), "restore-"+t.Format("20060102-150405"))
+	}
+	if err := config.WriteRestoreProfile(dir, items); err != nil {
+		fail(utils.ExitCode(err), "Failed to write the restored profile: %s", err)
+	}
+	fmt.Printf("Restored the history at %s (%s entries) to %s\n", utils.FormatDateTime(t), utils.FormatCount(len(items)), dir)
+	fmt.Printf("Open it with: XDG_CONFIG_HOME=%s %s\n", dir, os.Args[0])
+}
+
+func handleImport(args []string) {
+	fs := newFlagSet("import")
+	snippets := fs.Bool("snippets", false, "Import snippets, from a JSON export or a CSV file with name and value columns.")
+	from := fs.String("from", "", "Import the history of another clipboard manager: copyq, gpaste, clipman or greenclip.")
+	utils.HandleError(fs.Parse(args))
+
+	if fs.NArg() != 1 || *snippets && *from != "" {
+		failUsage("import")
+	}
+	path := fs.Arg(0)
+
+	if *snippets {
+		list, err := config.ReadSnippetExport(path)
+		if err != nil {
+			fail(utils.ExitCode(err), "Failed to read %s: %s", path, err)
+		}
+		added, replaced, err := config.ImportSnippets(list)
+		utils.HandleError(err)
+		fmt.Printf("Imported %s snippets, replaced %s with the same name.\n", utils.FormatCount(added), utils.FormatCount(replaced))
+		return
+	}
+
+	var items []config.ClipboardItem
+	var err error
+	if *from != "" {
+		if err := config.ValidateImportSource(*from); err != nil {
+			failErr(err)
+		}
+		var skipped int
+		items, skipped, err = config.ReadForeignHistory(*from, path)
+		if skipped > 0 {
+			fmt.Printf("Skipped %s entries that are not text.\n", utils.FormatCount(skipped))
+		}
+	} else {
+		items, err = config.ReadExport(path)
+	}
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to read %s: %s", path, err)
+	}
+	result, err := config.ImportItems(items)
+	utils.HandleError(err)
+
+	fmt.Printf("Imported %s entries, merged %s duplicates.\n", utils.FormatCount(result.Added), utils.FormatCount(result.Merged))
+	if result.Dropped > 0 {
+		fmt.Printf("Removed %s unpinned entries to stay within maxHistory and maxAge.\n", utils.FormatCount(result.Dropped))
+	}
+}
+
+// writes the history or snippets to the file, or the stdout if none is
+// given
+func handleExport(args []string) {
+	fs := newFlagSet("export")
+	format := fs.String("format", config.ExportJSON, "Format of the export: json, csv or markdown.")
+	snippets := fs.Bool("snippets", false, "Export the snippets instead of the history.")
+	utils.HandleError(fs.Parse(args))
+
+	if fs.NArg() > 1 {
+		failUsage("export")
+	}
+	if err := config.ValidateExportFormat(*format); err != nil {
+		failErr(err)
+	}
+
+	var out io.Writer = os.Stdout
+	if path := fs.Arg(0); path != "" && path != "-" {
+		// the export holds everything copied, including sensitive entries
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			fail(utils.ExitCode(err), "Failed to create %s: %s", path, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	var err error
+	if *snippets {
+		err = config.ExportSnippets(out, config.GetSnippets(), *format)
+	} else {
+		err = config.ExportItems(out, config.GetHistory(), *format)
+	}
+	utils.HandleError(err)
+}
+
+func handleMigrate(args []string) {
+	fs := newFlagSet("migrate")
+	from := fs.String("from", config.ClipseConfig.Storage, "Storage to copy the history from, defaults to the configured one.")
+	utils.HandleError(fs.Parse(args))
+
+	if fs.NArg() == 0 && fs.NFlag() == 0 {
+		found, err := app.RunMigrator(true)
+		utils.HandleError(err)
+		if !found {
+			fmt.Println("Found no data of older versions or other clipboard managers to migrate.")
+		}
+		return
+	}
+	if fs.NArg() != 1 {
+		failUsage("migrate")
+	}
+	to := fs.Arg(0)
+
+	count, err := config.MigrateStorage(*from, to)
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to migrate the history: %s", err)
+	}
+
+	fmt.Printf("Copied %s entries from the %s to the %s storage.\n", utils.FormatCount(count), *from, to)
+	if config.ClipseConfig.Storage != to {
+		fmt.Printf("Set \"storage\": \"%s\" in config.json and restart the listener to start using it.\n", to)
+	}
+}
+
+func handleCompact() {
+	before, after, err := config.CompactHistory()
+	if errors.Is(err, config.ErrNotLogStorage) {
+		fmt.Printf("Nothing to compact, %s.\n", err)
+		return
+	}
+	utils.HandleError(err)
+	fmt.Printf("Compacted the history log from %s to %s records.\n", utils.FormatCount(before), utils.FormatCount(after))
+}
+
+func handlePrune(args []string) {
+	fs := newFlagSet("prune")
+	maxAge := fs.String("max-age", "", "Removes entries older than this, eg 72h or 30d, instead of the configured maxAge.")
+	utils.HandleError(fs.Parse(args))
+
+	var age time.Duration
+	if *maxAge != "" {
+		var err error
+		if age, err = config.ParseMaxAge(*maxAge); err != nil {
+			fail(utils.ExitUsage, "%s", err)
+		}
+	}
+
+	removed, err := config.PruneHistory(age)
+	if errors.Is(err, config.ErrNoMaxAge) {
+		fail(utils.ExitUsage, "Nothing to prune, %s. Set maxAge in config.json or use %s prune --max-age <age>.", err, os.Args[0])
+	}
+	utils.HandleError(err)
+	fmt.Printf("Removed %s expired entries.\n", utils.FormatCount(removed))
+}
+
+func missingImages(items []config.ClipboardItem) int {
+	missing := 0
+	for _, item := range items {
+		if item.FilePath == "null" {
+			continue
+		}
+		if _, err := os.Stat(item.FilePath); os.IsNotExist(err) {
+			missing++
+		}
+	}
+	return missing
+}
@@ -1,7 +1,42 @@
 package config
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
+
+	"github.com/savedra1/clipse/config"
 )
 
 func Test(_ *testing.T) {}
+
+// the fields only some storages kept survive a save and load with sqlite
+func TestSQLiteRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	config.ClipseConfig.Storage = config.StorageSQLite
+	config.ClipseConfig.HistoryFilePath = filepath.Join(dir, "clipboard_history.json")
+	config.ClipseConfig.DatabasePath = filepath.Join(dir, "clipboard_history.db")
+	config.ClipseConfig.JournalFilePath = filepath.Join(dir, "history_journal.jsonl")
+	config.ClipseConfig.TempDirPath = dir
+
+	added, err := config.AddClipboardItem(context.Background(), "copied", "null", "firefox")
+	if err != nil {
+		t.Fatalf("failed to add entry: %s", err)
+	}
+
+	selected, err := config.AddPrimaryItem(context.Background(), "selected", "kitty", false)
+	if err != nil {
+		t.Fatalf("failed to add primary selection entry: %s", err)
+	}
+
+	history := config.GetHistory()
+	if len(history) != 2 {
+		t.Fatalf("got %d entries, want 2", len(history))
+	}
+	if got := history[1]; got.Recorded != added.Recorded || got.Source != "firefox" || got.Selection != "" {
+		t.Errorf("got entry %+v, want source firefox from the clipboard", got)
+	}
+	if got := history[0]; got.Recorded != selected.Recorded || got.Source != "kitty" || got.Selection != config.SelectionPrimary {
+		t.Errorf("got entry %+v, want source kitty from the primary selection", got)
+	}
+}
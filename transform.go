@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/savedra1/clipse/config"
+	"github.com/savedra1/clipse/filters"
+	"github.com/savedra1/clipse/transforms"
+	"github.com/savedra1/clipse/utils"
+)
+
+func handleTransform(args []string) {
+	if len(args) != 2 || !utils.IsInt(args[1]) {
+		fail(
+			utils.ExitUsage, "Usage: %s transform <name> <N>\nAvailable transforms: %s",
+			os.Args[0], strings.Join(transforms.Names(), ", "),
+		)
+	}
+
+	transform, ok := transforms.Get(args[0])
+	if !ok {
+		fail(utils.ExitUsage, "Unknown transform %q. Available transforms: %s", args[0], strings.Join(transforms.Names(), ", "))
+	}
+
+	n, _ := strconv.Atoi(args[1])
+	parent, err := config.NthItem(n)
+	if err != nil {
+		failErr(err)
+	}
+	if parent.FilePath != "null" {
+		fail(utils.ExitFailure, "Transforms can only be applied to text entries.")
+	}
+
+	derived, err := transform(parent.Value)
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to apply %s: %s", args[0], err)
+	}
+	utils.HandleError(config.AddDerivedItem(derived, parent, args[0]))
+	fmt.Println(derived)
+}
+
+func handleTrace(args []string) {
+	if len(args) != 1 || !utils.IsInt(args[0]) {
+		failUsage("trace")
+	}
+
+	n, _ := strconv.Atoi(args[0])
+	item, err := config.NthItem(n)
+	if err != nil {
+		failErr(err)
+	}
+
+	chain := config.ProvenanceChain(item.Recorded)
+	for i, entry := range chain {
+		fmt.Printf("%s%s  %s\n", strings.Repeat("  ", i), entry.Recorded, utils.Shorten(entry.Value))
+		if entry.Transform != "" {
+			fmt.Printf("%s  <- %s\n", strings.Repeat("  ", i), entry.Transform)
+		}
+	}
+	if last := chain[len(chain)-1]; last.Parent != "" {
+		fmt.Printf("%s%s  (no longer in history)\n", strings.Repeat("  ", len(chain)), last.Parent)
+	}
+}
+
+func handleMap(args []string) {
+	fs := newFlagSet("map")
+	query := fs.String("filter", "", "Entries to transform, eg `type:url pinned:false github`.")
+	name := fs.String("transform", "", "Name of the transform to run over the matching entries.")
+	apply := fs.Bool("apply", false, "Write the changes to the history. Without this only a dry-run is shown.")
+	utils.HandleError(fs.Parse(args))
+
+	transform, ok := transforms.Get(*name)
+	if !ok {
+		fail(utils.ExitUsage, "Unknown transform %q. Available transforms: %s", *name, strings.Join(transforms.Names(), ", "))
+	}
+
+	q, err := config.ParseItemQuery(*query)
+	if err != nil {
+		fail(utils.ExitUsage, "Invalid filter: %s", err)
+	}
+
+	updates := make(map[string]config.ItemPatch)
+	for _, item := range config.QueryItems(q) {
+		if item.FilePath != "null" {
+			continue
+		}
+		updated, err := transform(item.Value)
+		if err != nil {
+			fmt.Printf("skipping %s: %s\n", item.Recorded, err)
+			continue
+		}
+		if updated == item.Value {
+			continue
+		}
+		updates[item.Recorded] = config.ItemPatch{Value: &updated}
+		fmt.Printf("%s\n  - %s\n  + %s\n", item.Recorded, utils.Shorten(item.Value), utils.Shorten(updated))
+	}
+
+	switch {
+	case len(updates) == 0:
+		fmt.Println("No entries would change.")
+	case !*apply:
+		fmt.Printf("\n%d entries would change. Re-run with --apply to write the changes.\n", len(updates))
+	default:
+		utils.HandleError(config.PatchItems(updates))
+		fmt.Printf("\nUpdated %d entries.\n", len(updates))
+	}
+}
+
+func handleFilters(args []string) {
+	if len(args) == 1 && args[0] == "packs" {
+		for _, pack := range filters.Packs() {
+			status := "disabled"
+			if filters.PackEnabled(pack.Name) {
+				status = "enabled"
+			}
+			fmt.Printf("%-14s v%-4s %-9s %s\n", pack.Name, pack.Version, status, pack.Description)
+		}
+		return
+	}
+
+	if len(args) < 1 || args[0] != "test" || len(args) > 2 {
+		failUsage("filters")
+	}
+
+	var input []byte
+	var err error
+	if len(args) < 2 || args[1] == "-" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(args[1])
+	}
+	utils.HandleError(err)
+
+	rules := filters.ConfiguredRules()
+	if len(rules) == 0 {
+		fmt.Println("No capture filters configured.")
+	}
+
+	res := filters.Run(string(input), rules)
+	matched := make(map[string]bool)
+	for _, m := range res.Matches {
+		matched[m.Rule] = true
+	}
+
+	for _, rule := range rules {
+		status := "no match"
+		if matched[rule.Name()] {
+			status = "matched"
+		}
+		fmt.Printf("%-24s %-8s %s\n", rule.Name(), rule.Action(), status)
+		if res.Ignored && matched[rule.Name()] {
+			break // later rules are never reached
+		}
+	}
+
+	if res.Ignored {
+		fmt.Println("\nResult: ignored, nothing would be stored.")
+		return
+	}
+	value, applied := config.ApplyCaptureTransforms(res.Value)
+	for _, name := range config.ClipseConfig.CaptureTransforms {
+		status := "unchanged"
+		if slices.Contains(applied, name) {
+			status = "changed"
+		}
+		fmt.Printf("%-24s %-8s %s\n", name, "transform", status)
+	}
+	fmt.Printf("\nResult: would be stored as:\n%s\n", value)
+}
@@ -0,0 +1,99 @@
+package transforms
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/* Case conversions for moving identifiers between languages. Each line is
+split into words at separators and case changes and joined again in the
+target case, so a list of identifiers is converted one per line.
+*/
+
+func camelCase(s string) (string, error) {
+	return convertLines(s, func(words []string) string {
+		return words[0] + strings.Join(capitalizeAll(words[1:]), "")
+	}), nil
+}
+
+func pascalCase(s string) (string, error) {
+	return convertLines(s, func(words []string) string {
+		return strings.Join(capitalizeAll(words), "")
+	}), nil
+}
+
+func snakeCase(s string) (string, error) {
+	return convertLines(s, func(words []string) string {
+		return strings.Join(words, "_")
+	}), nil
+}
+
+func kebabCase(s string) (string, error) {
+	return convertLines(s, func(words []string) string {
+		return strings.Join(words, "-")
+	}), nil
+}
+
+func constantCase(s string) (string, error) {
+	return convertLines(s, func(words []string) string {
+		return strings.ToUpper(strings.Join(words, "_"))
+	}), nil
+}
+
+func titleCase(s string) (string, error) {
+	return convertLines(s, func(words []string) string {
+		return strings.Join(capitalizeAll(words), " ")
+	}), nil
+}
+
+// joins the words of each line, leaving lines without any as they are
+func convertLines(s string, join func(words []string) string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if words := splitWords(line); len(words) > 0 {
+			lines[i] = join(words)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splits s into lower case words at anything but letters and digits and
+// where the case changes, eg parseHTTPRequest_v2 is parse http request v2
+func splitWords(s string) []string {
+	words := []string{}
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || acronymEnd {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+func capitalizeAll(words []string) []string {
+	capitalized := make([]string, len(words))
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		capitalized[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+	return capitalized
+}
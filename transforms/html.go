@@ -0,0 +1,142 @@
+package transforms
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/* HTML copied as text, eg a page's source or the markup of a rich text
+editor, converted to the text a browser would show: block elements and
+<br> start new lines, list items get a bullet, and scripts, styles and
+comments are dropped.
+*/
+
+// a document, or an element commonly found in copied markup, along with a
+// closing tag
+var htmlPattern = regexp.MustCompile(
+	`(?is)^\s*(<!doctype html|<html|<meta|<(p|div|span|a|b|i|u|em|strong|ul|ol|li|h[1-6]|table|tr|td|pre|code|blockquote)[\s>]).*</[a-z][a-z0-9]*\s*>`,
+)
+
+// elements starting a new line, those also followed by a blank line are
+// in paragraphElements
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "div": true,
+	"dl": true, "dt": true, "dd": true, "fieldset": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "tr": true, "ul": true,
+}
+
+var paragraphElements = map[string]bool{
+	"blockquote": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "p": true, "pre": true, "table": true,
+}
+
+// elements whose content isn't shown
+var hiddenElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true, "title": true,
+}
+
+// IsHTML reports whether s looks like HTML markup rather than text that
+// happens to contain a tag.
+func IsHTML(s string) bool {
+	return htmlPattern.MatchString(s)
+}
+
+func stripHTML(s string) (string, error) {
+	return HTMLToText(s), nil
+}
+
+// HTMLToText returns the text of the HTML markup.
+func HTMLToText(s string) string {
+	var out strings.Builder
+	hidden := 0 // depth inside hidden elements
+	pre := 0    // depth inside pre elements, where white space is kept
+	newLines := 2
+
+	// ends the current line, and leaves a blank one after it if blank
+	breakLine := func(blank bool) {
+		want := 1
+		if blank {
+			want = 2
+		}
+		for ; newLines < want; newLines++ {
+			out.WriteByte('\n')
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		token := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch {
+			case hiddenElements[token.Data]:
+				if tt == html.StartTagToken {
+					hidden++
+				}
+			case token.Data == "br":
+				out.WriteByte('\n')
+				newLines++
+			case blockElements[token.Data]:
+				breakLine(paragraphElements[token.Data])
+				if token.Data == "li" {
+					out.WriteString("- ")
+					newLines = 0
+				}
+				if token.Data == "pre" {
+					pre++
+				}
+			case token.Data == "td" || token.Data == "th":
+				if newLines == 0 {
+					out.WriteByte('\t')
+				}
+			}
+		case html.EndTagToken:
+			switch {
+			case hiddenElements[token.Data]:
+				hidden = max(hidden-1, 0)
+			case blockElements[token.Data]:
+				if token.Data == "pre" {
+					pre = max(pre-1, 0)
+				}
+				breakLine(paragraphElements[token.Data])
+			}
+		case html.TextToken:
+			if hidden > 0 {
+				continue
+			}
+			text := token.Data
+			if pre == 0 {
+				text = strings.Join(strings.Fields(text), " ")
+				if text == "" {
+					continue
+				}
+				// keep a space between inline elements
+				if newLines == 0 && !strings.HasSuffix(out.String(), " ") && strings.TrimLeft(token.Data, " \t\n\r") != token.Data {
+					text = " " + text
+				}
+				if strings.TrimRight(token.Data, " \t\n\r") != token.Data {
+					text += " "
+				}
+			}
+			out.WriteString(text)
+			newLines = 0
+			if strings.HasSuffix(text, "\n") {
+				newLines = 1
+			}
+		}
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,173 @@
+package transforms
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/savedra1/clipse/utils"
+)
+
+/* Numbers written with thousand separators and decimal commas or points,
+reformatted between the 1,234.56 and 1.234,56 styles or stripped down to
+1234.56 for spreadsheets and code. A single separator followed by three
+digits, as in 1,234, is read as the decimal separator only if it is the
+one of the locale. Numbers that are part of something else, such as
+versions and IP addresses, are left alone.
+*/
+
+// an optional sign and currency symbol, digits grouped by , . ' or a
+// non-breaking space and an optional decimal part, and a currency symbol
+var numberPattern = regexp.MustCompile(
+	`([-+\x{2212}]?)(\p{Sc}\s?)?((?:\d{1,3}(?:[,.'\x{2019}\x{a0}\x{202f}\x{2009}]\d{3})+|\d+)(?:[.,]\d+)?)(\s?\p{Sc})?`,
+)
+
+// a number found in the text
+type number struct {
+	sign    string
+	prefix  string // currency symbol before the number
+	suffix  string // currency symbol after the number
+	digits  string // whole part without separators
+	frac    string // decimal part without the separator
+	grouped bool
+}
+
+func decimalPoint(s string) (string, error) {
+	return reformatNumbers(s, func(n number) string {
+		return n.prefix + n.format(",", ".") + n.suffix
+	}), nil
+}
+
+func decimalComma(s string) (string, error) {
+	return reformatNumbers(s, func(n number) string {
+		return n.prefix + n.format(".", ",") + n.suffix
+	}), nil
+}
+
+// drops currency symbols and thousand separators, eg -$1,234.50 becomes
+// -1234.50
+func plainNumbers(s string) (string, error) {
+	return reformatNumbers(s, func(n number) string {
+		n.grouped = false
+		if n.sign == "−" {
+			n.sign = "-"
+		}
+		return n.format("", ".")
+	}), nil
+}
+
+// formats the number with the given separators, grouping the whole part
+// only if it was grouped before
+func (n number) format(thousands, decimal string) string {
+	var b strings.Builder
+	b.WriteString(n.sign)
+	for i, d := range n.digits {
+		if n.grouped && i > 0 && (len(n.digits)-i)%3 == 0 {
+			b.WriteString(thousands)
+		}
+		b.WriteRune(d)
+	}
+	if n.frac != "" {
+		b.WriteString(decimal + n.frac)
+	}
+	return b.String()
+}
+
+// replaces every standalone number of s with format's output
+func reformatNumbers(s string, format func(number) string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range numberPattern.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		if partOfWord(s[:start], s[end:]) {
+			continue
+		}
+		n, ok := parseNumber(s[m[6]:m[7]])
+		if !ok {
+			continue
+		}
+		n.sign = s[m[2]:m[3]]
+		if m[4] >= 0 {
+			n.prefix = s[m[4]:m[5]]
+		}
+		if m[8] >= 0 {
+			n.suffix = s[m[8]:m[9]]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(format(n))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// whether the match continues text before or after it, eg the 1.2 of
+// v1.2.3 or 10.0.0.1
+func partOfWord(before, after string) bool {
+	if r, _ := utf8.DecodeLastRuneInString(before); r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+		return true
+	}
+	if r, size := utf8.DecodeLastRuneInString(before); r == '.' || r == ',' {
+		if p, _ := utf8.DecodeLastRuneInString(before[:len(before)-size]); unicode.IsDigit(p) {
+			return true
+		}
+	}
+	if r, size := utf8.DecodeRuneInString(after); r == '.' || r == ',' {
+		next, _ := utf8.DecodeRuneInString(after[size:])
+		return unicode.IsDigit(next)
+	}
+	r, _ := utf8.DecodeRuneInString(after)
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// splits the digits of a number into its whole and decimal parts, false
+// if its separators are inconsistent, eg 1,234,56
+func parseNumber(s string) (number, bool) {
+	var n number
+	seps := []rune{}
+	groups := []string{""}
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			groups[len(groups)-1] += string(r)
+			continue
+		}
+		seps = append(seps, r)
+		groups = append(groups, "")
+	}
+	if len(seps) == 0 {
+		n.digits = s
+		return n, true
+	}
+
+	lastSep, lastGroup := seps[len(seps)-1], groups[len(groups)-1]
+	decimal := false
+	switch {
+	case lastSep != '.' && lastSep != ',':
+	case len(seps) > 1 && seps[0] != lastSep:
+		decimal = true
+	case len(seps) > 1:
+	case len(lastGroup) != 3 || len(groups[0]) > 3 || strings.HasPrefix(groups[0], "0"):
+		decimal = true
+	default:
+		decimal = string(lastSep) == utils.DecimalSeparator()
+	}
+
+	if decimal {
+		n.frac = lastGroup
+		seps, groups = seps[:len(seps)-1], groups[:len(groups)-1]
+	}
+	for _, sep := range seps {
+		if sep != seps[0] {
+			return n, false
+		}
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return n, false
+		}
+	}
+	n.digits = strings.Join(groups, "")
+	n.grouped = len(groups) > 1
+	return n, true
+}
@@ -0,0 +1,221 @@
+package transforms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/* Named transforms that derive a new history entry from an existing
+one. The transform name is recorded on the derived entry so it can be
+traced back to its parent and re-derived.
+*/
+
+type Func func(string) (string, error)
+
+var registry = map[string]Func{
+	"dedent":         dedent,
+	"base64-decode":  base64Decode,
+	"strip-trackers": stripTrackers,
+	"upper":          upper,
+	"lower":          lower,
+	"trim":           trim,
+	"base64-encode":  base64Encode,
+	"url-encode":     urlEncode,
+	"url-decode":     urlDecode,
+	"json-format":    jsonFormat,
+	"json-minify":    jsonMinify,
+	"camel-case":     camelCase,
+	"pascal-case":    pascalCase,
+	"snake-case":     snakeCase,
+	"kebab-case":     kebabCase,
+	"constant-case":  constantCase,
+	"title-case":     titleCase,
+	"typography":     typography,
+	"decimal-point":  decimalPoint,
+	"decimal-comma":  decimalComma,
+	"plain-numbers":  plainNumbers,
+	"strip-html":     stripHTML,
+}
+
+// shown next to the names in the TUI transform menu
+var descriptions = map[string]string{
+	"dedent":         "remove the indentation common to all lines",
+	"base64-decode":  "decode base64, standard or URL safe",
+	"strip-trackers": "remove tracking params such as utm_source from URLs",
+	"upper":          "UPPER CASE",
+	"lower":          "lower case",
+	"trim":           "remove leading and trailing white space",
+	"base64-encode":  "encode as base64",
+	"url-encode":     "escape for use in a URL query",
+	"url-decode":     "unescape %XX sequences and +",
+	"json-format":    "indent JSON",
+	"json-minify":    "remove the white space from JSON",
+	"camel-case":     "camelCase",
+	"pascal-case":    "PascalCase",
+	"snake-case":     "snake_case",
+	"kebab-case":     "kebab-case",
+	"constant-case":  "CONSTANT_CASE",
+	"title-case":     "Title Case",
+	"typography":     "replace curly quotes, dashes and special spaces with ASCII",
+	"decimal-point":  "write numbers as 1,234.56",
+	"decimal-comma":  "write numbers as 1.234,56",
+	"plain-numbers":  "remove currency symbols and thousand separators, eg 1234.56",
+	"strip-html":     "convert HTML to the text it shows",
+}
+
+// Get returns the transform registered under name.
+func Get(name string) (Func, bool) {
+	fn, ok := registry[name]
+	return fn, ok
+}
+
+// Description returns what the transform does.
+func Description(name string) string {
+	return descriptions[name]
+}
+
+// Names returns all registered transform names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// removes the common leading whitespace from every non-blank line
+func dedent(s string) (string, error) {
+	lines := strings.Split(s, "\n")
+
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix = indent
+			first = false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func base64Decode(s string) (string, error) {
+	trimmed := strings.TrimSpace(s)
+	decoded, err := base64.StdEncoding.DecodeString(trimmed)
+	if err != nil {
+		if decoded, err = base64.URLEncoding.DecodeString(trimmed); err != nil {
+			return "", fmt.Errorf("not valid base64: %w", err)
+		}
+	}
+	return string(decoded), nil
+}
+
+func base64Encode(s string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
+
+func upper(s string) (string, error) {
+	return strings.ToUpper(s), nil
+}
+
+func lower(s string) (string, error) {
+	return strings.ToLower(s), nil
+}
+
+func trim(s string) (string, error) {
+	return strings.TrimSpace(s), nil
+}
+
+func urlEncode(s string) (string, error) {
+	return url.QueryEscape(s), nil
+}
+
+func urlDecode(s string) (string, error) {
+	decoded, err := url.QueryUnescape(strings.TrimSpace(s))
+	if err != nil {
+		return "", fmt.Errorf("not URL encoded: %w", err)
+	}
+	return decoded, nil
+}
+
+func jsonFormat(s string) (string, error) {
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(s), "", "  "); err != nil {
+		return "", fmt.Errorf("not valid JSON: %w", err)
+	}
+	return b.String(), nil
+}
+
+func jsonMinify(s string) (string, error) {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(s)); err != nil {
+		return "", fmt.Errorf("not valid JSON: %w", err)
+	}
+	return b.String(), nil
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// query params used purely for tracking, matched by exact name or prefix
+var (
+	trackingParams = map[string]bool{
+		"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true,
+		"msclkid": true, "yclid": true, "igshid": true, "mc_cid": true, "mc_eid": true,
+		"_hsenc": true, "_hsmi": true, "mkt_tok": true, "ref_src": true, "si": true,
+	}
+	trackingPrefixes = []string{"utm_", "pk_", "__hs"}
+)
+
+// removes tracking query params from every URL in s
+func stripTrackers(s string) (string, error) {
+	return urlPattern.ReplaceAllStringFunc(s, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || u.RawQuery == "" {
+			return raw
+		}
+		query := u.Query()
+		removed := false
+		for param := range query {
+			if isTrackingParam(param) {
+				query.Del(param)
+				removed = true
+			}
+		}
+		if !removed {
+			return raw // re-encoding would reorder the params
+		}
+		u.RawQuery = query.Encode()
+		return u.String()
+	}), nil
+}
+
+func isTrackingParam(param string) bool {
+	param = strings.ToLower(param)
+	if trackingParams[param] {
+		return true
+	}
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(param, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package transforms
+
+import "strings"
+
+/* Typography picked up from web pages and word processors, replaced with
+the plain ASCII characters code and shells expect. Escapes are used as
+most of these look the same as their replacements in an editor.
+*/
+
+var typographyReplacer = strings.NewReplacer(
+	// single quotes, apostrophes and primes
+	"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201b", "'", "\u2032", "'",
+	// double quotes
+	"\u201c", `"`, "\u201d", `"`, "\u201e", `"`, "\u201f", `"`, "\u2033", `"`,
+	// hyphens and dashes, em dashes usually started out as --
+	"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2013", "-", "\u2212", "-",
+	"\u2014", "--", "\u2015", "--",
+	"\u2026", "...",
+	// non-breaking, narrow and other fixed width spaces
+	"\u00a0", " ", "\u2002", " ", "\u2003", " ", "\u2004", " ", "\u2005", " ",
+	"\u2006", " ", "\u2007", " ", "\u2008", " ", "\u2009", " ", "\u200a", " ",
+	"\u202f", " ", "\u205f", " ",
+	// zero width space, word joiner, byte order mark and soft hyphen
+	"\u200b", "", "\u2060", "", "\ufeff", "", "\u00ad", "",
+)
+
+func typography(s string) (string, error) {
+	return typographyReplacer.Replace(s), nil
+}
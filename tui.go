@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/savedra1/clipse/app"
+	"github.com/savedra1/clipse/shell"
+	"github.com/savedra1/clipse/utils"
+)
+
+func launchTUI() {
+	runMigrator()
+	resolveKeyConflicts()
+	runTUI(app.NewModel())
+}
+
+// offers to migrate data of older versions or other clipboard managers
+// found since the last start
+func runMigrator() {
+	if _, err := app.RunMigrator(false); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to run the migration assistant: %s", err))
+	}
+}
+
+// asks which action keeps a key bound to more than one, before the TUI
+// starts with the key bindings
+func resolveKeyConflicts() {
+	if err := app.ResolveKeyConflicts(); err != nil {
+		utils.LogERROR(fmt.Sprintf("failed to resolve key binding conflicts: %s", err))
+	}
+}
+
+func runTUI(newModel app.Model) {
+	shell.KillExistingFG()
+	p := tea.NewProgram(newModel)
+	go newModel.ListenRealTime(p)
+	finalModel, err := p.Run()
+	utils.HandleError(err)
+	if m, ok := finalModel.(app.Model); ok {
+		utils.HandleError(m.FlushWrites())
+	}
+}
+
+func handleLink(uri string) {
+	resolveKeyConflicts()
+	newModel := app.NewModel()
+	if err := newModel.OpenLink(uri); err != nil {
+		failErr(err)
+	}
+	runTUI(newModel)
+}
+
+// opens the TUI on the emoji picker, eg from a hotkey
+func handleEmoji() {
+	resolveKeyConflicts()
+	newModel := app.NewModel()
+	newModel.OpenEmoji()
+	runTUI(newModel)
+}
+
+func handleTmux() {
+	resolveKeyConflicts()
+	newModel := app.NewModel()
+	newModel.LoadIntoTmux()
+	runTUI(newModel)
+}
+
+func handleRegisterLinks() {
+	desktopPath, err := shell.RegisterLinkHandler(os.Args[0])
+	if err != nil {
+		fail(utils.ExitCode(err), "Failed to register clipse:// links: %s", err)
+	}
+	fmt.Printf("Registered clipse:// link handler: %s\n", desktopPath)
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/* Contrast of hex colors as defined by WCAG 2: the ratio of the relative
+luminance of the lighter color to the darker one, each plus 0.05, from 1
+for the same color to 21 for black on white.
+*/
+
+const maxContrastSteps = 20 // steps taken towards black or white when raising contrast
+
+type rgb struct{ r, g, b float64 }
+
+// parses #rgb or #rrggbb, colors given as ANSI numbers or names depend on
+// the terminal palette so aren't parsed
+func parseHexColor(s string) (rgb, bool) {
+	hex, ok := strings.CutPrefix(strings.TrimSpace(s), "#")
+	if !ok {
+		return rgb{}, false
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return rgb{}, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255}, true
+}
+
+func (c rgb) hex() string {
+	channel := func(v float64) int { return int(math.Round(math.Max(0, math.Min(1, v)) * 255)) }
+	return fmt.Sprintf("#%02x%02x%02x", channel(c.r), channel(c.g), channel(c.b))
+}
+
+func (c rgb) luminance() float64 {
+	linear := func(v float64) float64 {
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.r) + 0.7152*linear(c.g) + 0.0722*linear(c.b)
+}
+
+func (c rgb) mix(to rgb, t float64) rgb {
+	return rgb{c.r + (to.r-c.r)*t, c.g + (to.g-c.g)*t, c.b + (to.b-c.b)*t}
+}
+
+func contrast(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	return (math.Max(la, lb) + 0.05) / (math.Min(la, lb) + 0.05)
+}
+
+// ContrastRatio returns the contrast between two hex colors, false if
+// either isn't a hex color.
+func ContrastRatio(fg, bg string) (float64, bool) {
+	f, okF := parseHexColor(fg)
+	b, okB := parseHexColor(bg)
+	if !okF || !okB {
+		return 0, false
+	}
+	return contrast(f, b), true
+}
+
+// RaiseContrast lightens or darkens the hex color fg, whichever stands out
+// more against bg, just enough to reach a contrast of minRatio. It returns fg
+// unchanged if it isn't a hex color or already has the contrast.
+func RaiseContrast(fg, bg string, minRatio float64) string {
+	f, okF := parseHexColor(fg)
+	b, okB := parseHexColor(bg)
+	if !okF || !okB || contrast(f, b) >= minRatio {
+		return fg
+	}
+
+	target := rgb{1, 1, 1}
+	if contrast(rgb{0, 0, 0}, b) > contrast(target, b) {
+		target = rgb{0, 0, 0}
+	}
+	for step := 1; step <= maxContrastSteps; step++ {
+		c := f.mix(target, float64(step)/maxContrastSteps)
+		if contrast(c, b) >= minRatio {
+			return c.hex()
+		}
+	}
+	return target.hex()
+}
@@ -1,17 +1,71 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime/debug"
 )
 
+/* Exit codes of the CLI, so scripts can branch on why a command failed.
+Errors carrying a code are created with NewExitError, permission errors
+from the OS exit with ExitPermission and any other error with ExitFailure.
+*/
+
+const (
+	ExitFailure    = 1 // any other error
+	ExitUsage      = 2 // invalid flags or arguments
+	ExitNoDaemon   = 3 // no listener is running
+	ExitEmpty      = 4 // the history or paste stack is empty
+	ExitNotFound   = 5 // the entry asked for is not in the history
+	ExitCorrupt    = 6 // the history can't be read or repaired
+	ExitPermission = 7 // a file can't be accessed or the history can't be unlocked
+)
+
+var quiet bool // set by --quiet, errors only show in the exit code and log
+
+// SetQuiet stops HandleError printing to the terminal.
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// ExitError is an error that makes the CLI exit with its code.
+type ExitError struct {
+	Code int
+	msg  string
+}
+
+func NewExitError(code int, msg string) error {
+	return &ExitError{Code: code, msg: msg}
+}
+
+func (e *ExitError) Error() string {
+	return e.msg
+}
+
+// ExitCode returns the code the CLI exits with for err, 0 if it is nil.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.Is(err, os.ErrPermission):
+		return ExitPermission
+	default:
+		return ExitFailure
+	}
+}
+
 func HandleError(err error) {
 	if err != nil {
-		debug.PrintStack()
+		if !quiet {
+			debug.PrintStack()
+		}
 		if logger != nil {
 			LogERROR(fmt.Sprint(err))
 		}
-		os.Exit(1)
+		os.Exit(ExitCode(err))
 	}
 }
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+/* Locale aware formatting of dates, counts and sizes shown to the user.
+Only the conventions that differ between locales are covered: the clock,
+date order and separators. Month and weekday names stay in English as
+Go's time package has no translations, locales that don't use English
+get numeric dates instead.
+*/
+
+type Locale struct {
+	Clock12    bool   // 3:04 PM rather than 15:04
+	DateLayout string // full date, eg 02.01.2006
+	DayLayout  string // date within the current year, eg Jan 2
+	Thousands  string // digit group separator
+	Decimal    string // decimal separator
+}
+
+var isoLocale = Locale{
+	DateLayout: "2006-01-02",
+	DayLayout:  "01-02",
+	Thousands:  ",",
+	Decimal:    ".",
+}
+
+// date and number conventions by language
+var languageLocales = map[string]Locale{
+	"en": {DateLayout: "02/01/2006", DayLayout: "2 Jan", Thousands: ",", Decimal: "."},
+	"de": {DateLayout: "02.01.2006", DayLayout: "02.01.", Thousands: ".", Decimal: ","},
+	"nl": {DateLayout: "02-01-2006", DayLayout: "02-01", Thousands: ".", Decimal: ","},
+	"fr": {DateLayout: "02/01/2006", DayLayout: "02/01", Thousands: " ", Decimal: ","},
+	"es": {DateLayout: "02/01/2006", DayLayout: "02/01", Thousands: ".", Decimal: ","},
+	"it": {DateLayout: "02/01/2006", DayLayout: "02/01", Thousands: ".", Decimal: ","},
+	"pt": {DateLayout: "02/01/2006", DayLayout: "02/01", Thousands: ".", Decimal: ","},
+	"pl": {DateLayout: "02.01.2006", DayLayout: "02.01", Thousands: " ", Decimal: ","},
+	"cs": {DateLayout: "02.01.2006", DayLayout: "02.01.", Thousands: " ", Decimal: ","},
+	"ru": {DateLayout: "02.01.2006", DayLayout: "02.01", Thousands: " ", Decimal: ","},
+	"uk": {DateLayout: "02.01.2006", DayLayout: "02.01", Thousands: " ", Decimal: ","},
+	"fi": {DateLayout: "02.01.2006", DayLayout: "02.01.", Thousands: " ", Decimal: ","},
+	"nb": {DateLayout: "02.01.2006", DayLayout: "02.01.", Thousands: " ", Decimal: ","},
+	"da": {DateLayout: "02.01.2006", DayLayout: "02.01.", Thousands: ".", Decimal: ","},
+	"sv": {DateLayout: "2006-01-02", DayLayout: "01-02", Thousands: " ", Decimal: ","},
+	"tr": {DateLayout: "02.01.2006", DayLayout: "02.01", Thousands: ".", Decimal: ","},
+	"ja": {DateLayout: "2006/01/02", DayLayout: "01/02", Thousands: ",", Decimal: "."},
+	"zh": {DateLayout: "2006/01/02", DayLayout: "01/02", Thousands: ",", Decimal: "."},
+	"ko": {DateLayout: "2006. 01. 02.", DayLayout: "01. 02.", Thousands: ",", Decimal: "."},
+}
+
+// regions that differ from their language's conventions
+var regionLocales = map[string]Locale{
+	"en_US": {Clock12: true, DateLayout: "01/02/2006", DayLayout: "Jan 2", Thousands: ",", Decimal: "."},
+	"en_CA": {Clock12: true, DateLayout: "2006-01-02", DayLayout: "Jan 2", Thousands: ",", Decimal: "."},
+	"en_AU": {Clock12: true, DateLayout: "02/01/2006", DayLayout: "2 Jan", Thousands: ",", Decimal: "."},
+	"en_NZ": {Clock12: true, DateLayout: "02/01/2006", DayLayout: "2 Jan", Thousands: ",", Decimal: "."},
+	"en_IN": {Clock12: true, DateLayout: "02/01/2006", DayLayout: "2 Jan", Thousands: ",", Decimal: "."},
+	"en_PH": {Clock12: true, DateLayout: "01/02/2006", DayLayout: "Jan 2", Thousands: ",", Decimal: "."},
+	"de_CH": {DateLayout: "02.01.2006", DayLayout: "02.01.", Thousands: "'", Decimal: "."},
+	"pt_BR": {DateLayout: "02/01/2006", DayLayout: "02/01", Thousands: ".", Decimal: ","},
+	"es_MX": {Clock12: true, DateLayout: "02/01/2006", DayLayout: "02/01", Thousands: ",", Decimal: "."},
+}
+
+var locale = isoLocale
+
+// SetLocale selects the formatting conventions of name, eg "de_DE.UTF-8",
+// falling back to the LC_ALL, LC_TIME and LANG environment variables when
+// name is empty. clock is "12h" or "24h" to override the locale's clock.
+func SetLocale(name, clock string) {
+	if name == "" {
+		name = envLocale()
+	}
+	locale = lookupLocale(name)
+
+	switch clock {
+	case "12h":
+		locale.Clock12 = true
+	case "24h":
+		locale.Clock12 = false
+	case "":
+	default:
+		LogWARN(fmt.Sprintf("unknown clock %q, must be 12h or 24h", clock))
+	}
+}
+
+func envLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// finds the conventions of a POSIX locale name, eg en_GB.UTF-8 or de_AT@euro
+func lookupLocale(name string) Locale {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	name = strings.ReplaceAll(name, "-", "_")
+
+	if l, ok := regionLocales[name]; ok {
+		return l
+	}
+	language, _, _ := strings.Cut(strings.ToLower(name), "_")
+	if l, ok := languageLocales[language]; ok {
+		return l
+	}
+	return isoLocale
+}
+
+// FormatClock formats the time of day, eg 15:04 or 3:04 PM.
+func FormatClock(t time.Time) string {
+	if locale.Clock12 {
+		return t.Format("3:04 PM")
+	}
+	return t.Format("15:04")
+}
+
+// FormatDate formats the date, eg 02.01.2006.
+func FormatDate(t time.Time) string {
+	return t.Format(locale.DateLayout)
+}
+
+// FormatDateTime formats the date and time of day in local time.
+func FormatDateTime(t time.Time) string {
+	t = t.Local()
+	return FormatDate(t) + " " + FormatClock(t)
+}
+
+// DecimalSeparator returns the decimal separator of the locale, eg ",".
+func DecimalSeparator() string {
+	return locale.Decimal
+}
+
+// FormatCount formats n with digit grouping, eg 12,345.
+func FormatCount(n int) string {
+	digits := fmt.Sprint(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(locale.Thousands)
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// FormatSize formats a size in bytes, eg 512 B, 1.5 KB or 12 MB.
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return FormatCount(int(bytes)) + " B"
+	}
+
+	size := float64(bytes)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for size >= unit && i < len(suffixes)-1 {
+		size /= unit
+		i++
+	}
+
+	formatted := fmt.Sprintf("%.1f", size)
+	if size >= 10 {
+		formatted = fmt.Sprintf("%.0f", size)
+	}
+	formatted = strings.TrimSuffix(formatted, ".0")
+	return strings.Replace(formatted, ".", locale.Decimal, 1) + " " + suffixes[i]
+}
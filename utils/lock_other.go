@@ -0,0 +1,20 @@
+//go:build !unix
+
+package utils
+
+import "context"
+
+// FileLock is a no-op on platforms without flock support.
+type FileLock struct{}
+
+func LockFile(_ string, _ bool) (*FileLock, error) {
+	return &FileLock{}, nil
+}
+
+func LockFileContext(_ context.Context, _ string, _ bool) (*FileLock, error) {
+	return &FileLock{}, nil
+}
+
+func (l *FileLock) Unlock() error {
+	return nil
+}
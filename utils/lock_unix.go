@@ -0,0 +1,61 @@
+//go:build unix
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+const lockRetryInterval = 10 * time.Millisecond
+
+// FileLock is an advisory flock held on a dedicated lock file. A separate
+// lock file is used so the lock survives the locked file being replaced by
+// an atomic rename.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile blocks until a shared or exclusive lock is acquired on path,
+// creating the lock file if needed.
+func LockFile(path string, exclusive bool) (*FileLock, error) {
+	return LockFileContext(context.Background(), path, exclusive)
+}
+
+// LockFileContext is LockFile giving up with the error of ctx once it is
+// done.
+func LockFileContext(ctx context.Context, path string, exclusive bool) (*FileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+		if err == nil {
+			return &FileLock{file: file}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			file.Close()
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func (l *FileLock) Unlock() error {
+	defer l.file.Close()
+	return syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+}
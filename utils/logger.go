@@ -5,7 +5,10 @@ import (
 	"os"
 )
 
-var logger *log.Logger
+var (
+	logger  *log.Logger
+	pending []string // messages logged while loading the config, before the logger is set up
+)
 
 func SetUpLogger(logFilePath string) {
 	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
@@ -13,16 +16,29 @@ func SetUpLogger(logFilePath string) {
 		log.Fatalf("Failed to open log file: %s", err)
 	}
 	logger = log.New(file, "", log.LstdFlags|log.Lshortfile)
+
+	for _, message := range pending {
+		logger.Print(message)
+	}
+	pending = nil
 }
 
 func LogERROR(message string) {
-	logger.Printf("ERROR: %s", message)
+	logMessage("ERROR: " + message)
 }
 
 func LogINFO(message string) {
-	logger.Printf("INFO: %s", message)
+	logMessage("INFO: " + message)
 }
 
 func LogWARN(message string) {
-	logger.Printf("WARN: %s", message)
+	logMessage("WARN: " + message)
+}
+
+func logMessage(message string) {
+	if logger == nil {
+		pending = append(pending, message)
+		return
+	}
+	logger.Print(message)
 }
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// ReadPassphrase prompts for a passphrase on the controlling terminal
+// without echoing it, so it works even when stdin is piped.
+func ReadPassphrase(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal available to read passphrase: %w", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	passphrase, err := term.ReadPassword(tty.Fd())
+	fmt.Fprintln(tty)
+	if err != nil {
+		return nil, err
+	}
+	return passphrase, nil
+}
+
+// ReadPassphraseStdin reads a passphrase from the first line of stdin, used
+// by background processes that are handed the passphrase over a pipe.
+func ReadPassphraseStdin() ([]byte, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, fmt.Errorf("failed to read passphrase from stdin: %w", err)
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+var pprofAddr string // served by this process, empty if none
+
+// ServePprof serves the net/http/pprof endpoints on addr, which should be on
+// localhost, for as long as the process runs.
+func ServePprof(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	pprofAddr = listener.Addr().String()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			LogERROR("pprof server stopped: " + err.Error())
+		}
+	}()
+	return nil
+}
+
+// PprofAddr returns the address pprof is served on by this process, empty
+// if it isn't.
+func PprofAddr() string {
+	return pprofAddr
+}
@@ -0,0 +1,184 @@
+//go:build linux
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+/* Self-sandboxing of the listener processes with landlock and seccomp.
+Both are inherited by the processes they start, eg wl-paste and xclip,
+and can't be lifted once applied.
+*/
+
+const (
+	landlockRead = unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_EXECUTE
+
+	landlockWrite = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+
+	// rights that can be granted on a file rather than a dir
+	landlockFile = unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE
+)
+
+// Sandbox limits this process to reading and executing files below
+// readable and writing below writable, blocks TCP connections unless
+// allowTCP is set, and denies syscalls a clipboard daemon never needs, eg
+// ptrace and mount. Returns an error without applying anything if the
+// kernel doesn't support landlock.
+func Sandbox(writable, readable []string, allowTCP bool) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("landlock is not supported: %w", errno)
+	}
+
+	handled := uint64(landlockRead | landlockWrite)
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	if abi >= 4 && !allowTCP {
+		// no rules are added for TCP, so all binds and connections are denied
+		attr.Access_net = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+	}
+
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(fd))
+
+	for _, path := range readable {
+		if err := landlockAllow(int(fd), path, landlockRead&handled); err != nil {
+			return err
+		}
+	}
+	for _, path := range writable {
+		if err := landlockAllow(int(fd), path, handled); err != nil {
+			return err
+		}
+	}
+
+	// applied to every thread of the Go runtime, not only the calling one
+	if _, _, errno := syscall.AllThreadsSyscall(unix.SYS_PRCTL, unix.PR_SET_NO_NEW_PRIVS, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.AllThreadsSyscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to apply landlock ruleset: %w", errno)
+	}
+	return applySeccomp()
+}
+
+// adds a rule allowing access below path, paths that don't exist are skipped
+func landlockAllow(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err == unix.ENOENT || err == unix.ENOTDIR {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %s for sandbox rule: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		return fmt.Errorf("failed to stat %s for sandbox rule: %w", path, err)
+	}
+	if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= landlockFile
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(
+		unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0,
+	)
+	if errno != 0 {
+		return fmt.Errorf("failed to add sandbox rule for %s: %w", path, errno)
+	}
+	return nil
+}
+
+// denies the blocked syscalls with EPERM on every thread, along with any
+// syscall made through another architecture's ABI
+func applySeccomp() error {
+	if seccompArch == 0 {
+		return nil // no syscall table for this architecture
+	}
+	filter := seccompFilter(seccompArch, blockedSyscalls)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	_, _, errno := unix.Syscall(
+		unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER, unix.SECCOMP_FILTER_FLAG_TSYNC,
+		uintptr(unsafe.Pointer(&prog)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("failed to apply seccomp filter: %w", errno)
+	}
+	return nil
+}
+
+func seccompFilter(arch uint32, blocked []uintptr) []unix.SockFilter {
+	const (
+		load    = unix.BPF_LD | unix.BPF_W | unix.BPF_ABS
+		jumpEq  = unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+		ret     = unix.BPF_RET | unix.BPF_K
+		archOff = 4 // offsets in struct seccomp_data
+		nrOff   = 0
+	)
+	deny := uint32(unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM))
+
+	filter := []unix.SockFilter{
+		{Code: load, K: archOff},
+		{Code: jumpEq, Jt: 1, K: arch},
+		{Code: ret, K: deny},
+		{Code: load, K: nrOff},
+	}
+	for i, nr := range blocked {
+		// jump to the deny after the final allow
+		filter = append(filter, unix.SockFilter{Code: jumpEq, Jt: uint8(len(blocked) - i), K: uint32(nr)})
+	}
+	return append(filter,
+		unix.SockFilter{Code: ret, K: unix.SECCOMP_RET_ALLOW},
+		unix.SockFilter{Code: ret, K: deny},
+	)
+}
+
+// SandboxSystemPaths returns the dirs the listener and the clipboard tools
+// it runs need to read: system dirs and every dir in $PATH.
+func SandboxSystemPaths() []string {
+	paths := []string{
+		"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+		"/etc", "/opt", "/nix", "/gnu", "/run", "/proc", "/sys", "/dev",
+	}
+	for _, dir := range strings.Split(os.Getenv("PATH"), ":") {
+		if dir != "" {
+			paths = append(paths, dir)
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, exe)
+	}
+	return paths
+}
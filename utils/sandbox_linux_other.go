@@ -0,0 +1,10 @@
+//go:build linux && !amd64 && !arm64
+
+package utils
+
+// seccomp is skipped on architectures without a syscall table here,
+// landlock is still applied
+var (
+	seccompArch     uint32
+	blockedSyscalls []uintptr
+)
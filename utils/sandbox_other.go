@@ -0,0 +1,14 @@
+//go:build !linux
+
+package utils
+
+import "errors"
+
+// Sandbox is only supported on Linux.
+func Sandbox(_, _ []string, _ bool) error {
+	return errors.New("sandboxing is only supported on Linux")
+}
+
+func SandboxSystemPaths() []string {
+	return nil
+}
@@ -26,25 +26,48 @@ func Shorten(s string) string {
 	return strings.ReplaceAll(sl[:maxChar-3], "  ", " ") + "..."
 }
 
+// Truncate puts s on a single line, collapsing runs of white space, and cuts
+// it to at most n characters ending with an ellipsis.
+func Truncate(s string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	if n < 1 {
+		return ""
+	}
+	return string(runes[:n-1]) + "…"
+}
+
 func GetStdin() string {
 	/*
 		Gets piped input from the terminal when n
 		no additional arg provided
 	*/
-	buffer := make([]byte, 1024)
-	n, err := os.Stdin.Read(buffer)
-	if err != nil && err != io.EOF {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
 		return "Error reading Stdin"
 	}
-	return string(buffer[:n])
+	return string(input)
+}
+
+// Returns true if data is being piped into the stdin rather than
+// it being attached to a terminal.
+func StdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
 }
 
 func GetTime() string {
-	return time.Now().Format(("2006-01-02 15:04:05.000000000"))
+	return FormatTime(time.Now())
 }
 
+// the nanoseconds of the current time, used to name image files
 func GetTimeStamp() string {
-	return strings.Split(GetTime(), ".")[1]
+	return fmt.Sprintf("%09d", time.Now().Nanosecond())
 }
 
 func GetImgIdentifier(filename string) string {
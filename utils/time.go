@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeLayout is used for stored timestamps: RFC3339 in UTC with a fixed
+// number of fractional digits, so timestamps sort chronologically as strings.
+const TimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// timestamps were stored in local time without a zone before TimeLayout
+const legacyTimeLayout = "2006-01-02 15:04:05.000000000"
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var clockLayouts = []string{"15:04:05", "15:04"}
+
+// ParseTime reads a point in time relative to now in local time, eg
+// "yesterday 18:00", "today 09:30", "18:00", "2h ago", "3d ago" or
+// "2024-05-01 12:00".
+func ParseTime(spec string, now time.Time) (time.Time, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+
+	if ago, ok := strings.CutSuffix(spec, " ago"); ok {
+		d, err := ParseDuration(strings.TrimSpace(ago))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q: %w", spec, err)
+		}
+		return now.Add(-d), nil
+	}
+
+	day, clock, _ := strings.Cut(spec, " ")
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch day {
+	case "now":
+		if clock == "" {
+			return now, nil
+		}
+	case "today":
+		return atClock(midnight, clock, spec)
+	case "yesterday":
+		return atClock(midnight.AddDate(0, 0, -1), clock, spec)
+	}
+
+	if t, err := atClock(midnight, spec, spec); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, spec, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected eg \"yesterday 18:00\", \"2h ago\" or \"2024-05-01 12:00\"", spec)
+}
+
+// the clock time on the given day, or the start of the day if clock is empty
+func atClock(day time.Time, clock, spec string) (time.Time, error) {
+	if clock == "" {
+		return day, nil
+	}
+	for _, layout := range clockLayouts {
+		if c, err := time.Parse(layout, clock); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), c.Second(), 0, day.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: bad clock time %q", spec, clock)
+}
+
+// ParseDuration is time.ParseDuration with support for days, eg "3d".
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// FormatTime formats t for storage.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// ParseTimeStamp reads a stored timestamp, including ones in the legacy
+// local time layout.
+func ParseTimeStamp(ts string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(legacyTimeLayout, ts, time.Local)
+}
+
+// NormalizeTimeStamp converts a stored timestamp to TimeLayout. Empty and
+// unparsable timestamps are returned as they are.
+func NormalizeTimeStamp(ts string) string {
+	t, err := ParseTimeStamp(ts)
+	if err != nil {
+		return ts
+	}
+	return FormatTime(t)
+}
+
+// RelativeTime describes t relative to now in local time, eg "just now",
+// "2 minutes ago", "yesterday 14:02" or "Mon 09:30", using the locale's
+// clock and date formats.
+func RelativeTime(t, now time.Time) string {
+	t, now = t.Local(), now.Local()
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch {
+	case !t.Before(midnight):
+		return plural(int(d.Hours()), "hour") + " ago"
+	case !t.Before(midnight.AddDate(0, 0, -1)):
+		return "yesterday " + FormatClock(t)
+	case !t.Before(midnight.AddDate(0, 0, -6)):
+		return t.Format("Mon ") + FormatClock(t)
+	case t.Year() == now.Year():
+		return t.Format(locale.DayLayout) + " " + FormatClock(t)
+	}
+	return FormatDate(t)
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
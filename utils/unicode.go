@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/unicode/runenames"
+)
+
+// RuneName returns the Unicode name of r in lower case, eg "grinning face",
+// or "" if it is unassigned.
+func RuneName(r rune) string {
+	return strings.ToLower(runenames.Name(r))
+}
+
+// EscapeUnicode writes s with \u escapes for everything but printable
+// ASCII, the way JSON and JavaScript strings take it. Characters outside
+// the basic plane become surrogate pairs, eg 😀 is \ud83d\ude00.
+func EscapeUnicode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r > 0xffff:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
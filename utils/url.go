@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Returns true if s, ignoring surrounding whitespace, is a single
+// http(s) URL.
+func IsURL(s string) bool {
+	trimmed := strings.TrimSpace(s)
+	if strings.ContainsAny(trimmed, " \n\t") {
+		return false
+	}
+	u, err := url.Parse(trimmed)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}